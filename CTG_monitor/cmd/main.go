@@ -17,8 +17,18 @@ import (
 	"google.golang.org/grpc"
 
 	"CTG_monitor/configs"
+	"CTG_monitor/internal/alarms"
+	"CTG_monitor/internal/channels"
+	"CTG_monitor/internal/chunks"
+	"CTG_monitor/internal/clock"
+	"CTG_monitor/internal/coincidence"
 	"CTG_monitor/internal/database"
+	"CTG_monitor/internal/figo"
+	"CTG_monitor/internal/filters"
 	"CTG_monitor/internal/handlers"
+	"CTG_monitor/internal/quality"
+	"CTG_monitor/internal/reorder"
+	"CTG_monitor/internal/spool"
 	pb "CTG_monitor/proto"
 )
 
@@ -37,44 +47,133 @@ func main() {
 	}
 	defer database.CloseDatabase()
 
-	if err := database.RunMigrations(db); err != nil {
+	chunkFormat, err := chunks.ParseFormat(cfg.Storage.Encoding, cfg.Storage.ValueScale)
+	if err != nil {
+		log.Fatalf("Ошибка конфигурации хранения: %v", err)
+	}
+	if err := database.RunMigrations(db, chunkFormat); err != nil {
 		log.Fatalf("Ошибка миграций: %v", err)
 	}
 
-	// 3. Создание основных компонентов
-	dataBuffer := handlers.NewDataBuffer(db)
+	// 3. Журнал предзаписи: сообщения хранятся на диске до записи в БД
+	ingestSpool, err := spool.Open(spool.Config{
+		Dir:          cfg.Spool.Dir,
+		SegmentBytes: cfg.Spool.SegmentBytes,
+		SyncWrites:   cfg.Spool.SyncWrites,
+	})
+	if err != nil {
+		log.Fatalf("Ошибка открытия спула: %v", err)
+	}
+	defer ingestSpool.Close()
+
+	if err := handlers.InitMedicalRecordsClient("localhost:50052"); err != nil {
+		log.Printf("Не удалось подключиться к сервису медкарт: %v", err)
+		log.Println("Продолжаем работу без интеграции с медкартами")
+	}
+	defer handlers.CloseMedicalRecordsClient()
+
+	// 4. Создание основных компонентов
+	channelRegistry := channels.NewRegistry(channels.Defaults(), cfg.Filters.Chains)
+	dataBuffer := handlers.NewDataBuffer(db, ingestSpool, channelRegistry, chunkFormat)
 	sessionManager := handlers.NewSessionManager(db, dataBuffer)
-	grpcStreamer := handlers.NewGRPCStreamer()
+	grpcStreamer := handlers.NewGRPCStreamer(sessionManager)
+	dataBuffer.SetBackfillCallback(grpcStreamer.BroadcastSessionUpdate)
+	// Завершенная сессия уходит в медкарты, когда все ее точки записаны в БД
+	sessionManager.SetStoredCallback(handlers.SendSessionToMedicalRecords)
+
+	// Сессии, не завершенные до перезапуска, продолжаем или закрываем до приема данных
+	if _, err := sessionManager.RestoreActiveSessions(
+		cfg.Sessions.RestorePolicy, cfg.Sessions.MaxResumeGap); err != nil {
+		log.Printf("Ошибка восстановления сессий: %v", err)
+	}
+
+	// 5. Создание MQTT Stream Processor
+	filterBank := filters.NewBank(channelRegistry.FilterChains())
+	reorderBank := reorder.NewBank(reorder.Config{
+		Window:         cfg.Reorder.Window,
+		MaxHold:        cfg.Reorder.MaxHold,
+		History:        cfg.Reorder.History,
+		ResetThreshold: cfg.Reorder.Reset,
+	})
+	clockBank := clock.NewBank(clock.Config{Window: cfg.Clock.Window})
+	coincidenceBank := coincidence.NewBank(coincidence.Config{
+		Window:    cfg.Coincidence.Window,
+		Tolerance: cfg.Coincidence.Tolerance,
+		Ratio:     cfg.Coincidence.Ratio,
+	}, coincidence.DefaultPairs())
+	figoBank := figo.NewBank(figo.Config{
+		Window:      cfg.Analysis.BaselineWindow,
+		MinBaseline: cfg.Analysis.MinBaseline,
+		Rate:        cfg.Analysis.Rate,
+	})
+	qualityBank := quality.NewBank(quality.Config{
+		Window:   cfg.Quality.Window,
+		Interval: cfg.Quality.Interval,
+	})
+
+	// Клинические тревоги: неснятые до перезапуска возвращаются продолженным сессиям, новые ведет движок
+	alarmEngine := alarms.NewEngine(alarms.Config{
+		Bradycardia:        cfg.Alarms.Bradycardia,
+		Tachycardia:        cfg.Alarms.Tachycardia,
+		RateSustain:        cfg.Alarms.RateSustain,
+		ReducedVariability: cfg.Alarms.ReducedVariability,
+		LateDecelerations:  cfg.Alarms.LateDecelerations,
+		LateWindow:         cfg.Alarms.LateWindow,
+		SignalLoss:         cfg.Alarms.SignalLoss,
+		Escalation:         cfg.Alarms.Escalation,
+	})
+	alarmManager := handlers.NewAlarmManager(db, alarmEngine, sessionManager, grpcStreamer)
+	if _, _, err := alarmManager.RestoreAlarms(); err != nil {
+		log.Printf("Ошибка восстановления тревог после перезапуска: %v", err)
+	}
 
-	// 4. Создание MQTT Stream Processor
 	mqttProcessor := handlers.NewMQTTStreamProcessor(
 		sessionManager,
 		grpcStreamer,
 		dataBuffer,
+		channelRegistry,
+		filterBank,
+		reorderBank,
+		clockBank,
+		coincidenceBank,
+		figoBank,
+		qualityBank,
+		alarmManager,
+		ingestSpool,
+		handlers.SegmentConfig{
+			Policy: cfg.Segments.Policy,
+			MaxGap: cfg.Segments.MaxGap,
+		},
 	)
 
-	// 5. Инициализация MQTT клиента
+	// Сообщения, не записанные в БД до перезапуска, обрабатываем до подписки
+	mqttProcessor.ReplaySpool()
+
+	// 6. Инициализация MQTT клиента
 	mqttClient, err := initMQTTWithAuth(cfg.MQTT)
 	if err != nil {
 		log.Fatalf("Ошибка MQTT: %v", err)
 	}
 	defer mqttClient.Disconnect(250)
 
-	// 6. Подписка на MQTT топики с правильным обработчиком
+	// 7. Подписка на MQTT топики с правильным обработчиком
 	messageHandler := func(client mqtt.Client, msg mqtt.Message) {
 		mqttProcessor.HandleIncomingMQTT(msg.Topic(), msg.Payload())
 	}
 
-	topic := "medical/ctg/+/+" // Подписываемся на все устройства и типы данных
-	token := mqttClient.Subscribe(topic, byte(cfg.MQTT.QoS), messageHandler)
+	topics := map[string]byte{
+		"medical/ctg/+/+":          byte(cfg.MQTT.QoS), // Все устройства и типы данных
+		"medical/ctg/backfill/+/+": byte(cfg.MQTT.QoS), // Выгрузка данных, накопленных без связи
+	}
+	token := mqttClient.SubscribeMultiple(topics, messageHandler)
 	if token.Wait() && token.Error() != nil {
 		log.Fatalf("Ошибка подписки MQTT: %v", token.Error())
 	}
 
-	log.Printf("MQTT клиент подключён к %s, топик: %s",
-		cfg.MQTT.Broker, topic)
+	log.Printf("MQTT клиент подключён к %s, топиков: %d",
+		cfg.MQTT.Broker, len(topics))
 
-	// 7. Запуск gRPC сервера
+	// 8. Запуск gRPC сервера
 	grpcServer := grpc.NewServer()
 	pb.RegisterCTGStreamServiceServer(grpcServer, grpcStreamer)
 
@@ -90,14 +189,8 @@ func main() {
 		}
 	}()
 
-	if err := handlers.InitMedicalRecordsClient("localhost:50052"); err != nil {
-		log.Printf("Не удалось подключиться к сервису медкарт: %v", err)
-		log.Println("Продолжаем работу без интеграции с медкартами")
-	}
-	defer handlers.CloseMedicalRecordsClient()
-
-	// 8. Запуск REST API сервера
-	restAPI := handlers.NewRESTAPIServer(sessionManager, grpcStreamer, mqttProcessor)
+	// 9. Запуск REST API сервера
+	restAPI := handlers.NewRESTAPIServer(sessionManager, grpcStreamer, mqttProcessor, alarmManager)
 	router := restAPI.SetupRoutes()
 
 	go func() {
@@ -110,10 +203,10 @@ func main() {
 	log.Println("Сервис запущен → Ctrl+C для остановки")
 	log.Println("Архитектура потокового процессинга:")
 	log.Println("MQTT 🔄 Stream Processor → gRPC Stream")
-	log.Println("MQTT → Stream Processor → Data Buffer → Database")
+	log.Println("MQTT → Spool → Stream Processor → Data Buffer → Database")
 	log.Println("REST API → Session Manager")
 
-	// 9. Graceful shutdown
+	// 10. Graceful shutdown
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 	<-sigChan
@@ -122,6 +215,7 @@ func main() {
 
 	// Остановка компонентов в обратном порядке
 	mqttProcessor.Stop()
+	alarmManager.Stop()
 	grpcStreamer.Stop()
 	dataBuffer.Stop()
 	grpcServer.GracefulStop()
@@ -4,12 +4,27 @@ package configs
 import (
 	"os"
 	"strconv"
+	"strings"
+	"time"
+
+	"CTG_monitor/internal/channels"
 )
 
 type Config struct {
-	Database DatabaseConfig
-	App      AppConfig
-	MQTT     MQTTConfig
+	Database    DatabaseConfig
+	App         AppConfig
+	MQTT        MQTTConfig
+	Filters     FiltersConfig
+	Spool       SpoolConfig
+	Storage     StorageConfig
+	Sessions    SessionsConfig
+	Reorder     ReorderConfig
+	Segments    SegmentsConfig
+	Clock       ClockConfig
+	Coincidence CoincidenceConfig
+	Analysis    AnalysisConfig
+	Alarms      AlarmsConfig
+	Quality     QualityConfig
 }
 
 type DatabaseConfig struct {
@@ -36,6 +51,82 @@ type MQTTConfig struct {
 	QoS      int
 }
 
+type FiltersConfig struct {
+	// Цепочки фильтров артефактов по каналам (применяются по порядку)
+	Chains map[string][]string
+}
+
+// filterChains читает цепочки фильтров каналов из FILTERS_<КАНАЛ>,
+// по умолчанию используется цепочка из реестра каналов
+func filterChains() map[string][]string {
+	chains := make(map[string][]string)
+	for _, channel := range channels.Defaults() {
+		key := "FILTERS_" + strings.ToUpper(channel.Name)
+		chains[channel.Name] = getEnvAsList(key, strings.Join(channel.Filters, ","))
+	}
+	return chains
+}
+
+type SpoolConfig struct {
+	Dir          string // каталог журнала предзаписи входящих сообщений
+	SegmentBytes int64  // размер сегмента журнала
+	SyncWrites   bool   // fsync после каждой записи
+}
+
+type StorageConfig struct {
+	Encoding   string  // кодировка новых кадров в блоках точек: codec или json
+	ValueScale float64 // шагов значения в единице при квантовании codec (0 - без потерь)
+}
+
+type SessionsConfig struct {
+	RestorePolicy string        // что делать с незавершенными сессиями при старте: auto, resume, close
+	MaxResumeGap  time.Duration // перерыв в данных, после которого auto закрывает сессию
+}
+
+type ReorderConfig struct {
+	Window  time.Duration // сколько времени сигнала точка ждет более ранних
+	MaxHold time.Duration // максимальное ожидание точки по часам сервера
+	History time.Duration // глубина поиска дублей среди выпущенных точек
+	Reset   time.Duration // откат time_sec назад, считающийся сбросом шкалы устройства
+}
+
+type ClockConfig struct {
+	Window time.Duration // период наблюдений для оценки смещения и дрейфа часов устройства
+}
+
+type CoincidenceConfig struct {
+	Window    time.Duration // окно сравнения каналов ЧСС (второй плод, ЧСС матери)
+	Tolerance float64       // разница ЧСС, уд/мин, при которой точки каналов считаются совпадающими
+	Ratio     float64       // доля совпадающих точек в окне для предупреждения
+}
+
+type AnalysisConfig struct {
+	BaselineWindow time.Duration // окно базального ритма и вариабельности ЧСС плода
+	MinBaseline    time.Duration // сигнал без эпизодов, нужный для определения базального ритма
+	Rate           float64       // частота равномерной сетки, к которой приводятся точки перед анализом, Гц
+}
+
+type QualityConfig struct {
+	Window   time.Duration // скользящее окно индекса качества сигнала
+	Interval time.Duration // период рассылки индекса качества
+}
+
+type AlarmsConfig struct {
+	Bradycardia        float64       // порог брадикардии, уд/мин
+	Tachycardia        float64       // порог тахикардии, уд/мин
+	RateSustain        time.Duration // сколько ЧСС должна быть за порогом для тревоги
+	ReducedVariability time.Duration // сколько должна длиться сниженная вариабельность
+	LateDecelerations  int           // поздних децелераций в окне для тревоги
+	LateWindow         time.Duration // окно подсчета поздних децелераций
+	SignalLoss         time.Duration // длительность потери сигнала для тревоги
+	Escalation         time.Duration // период эскалации неподтвержденной тревоги
+}
+
+type SegmentsConfig struct {
+	Policy string        // при сбросе шкалы или перерыве: split (новая сессия), segment (граница в сессии), off
+	MaxGap time.Duration // перерыв в данных, начинающий новый сегмент
+}
+
 // LoadConfig загружает конфигурацию из .env файла
 func LoadConfig() *Config {
 
@@ -61,6 +152,59 @@ func LoadConfig() *Config {
 			Password: getEnv("MQTT_PASSWORD", ""),
 			QoS:      getEnvAsInt("MQTT_QOS", 1),
 		},
+		Filters: FiltersConfig{
+			Chains: filterChains(),
+		},
+		Spool: SpoolConfig{
+			Dir:          getEnv("SPOOL_DIR", "spool"),
+			SegmentBytes: int64(getEnvAsInt("SPOOL_SEGMENT_MB", 16)) << 20,
+			SyncWrites:   getEnvAsBool("SPOOL_SYNC_WRITES", true),
+		},
+		Storage: StorageConfig{
+			Encoding:   getEnv("CHUNK_ENCODING", "codec"),
+			ValueScale: getEnvAsFloat("CHUNK_VALUE_SCALE", 0),
+		},
+		Sessions: SessionsConfig{
+			RestorePolicy: getEnv("SESSION_RESTORE_POLICY", "auto"),
+			MaxResumeGap:  getEnvAsDuration("SESSION_MAX_RESUME_GAP", 10*time.Minute),
+		},
+		Reorder: ReorderConfig{
+			Window:  getEnvAsDuration("REORDER_WINDOW", time.Second),
+			MaxHold: getEnvAsDuration("REORDER_MAX_HOLD", 2*time.Second),
+			History: getEnvAsDuration("REORDER_HISTORY", 30*time.Second),
+			Reset:   getEnvAsDuration("REORDER_RESET_THRESHOLD", 5*time.Second),
+		},
+		Segments: SegmentsConfig{
+			Policy: getEnv("SEGMENT_POLICY", "segment"),
+			MaxGap: getEnvAsDuration("SEGMENT_MAX_GAP", 5*time.Minute),
+		},
+		Clock: ClockConfig{
+			Window: getEnvAsDuration("CLOCK_ESTIMATION_WINDOW", 10*time.Minute),
+		},
+		Coincidence: CoincidenceConfig{
+			Window:    getEnvAsDuration("COINCIDENCE_WINDOW", time.Minute),
+			Tolerance: getEnvAsFloat("COINCIDENCE_TOLERANCE", 5),
+			Ratio:     getEnvAsFloat("COINCIDENCE_RATIO", 0.8),
+		},
+		Analysis: AnalysisConfig{
+			BaselineWindow: getEnvAsDuration("FIGO_BASELINE_WINDOW", 10*time.Minute),
+			MinBaseline:    getEnvAsDuration("FIGO_MIN_BASELINE", 2*time.Minute),
+			Rate:           getEnvAsFloat("ANALYSIS_RATE", 4),
+		},
+		Quality: QualityConfig{
+			Window:   getEnvAsDuration("QUALITY_WINDOW", 2*time.Minute),
+			Interval: getEnvAsDuration("QUALITY_INTERVAL", 30*time.Second),
+		},
+		Alarms: AlarmsConfig{
+			Bradycardia:        getEnvAsFloat("ALARM_BRADYCARDIA", 110),
+			Tachycardia:        getEnvAsFloat("ALARM_TACHYCARDIA", 160),
+			RateSustain:        getEnvAsDuration("ALARM_RATE_SUSTAIN", 3*time.Minute),
+			ReducedVariability: getEnvAsDuration("ALARM_REDUCED_VARIABILITY", 30*time.Minute),
+			LateDecelerations:  getEnvAsInt("ALARM_LATE_DECELERATIONS", 2),
+			LateWindow:         getEnvAsDuration("ALARM_LATE_WINDOW", 30*time.Minute),
+			SignalLoss:         getEnvAsDuration("ALARM_SIGNAL_LOSS", time.Minute),
+			Escalation:         getEnvAsDuration("ALARM_ESCALATION", 2*time.Minute),
+		},
 	}
 }
 
@@ -81,3 +225,44 @@ func getEnvAsInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+// getEnvAsFloat получает переменную окружения как float64
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsBool получает переменную окружения как bool
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsDuration получает переменную окружения как time.Duration (например, 10m)
+func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsList получает переменную окружения как список через запятую
+func getEnvAsList(key, defaultValue string) []string {
+	var result []string
+	for _, item := range strings.Split(getEnv(key, defaultValue), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
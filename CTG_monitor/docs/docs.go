@@ -24,49 +24,235 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/monitoring/cleanup": {
+        "/alarms": {
+            "get": {
+                "description": "Без параметра history возвращает неснятые тревоги из памяти движка. При history=true возвращает тревоги из БД, начиная с последних, с фильтром по состоянию (raised, acknowledged, cleared)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "alarms"
+                ],
+                "summary": "Клинические тревоги",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Идентификатор устройства",
+                        "name": "device_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Читать историю из БД",
+                        "name": "history",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Состояние тревоги (только для истории)",
+                        "name": "state",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Максимум тревог истории",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Тревоги",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.AlarmsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/alarms/{alarm_id}/acknowledge": {
             "post": {
-                "description": "Выполняет очистку зависших и неактивных сессий в системе",
+                "description": "Переводит поднятую тревогу в состояние acknowledged и останавливает ее эскалацию. Повторное подтверждение возвращает тревогу без изменений",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "monitoring"
+                    "alarms"
+                ],
+                "summary": "Подтверждение тревоги",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID тревоги",
+                        "name": "alarm_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Кто подтверждает тревогу",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.AcknowledgeAlarmRequest"
+                        }
+                    }
                 ],
-                "summary": "Очистка зависших сессий",
                 "responses": {
                     "200": {
-                        "description": "Результат очистки",
+                        "description": "Тревога подтверждена",
                         "schema": {
-                            "$ref": "#/definitions/handlers.CleanupResponse"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handlers.SuccessResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.CTGAlarm"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат данных",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Тревога не найдена или уже снята",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/monitoring/health": {
+        "/cards/{card_id}/sessions": {
             "get": {
-                "description": "Возвращает информацию о текущем состоянии и работоспособности сервиса мониторинга КТГ",
+                "description": "Возвращает все сессии медицинской карты, начиная с последней",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "monitoring"
+                    "cards"
+                ],
+                "summary": "Сессии медицинской карты",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "UUID медицинской карты",
+                        "name": "card_id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Проверка состояния сервиса",
                 "responses": {
                     "200": {
-                        "description": "Состояние сервиса",
+                        "description": "Сессии карты",
                         "schema": {
-                            "$ref": "#/definitions/handlers.HealthResponse"
+                            "$ref": "#/definitions/handlers.CardSessionsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID медицинской карты",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/sessions/start": {
+        "/channels": {
+            "get": {
+                "description": "Возвращает каналы с единицами измерения, допустимым диапазоном, частотой и цепочкой фильтров. Сообщения незарегистрированных каналов отклоняются",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "channels"
+                ],
+                "summary": "Зарегистрированные каналы данных",
+                "responses": {
+                    "200": {
+                        "description": "Каналы данных",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ChannelsResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/devices": {
+            "get": {
+                "description": "Возвращает устройства, которые записывали сессии, ведут сессию сейчас или передают данные без медицинской карты, и их статус",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "devices"
+                ],
+                "summary": "Устройства КТГ",
+                "responses": {
+                    "200": {
+                        "description": "Устройства",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.DevicesResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/devices/unassigned": {
+            "get": {
+                "description": "Возвращает устройства, которые передают данные без активной сессии, и объем накопленных данных",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "devices"
+                ],
+                "summary": "Устройства без привязки к медицинской карте",
+                "responses": {
+                    "200": {
+                        "description": "Непривязанные устройства",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UnassignedDevicesResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/devices/{device_id}/alarms/acknowledge": {
             "post": {
-                "description": "Создает новую сессию мониторинга КТГ для указанной медицинской карты и устройства",
+                "description": "Переводит все поднятые тревоги устройства в состояние acknowledged и возвращает подтвержденные",
                 "consumes": [
                     "application/json"
                 ],
@@ -74,23 +260,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "sessions"
+                    "alarms"
                 ],
-                "summary": "Запуск новой сессии мониторинга КТГ",
+                "summary": "Подтверждение тревог устройства",
                 "parameters": [
                     {
-                        "description": "Данные для создания сессии",
+                        "type": "string",
+                        "description": "Идентификатор устройства",
+                        "name": "device_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Кто подтверждает тревоги",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.SessionRequest"
+                            "$ref": "#/definitions/handlers.AcknowledgeAlarmRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Сессия успешно запущена",
+                        "description": "Тревоги подтверждены",
                         "schema": {
                             "allOf": [
                                 {
@@ -100,7 +293,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/handlers.SessionResponse"
+                                            "$ref": "#/definitions/handlers.AlarmsResponse"
                                         }
                                     }
                                 }
@@ -112,45 +305,44 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
-                    },
-                    "409": {
-                        "description": "Сессия для устройства уже активна",
-                        "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
-                        }
-                    },
-                    "500": {
-                        "description": "Внутренняя ошибка сервера",
-                        "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
-                        }
                     }
                 }
             }
         },
-        "/sessions/stop/{session_id}": {
+        "/devices/{device_id}/bind": {
             "post": {
-                "description": "Завершает указанную активную сессию мониторинга КТГ",
+                "description": "Начинает сессию для устройства, передающего данные без карты. При keep_data=true данные, полученные до привязки, сохраняются в сессии, иначе отбрасываются",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "sessions"
+                    "devices"
                 ],
-                "summary": "Завершение активной сессии мониторинга",
+                "summary": "Привязка устройства к медицинской карте",
                 "parameters": [
                     {
                         "type": "string",
-                        "format": "uuid",
-                        "description": "UUID сессии",
-                        "name": "session_id",
+                        "description": "Идентификатор устройства",
+                        "name": "device_id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Карта пациента и судьба накопленных данных",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BindDeviceRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Сессия успешно завершена",
+                        "description": "Устройство привязано",
                         "schema": {
                             "allOf": [
                                 {
@@ -160,7 +352,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/handlers.SessionResponse"
+                                            "$ref": "#/definitions/handlers.BindDeviceResponse"
                                         }
                                     }
                                 }
@@ -168,77 +360,1641 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Неверный ID сессии",
+                        "description": "Неверный формат данных",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Сессия не найдена",
+                    "409": {
+                        "description": "У устройства уже есть активная сессия",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "handlers.CleanupResponse": {
-            "description": "Результат операции очистки зависших сессий",
-            "type": "object",
-            "properties": {
-                "active_sessions": {
-                    "description": "Количество активных сессий после очистки",
-                    "type": "integer",
-                    "example": 2
-                },
-                "message": {
-                    "description": "Сообщение о результате",
-                    "type": "string",
-                    "example": "Очистка сессий выполнена"
+        },
+        "/devices/{device_id}/status": {
+            "get": {
+                "description": "Возвращает статус устройства, активную и последнюю сессии, данные без привязки к карте, оценку часов, качество сигнала по каналам и неснятые тревоги",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "devices"
+                ],
+                "summary": "Статус устройства КТГ",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Идентификатор устройства",
+                        "name": "device_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Статус устройства",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.DeviceStatusResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Устройство не найдено",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
                 }
             }
         },
-        "handlers.ErrorResponse": {
-            "description": "Стандартная структура ответа об ошибке",
-            "type": "object",
-            "properties": {
-                "details": {
-                    "description": "Дополнительные детали ошибки",
+        "/monitoring/analysis": {
+            "get": {
+                "description": "Возвращает для каждого канала ЧСС плода текущий базальный ритм, амплитуду и класс вариабельности, количество акцелераций и децелераций по типам с начала наблюдения; для канала сокращений матки - тонус, количество схваток, частоту за 10 минут и признак тахисистолии",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "monitoring"
+                ],
+                "summary": "Анализ ЧСС плода по FIGO",
+                "responses": {
+                    "200": {
+                        "description": "Оценки по каналам",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.AnalysisStatsResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/monitoring/cleanup": {
+            "post": {
+                "description": "Выполняет очистку зависших и неактивных сессий в системе",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "monitoring"
+                ],
+                "summary": "Очистка зависших сессий",
+                "responses": {
+                    "200": {
+                        "description": "Результат очистки",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CleanupResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/monitoring/clocks": {
+            "get": {
+                "description": "Возвращает для каждого устройства оценку смещения его часов относительно сервера и скорость их ухода. Оценка строится по меткам времени измерений",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "monitoring"
+                ],
+                "summary": "Смещение и дрейф часов устройств",
+                "responses": {
+                    "200": {
+                        "description": "Оценки часов устройств",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ClockStatsResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/monitoring/coincidence": {
+            "get": {
+                "description": "Возвращает для каждого устройства долю совпадающих точек в парах ЧСС плода 1 и 2, ЧСС плода и ЧСС матери. Активное совпадение означает, что датчики записывают одно сердце или ЧСС матери",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "monitoring"
+                ],
+                "summary": "Совпадение каналов ЧСС",
+                "responses": {
+                    "200": {
+                        "description": "Состояние пар каналов",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CoincidenceStatsResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/monitoring/filters": {
+            "get": {
+                "description": "Возвращает количество обработанных и исправленных точек для каждого фильтра по каналам устройств",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "monitoring"
+                ],
+                "summary": "Статистика фильтров артефактов",
+                "responses": {
+                    "200": {
+                        "description": "Статистика фильтров",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FilterStatsResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/monitoring/health": {
+            "get": {
+                "description": "Возвращает информацию о текущем состоянии и работоспособности сервиса мониторинга КТГ",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "monitoring"
+                ],
+                "summary": "Проверка состояния сервиса",
+                "responses": {
+                    "200": {
+                        "description": "Состояние сервиса",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/monitoring/quality": {
+            "get": {
+                "description": "Возвращает для каждого канала сигнала устройства индекс качества (доля точек скользящего окна с сигналом без исправлений фильтрами), класс good/fair/poor, признак текущей потери сигнала и число эпизодов потери с начала наблюдения. Запись класса poor интерпретировать нельзя",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "monitoring"
+                ],
+                "summary": "Качество сигнала",
+                "responses": {
+                    "200": {
+                        "description": "Оценки по каналам",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.QualityStatsResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/monitoring/reorder": {
+            "get": {
+                "description": "Возвращает для каждого канала устройства количество переставленных, дублирующихся, конфликтующих и опоздавших точек",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "monitoring"
+                ],
+                "summary": "Статистика упорядочивания точек",
+                "responses": {
+                    "200": {
+                        "description": "Счетчики буферов упорядочивания",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ReorderStatsResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/sessions": {
+            "get": {
+                "description": "Возвращает страницу сессий с отбором по статусу, медицинской карте, устройству и интервалу дат. В интервал попадают сессии, пересекающие его (активные - до текущего момента). Страницы упорядочены по времени начала; следующая страница запрашивается с cursor из next_cursor того же запроса",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "Список сессий",
+                "parameters": [
+                    {
+                        "enum": [
+                            "active",
+                            "stopped"
+                        ],
+                        "type": "string",
+                        "description": "Статус сессии",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "UUID медицинской карты",
+                        "name": "card_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Идентификатор устройства",
+                        "name": "device_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Начало интервала дат, RFC3339",
+                        "name": "from_time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Конец интервала дат, RFC3339",
+                        "name": "to_time",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "start_time",
+                            "-start_time"
+                        ],
+                        "type": "string",
+                        "default": "-start_time",
+                        "description": "Порядок: start_time - с первых, -start_time - с последних",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Размер страницы (не больше 500)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор следующей страницы",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Страница сессий",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SessionListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры фильтра или курсор",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/sessions/active": {
+            "get": {
+                "description": "Возвращает сессии, идущие сейчас, в порядке начала",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "Активные сессии",
+                "responses": {
+                    "200": {
+                        "description": "Активные сессии",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ActiveSessionsResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/sessions/start": {
+            "post": {
+                "description": "Создает новую сессию мониторинга КТГ для указанной медицинской карты и устройства. Сессия с протоколом nst (нестрессовый тест, 20-40 минут, реактивность по акцелерациям) или cst (стрессовый тест, 10-60 минут, поздние децелерации на схватки) завершается сама, когда критерии выполнены или время вышло, и сохраняет заключение",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "Запуск новой сессии мониторинга КТГ",
+                "parameters": [
+                    {
+                        "description": "Данные для создания сессии",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SessionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Сессия успешно запущена",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handlers.SuccessResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/handlers.SessionResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат данных",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Сессия для устройства уже активна",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/sessions/stop/{session_id}": {
+            "post": {
+                "description": "Завершает указанную активную сессию мониторинга КТГ",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "Завершение активной сессии мониторинга",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "UUID сессии",
+                        "name": "session_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Сессия успешно завершена",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handlers.SuccessResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/handlers.SessionResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID сессии",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Сессия не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/sessions/{session_id}": {
+            "get": {
+                "description": "Возвращает сессию: статус, длительность, протокол и итоги, сегменты шкалы времени и качество сигнала. Точки и события анализа - в /sessions/{session_id}/data",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "Сессия мониторинга",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "UUID сессии",
+                        "name": "session_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Сессия",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SessionDetailResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID сессии",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Сессия не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/sessions/{session_id}/archive": {
+            "get": {
+                "description": "Возвращает файл архива сессии: данные сессии (JSON) и точки всех каналов в компактной двоичной кодировке (pkg/codec)",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "Архив сессии",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "UUID сессии",
+                        "name": "session_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Архив сессии (.ctga)",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID сессии",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Сессия не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/sessions/{session_id}/data": {
+            "get": {
+                "description": "Возвращает точки ЧСС плода и маточных сокращений, события анализа ЧСС плода и схваток, сводку схваток, долю точек с сигналом и эпизоды потери сигнала по каналам. Интервал задается временем сессии (from/to, секунды) или абсолютным временем (from_time/to_time, RFC3339), но не обоими способами сразу. При max_points ряды каналов, в которых точек больше, прореживаются методом LTTB (Largest-Triangle-Three-Buckets); сводка схваток и качество сигнала считаются по всем точкам",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "Данные КТГ сессии",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "UUID сессии",
+                        "name": "session_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "fetal_heart_rate,uterine_contractions",
+                        "description": "Каналы через запятую (по умолчанию все)",
+                        "name": "channels",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Максимум точек на канал (0 - без прореживания, иначе не меньше 3)",
+                        "name": "max_points",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Начало интервала, секунды от начала сессии",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Конец интервала, секунды от начала сессии",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Начало интервала, RFC3339",
+                        "name": "from_time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Конец интервала, RFC3339",
+                        "name": "to_time",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Данные сессии",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SessionDataResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID сессии, интервал, канал или max_points",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Сессия не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/sessions/{session_id}/rollups": {
+            "get": {
+                "description": "Возвращает ряды каналов на интервале, сведенные до ширины графика: самые крупные сводки (1, 10 или 60 с), которых на точку графика приходится не меньше одной. Если интервал короче ширины графика в секундах, возвращаются исходные точки",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "Обзор сессии в подобранном разрешении",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "UUID сессии",
+                        "name": "session_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "fetal_heart_rate,uterine_contractions",
+                        "description": "Каналы через запятую (по умолчанию все)",
+                        "name": "channels",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Начало интервала, секунды от начала сессии (по умолчанию 0)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Конец интервала, секунды от начала сессии (по умолчанию длительность сессии)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1000,
+                        "description": "Ширина графика, точек",
+                        "name": "width",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Ряды сессии",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SessionRollupsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID сессии, интервал, ширина или канал",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Сессия не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "channels.Channel": {
+            "type": "object",
+            "properties": {
+                "fetus": {
+                    "description": "Номер плода для каналов ЧСС плода",
+                    "type": "integer",
+                    "example": 1
+                },
+                "filters": {
+                    "description": "Цепочка фильтров артефактов",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "hampel"
+                    ]
+                },
+                "kind": {
+                    "description": "signal или marker",
+                    "type": "string",
+                    "example": "signal"
+                },
+                "max": {
+                    "description": "Верхняя граница допустимых значений",
+                    "type": "number",
+                    "example": 220
+                },
+                "min": {
+                    "description": "Нижняя граница допустимых значений",
+                    "type": "number",
+                    "example": 50
+                },
+                "name": {
+                    "description": "Имя канала (тип данных в топике)",
+                    "type": "string",
+                    "example": "fetal_heart_rate"
+                },
+                "sample_rate": {
+                    "description": "Номинальная частота, Гц (0 - нерегулярные измерения)",
+                    "type": "number",
+                    "example": 4
+                },
+                "title": {
+                    "description": "Название для интерфейса",
+                    "type": "string",
+                    "example": "ЧСС плода"
+                },
+                "units": {
+                    "description": "Единицы измерения",
+                    "type": "string",
+                    "example": "bpm"
+                }
+            }
+        },
+        "clock.DeviceStats": {
+            "type": "object",
+            "properties": {
+                "buckets": {
+                    "description": "Интервалов в окне оценки",
+                    "type": "integer"
+                },
+                "device_id": {
+                    "type": "string"
+                },
+                "drift_ppm": {
+                    "description": "Скорость ухода часов устройства, мкс/с",
+                    "type": "number"
+                },
+                "last_observed": {
+                    "description": "Последнее наблюдение",
+                    "type": "string"
+                },
+                "observations": {
+                    "description": "Всего наблюдений",
+                    "type": "integer"
+                },
+                "offset_ms": {
+                    "description": "Часы сервера минус часы устройства, мс",
+                    "type": "number"
+                }
+            }
+        },
+        "clock.Stats": {
+            "type": "object",
+            "properties": {
+                "buckets": {
+                    "description": "Интервалов в окне оценки",
+                    "type": "integer"
+                },
+                "drift_ppm": {
+                    "description": "Скорость ухода часов устройства, мкс/с",
+                    "type": "number"
+                },
+                "last_observed": {
+                    "description": "Последнее наблюдение",
+                    "type": "string"
+                },
+                "observations": {
+                    "description": "Всего наблюдений",
+                    "type": "integer"
+                },
+                "offset_ms": {
+                    "description": "Часы сервера минус часы устройства, мс",
+                    "type": "number"
+                }
+            }
+        },
+        "coincidence.DeviceStats": {
+            "type": "object",
+            "properties": {
+                "device_id": {
+                    "type": "string"
+                },
+                "pairs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/coincidence.PairStats"
+                    }
+                }
+            }
+        },
+        "coincidence.PairStats": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "channels": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "pairs": {
+                    "description": "пар точек в окне",
+                    "type": "integer"
+                },
+                "ratio": {
+                    "type": "number"
+                }
+            }
+        },
+        "decel.Counts": {
+            "type": "object",
+            "properties": {
+                "early": {
+                    "type": "integer"
+                },
+                "late": {
+                    "type": "integer"
+                },
+                "prolonged": {
+                    "type": "integer"
+                },
+                "variable": {
+                    "type": "integer"
+                }
+            }
+        },
+        "figo.ChannelStats": {
+            "type": "object",
+            "properties": {
+                "accelerations": {
+                    "type": "integer"
+                },
+                "band": {
+                    "description": "класс вариабельности",
+                    "type": "string"
+                },
+                "baseline": {
+                    "description": "базальный ритм (0 - еще не определен)",
+                    "type": "number"
+                },
+                "contractions": {
+                    "description": "найдено схваток",
+                    "type": "integer"
+                },
+                "data_type": {
+                    "type": "string"
+                },
+                "deceleration_types": {
+                    "description": "децелерации по типам относительно схваток",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/decel.Counts"
+                        }
+                    ]
+                },
+                "decelerations": {
+                    "type": "integer"
+                },
+                "device_id": {
+                    "type": "string"
+                },
+                "frequency": {
+                    "description": "схваток за последние 10 минут",
+                    "type": "number"
+                },
+                "tachysystole": {
+                    "description": "более 5 схваток за 10 минут в среднем за 30 минут",
+                    "type": "boolean"
+                },
+                "tone": {
+                    "description": "Канал сокращений матки",
+                    "type": "number"
+                },
+                "variability": {
+                    "description": "амплитуда вариабельности, уд/мин",
+                    "type": "number"
+                }
+            }
+        },
+        "filters.ChannelStats": {
+            "type": "object",
+            "properties": {
+                "data_type": {
+                    "type": "string"
+                },
+                "device_id": {
+                    "type": "string"
+                },
+                "filters": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/filters.FilterStats"
+                    }
+                }
+            }
+        },
+        "filters.FilterStats": {
+            "type": "object",
+            "properties": {
+                "detected": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "processed": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.AcknowledgeAlarmRequest": {
+            "description": "Кто подтверждает тревогу (врач или акушерка центрального поста)",
+            "type": "object",
+            "required": [
+                "acknowledged_by"
+            ],
+            "properties": {
+                "acknowledged_by": {
+                    "description": "Кто подтвердил тревогу",
+                    "type": "string",
+                    "example": "Иванова А.П."
+                }
+            }
+        },
+        "handlers.ActiveSessionsResponse": {
+            "description": "Список всех активных сессий мониторинга",
+            "type": "object",
+            "properties": {
+                "count": {
+                    "description": "Количество активных сессий",
+                    "type": "integer",
+                    "example": 3
+                },
+                "sessions": {
+                    "description": "Список активных сессий",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.SessionResponse"
+                    }
+                }
+            }
+        },
+        "handlers.AlarmsResponse": {
+            "description": "Клинические тревоги: неснятые или история из БД",
+            "type": "object",
+            "properties": {
+                "alarms": {
+                    "description": "Тревоги",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CTGAlarm"
+                    }
+                },
+                "count": {
+                    "description": "Количество тревог",
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "handlers.AnalysisStatsResponse": {
+            "description": "Базальный ритм, вариабельность и число эпизодов по каналам ЧСС плода, схватки по каналу сокращений матки",
+            "type": "object",
+            "properties": {
+                "channels": {
+                    "description": "Оценки по каналам устройств",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/figo.ChannelStats"
+                    }
+                },
+                "count": {
+                    "description": "Количество каналов",
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "handlers.BindDeviceRequest": {
+            "description": "Привязка устройства, передающего данные без медкарты, к карте пациента",
+            "type": "object",
+            "required": [
+                "card_id"
+            ],
+            "properties": {
+                "card_id": {
+                    "description": "UUID медицинской карты пациента",
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                },
+                "keep_data": {
+                    "description": "Сохранить данные, полученные до привязки",
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "handlers.BindDeviceResponse": {
+            "description": "Созданная сессия и судьба данных, полученных до привязки",
+            "type": "object",
+            "properties": {
+                "discarded_points": {
+                    "description": "Точек отброшено",
+                    "type": "integer",
+                    "example": 0
+                },
+                "kept_points": {
+                    "description": "Точек перенесено в сессию",
+                    "type": "integer",
+                    "example": 1200
+                },
+                "session": {
+                    "description": "Созданная сессия",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/handlers.SessionResponse"
+                        }
+                    ]
+                }
+            }
+        },
+        "handlers.CardSessionsResponse": {
+            "description": "Список сессий для конкретной медицинской карты",
+            "type": "object",
+            "properties": {
+                "card_id": {
+                    "description": "UUID медицинской карты",
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                },
+                "count": {
+                    "description": "Количество сессий",
+                    "type": "integer",
+                    "example": 5
+                },
+                "sessions": {
+                    "description": "Список сессий",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.SessionResponse"
+                    }
+                }
+            }
+        },
+        "handlers.ChannelsResponse": {
+            "description": "Каналы, которые принимает и хранит сервис",
+            "type": "object",
+            "properties": {
+                "channels": {
+                    "description": "Описание каналов",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/channels.Channel"
+                    }
+                },
+                "count": {
+                    "description": "Количество каналов",
+                    "type": "integer",
+                    "example": 9
+                }
+            }
+        },
+        "handlers.CleanupResponse": {
+            "description": "Результат операции очистки зависших сессий",
+            "type": "object",
+            "properties": {
+                "active_sessions": {
+                    "description": "Количество активных сессий после очистки",
+                    "type": "integer",
+                    "example": 2
+                },
+                "message": {
+                    "description": "Сообщение о результате",
+                    "type": "string",
+                    "example": "Очистка сессий выполнена"
+                }
+            }
+        },
+        "handlers.ClockStatsResponse": {
+            "description": "Смещение и дрейф часов устройств относительно сервера",
+            "type": "object",
+            "properties": {
+                "count": {
+                    "description": "Количество устройств",
+                    "type": "integer",
+                    "example": 2
+                },
+                "devices": {
+                    "description": "Оценки по устройствам",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/clock.DeviceStats"
+                    }
+                }
+            }
+        },
+        "handlers.CoincidenceStatsResponse": {
+            "description": "Сравнение каналов ЧСС плодов между собой и с ЧСС матери",
+            "type": "object",
+            "properties": {
+                "count": {
+                    "description": "Количество устройств",
+                    "type": "integer",
+                    "example": 2
+                },
+                "devices": {
+                    "description": "Пары каналов по устройствам",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/coincidence.DeviceStats"
+                    }
+                }
+            }
+        },
+        "handlers.ContractionSummary": {
+            "description": "Количество и частота схваток, эпизоды тахисистолии",
+            "type": "object",
+            "properties": {
+                "count": {
+                    "description": "Количество схваток",
+                    "type": "integer",
+                    "example": 12
+                },
+                "frequency": {
+                    "description": "Схваток за 10 минут в среднем за интервал",
+                    "type": "number",
+                    "example": 3.5
+                },
+                "mean_amplitude": {
+                    "description": "Средний подъем над тонусом",
+                    "type": "number",
+                    "example": 42
+                },
+                "mean_duration": {
+                    "description": "Средняя длительность схватки, с",
+                    "type": "number",
+                    "example": 65.2
+                },
+                "tachysystole": {
+                    "description": "Эпизодов тахисистолии",
+                    "type": "integer",
+                    "example": 0
+                }
+            }
+        },
+        "handlers.DeviceStatusResponse": {
+            "description": "Текущий статус устройства КТГ. Последняя сессия, часы, качество сигнала и тревоги заполняются только в /devices/{device_id}/status",
+            "type": "object",
+            "properties": {
+                "card_id": {
+                    "description": "UUID медицинской карты активной сессии",
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                },
+                "clock": {
+                    "description": "Оценка часов устройства",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/clock.Stats"
+                        }
+                    ]
+                },
+                "device_id": {
+                    "description": "Идентификатор устройства",
+                    "type": "string",
+                    "example": "CTG-DEVICE-001"
+                },
+                "duration": {
+                    "description": "Продолжительность активной сессии в секундах",
+                    "type": "integer",
+                    "example": 3600
+                },
+                "last_session": {
+                    "description": "Последняя сессия устройства",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/handlers.SessionResponse"
+                        }
+                    ]
+                },
+                "open_alarms": {
+                    "description": "Неснятые тревоги",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CTGAlarm"
+                    }
+                },
+                "quality": {
+                    "description": "Качество сигнала по каналам",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/quality.ChannelStats"
+                    }
+                },
+                "session_id": {
+                    "description": "UUID активной сессии (если есть)",
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440001"
+                },
+                "start_time": {
+                    "description": "Время начала активной сессии",
+                    "type": "string",
+                    "example": "2023-09-01T10:00:00Z"
+                },
+                "status": {
+                    "description": "Статус устройства: идет сессия, данные без карты, простой",
+                    "type": "string",
+                    "enum": [
+                        "active",
+                        "unassigned",
+                        "idle"
+                    ],
+                    "example": "active"
+                },
+                "unassigned": {
+                    "description": "Данные, ожидающие привязки к карте",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/handlers.UnassignedDeviceInfo"
+                        }
+                    ]
+                }
+            }
+        },
+        "handlers.DevicesResponse": {
+            "description": "Список всех известных устройств КТГ и их статус",
+            "type": "object",
+            "properties": {
+                "count": {
+                    "description": "Количество устройств",
+                    "type": "integer",
+                    "example": 2
+                },
+                "devices": {
+                    "description": "Устройства в порядке идентификаторов",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.DeviceStatusResponse"
+                    }
+                }
+            }
+        },
+        "handlers.ErrorResponse": {
+            "description": "Стандартная структура ответа об ошибке",
+            "type": "object",
+            "properties": {
+                "details": {
+                    "description": "Дополнительные детали ошибки",
+                    "type": "string",
+                    "example": "field required"
+                },
+                "error": {
+                    "description": "Описание ошибки",
+                    "type": "string",
+                    "example": "Неверный формат данных"
+                }
+            }
+        },
+        "handlers.FilterStatsResponse": {
+            "description": "Статистика фильтров артефактов по каналам устройств",
+            "type": "object",
+            "properties": {
+                "channels": {
+                    "description": "Статистика по каналам устройств",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/filters.ChannelStats"
+                    }
+                },
+                "count": {
+                    "description": "Количество каналов",
+                    "type": "integer",
+                    "example": 4
+                }
+            }
+        },
+        "handlers.HealthResponse": {
+            "description": "Информация о состоянии и работоспособности сервиса",
+            "type": "object",
+            "properties": {
+                "active_sessions": {
+                    "description": "Количество активных сессий",
+                    "type": "integer",
+                    "example": 3
+                },
+                "service": {
+                    "description": "Название сервиса",
+                    "type": "string",
+                    "example": "CTG Monitor"
+                },
+                "status": {
+                    "description": "Статус сервиса",
+                    "type": "string",
+                    "example": "healthy"
+                },
+                "timestamp": {
+                    "description": "Время проверки",
+                    "type": "string",
+                    "example": "2023-09-01T10:00:00Z"
+                }
+            }
+        },
+        "handlers.QualityStatsResponse": {
+            "description": "Индекс качества сигнала и эпизоды потери по каналам устройств",
+            "type": "object",
+            "properties": {
+                "channels": {
+                    "description": "Оценки по каналам устройств",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/quality.ChannelStats"
+                    }
+                },
+                "count": {
+                    "description": "Количество каналов",
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "handlers.ReorderStatsResponse": {
+            "description": "Переупорядоченные, дублирующиеся и опоздавшие точки по каналам устройств",
+            "type": "object",
+            "properties": {
+                "channels": {
+                    "description": "Счетчики по каналам устройств",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/reorder.ChannelStats"
+                    }
+                },
+                "count": {
+                    "description": "Количество каналов",
+                    "type": "integer",
+                    "example": 4
+                }
+            }
+        },
+        "handlers.RollupBucket": {
+            "description": "Минимум, максимум и среднее по точкам с сигналом за интервал [t, t+resolution); без сигнала -1",
+            "type": "object",
+            "properties": {
+                "count": {
+                    "description": "Всего точек",
+                    "type": "integer",
+                    "example": 240
+                },
+                "max": {
+                    "description": "Максимум",
+                    "type": "number",
+                    "example": 151
+                },
+                "mean": {
+                    "description": "Среднее",
+                    "type": "number",
+                    "example": 140.5
+                },
+                "min": {
+                    "description": "Минимум",
+                    "type": "number",
+                    "example": 132
+                },
+                "t": {
+                    "description": "Начало интервала, секунды от начала сессии",
+                    "type": "number",
+                    "example": 60
+                },
+                "valid": {
+                    "description": "Точек с сигналом",
+                    "type": "integer",
+                    "example": 236
+                }
+            }
+        },
+        "handlers.SessionDataResponse": {
+            "description": "Данные мониторинга КТГ, собранные во время сессии. Время точки t - секунды от начала сессии, w - абсолютное время UTC (мс Unix)",
+            "type": "object",
+            "properties": {
+                "channels": {
+                    "description": "Остальные каналы по имени (см. /channels)",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "array",
+                        "items": {
+                            "$ref": "#/definitions/models.CTGPoint"
+                        }
+                    }
+                },
+                "contractions": {
+                    "description": "Сводка схваток за интервал (если есть токограмма)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/handlers.ContractionSummary"
+                        }
+                    ]
+                },
+                "device_id": {
+                    "description": "Идентификатор устройства",
+                    "type": "string",
+                    "example": "CTG-DEVICE-001"
+                },
+                "events": {
+                    "description": "Результаты анализа ЧСС плода и схваток по FIGO",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CTGEvent"
+                    }
+                },
+                "fhr2_data": {
+                    "description": "ЧСС второго плода (двойня)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CTGPoint"
+                    }
+                },
+                "fhr_data": {
+                    "description": "Данные частоты сердечных сокращений плода",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CTGPoint"
+                    }
+                },
+                "max_points": {
+                    "description": "Максимум точек на канал из запроса",
+                    "type": "integer",
+                    "example": 1000
+                },
+                "quality": {
+                    "description": "Доля точек с сигналом и эпизоды потери сигнала за интервал по каналам",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/models.ChannelQuality"
+                    }
+                },
+                "segments": {
+                    "description": "Сегменты шкалы времени",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CTGSegment"
+                    }
+                },
+                "session_id": {
+                    "description": "UUID сессии",
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440001"
+                },
+                "source_points": {
+                    "description": "Точек в интервале до прореживания",
+                    "type": "integer",
+                    "example": 21600
+                },
+                "start_time": {
+                    "description": "Время начала сессии",
+                    "type": "string",
+                    "example": "2023-09-01T10:00:00Z"
+                },
+                "total_points": {
+                    "description": "Общее количество точек данных",
+                    "type": "integer",
+                    "example": 1250
+                },
+                "uc_data": {
+                    "description": "Данные маточных сокращений",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CTGPoint"
+                    }
+                }
+            }
+        },
+        "handlers.SessionDetailResponse": {
+            "description": "Сессия мониторинга КТГ: сегменты шкалы времени, продолжение после сброса шкалы, качество сигнала и число событий анализа",
+            "type": "object",
+            "properties": {
+                "card_id": {
+                    "description": "UUID медицинской карты",
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                },
+                "continues_from": {
+                    "description": "Предыдущая сессия, если эта открыта как продолжение",
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440002"
+                },
+                "device_id": {
+                    "description": "Идентификатор устройства",
+                    "type": "string",
+                    "example": "CTG-DEVICE-001"
+                },
+                "duration": {
+                    "description": "Продолжительность в секундах",
+                    "type": "integer",
+                    "example": 5400
+                },
+                "end_time": {
+                    "description": "Время окончания сессии (если завершена)",
+                    "type": "string",
+                    "example": "2023-09-01T11:30:00Z"
+                },
+                "event_count": {
+                    "description": "Событий анализа (см. /sessions/{session_id}/data)",
+                    "type": "integer",
+                    "example": 42
+                },
+                "last_data_at": {
+                    "description": "Последняя запись точек в БД",
                     "type": "string",
-                    "example": "field required"
+                    "example": "2023-09-01T11:29:58Z"
                 },
-                "error": {
-                    "description": "Описание ошибки",
+                "protocol": {
+                    "description": "Протокол теста",
                     "type": "string",
-                    "example": "Неверный формат данных"
+                    "example": "nst"
+                },
+                "protocol_result": {
+                    "description": "Заключение теста (после завершения)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ProtocolResult"
+                        }
+                    ]
+                },
+                "segments": {
+                    "description": "Сегменты шкалы времени",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CTGSegment"
+                    }
+                },
+                "session_id": {
+                    "description": "UUID сессии",
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440001"
+                },
+                "signal_quality": {
+                    "description": "Качество сигнала по каналам (после завершения)",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/models.ChannelQuality"
+                    }
+                },
+                "start_time": {
+                    "description": "Время начала сессии",
+                    "type": "string",
+                    "example": "2023-09-01T10:00:00Z"
+                },
+                "status": {
+                    "description": "Статус сессии",
+                    "type": "string",
+                    "enum": [
+                        "active",
+                        "stopped"
+                    ],
+                    "example": "active"
+                },
+                "summary": {
+                    "description": "Итоги сессии (после завершения)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.SessionSummary"
+                        }
+                    ]
                 }
             }
         },
-        "handlers.HealthResponse": {
-            "description": "Информация о состоянии и работоспособности сервиса",
+        "handlers.SessionListResponse": {
+            "description": "Сессии по фильтру; следующая страница запрашивается с cursor=next_cursor",
             "type": "object",
             "properties": {
-                "active_sessions": {
-                    "description": "Количество активных сессий",
+                "count": {
+                    "description": "Количество сессий на странице",
                     "type": "integer",
-                    "example": 3
+                    "example": 50
                 },
-                "service": {
-                    "description": "Название сервиса",
-                    "type": "string",
-                    "example": "CTG Monitor"
-                },
-                "status": {
-                    "description": "Статус сервиса",
+                "next_cursor": {
+                    "description": "Курсор следующей страницы (нет на последней)",
                     "type": "string",
-                    "example": "healthy"
+                    "example": "MjAyMy0wOS0wMV"
                 },
-                "timestamp": {
-                    "description": "Время проверки",
-                    "type": "string",
-                    "example": "2023-09-01T10:00:00Z"
+                "sessions": {
+                    "description": "Сессии страницы",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.SessionResponse"
+                    }
                 }
             }
         },
@@ -259,6 +2015,15 @@ const docTemplate = `{
                     "description": "Идентификатор устройства КТГ",
                     "type": "string",
                     "example": "CTG-DEVICE-001"
+                },
+                "protocol": {
+                    "description": "Протокол теста; без протокола - мониторинг без ограничения по времени",
+                    "type": "string",
+                    "enum": [
+                        "nst",
+                        "cst"
+                    ],
+                    "example": "nst"
                 }
             }
         },
@@ -286,6 +2051,19 @@ const docTemplate = `{
                     "type": "string",
                     "example": "2023-09-01T11:30:00Z"
                 },
+                "protocol": {
+                    "description": "Протокол теста",
+                    "type": "string",
+                    "example": "nst"
+                },
+                "protocol_result": {
+                    "description": "Заключение теста (после завершения)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ProtocolResult"
+                        }
+                    ]
+                },
                 "session_id": {
                     "description": "UUID сессии",
                     "type": "string",
@@ -304,6 +2082,65 @@ const docTemplate = `{
                         "stopped"
                     ],
                     "example": "active"
+                },
+                "summary": {
+                    "description": "Итоги сессии (после завершения)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.SessionSummary"
+                        }
+                    ]
+                }
+            }
+        },
+        "handlers.SessionRollupsResponse": {
+            "description": "Ряды каналов на интервале в разрешении, подобранном под ширину графика: сводки (min/max/mean за интервал resolution секунд) или исходные точки при resolution=0",
+            "type": "object",
+            "properties": {
+                "from": {
+                    "description": "Начало интервала, секунды от начала сессии",
+                    "type": "number",
+                    "example": 0
+                },
+                "points": {
+                    "description": "Исходные точки по каналам (при resolution=0)",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "array",
+                        "items": {
+                            "$ref": "#/definitions/models.CTGPoint"
+                        }
+                    }
+                },
+                "resolution": {
+                    "description": "Длительность интервала сводки, с (0 - исходные точки)",
+                    "type": "integer",
+                    "example": 1
+                },
+                "rollups": {
+                    "description": "Сводки по каналам",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "array",
+                        "items": {
+                            "$ref": "#/definitions/handlers.RollupBucket"
+                        }
+                    }
+                },
+                "session_id": {
+                    "description": "UUID сессии",
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440001"
+                },
+                "to": {
+                    "description": "Конец интервала, секунды от начала сессии",
+                    "type": "number",
+                    "example": 5400
+                },
+                "width": {
+                    "description": "Ширина графика, точек",
+                    "type": "integer",
+                    "example": 1000
                 }
             }
         },
@@ -320,6 +2157,488 @@ const docTemplate = `{
                     "example": "Операция выполнена успешно"
                 }
             }
+        },
+        "handlers.UnassignedDeviceInfo": {
+            "type": "object",
+            "properties": {
+                "device_id": {
+                    "description": "Идентификатор устройства",
+                    "type": "string",
+                    "example": "CTG-DEVICE-001"
+                },
+                "dropped_points": {
+                    "description": "Отброшено из-за переполнения",
+                    "type": "integer",
+                    "example": 0
+                },
+                "first_seen": {
+                    "description": "Первая точка без карты",
+                    "type": "string",
+                    "example": "2023-09-01T10:00:00Z"
+                },
+                "last_seen": {
+                    "description": "Последняя точка без карты",
+                    "type": "string",
+                    "example": "2023-09-01T10:05:00Z"
+                },
+                "pending_points": {
+                    "description": "Точек ожидает привязки",
+                    "type": "integer",
+                    "example": 1200
+                }
+            }
+        },
+        "handlers.UnassignedDevicesResponse": {
+            "description": "Устройства, передающие данные без привязки к медицинской карте",
+            "type": "object",
+            "properties": {
+                "count": {
+                    "description": "Количество устройств",
+                    "type": "integer",
+                    "example": 1
+                },
+                "devices": {
+                    "description": "Непривязанные устройства",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.UnassignedDeviceInfo"
+                    }
+                }
+            }
+        },
+        "models.CTGAlarm": {
+            "type": "object",
+            "properties": {
+                "acknowledged_at": {
+                    "type": "string"
+                },
+                "acknowledged_by": {
+                    "type": "string"
+                },
+                "channel": {
+                    "type": "string"
+                },
+                "cleared_at": {
+                    "type": "string"
+                },
+                "device_id": {
+                    "type": "string"
+                },
+                "escalated_at": {
+                    "type": "string"
+                },
+                "escalations": {
+                    "description": "Сколько раз тревога эскалировалась без подтверждения",
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "priority": {
+                    "description": "low, medium, high",
+                    "type": "string"
+                },
+                "raised_at": {
+                    "type": "string"
+                },
+                "rule": {
+                    "type": "string"
+                },
+                "session_id": {
+                    "description": "null, если устройство не привязано к карте",
+                    "type": "string"
+                },
+                "session_time": {
+                    "description": "Время сигнала на шкале сессии, с",
+                    "type": "number"
+                },
+                "state": {
+                    "type": "string"
+                },
+                "value": {
+                    "description": "Значение, вызвавшее тревогу",
+                    "type": "number"
+                }
+            }
+        },
+        "models.CTGEvent": {
+            "type": "object",
+            "properties": {
+                "amplitude": {
+                    "description": "Отклонение от базального ритма (тонуса) в пике",
+                    "type": "number"
+                },
+                "band": {
+                    "description": "Класс вариабельности (reduced, normal, increased) или качества сигнала (good, fair, poor)",
+                    "type": "string"
+                },
+                "baseline": {
+                    "description": "Базальный ритм или тонус матки на момент события",
+                    "type": "number"
+                },
+                "channel": {
+                    "description": "Канал, по которому найдено событие",
+                    "type": "string"
+                },
+                "contraction_peak": {
+                    "description": "Пик схватки, с которой связана децелерация",
+                    "type": "number"
+                },
+                "end": {
+                    "description": "Окончание эпизода",
+                    "type": "number"
+                },
+                "frequency": {
+                    "description": "Схваток за последние 10 минут на момент события",
+                    "type": "number"
+                },
+                "kind": {
+                    "description": "Тип децелерации: early, late, variable, prolonged",
+                    "type": "string"
+                },
+                "lag": {
+                    "description": "Надир минус пик схватки, с",
+                    "type": "number"
+                },
+                "peak": {
+                    "description": "Время пика акцелерации или схватки, надира децелерации",
+                    "type": "number"
+                },
+                "start": {
+                    "description": "Начало эпизода, окна оценки или момент смены оценки",
+                    "type": "number"
+                },
+                "type": {
+                    "description": "baseline, variability, acceleration, deceleration, contraction, tachysystole, signal_quality, signal_loss",
+                    "type": "string"
+                },
+                "value": {
+                    "description": "ЧСС в пике/надире, базальный ритм, амплитуда вариабельности, давление в пике схватки, частота схваток за 30 минут, индекс качества сигнала или длительность потери",
+                    "type": "number"
+                }
+            }
+        },
+        "models.CTGPoint": {
+            "type": "object",
+            "properties": {
+                "f": {
+                    "description": "Причины изменения или пометки точки",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.SampleFlags"
+                        }
+                    ]
+                },
+                "r": {
+                    "description": "Исходное значение устройства (только если отличается от V)",
+                    "type": "number"
+                },
+                "t": {
+                    "description": "Время в секундах (компактно)",
+                    "type": "number"
+                },
+                "v": {
+                    "description": "Очищенное значение",
+                    "type": "number"
+                },
+                "w": {
+                    "description": "Абсолютное время измерения UTC (мс Unix)",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.CTGSegment": {
+            "type": "object",
+            "properties": {
+                "device_time_sec": {
+                    "description": "Первое time_sec устройства в сегменте",
+                    "type": "number"
+                },
+                "index": {
+                    "description": "Номер сегмента в сессии",
+                    "type": "integer"
+                },
+                "offset": {
+                    "description": "Смещение time_sec на шкалу сессии",
+                    "type": "number"
+                },
+                "reason": {
+                    "description": "start, time_reset или gap",
+                    "type": "string"
+                },
+                "start_time": {
+                    "description": "Время сервера в начале сегмента",
+                    "type": "string"
+                }
+            }
+        },
+        "models.ChannelQuality": {
+            "type": "object",
+            "properties": {
+                "corrected": {
+                    "description": "Точек, исправленных фильтрами артефактов",
+                    "type": "integer"
+                },
+                "loss_episodes": {
+                    "description": "Эпизоды потери сигнала",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.LossEpisode"
+                    }
+                },
+                "loss_seconds": {
+                    "description": "Суммарная длительность эпизодов потери, с",
+                    "type": "number"
+                },
+                "points": {
+                    "description": "Всего точек",
+                    "type": "integer"
+                },
+                "spikes": {
+                    "description": "Из них выбросов, замененных фильтрами spike и Хампеля",
+                    "type": "integer"
+                },
+                "valid": {
+                    "description": "Точек с сигналом (не -1)",
+                    "type": "integer"
+                },
+                "valid_percent": {
+                    "description": "Доля точек с сигналом, %",
+                    "type": "number"
+                }
+            }
+        },
+        "models.LossEpisode": {
+            "type": "object",
+            "properties": {
+                "end": {
+                    "description": "Первая точка с сигналом после потери (или последняя точка без сигнала)",
+                    "type": "number"
+                },
+                "start": {
+                    "description": "Первая точка без сигнала",
+                    "type": "number"
+                }
+            }
+        },
+        "models.ProtocolResult": {
+            "type": "object",
+            "properties": {
+                "accelerations": {
+                    "description": "Акцелерации за время теста",
+                    "type": "integer"
+                },
+                "completed_at": {
+                    "description": "Время сервера на момент заключения",
+                    "type": "string"
+                },
+                "contractions": {
+                    "description": "Схватки, по которым оценивался тест",
+                    "type": "integer"
+                },
+                "duration": {
+                    "description": "Длительность записи по протоколу, с",
+                    "type": "number"
+                },
+                "late_decelerations": {
+                    "description": "Схватки, за которыми последовала поздняя децелерация",
+                    "type": "integer"
+                },
+                "outcome": {
+                    "description": "reactive, non_reactive, negative, positive, equivocal, unsatisfactory, incomplete",
+                    "type": "string"
+                },
+                "protocol": {
+                    "description": "nst, cst",
+                    "type": "string"
+                },
+                "reason": {
+                    "description": "criteria_met, time_limit, manual, interrupted",
+                    "type": "string"
+                },
+                "summary": {
+                    "description": "Текст заключения",
+                    "type": "string"
+                }
+            }
+        },
+        "models.SampleFlags": {
+            "type": "integer",
+            "format": "int32",
+            "enum": [
+                1,
+                2,
+                4,
+                8,
+                16,
+                32,
+                64,
+                128,
+                256
+            ],
+            "x-enum-comments": {
+                "FlagBackfill": "точка выгружена устройством после восстановления связи",
+                "FlagDoppler": "исправлено удвоение/деление пополам ЧСС",
+                "FlagLate": "точка пришла после закрытия окна упорядочивания",
+                "FlagMaternalHR": "датчик ЧСС плода записывает ЧСС матери",
+                "FlagOutOfRange": "значение вне допустимого диапазона заменено на -1",
+                "FlagOutlier": "выброс по фильтру Хампеля заменен медианой",
+                "FlagSameHeart": "оба датчика ЧСС плода записывают одно сердце",
+                "FlagSignalLoss": "устройство сообщило о потере сигнала (-1)",
+                "FlagSpike": "единичный выброс заменен продолжением сигнала"
+            },
+            "x-enum-descriptions": [
+                "единичный выброс заменен продолжением сигнала",
+                "выброс по фильтру Хампеля заменен медианой",
+                "исправлено удвоение/деление пополам ЧСС",
+                "значение вне допустимого диапазона заменено на -1",
+                "устройство сообщило о потере сигнала (-1)",
+                "точка пришла после закрытия окна упорядочивания",
+                "точка выгружена устройством после восстановления связи",
+                "оба датчика ЧСС плода записывают одно сердце",
+                "датчик ЧСС плода записывает ЧСС матери"
+            ],
+            "x-enum-varnames": [
+                "FlagSpike",
+                "FlagOutlier",
+                "FlagDoppler",
+                "FlagOutOfRange",
+                "FlagSignalLoss",
+                "FlagLate",
+                "FlagBackfill",
+                "FlagSameHeart",
+                "FlagMaternalHR"
+            ]
+        },
+        "models.SessionSummary": {
+            "type": "object",
+            "properties": {
+                "accelerations": {
+                    "description": "Акцелерации",
+                    "type": "integer"
+                },
+                "alarms": {
+                    "description": "Поднятых тревог",
+                    "type": "integer"
+                },
+                "baseline": {
+                    "description": "Базальный ритм, уд/мин (0 - не определен)",
+                    "type": "number"
+                },
+                "contraction_frequency": {
+                    "description": "Схваток за 10 минут в среднем за сессию",
+                    "type": "number"
+                },
+                "contractions": {
+                    "description": "Схватки",
+                    "type": "integer"
+                },
+                "decelerations": {
+                    "description": "Децелерации по типам",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/decel.Counts"
+                        }
+                    ]
+                },
+                "duration": {
+                    "description": "Длительность сессии, с",
+                    "type": "number"
+                },
+                "signal_quality": {
+                    "description": "Доля точек ЧСС с сигналом, %",
+                    "type": "number"
+                },
+                "spikes_corrected": {
+                    "description": "Выбросов, замененных фильтрами, по всем каналам",
+                    "type": "integer"
+                },
+                "variability": {
+                    "description": "Амплитуда вариабельности, уд/мин",
+                    "type": "number"
+                },
+                "variability_band": {
+                    "description": "reduced, normal, increased",
+                    "type": "string"
+                }
+            }
+        },
+        "quality.ChannelStats": {
+            "type": "object",
+            "properties": {
+                "band": {
+                    "description": "good, fair, poor",
+                    "type": "string"
+                },
+                "data_type": {
+                    "type": "string"
+                },
+                "device_id": {
+                    "type": "string"
+                },
+                "in_loss": {
+                    "description": "Сигнал сейчас потерян дольше MinLoss",
+                    "type": "boolean"
+                },
+                "index": {
+                    "description": "Доля точек окна с сигналом без исправлений, %",
+                    "type": "number"
+                },
+                "loss_episodes": {
+                    "description": "Эпизодов потери с начала наблюдения",
+                    "type": "integer"
+                },
+                "points": {
+                    "description": "Точек в окне",
+                    "type": "integer"
+                },
+                "valid_percent": {
+                    "description": "Доля точек окна с сигналом, %",
+                    "type": "number"
+                }
+            }
+        },
+        "reorder.ChannelStats": {
+            "type": "object",
+            "properties": {
+                "conflicts": {
+                    "description": "то же время с другим значением, отброшены",
+                    "type": "integer"
+                },
+                "data_type": {
+                    "type": "string"
+                },
+                "device_id": {
+                    "type": "string"
+                },
+                "duplicates": {
+                    "description": "точные дубли (то же время и значение), отброшены",
+                    "type": "integer"
+                },
+                "held": {
+                    "description": "сейчас ожидают в буфере",
+                    "type": "integer"
+                },
+                "late": {
+                    "description": "пришли после закрытия окна, выпущены вне порядка",
+                    "type": "integer"
+                },
+                "received": {
+                    "description": "всего поступило точек",
+                    "type": "integer"
+                },
+                "reordered": {
+                    "description": "пришли не по порядку и были переставлены",
+                    "type": "integer"
+                },
+                "resets": {
+                    "description": "сбросы шкалы времени устройства",
+                    "type": "integer"
+                }
+            }
         }
     },
     "tags": [
@@ -327,6 +2646,18 @@ const docTemplate = `{
             "description": "Управление сессиями мониторинга",
             "name": "sessions"
         },
+        {
+            "description": "История сессий медицинских карт",
+            "name": "cards"
+        },
+        {
+            "description": "Устройства КТГ и привязка к медицинским картам",
+            "name": "devices"
+        },
+        {
+            "description": "Каналы данных мониторинга",
+            "name": "channels"
+        },
         {
             "description": "Мониторинг состояния сервиса",
             "name": "monitoring"
@@ -0,0 +1,366 @@
+// internal/alarms/engine.go
+package alarms
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"CTG_monitor/internal/figo"
+	"CTG_monitor/internal/models"
+	"ctg_common/decel"
+	"github.com/google/uuid"
+)
+
+// ErrAlarmNotFound тревога не найдена среди неснятых
+var ErrAlarmNotFound = errors.New("тревога не найдена или уже снята")
+
+// alarmKey у правила на канале устройства не больше одной неснятой тревоги
+type alarmKey struct {
+	deviceID string
+	channel  string
+	rule     string
+}
+
+// channelKey ключ канала конкретного устройства
+type channelKey struct {
+	deviceID string
+	channel  string
+}
+
+// Engine проверяет правила по точкам и событиям анализа и ведет жизненный цикл
+// тревог. Время условий - шкала сессии, время жизненного цикла - часы сервера.
+// Возвращаемые тревоги - копии после изменения; сохранение и рассылка на вызывающем.
+type Engine struct {
+	cfg      Config
+	channels map[channelKey]*channelState
+	open     map[alarmKey]*models.CTGAlarm
+	mu       sync.Mutex
+}
+
+// NewEngine создает движок тревог
+func NewEngine(cfg Config) *Engine {
+	return &Engine{
+		cfg:      cfg.withDefaults(),
+		channels: make(map[channelKey]*channelState),
+		open:     make(map[alarmKey]*models.CTGAlarm),
+	}
+}
+
+// ObserveSample учитывает точку канала ЧСС плода (-1 - потеря сигнала)
+func (e *Engine) ObserveSample(deviceID, channel string, t, v float64, now time.Time) []models.CTGAlarm {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	state := e.channel(deviceID, channel)
+	if state.restored {
+		// Первая точка после перезапуска: серии до него неизвестны
+		state.low.interrupt(t)
+		state.high.interrupt(t)
+		state.loss.interrupt(t)
+		state.restored = false
+	}
+	valid := v != -1
+	state.loss.observe(!valid, t)
+	if valid {
+		state.low.observe(v < e.cfg.Bradycardia, t)
+		state.high.observe(v > e.cfg.Tachycardia, t)
+	} else {
+		// ЧСС во время потери сигнала неизвестна: серии по ЧСС прерываются
+		state.low.interrupt(t)
+		state.high.interrupt(t)
+	}
+
+	var changes []models.CTGAlarm
+	add := func(alarm models.CTGAlarm, changed bool) {
+		if changed {
+			changes = append(changes, alarm)
+		}
+	}
+	key := func(rule string) alarmKey {
+		return alarmKey{deviceID: deviceID, channel: channel, rule: rule}
+	}
+
+	// Тревоги по ЧСС поднимаются и снимаются только по точкам с сигналом
+	if valid {
+		sustain := e.cfg.RateSustain.Seconds()
+		add(e.apply(key(RuleBradycardia), state.low.lasted(t) >= sustain, state.low.settled(t, clearDelay),
+			v, t, now, func() string { return rateMessage(RuleBradycardia, v, e.cfg.Bradycardia, e.cfg.RateSustain) }))
+		add(e.apply(key(RuleTachycardia), state.high.lasted(t) >= sustain, state.high.settled(t, clearDelay),
+			v, t, now, func() string { return rateMessage(RuleTachycardia, v, e.cfg.Tachycardia, e.cfg.RateSustain) }))
+	}
+
+	lost := state.loss.lasted(t)
+	add(e.apply(key(RuleSignalLoss), lost >= e.cfg.SignalLoss.Seconds(), state.loss.settled(t, clearDelay),
+		lost, t, now, func() string { return fmt.Sprintf("Потеря сигнала дольше %s", e.cfg.SignalLoss) }))
+
+	add(e.evaluateReduced(deviceID, channel, state, t, now))
+	add(e.evaluateLate(deviceID, channel, state, t, now))
+	return changes
+}
+
+// ObserveEvent учитывает событие анализа: вариабельность и поздние децелерации
+// канала ЧСС плода, начало и окончание тахисистолии
+func (e *Engine) ObserveEvent(deviceID string, event models.CTGEvent, now time.Time) []models.CTGAlarm {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var alarm models.CTGAlarm
+	var changed bool
+	switch event.Type {
+	case models.EventVariability:
+		state := e.channel(deviceID, event.Channel)
+		state.reduced.observe(event.Band == figo.BandReduced, event.Start)
+		state.variability = event.Value
+		alarm, changed = e.evaluateReduced(deviceID, event.Channel, state, event.Start, now)
+	case models.EventDeceleration:
+		if event.Kind != decel.Late {
+			return nil
+		}
+		state := e.channel(deviceID, event.Channel)
+		state.late = append(state.late, event.Peak)
+		alarm, changed = e.evaluateLate(deviceID, event.Channel, state, event.End, now)
+	case models.EventTachysystole:
+		active := event.End == 0
+		t := event.Start
+		if !active {
+			t = event.End
+		}
+		alarm, changed = e.apply(alarmKey{deviceID: deviceID, channel: event.Channel, rule: RuleTachysystole},
+			active, !active, event.Value, t, now, func() string {
+				return fmt.Sprintf("Тахисистолия: %.1f схваток за 10 минут в среднем за 30 минут", event.Value)
+			})
+	}
+	if !changed {
+		return nil
+	}
+	return []models.CTGAlarm{alarm}
+}
+
+// evaluateReduced тревога сниженной вариабельности
+func (e *Engine) evaluateReduced(deviceID, channel string, state *channelState, t float64, now time.Time) (models.CTGAlarm, bool) {
+	return e.apply(alarmKey{deviceID: deviceID, channel: channel, rule: RuleReducedVariability},
+		state.reduced.lasted(t) >= e.cfg.ReducedVariability.Seconds(), !state.reduced.on,
+		state.variability, t, now, func() string {
+			return fmt.Sprintf("Сниженная вариабельность (%.1f уд/мин) дольше %s", state.variability, e.cfg.ReducedVariability)
+		})
+}
+
+// evaluateLate тревога повторяющихся поздних децелераций в окне
+func (e *Engine) evaluateLate(deviceID, channel string, state *channelState, t float64, now time.Time) (models.CTGAlarm, bool) {
+	drop := 0
+	for drop < len(state.late) && state.late[drop] < t-e.cfg.LateWindow.Seconds() {
+		drop++
+	}
+	state.late = state.late[drop:]
+
+	count := len(state.late)
+	return e.apply(alarmKey{deviceID: deviceID, channel: channel, rule: RuleLateDecelerations},
+		count >= e.cfg.LateDecelerations, count < e.cfg.LateDecelerations,
+		float64(count), t, now, func() string {
+			return fmt.Sprintf("Поздние децелерации: %d за %s", count, e.cfg.LateWindow)
+		})
+}
+
+// apply поднимает тревогу правила, если условие выполнено и тревоги нет, или
+// снимает неснятую тревогу, если условие прошло
+func (e *Engine) apply(key alarmKey, raise, clear bool, value, t float64, now time.Time, message func() string) (models.CTGAlarm, bool) {
+	alarm, exists := e.open[key]
+	switch {
+	case exists && clear:
+		cleared := now
+		alarm.State = models.AlarmCleared
+		alarm.ClearedAt = &cleared
+		delete(e.open, key)
+		return *alarm, true
+	case !exists && raise:
+		alarm = &models.CTGAlarm{
+			ID:          uuid.New(),
+			DeviceID:    key.deviceID,
+			Channel:     key.channel,
+			Rule:        key.rule,
+			Priority:    rulePriority[key.rule],
+			State:       models.AlarmRaised,
+			Message:     message(),
+			Value:       value,
+			SessionTime: t,
+			RaisedAt:    now,
+		}
+		e.open[key] = alarm
+		return *alarm, true
+	}
+	return models.CTGAlarm{}, false
+}
+
+// AttachSession запоминает сессию, к которой относится тревога
+func (e *Engine) AttachSession(id uuid.UUID, sessionID uuid.UUID) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if alarm := e.find(id); alarm != nil {
+		alarm.SessionID = &sessionID
+	}
+}
+
+// Restore возвращает в движок неснятую тревогу, сохраненную до перезапуска. История
+// сигнала не сохраняется, поэтому условие правила восстанавливается по самой тревоге:
+// тревоги по ЧСС и потере сигнала снимаются после clearDelay секунд нормальных точек,
+// поздние децелерации - когда окно отсчитается от момента подъема. Тахисистолию
+// восстановить нельзя: анализ схваток начинается заново и об ее окончании не сообщит.
+func (e *Engine) Restore(alarm models.CTGAlarm) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	key := alarmKey{deviceID: alarm.DeviceID, channel: alarm.Channel, rule: alarm.Rule}
+	if _, exists := e.open[key]; exists {
+		return false
+	}
+
+	state := e.channel(alarm.DeviceID, alarm.Channel)
+	switch alarm.Rule {
+	case RuleBradycardia, RuleTachycardia, RuleSignalLoss:
+		state.restored = true
+	case RuleReducedVariability:
+		state.reduced = run{on: true, since: alarm.SessionTime - e.cfg.ReducedVariability.Seconds(), last: alarm.SessionTime}
+		state.variability = alarm.Value
+	case RuleLateDecelerations:
+		for range int(alarm.Value) {
+			state.late = append(state.late, alarm.SessionTime)
+		}
+	default:
+		return false
+	}
+	e.open[key] = &alarm
+	return true
+}
+
+// Acknowledge подтверждает тревогу. Повторное подтверждение ничего не меняет (changed = false).
+func (e *Engine) Acknowledge(id uuid.UUID, by string, now time.Time) (models.CTGAlarm, bool, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	alarm := e.find(id)
+	if alarm == nil {
+		return models.CTGAlarm{}, false, ErrAlarmNotFound
+	}
+	changed := acknowledge(alarm, by, now)
+	return *alarm, changed, nil
+}
+
+// AcknowledgeDevice подтверждает все неподтвержденные тревоги устройства
+func (e *Engine) AcknowledgeDevice(deviceID, by string, now time.Time) []models.CTGAlarm {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var changes []models.CTGAlarm
+	for key, alarm := range e.open {
+		if key.deviceID == deviceID && acknowledge(alarm, by, now) {
+			changes = append(changes, *alarm)
+		}
+	}
+	sortAlarms(changes)
+	return changes
+}
+
+// Escalate повышает приоритет тревог, не подтвержденных в течение периода эскалации
+func (e *Engine) Escalate(now time.Time) []models.CTGAlarm {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var changes []models.CTGAlarm
+	for _, alarm := range e.open {
+		if alarm.State != models.AlarmRaised {
+			continue
+		}
+		since := alarm.RaisedAt
+		if alarm.EscalatedAt != nil {
+			since = *alarm.EscalatedAt
+		}
+		if now.Sub(since) < e.cfg.Escalation {
+			continue
+		}
+		escalated := now
+		alarm.EscalatedAt = &escalated
+		alarm.Escalations++
+		alarm.Priority = escalate(alarm.Priority)
+		changes = append(changes, *alarm)
+	}
+	sortAlarms(changes)
+	return changes
+}
+
+// ResetDevice забывает историю устройства (новый пациент) и снимает его тревоги
+func (e *Engine) ResetDevice(deviceID string, now time.Time) []models.CTGAlarm {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for key := range e.channels {
+		if key.deviceID == deviceID {
+			delete(e.channels, key)
+		}
+	}
+
+	var changes []models.CTGAlarm
+	for key, alarm := range e.open {
+		if key.deviceID != deviceID {
+			continue
+		}
+		cleared := now
+		alarm.State = models.AlarmCleared
+		alarm.ClearedAt = &cleared
+		delete(e.open, key)
+		changes = append(changes, *alarm)
+	}
+	sortAlarms(changes)
+	return changes
+}
+
+// Open возвращает неснятые тревоги по времени поднятия
+func (e *Engine) Open() []models.CTGAlarm {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	alarms := make([]models.CTGAlarm, 0, len(e.open))
+	for _, alarm := range e.open {
+		alarms = append(alarms, *alarm)
+	}
+	sortAlarms(alarms)
+	return alarms
+}
+
+func (e *Engine) channel(deviceID, channel string) *channelState {
+	key := channelKey{deviceID: deviceID, channel: channel}
+	state, exists := e.channels[key]
+	if !exists {
+		state = &channelState{}
+		e.channels[key] = state
+	}
+	return state
+}
+
+func (e *Engine) find(id uuid.UUID) *models.CTGAlarm {
+	for _, alarm := range e.open {
+		if alarm.ID == id {
+			return alarm
+		}
+	}
+	return nil
+}
+
+// acknowledge переводит поднятую тревогу в подтвержденные
+func acknowledge(alarm *models.CTGAlarm, by string, now time.Time) bool {
+	if alarm.State != models.AlarmRaised {
+		return false
+	}
+	acknowledged := now
+	alarm.State = models.AlarmAcknowledged
+	alarm.AcknowledgedAt = &acknowledged
+	alarm.AcknowledgedBy = by
+	return true
+}
+
+func sortAlarms(alarms []models.CTGAlarm) {
+	sort.Slice(alarms, func(i, j int) bool { return alarms[i].RaisedAt.Before(alarms[j].RaisedAt) })
+}
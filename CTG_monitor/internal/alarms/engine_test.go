@@ -0,0 +1,142 @@
+package alarms
+
+import (
+	"testing"
+	"time"
+
+	"CTG_monitor/internal/models"
+)
+
+// sample точка ЧСС; повторяется каждые 0.25 с до until
+type sample struct {
+	value float64
+	until float64
+}
+
+func TestRateAlarmsAndSignalLoss(t *testing.T) {
+	cases := []struct {
+		name    string
+		trace   []sample
+		raised  []string // правила поднятых тревог по порядку
+		cleared []string // правила снятых тревог по порядку
+		value   float64  // значение в тревоге брадикардии
+	}{
+		{
+			name:   "брадикардия",
+			trace:  []sample{{140, 60}, {100, 250}},
+			raised: []string{RuleBradycardia},
+			value:  100,
+		},
+		{
+			name:   "одна низкая точка и потеря сигнала",
+			trace:  []sample{{140, 60}, {100, 60.25}, {-1, 300}},
+			raised: []string{RuleSignalLoss},
+		},
+		{
+			name:   "серия прерывается потерей сигнала",
+			trace:  []sample{{100, 150}, {-1, 160}, {100, 350}},
+			raised: []string{RuleBradycardia},
+			value:  100,
+		},
+		{
+			name:    "потеря сигнала не снимает брадикардию",
+			trace:   []sample{{100, 200}, {-1, 230}, {100, 240}, {140, 260}},
+			raised:  []string{RuleBradycardia},
+			cleared: []string{RuleBradycardia},
+			value:   100,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			engine := NewEngine(Config{})
+			now := time.Now()
+			var raised, cleared []string
+			var brady *models.CTGAlarm
+			tm := 0.0
+			for _, s := range c.trace {
+				for ; tm < s.until; tm += 0.25 {
+					for _, alarm := range engine.ObserveSample("CTG-001", "fetal_heart_rate", tm, s.value, now) {
+						switch alarm.State {
+						case models.AlarmRaised:
+							raised = append(raised, alarm.Rule)
+							if alarm.Rule == RuleBradycardia {
+								brady = &alarm
+							}
+						case models.AlarmCleared:
+							cleared = append(cleared, alarm.Rule)
+							// Брадикардия не снимается, пока нет сигнала
+							if alarm.Rule == RuleBradycardia && tm < 240 {
+								t.Errorf("брадикардия снята на %.2f с", tm)
+							}
+						}
+					}
+				}
+			}
+
+			if !equalRules(raised, c.raised) || !equalRules(cleared, c.cleared) {
+				t.Fatalf("подняты %v, сняты %v; ожидалось %v, %v", raised, cleared, c.raised, c.cleared)
+			}
+			if brady != nil && brady.Value != c.value {
+				t.Errorf("значение в тревоге брадикардии %.0f, ожидалось %.0f: %s", brady.Value, c.value, brady.Message)
+			}
+		})
+	}
+}
+
+// equalRules сравнивает списки правил; nil и пустой равны
+func equalRules(got, want []string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestRestore(t *testing.T) {
+	engine := NewEngine(Config{})
+	now := time.Now()
+	restored := models.CTGAlarm{
+		DeviceID:    "CTG-001",
+		Channel:     "fetal_heart_rate",
+		Rule:        RuleBradycardia,
+		Priority:    PriorityHigh,
+		State:       models.AlarmRaised,
+		Value:       100,
+		SessionTime: 500,
+	}
+	if !engine.Restore(restored) {
+		t.Fatal("тревога брадикардии не восстановлена")
+	}
+	if engine.Restore(models.CTGAlarm{DeviceID: "CTG-001", Channel: "uterine_contractions", Rule: RuleTachysystole}) {
+		t.Error("тахисистолия восстановлена, но снять ее будет некому")
+	}
+
+	// После перезапуска ЧСС все еще низкая: тревога не снимается и не поднимается заново
+	tm := 600.0
+	for ; tm < 900; tm += 0.25 {
+		if changes := engine.ObserveSample("CTG-001", "fetal_heart_rate", tm, 100, now); len(changes) != 0 {
+			t.Fatalf("на %.2f с изменились тревоги: %v", tm, changes)
+		}
+	}
+	if open := engine.Open(); len(open) != 1 || open[0].Rule != RuleBradycardia {
+		t.Fatalf("неснятые тревоги: %v", open)
+	}
+
+	// Нормальная ЧСС снимает тревогу через clearDelay
+	start := tm
+	for ; tm < start+2*clearDelay; tm += 0.25 {
+		for _, alarm := range engine.ObserveSample("CTG-001", "fetal_heart_rate", tm, 140, now) {
+			if alarm.State != models.AlarmCleared || tm-start < clearDelay-0.25 {
+				t.Fatalf("на %.2f с тревога %s перешла в %s", tm, alarm.Rule, alarm.State)
+			}
+		}
+	}
+	if open := engine.Open(); len(open) != 0 {
+		t.Errorf("тревоги не сняты: %v", open)
+	}
+}
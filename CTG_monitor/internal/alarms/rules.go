@@ -0,0 +1,157 @@
+// internal/alarms/rules.go
+package alarms
+
+import (
+	"fmt"
+	"time"
+)
+
+// Правила тревог
+const (
+	RuleBradycardia        = "bradycardia"         // ЧСС плода ниже порога дольше заданного времени
+	RuleTachycardia        = "tachycardia"         // ЧСС плода выше порога дольше заданного времени
+	RuleReducedVariability = "reduced_variability" // сниженная вариабельность дольше заданного времени
+	RuleLateDecelerations  = "late_decelerations"  // повторяющиеся поздние децелерации
+	RuleSignalLoss         = "signal_loss"         // устройство долго сообщает о потере сигнала (-1)
+	RuleTachysystole       = "tachysystole"        // более 5 схваток за 10 минут в среднем за 30 минут
+)
+
+// Приоритеты тревог по возрастанию
+const (
+	PriorityLow    = "low"
+	PriorityMedium = "medium"
+	PriorityHigh   = "high"
+)
+
+// rulePriority начальный приоритет тревоги правила
+var rulePriority = map[string]string{
+	RuleBradycardia:        PriorityHigh,
+	RuleTachycardia:        PriorityMedium,
+	RuleReducedVariability: PriorityMedium,
+	RuleLateDecelerations:  PriorityHigh,
+	RuleSignalLoss:         PriorityLow,
+	RuleTachysystole:       PriorityMedium,
+}
+
+// clearDelay сколько ЧСС должна оставаться в норме, чтобы тревога по ней снялась, с
+const clearDelay = 10.0
+
+// Значения по умолчанию
+const (
+	defaultBradycardia        = 110.0
+	defaultTachycardia        = 160.0
+	defaultRateSustain        = 3 * time.Minute
+	defaultReducedVariability = 30 * time.Minute
+	defaultLateDecelerations  = 2
+	defaultLateWindow         = 30 * time.Minute
+	defaultSignalLoss         = time.Minute
+	defaultEscalation         = 2 * time.Minute
+)
+
+// Config пороги правил и таймер эскалации
+type Config struct {
+	Bradycardia        float64       // порог брадикардии, уд/мин
+	Tachycardia        float64       // порог тахикардии, уд/мин
+	RateSustain        time.Duration // сколько ЧСС должна быть за порогом
+	ReducedVariability time.Duration // сколько должна длиться сниженная вариабельность
+	LateDecelerations  int           // поздних децелераций в окне для тревоги
+	LateWindow         time.Duration // окно подсчета поздних децелераций
+	SignalLoss         time.Duration // длительность серии -1 для тревоги
+	Escalation         time.Duration // период эскалации неподтвержденной тревоги
+}
+
+// withDefaults заполняет незаданные параметры
+func (c Config) withDefaults() Config {
+	if c.Bradycardia <= 0 {
+		c.Bradycardia = defaultBradycardia
+	}
+	if c.Tachycardia <= 0 {
+		c.Tachycardia = defaultTachycardia
+	}
+	if c.RateSustain <= 0 {
+		c.RateSustain = defaultRateSustain
+	}
+	if c.ReducedVariability <= 0 {
+		c.ReducedVariability = defaultReducedVariability
+	}
+	if c.LateDecelerations <= 0 {
+		c.LateDecelerations = defaultLateDecelerations
+	}
+	if c.LateWindow <= 0 {
+		c.LateWindow = defaultLateWindow
+	}
+	if c.SignalLoss <= 0 {
+		c.SignalLoss = defaultSignalLoss
+	}
+	if c.Escalation <= 0 {
+		c.Escalation = defaultEscalation
+	}
+	return c
+}
+
+// escalate повышает приоритет на ступень
+func escalate(priority string) string {
+	switch priority {
+	case PriorityLow:
+		return PriorityMedium
+	default:
+		return PriorityHigh
+	}
+}
+
+// run серия точек, на которых выполняется условие правила
+type run struct {
+	on    bool
+	since float64 // начало серии
+	last  float64 // последняя точка, где условие выполнялось
+}
+
+// observe учитывает точку: условие выполняется или нет
+func (r *run) observe(on bool, t float64) {
+	if on {
+		if !r.on {
+			r.since = t
+		}
+		r.last = t
+	}
+	r.on = on
+}
+
+// interrupt прерывает серию точкой без сигнала: новая серия начнется заново, а
+// снятие тревоги отсчитывается от конца перерыва
+func (r *run) interrupt(t float64) {
+	r.on = false
+	r.last = t
+}
+
+// lasted длительность текущей серии (0, если условие не выполняется)
+func (r run) lasted(t float64) float64 {
+	if !r.on {
+		return 0
+	}
+	return t - r.since
+}
+
+// settled условие не выполняется уже не меньше delay
+func (r run) settled(t, delay float64) bool {
+	return !r.on && t-r.last >= delay
+}
+
+// channelState состояние правил одного канала ЧСС плода
+type channelState struct {
+	low, high, loss run
+	reduced         run
+	variability     float64   // амплитуда вариабельности из последней оценки
+	late            []float64 // надиры поздних децелераций в окне
+	restored        bool      // тревоги канала восстановлены после перезапуска
+}
+
+// rateMessage текст тревоги по ЧСС
+func rateMessage(rule string, v, limit float64, sustain time.Duration) string {
+	switch rule {
+	case RuleBradycardia:
+		return fmt.Sprintf("Брадикардия: ЧСС %.0f уд/мин ниже %.0f дольше %s", v, limit, sustain)
+	default:
+		return fmt.Sprintf("Тахикардия: ЧСС %.0f уд/мин выше %.0f дольше %s", v, limit, sustain)
+	}
+}
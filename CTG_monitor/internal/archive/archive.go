@@ -0,0 +1,118 @@
+// internal/archive/archive.go
+package archive
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"CTG_monitor/internal/chunks"
+	"CTG_monitor/internal/models"
+)
+
+// Extension расширение файла архива сессии
+const Extension = ".ctga"
+
+// magic начало файла архива: сигнатура и версия формата
+var magic = []byte("CTGARCH\x01")
+
+// ErrFormat файл не является архивом сессии
+var ErrFormat = errors.New("неверный формат архива сессии")
+
+// Archive сессия с точками всех каналов
+type Archive struct {
+	Session models.CTGSession
+	Series  map[string][]models.CTGPoint
+}
+
+// Write пишет архив сессии: сигнатуру, сессию (JSON, без точек) и ряды каналов
+// в порядке имен - имя и кадр точек в кодировке format. Длины частей - uvarint.
+func Write(w io.Writer, session *models.CTGSession, series map[string][]models.CTGPoint, format chunks.Format) error {
+	sessionJSON, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(series))
+	for name := range series {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buf := append([]byte{}, magic...)
+	buf = appendBytes(buf, sessionJSON)
+	buf = binary.AppendUvarint(buf, uint64(len(names)))
+	for _, name := range names {
+		frame, err := chunks.AppendFrame(nil, series[name], format)
+		if err != nil {
+			return fmt.Errorf("канал %s: %w", name, err)
+		}
+		buf = appendBytes(buf, []byte(name))
+		buf = appendBytes(buf, frame)
+	}
+
+	_, err = w.Write(buf)
+	return err
+}
+
+// Read читает архив сессии. Поврежденный или обрезанный архив - ошибка ErrFormat.
+func Read(r io.Reader) (*Archive, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(data, magic) {
+		return nil, ErrFormat
+	}
+	data = data[len(magic):]
+
+	sessionJSON, data, err := readBytes(data)
+	if err != nil {
+		return nil, err
+	}
+	archive := &Archive{Series: make(map[string][]models.CTGPoint)}
+	if err := json.Unmarshal(sessionJSON, &archive.Session); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFormat, err)
+	}
+
+	count, n := binary.Uvarint(data)
+	if n <= 0 {
+		return nil, ErrFormat
+	}
+	data = data[n:]
+	for ; count > 0; count-- {
+		var name, frame []byte
+		if name, data, err = readBytes(data); err != nil {
+			return nil, err
+		}
+		if frame, data, err = readBytes(data); err != nil {
+			return nil, err
+		}
+		points, err := chunks.DecodeFrames(frame)
+		if err != nil {
+			return nil, fmt.Errorf("%w: канал %s: %v", ErrFormat, name, err)
+		}
+		archive.Series[string(name)] = points
+	}
+	return archive, nil
+}
+
+// appendBytes дописывает длину и содержимое
+func appendBytes(dst, value []byte) []byte {
+	dst = binary.AppendUvarint(dst, uint64(len(value)))
+	return append(dst, value...)
+}
+
+// readBytes читает часть, записанную appendBytes
+func readBytes(data []byte) ([]byte, []byte, error) {
+	size, n := binary.Uvarint(data)
+	if n <= 0 || size > uint64(len(data)-n) {
+		return nil, nil, ErrFormat
+	}
+	data = data[n:]
+	return data[:size], data[size:], nil
+}
@@ -0,0 +1,123 @@
+package archive
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"CTG_monitor/internal/channels"
+	"CTG_monitor/internal/chunks"
+	"CTG_monitor/internal/models"
+	"github.com/google/uuid"
+)
+
+// testArchive сессия с двумя каналами и закодированный архив
+func testArchive(t *testing.T) (*models.CTGSession, map[string][]models.CTGPoint, []byte) {
+	t.Helper()
+	session := &models.CTGSession{
+		ID:        uuid.New(),
+		CardID:    uuid.New(),
+		DeviceID:  "CTG-001",
+		StartTime: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC),
+		Events:    []models.CTGEvent{{Type: models.EventSignalLoss, Channel: channels.FetalHeartRate, Start: 0.5, End: 0.75, Value: 0.3}},
+	}
+	raw := 210.0
+	series := map[string][]models.CTGPoint{
+		channels.FetalHeartRate: {
+			{T: 0, V: 140, W: 1767268800000},
+			{T: 0.25, V: 141, R: &raw, F: models.FlagOutlier, W: 1767268800250},
+			{T: 0.5, V: -1, F: models.FlagSignalLoss, W: 1767268800500},
+		},
+		channels.UterineContractions: {{T: 0, V: 12.5}, {T: 0.25, V: 13}},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, session, series, chunks.DefaultFormat); err != nil {
+		t.Fatalf("ошибка записи архива: %v", err)
+	}
+	return session, series, buf.Bytes()
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, format := range []string{chunks.EncodingCodec, chunks.EncodingJSON} {
+		t.Run(format, func(t *testing.T) {
+			session, series, _ := testArchive(t)
+			chunkFormat, err := chunks.ParseFormat(format, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := Write(&buf, session, series, chunkFormat); err != nil {
+				t.Fatal(err)
+			}
+
+			archive, err := Read(&buf)
+			if err != nil {
+				t.Fatalf("ошибка чтения архива: %v", err)
+			}
+			if archive.Session.ID != session.ID || archive.Session.DeviceID != session.DeviceID ||
+				!archive.Session.StartTime.Equal(session.StartTime) || !reflect.DeepEqual(archive.Session.Events, session.Events) {
+				t.Errorf("сессия прочитана как %+v", archive.Session)
+			}
+			if !reflect.DeepEqual(archive.Series, series) {
+				t.Errorf("ряды прочитаны как %+v, ожидались %+v", archive.Series, series)
+			}
+		})
+	}
+}
+
+func TestEmptySeries(t *testing.T) {
+	session, _, _ := testArchive(t)
+	var buf bytes.Buffer
+	if err := Write(&buf, session, nil, chunks.DefaultFormat); err != nil {
+		t.Fatal(err)
+	}
+	archive, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("ошибка чтения архива: %v", err)
+	}
+	if len(archive.Series) != 0 {
+		t.Errorf("прочитаны ряды %v", archive.Series)
+	}
+}
+
+func TestInvalidArchive(t *testing.T) {
+	_, _, data := testArchive(t)
+
+	cases := []struct {
+		name string
+		data []byte
+	}{
+		{"пустой файл", nil},
+		{"чужая сигнатура", append([]byte("CTGARCH\x02"), data[len(magic):]...)},
+		{"длина сессии больше файла", binary.AppendUvarint(append([]byte{}, magic...), 1<<40)},
+		{"каналов больше записанных", func() []byte {
+			b := appendBytes(append([]byte{}, magic...), []byte("{}"))
+			return binary.AppendUvarint(b, 1000)
+		}()},
+		{"сессия не JSON", appendBytes(append([]byte{}, magic...), []byte("not json"))},
+		{"поврежденный кадр", func() []byte {
+			b := appendBytes(append([]byte{}, magic...), []byte("{}"))
+			b = binary.AppendUvarint(b, 1)
+			b = appendBytes(b, []byte(channels.FetalHeartRate))
+			return appendBytes(b, []byte{0xff, 0xff})
+		}()},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if _, err := Read(bytes.NewReader(c.data)); !errors.Is(err, ErrFormat) {
+				t.Errorf("ошибка %v, ожидалась ErrFormat", err)
+			}
+		})
+	}
+
+	// Архив, обрезанный в любом месте
+	for n := 0; n < len(data); n++ {
+		if _, err := Read(bytes.NewReader(data[:n])); !errors.Is(err, ErrFormat) {
+			t.Fatalf("архив обрезан до %d из %d байт: ошибка %v", n, len(data), err)
+		}
+	}
+}
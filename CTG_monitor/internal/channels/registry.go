@@ -0,0 +1,126 @@
+// internal/channels/registry.go
+package channels
+
+import (
+	"log"
+	"regexp"
+	"sort"
+)
+
+// Имена каналов (совпадают с типом данных в топике medical/ctg/<type>/<device>)
+const (
+	FetalHeartRate      = "fetal_heart_rate"
+	FetalHeartRate2     = "fetal_heart_rate_2"
+	UterineContractions = "uterine_contractions"
+	MaternalHeartRate   = "maternal_heart_rate"
+	MaternalSpO2        = "maternal_spo2"
+	MaternalBPSystolic  = "maternal_bp_systolic"
+	MaternalBPDiastolic = "maternal_bp_diastolic"
+	MaternalTemperature = "maternal_temperature"
+	FetalMovement       = "fetal_movement"
+)
+
+// Виды каналов
+const (
+	KindSignal = "signal" // непрерывный сигнал с постоянной частотой
+	KindMarker = "marker" // отметки событий (значение - признак или интенсивность)
+)
+
+// namePattern допустимое имя канала (используется в топиках и ключах блоков точек)
+var namePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// Channel описание канала данных
+type Channel struct {
+	Name       string   `json:"name" example:"fetal_heart_rate"` // Имя канала (тип данных в топике)
+	Title      string   `json:"title" example:"ЧСС плода"`       // Название для интерфейса
+	Units      string   `json:"units" example:"bpm"`             // Единицы измерения
+	Kind       string   `json:"kind" example:"signal"`           // signal или marker
+	Min        float64  `json:"min" example:"50"`                // Нижняя граница допустимых значений
+	Max        float64  `json:"max" example:"220"`               // Верхняя граница допустимых значений
+	SampleRate float64  `json:"sample_rate" example:"4"`         // Номинальная частота, Гц (0 - нерегулярные измерения)
+	Filters    []string `json:"filters" example:"hampel"`        // Цепочка фильтров артефактов
+	Fetus      int      `json:"fetus,omitempty" example:"1"`     // Номер плода для каналов ЧСС плода
+}
+
+// InRange проверяет значение по допустимому диапазону канала.
+// -1 означает потерю сигнала и допустимо всегда.
+func (c Channel) InRange(value float64) bool {
+	return value == -1 || value >= c.Min && value <= c.Max
+}
+
+// Defaults стандартный набор каналов КТГ и мониторинга матери
+func Defaults() []Channel {
+	return []Channel{
+		{Name: FetalHeartRate, Title: "ЧСС плода", Units: "bpm", Kind: KindSignal,
+			Min: 50, Max: 220, SampleRate: 4, Filters: []string{"hampel"}, Fetus: 1},
+		{Name: FetalHeartRate2, Title: "ЧСС второго плода", Units: "bpm", Kind: KindSignal,
+			Min: 50, Max: 220, SampleRate: 4, Filters: []string{"hampel"}, Fetus: 2},
+		{Name: UterineContractions, Title: "Сокращения матки", Units: "mmHg", Kind: KindSignal,
+			Min: -5, Max: 150, SampleRate: 4, Filters: []string{"hampel"}},
+		{Name: MaternalHeartRate, Title: "ЧСС матери", Units: "bpm", Kind: KindSignal,
+			Min: 30, Max: 240, SampleRate: 4, Filters: []string{"hampel"}},
+		{Name: MaternalSpO2, Title: "SpO2 матери", Units: "%", Kind: KindSignal,
+			Min: 50, Max: 100, SampleRate: 1},
+		{Name: MaternalBPSystolic, Title: "Систолическое АД матери", Units: "mmHg", Kind: KindSignal,
+			Min: 50, Max: 250},
+		{Name: MaternalBPDiastolic, Title: "Диастолическое АД матери", Units: "mmHg", Kind: KindSignal,
+			Min: 20, Max: 150},
+		{Name: MaternalTemperature, Title: "Температура матери", Units: "°C", Kind: KindSignal,
+			Min: 30, Max: 43, SampleRate: 1.0 / 60},
+		{Name: FetalMovement, Title: "Шевеления плода", Units: "", Kind: KindMarker,
+			Min: 0, Max: 1},
+	}
+}
+
+// Registry набор известных каналов. После создания только читается.
+type Registry struct {
+	channels map[string]Channel
+}
+
+// NewRegistry создает реестр каналов. chains переопределяет цепочки фильтров
+// каналов (ключ - имя канала); у каналов-отметок фильтров нет.
+// Каналы с недопустимым именем пропускаются с предупреждением.
+func NewRegistry(channels []Channel, chains map[string][]string) *Registry {
+	registry := &Registry{channels: make(map[string]Channel, len(channels))}
+	for _, channel := range channels {
+		if !namePattern.MatchString(channel.Name) {
+			log.Printf("Недопустимое имя канала %q, пропускаем", channel.Name)
+			continue
+		}
+		if chain, ok := chains[channel.Name]; ok {
+			channel.Filters = chain
+		}
+		if channel.Kind == KindMarker {
+			channel.Filters = nil
+		}
+		registry.channels[channel.Name] = channel
+	}
+	return registry
+}
+
+// Lookup возвращает канал по имени
+func (r *Registry) Lookup(name string) (Channel, bool) {
+	channel, ok := r.channels[name]
+	return channel, ok
+}
+
+// All возвращает каналы, упорядоченные по имени
+func (r *Registry) All() []Channel {
+	all := make([]Channel, 0, len(r.channels))
+	for _, channel := range r.channels {
+		all = append(all, channel)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+	return all
+}
+
+// FilterChains цепочки фильтров по каналам (для filters.NewBank)
+func (r *Registry) FilterChains() map[string][]string {
+	chains := make(map[string][]string, len(r.channels))
+	for name, channel := range r.channels {
+		if len(channel.Filters) > 0 {
+			chains[name] = channel.Filters
+		}
+	}
+	return chains
+}
@@ -0,0 +1,132 @@
+// internal/chunks/chunks.go
+package chunks
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"CTG_monitor/internal/models"
+	"ctg_common/chunk"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Build раскладывает точки канала по блокам chunk.Span. Каждый блок содержит
+// один кадр в кодировке format с точками, попавшими в его интервал, в исходном порядке.
+func Build(sessionID uuid.UUID, channel string, points []models.CTGPoint, format Format) ([]models.CTGChunk, error) {
+	buckets := make(map[int64][]models.CTGPoint)
+	var order []int64
+	for _, point := range points {
+		bucket := chunk.Bucket(point.T)
+		if _, ok := buckets[bucket]; !ok {
+			order = append(order, bucket)
+		}
+		buckets[bucket] = append(buckets[bucket], point)
+	}
+
+	now := time.Now().UTC()
+	rows := make([]models.CTGChunk, 0, len(order))
+	for _, bucket := range order {
+		bucketPoints := buckets[bucket]
+		data, err := AppendFrame(nil, bucketPoints, format)
+		if err != nil {
+			return nil, err
+		}
+		row := models.CTGChunk{
+			SessionID: sessionID,
+			Channel:   channel,
+			Bucket:    bucket,
+			FromTime:  bucketPoints[0].T,
+			ToTime:    bucketPoints[0].T,
+			Count:     len(bucketPoints),
+			Data:      data,
+			UpdatedAt: now,
+		}
+		for _, point := range bucketPoints[1:] {
+			row.FromTime = min(row.FromTime, point.T)
+			row.ToTime = max(row.ToTime, point.T)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// Write дописывает кадры блоков в ctg_chunks одним запросом: новый блок
+// вставляется, в существующий кадр дописывается в конец. Ключи блоков
+// (сессия, канал, номер) в одном вызове не должны повторяться.
+func Write(db *gorm.DB, rows []models.CTGChunk) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	return db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "session_id"}, {Name: "channel"}, {Name: "bucket"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"data":       gorm.Expr("ctg_chunks.data || excluded.data"),
+			"count":      gorm.Expr("ctg_chunks.count + excluded.count"),
+			"from_time":  gorm.Expr("LEAST(ctg_chunks.from_time, excluded.from_time)"),
+			"to_time":    gorm.Expr("GREATEST(ctg_chunks.to_time, excluded.to_time)"),
+			"updated_at": gorm.Expr("excluded.updated_at"),
+		}),
+	}).Create(&rows).Error
+}
+
+// Decode разбирает кадры блока в порядке записи
+func Decode(row models.CTGChunk) ([]models.CTGPoint, error) {
+	return DecodeFrames(row.Data)
+}
+
+// Assemble собирает ряды каналов из блоков. Точки упорядочиваются по времени;
+// из точек с одинаковым временем остается записанная первой, поэтому повторная
+// запись тех же точек (повтор после ошибки, выгрузка задним числом) безопасна.
+func Assemble(rows []models.CTGChunk) (map[string][]models.CTGPoint, error) {
+	series := make(map[string][]models.CTGPoint)
+	for _, row := range rows {
+		points, err := Decode(row)
+		if err != nil {
+			return nil, fmt.Errorf("блок %s/%d сессии %s: %w", row.Channel, row.Bucket, row.SessionID, err)
+		}
+		series[row.Channel] = append(series[row.Channel], points...)
+	}
+
+	for name, points := range series {
+		series[name] = Dedupe(points)
+	}
+	return series, nil
+}
+
+// Dedupe упорядочивает точки по времени на месте и оставляет из точек с одинаковым
+// временем первую по порядку в points
+func Dedupe(points []models.CTGPoint) []models.CTGPoint {
+	sort.SliceStable(points, func(i, j int) bool { return points[i].T < points[j].T })
+	unique := points[:0]
+	for i, point := range points {
+		if i > 0 && point.T == unique[len(unique)-1].T {
+			continue
+		}
+		unique = append(unique, point)
+	}
+	return unique
+}
+
+// Load читает и собирает ряды каналов names сессии (пусто - всех каналов). from и to
+// (время сессии, с) отбирают блоки, пересекающие интервал; точки на краях блоков не отсекаются.
+func Load(db *gorm.DB, sessionID uuid.UUID, names []string, from, to *float64) (map[string][]models.CTGPoint, error) {
+	query := db.Where("session_id = ?", sessionID)
+	if len(names) > 0 {
+		query = query.Where("channel IN ?", names)
+	}
+	if from != nil {
+		query = query.Where("to_time >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("from_time <= ?", *to)
+	}
+
+	var rows []models.CTGChunk
+	if err := query.Order("channel, bucket").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("не удалось прочитать блоки точек сессии %s: %w", sessionID, err)
+	}
+	return Assemble(rows)
+}
@@ -0,0 +1,117 @@
+package chunks
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"CTG_monitor/internal/models"
+	"ctg_common/chunk"
+	"ctg_common/codec"
+)
+
+// testPoints блок ЧСС 4 Гц на chunk.Span: базальный ритм с вариабельностью,
+// потери сигнала, исправленные выбросы и абсолютное время с дрейфом часов
+func testPoints() []models.CTGPoint {
+	rng := rand.New(rand.NewSource(1))
+	points := make([]models.CTGPoint, int(chunk.Span*4))
+	start := int64(1760000000000)
+	for i := range points {
+		t := float64(i) * 0.25
+		point := models.CTGPoint{
+			T: t,
+			V: math.Round((140+8*math.Sin(t/20)+rng.NormFloat64()*2)*4) / 4,
+			W: start + int64(t*1000) + int64(i/400),
+		}
+		switch {
+		case i%600 < 20:
+			point.V = -1
+			point.F = models.FlagSignalLoss
+		case i%97 == 0:
+			raw := point.V * 2
+			point.R = &raw
+			point.F = models.FlagDoppler
+		}
+		points[i] = point
+	}
+	return points
+}
+
+func TestFrameRoundTrip(t *testing.T) {
+	points := testPoints()
+	formats := map[string]Format{
+		"json":  {Encoding: chunk.EncodingJSON},
+		"codec": DefaultFormat,
+		"fixed": {Encoding: chunk.EncodingCodec, Options: codec.Options{ValueScale: 4}},
+	}
+	for name, format := range formats {
+		var data []byte
+		var err error
+		// Два кадра в одном блоке, как после двух записей
+		for _, part := range [][]models.CTGPoint{points[:1000], points[1000:]} {
+			if data, err = AppendFrame(data, part, format); err != nil {
+				t.Fatalf("%s: не удалось закодировать: %v", name, err)
+			}
+		}
+		decoded, err := DecodeFrames(data)
+		if err != nil {
+			t.Fatalf("%s: не удалось разобрать: %v", name, err)
+		}
+		if !reflect.DeepEqual(decoded, points) {
+			t.Fatalf("%s: точки после разбора отличаются", name)
+		}
+	}
+}
+
+func BenchmarkEncode(b *testing.B) {
+	points := testPoints()
+	benchmarks := []struct {
+		name   string
+		encode func() ([]byte, error)
+	}{
+		{"json", func() ([]byte, error) { return AppendFrame(nil, points, Format{Encoding: chunk.EncodingJSON}) }},
+		{"codec", func() ([]byte, error) { return AppendFrame(nil, points, DefaultFormat) }},
+		{"codec_fixed", func() ([]byte, error) {
+			return AppendFrame(nil, points, Format{Encoding: chunk.EncodingCodec, Options: codec.Options{ValueScale: 4}})
+		}},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			var size int
+			for i := 0; i < b.N; i++ {
+				data, err := bm.encode()
+				if err != nil {
+					b.Fatal(err)
+				}
+				size = len(data)
+			}
+			b.ReportMetric(float64(size)/float64(len(points)), "bytes/point")
+		})
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	points := testPoints()
+	formats := []struct {
+		name   string
+		format Format
+	}{
+		{"json", Format{Encoding: chunk.EncodingJSON}},
+		{"codec", DefaultFormat},
+		{"codec_fixed", Format{Encoding: chunk.EncodingCodec, Options: codec.Options{ValueScale: 4}}},
+	}
+	for _, bm := range formats {
+		data, err := AppendFrame(nil, points, bm.format)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(bm.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := DecodeFrames(data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
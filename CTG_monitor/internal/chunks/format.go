@@ -0,0 +1,64 @@
+// internal/chunks/format.go
+package chunks
+
+import (
+	"fmt"
+
+	"CTG_monitor/internal/models"
+	"ctg_common/chunk"
+	"ctg_common/codec"
+)
+
+// Названия кодировок кадров в конфигурации
+const (
+	EncodingJSON  = "json"
+	EncodingCodec = "codec"
+)
+
+// Format кодировка, в которой записываются новые кадры. Прочитать можно кадры
+// любой кодировки, поэтому формат можно менять без перезаписи блоков.
+type Format struct {
+	Encoding byte          // chunk.EncodingJSON или chunk.EncodingCodec
+	Options  codec.Options // параметры codec: шаг времени и квантование значений
+}
+
+// DefaultFormat codec без потерь значений, время с точностью до 1 мс
+var DefaultFormat = Format{Encoding: chunk.EncodingCodec}
+
+// ParseFormat формат по названию кодировки и числу шагов значения в единице
+// (0 - значения без потерь)
+func ParseFormat(encoding string, valueScale float64) (Format, error) {
+	switch encoding {
+	case EncodingJSON:
+		return Format{Encoding: chunk.EncodingJSON}, nil
+	case EncodingCodec, "":
+		return Format{Encoding: chunk.EncodingCodec, Options: codec.Options{ValueScale: valueScale}}, nil
+	default:
+		return Format{}, fmt.Errorf("неизвестная кодировка блоков точек: %s", encoding)
+	}
+}
+
+// AppendFrame кодирует точки и дописывает кадр в dst; формат кадра описан в chunk.AppendPoints
+func AppendFrame(dst []byte, points []models.CTGPoint, format Format) ([]byte, error) {
+	framePoints := make([]chunk.Point, len(points))
+	for i, point := range points {
+		framePoints[i] = chunk.Point{T: point.T, V: point.V, R: point.R, F: uint16(point.F), W: point.W}
+	}
+	return chunk.AppendPoints(dst, framePoints, format.Encoding, format.Options)
+}
+
+// DecodeFrames разбирает кадры в порядке записи
+func DecodeFrames(data []byte) ([]models.CTGPoint, error) {
+	framePoints, err := chunk.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	var points []models.CTGPoint
+	if framePoints != nil {
+		points = make([]models.CTGPoint, len(framePoints))
+	}
+	for i, point := range framePoints {
+		points[i] = models.CTGPoint{T: point.T, V: point.V, R: point.R, F: models.SampleFlags(point.F), W: point.W}
+	}
+	return points, nil
+}
@@ -0,0 +1,71 @@
+// internal/clock/bank.go
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Bank хранит оценки часов для каждого устройства
+type Bank struct {
+	cfg        Config
+	estimators map[string]*Estimator
+	mu         sync.Mutex
+}
+
+// DeviceStats оценка часов одного устройства
+type DeviceStats struct {
+	DeviceID string `json:"device_id"`
+	Stats
+}
+
+// NewBank создает банк оценок часов
+func NewBank(cfg Config) *Bank {
+	return &Bank{
+		cfg:        cfg,
+		estimators: make(map[string]*Estimator),
+	}
+}
+
+// Observe учитывает время измерения точки устройства (см. Estimator.Observe)
+func (b *Bank) Observe(deviceID string, deviceTime, received time.Time) {
+	b.getEstimator(deviceID).Observe(deviceTime, received)
+}
+
+// ToServer переводит время устройства на часы сервера (см. Estimator.ToServer)
+func (b *Bank) ToServer(deviceID string, deviceTime time.Time) (time.Time, bool) {
+	return b.getEstimator(deviceID).ToServer(deviceTime)
+}
+
+// ResetDevice забывает наблюдения устройства
+func (b *Bank) ResetDevice(deviceID string) {
+	b.getEstimator(deviceID).Reset()
+}
+
+// Stats возвращает оценки по всем устройствам
+func (b *Bank) Stats() []DeviceStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stats := make([]DeviceStats, 0, len(b.estimators))
+	for deviceID, estimator := range b.estimators {
+		stats = append(stats, DeviceStats{
+			DeviceID: deviceID,
+			Stats:    estimator.Stats(),
+		})
+	}
+	return stats
+}
+
+// getEstimator возвращает оценку устройства, создавая ее при первом обращении
+func (b *Bank) getEstimator(deviceID string) *Estimator {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	estimator, exists := b.estimators[deviceID]
+	if !exists {
+		estimator = NewEstimator(b.cfg)
+		b.estimators[deviceID] = estimator
+	}
+	return estimator
+}
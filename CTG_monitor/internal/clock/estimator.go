@@ -0,0 +1,163 @@
+// internal/clock/estimator.go
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// bucketSize интервал, за который сохраняется одно наблюдение смещения.
+// Берется минимальное смещение интервала: задержка сети только увеличивает его.
+const bucketSize = 10 * time.Second
+
+// minBucketsForDrift сколько интервалов нужно, чтобы оценивать дрейф
+const minBucketsForDrift = 6
+
+// Config параметры оценки часов устройства
+type Config struct {
+	Window time.Duration // за какой период учитываются наблюдения
+}
+
+// Stats состояние оценки часов устройства
+type Stats struct {
+	OffsetMs     float64   `json:"offset_ms"`     // Часы сервера минус часы устройства, мс
+	DriftPPM     float64   `json:"drift_ppm"`     // Скорость ухода часов устройства, мкс/с
+	Observations int       `json:"observations"`  // Всего наблюдений
+	Buckets      int       `json:"buckets"`       // Интервалов в окне оценки
+	LastObserved time.Time `json:"last_observed"` // Последнее наблюдение
+}
+
+// observation минимальное смещение за интервал
+type observation struct {
+	at     time.Time // время сервера
+	offset float64   // секунды
+}
+
+// Estimator оценивает смещение и дрейф часов одного устройства относительно сервера
+// по парам (время измерения на устройстве, время приема на сервере)
+type Estimator struct {
+	cfg Config
+
+	buckets []observation // по возрастанию времени
+	count   int
+
+	// Линейная модель offset(at) = intercept + slope * (at - ref)
+	ref       time.Time
+	intercept float64
+	slope     float64
+
+	mu sync.Mutex
+}
+
+// NewEstimator создает оценку часов устройства
+func NewEstimator(cfg Config) *Estimator {
+	return &Estimator{cfg: cfg}
+}
+
+// Observe учитывает точку, измеренную устройством в deviceTime и принятую сервером в received
+func (e *Estimator) Observe(deviceTime, received time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.count++
+	offset := received.Sub(deviceTime).Seconds()
+	bucketStart := received.Truncate(bucketSize)
+
+	if n := len(e.buckets); n > 0 && e.buckets[n-1].at.Truncate(bucketSize).Equal(bucketStart) {
+		if offset < e.buckets[n-1].offset {
+			e.buckets[n-1] = observation{at: received, offset: offset}
+		}
+	} else {
+		e.buckets = append(e.buckets, observation{at: received, offset: offset})
+	}
+
+	cutoff := received.Add(-e.cfg.Window)
+	drop := 0
+	for drop < len(e.buckets)-1 && e.buckets[drop].at.Before(cutoff) {
+		drop++
+	}
+	e.buckets = e.buckets[drop:]
+
+	e.fit()
+}
+
+// Reset забывает наблюдения, например после перезапуска устройства
+func (e *Estimator) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.buckets = nil
+	e.intercept, e.slope = 0, 0
+	e.ref = time.Time{}
+}
+
+// ToServer переводит время устройства на часы сервера.
+// ok=false, если наблюдений еще нет.
+func (e *Estimator) ToServer(deviceTime time.Time) (time.Time, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if len(e.buckets) == 0 {
+		return deviceTime, false
+	}
+	offset := e.offsetAt(deviceTime)
+	return deviceTime.Add(time.Duration(offset * float64(time.Second))), true
+}
+
+// Stats возвращает текущую оценку
+func (e *Estimator) Stats() Stats {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	stats := Stats{
+		DriftPPM:     e.slope * 1e6,
+		Observations: e.count,
+		Buckets:      len(e.buckets),
+	}
+	if n := len(e.buckets); n > 0 {
+		stats.LastObserved = e.buckets[n-1].at
+		stats.OffsetMs = e.offsetAt(stats.LastObserved) * 1000
+	}
+	return stats
+}
+
+// offsetAt смещение по модели, вызывается под mu
+func (e *Estimator) offsetAt(at time.Time) float64 {
+	return e.intercept + e.slope*at.Sub(e.ref).Seconds()
+}
+
+// fit пересчитывает линейную модель по минимумам интервалов, вызывается под mu
+func (e *Estimator) fit() {
+	n := len(e.buckets)
+	e.ref = e.buckets[n-1].at
+
+	if n < minBucketsForDrift {
+		// Мало данных для дрейфа: смещение - минимум окна
+		e.slope = 0
+		e.intercept = e.buckets[0].offset
+		for _, bucket := range e.buckets[1:] {
+			if bucket.offset < e.intercept {
+				e.intercept = bucket.offset
+			}
+		}
+		return
+	}
+
+	var sumX, sumY, sumXX, sumXY float64
+	for _, bucket := range e.buckets {
+		x := bucket.at.Sub(e.ref).Seconds()
+		sumX += x
+		sumY += bucket.offset
+		sumXX += x * x
+		sumXY += x * bucket.offset
+	}
+	count := float64(n)
+	denominator := count*sumXX - sumX*sumX
+	if denominator == 0 {
+		e.slope = 0
+		e.intercept = sumY / count
+		return
+	}
+	e.slope = (count*sumXY - sumX*sumY) / denominator
+	e.intercept = (sumY - e.slope*sumX) / count
+}
@@ -0,0 +1,115 @@
+package clock
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// segment участок наблюдений: часы устройства отстают на offset + drift*t секунд,
+// где t - время от начала участка; точки приходят раз в секунду с задержкой сети
+// до jitter (без задержки - каждая пятая)
+type segment struct {
+	duration time.Duration
+	offset   float64
+	drift    float64
+	jitter   time.Duration
+}
+
+func TestEstimator(t *testing.T) {
+	cases := []struct {
+		name       string
+		window     time.Duration
+		segments   []segment
+		wantOffset float64 // мс
+		wantDrift  float64 // ppm
+	}{
+		{
+			name:       "постоянное смещение по минимуму окна",
+			window:     10 * time.Minute,
+			segments:   []segment{{duration: 30 * time.Second, offset: 2}},
+			wantOffset: 2000,
+		},
+		{
+			name:       "задержка сети не смещает оценку",
+			window:     10 * time.Minute,
+			segments:   []segment{{duration: 2 * time.Minute, offset: -1.5, jitter: 300 * time.Millisecond}},
+			wantOffset: -1500,
+		},
+		{
+			name:   "дрейф часов",
+			window: 30 * time.Minute,
+			segments: []segment{
+				{duration: 10 * time.Minute, offset: 0.5, drift: 100e-6, jitter: 200 * time.Millisecond},
+			},
+			wantOffset: 560,
+			wantDrift:  100,
+		},
+		{
+			name:   "старые наблюдения выходят из окна",
+			window: 2 * time.Minute,
+			segments: []segment{
+				{duration: 5 * time.Minute, offset: 1, jitter: 100 * time.Millisecond},
+				{duration: 5 * time.Minute, offset: 3, jitter: 100 * time.Millisecond},
+			},
+			wantOffset: 3000,
+		},
+	}
+
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			estimator := NewEstimator(Config{Window: c.window})
+
+			at := start
+			var lastDevice, lastReceived time.Time
+			for _, s := range c.segments {
+				steps := int(s.duration / time.Second)
+				for i := 0; i < steps; i++ {
+					elapsed := float64(i)
+					offset := s.offset + s.drift*elapsed
+					lastDevice = at.Add(-time.Duration(offset * float64(time.Second)))
+					lastReceived = at.Add(time.Duration(i%5) * s.jitter / 4)
+					estimator.Observe(lastDevice, lastReceived)
+					at = at.Add(time.Second)
+				}
+			}
+
+			stats := estimator.Stats()
+			if math.Abs(stats.OffsetMs-c.wantOffset) > 1 {
+				t.Errorf("смещение %.3f мс, ожидалось %.0f", stats.OffsetMs, c.wantOffset)
+			}
+			if math.Abs(stats.DriftPPM-c.wantDrift) > 1 {
+				t.Errorf("дрейф %.3f ppm, ожидался %.0f", stats.DriftPPM, c.wantDrift)
+			}
+
+			// Время последней точки на устройстве переводится примерно во время ее приема
+			server, ok := estimator.ToServer(lastDevice)
+			if !ok {
+				t.Fatal("перевод времени без оценки")
+			}
+			if diff := server.Sub(lastReceived); diff.Abs() > 500*time.Millisecond {
+				t.Errorf("время сервера %v отличается от времени приема на %v", server, diff)
+			}
+		})
+	}
+}
+
+func TestEstimatorReset(t *testing.T) {
+	estimator := NewEstimator(Config{Window: time.Minute})
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	deviceTime := now.Add(-time.Second)
+	if server, ok := estimator.ToServer(deviceTime); ok || !server.Equal(deviceTime) {
+		t.Fatalf("без наблюдений время переведено: %v, %v", server, ok)
+	}
+
+	estimator.Observe(deviceTime, now)
+	estimator.Reset()
+	if _, ok := estimator.ToServer(deviceTime); ok {
+		t.Fatal("после сброса остались наблюдения")
+	}
+	if stats := estimator.Stats(); stats.Buckets != 0 || stats.Observations != 1 {
+		t.Errorf("после сброса: %+v", stats)
+	}
+}
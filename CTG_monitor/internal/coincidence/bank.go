@@ -0,0 +1,66 @@
+// internal/coincidence/bank.go
+package coincidence
+
+import (
+	"sort"
+	"sync"
+)
+
+// Bank хранит детекторы совпадения каналов для каждого устройства
+type Bank struct {
+	cfg       Config
+	pairs     []Pair
+	detectors map[string]*Detector
+	mu        sync.Mutex
+}
+
+// DeviceStats состояние пар каналов одного устройства
+type DeviceStats struct {
+	DeviceID string      `json:"device_id"`
+	Pairs    []PairStats `json:"pairs"`
+}
+
+// NewBank создает банк детекторов совпадения каналов
+func NewBank(cfg Config, pairs []Pair) *Bank {
+	return &Bank{
+		cfg:       cfg,
+		pairs:     pairs,
+		detectors: make(map[string]*Detector),
+	}
+}
+
+// Observe учитывает точку канала устройства (см. Detector.Observe)
+func (b *Bank) Observe(deviceID, channel string, t, v float64) ([]string, []Change) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	detector, exists := b.detectors[deviceID]
+	if !exists {
+		detector = NewDetector(b.cfg, b.pairs)
+		b.detectors[deviceID] = detector
+	}
+	return detector.Observe(channel, t, v)
+}
+
+// ResetDevice забывает историю устройства (новый пациент)
+func (b *Bank) ResetDevice(deviceID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.detectors, deviceID)
+}
+
+// Stats возвращает состояние пар каналов по всем устройствам
+func (b *Bank) Stats() []DeviceStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stats := make([]DeviceStats, 0, len(b.detectors))
+	for deviceID, detector := range b.detectors {
+		stats = append(stats, DeviceStats{
+			DeviceID: deviceID,
+			Pairs:    detector.Stats(),
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].DeviceID < stats[j].DeviceID })
+	return stats
+}
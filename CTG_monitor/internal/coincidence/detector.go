@@ -0,0 +1,241 @@
+// internal/coincidence/detector.go
+package coincidence
+
+import (
+	"math"
+	"time"
+
+	"CTG_monitor/internal/channels"
+)
+
+// Виды совпадения каналов
+const (
+	KindSameHeart  = "same_heart"  // оба датчика ЧСС плода записывают одно сердце
+	KindMaternalHR = "maternal_hr" // датчик ЧСС плода записывает ЧСС матери
+)
+
+// maxPairSkew максимальная разница времени точек, сравниваемых как пара, с
+const maxPairSkew = 1.0
+
+// minPairsInWindow минимум пар точек в окне, чтобы судить о совпадении
+const minPairsInWindow = 20
+
+// Pair пара сравниваемых каналов. Marked - каналы, точки которых помечаются,
+// пока совпадение активно.
+type Pair struct {
+	Kind     string   `json:"kind"`
+	Channels []string `json:"channels"`
+	Marked   []string `json:"-"`
+}
+
+// DefaultPairs пары каналов для КТГ двойни
+func DefaultPairs() []Pair {
+	return []Pair{
+		{
+			Kind:     KindSameHeart,
+			Channels: []string{channels.FetalHeartRate, channels.FetalHeartRate2},
+			Marked:   []string{channels.FetalHeartRate, channels.FetalHeartRate2},
+		},
+		{
+			Kind:     KindMaternalHR,
+			Channels: []string{channels.FetalHeartRate, channels.MaternalHeartRate},
+			Marked:   []string{channels.FetalHeartRate},
+		},
+		{
+			Kind:     KindMaternalHR,
+			Channels: []string{channels.FetalHeartRate2, channels.MaternalHeartRate},
+			Marked:   []string{channels.FetalHeartRate2},
+		},
+	}
+}
+
+// Config параметры обнаружения совпадения каналов
+type Config struct {
+	Window    time.Duration // окно сравнения каналов
+	Tolerance float64       // разница значений, при которой точки считаются совпадающими
+	Ratio     float64       // доля совпадающих пар в окне, начиная с которой выдается предупреждение
+}
+
+// Change смена состояния совпадения пары каналов
+type Change struct {
+	Kind     string   `json:"kind"`
+	Channels []string `json:"channels"`
+	Active   bool     `json:"active"`    // true - совпадение началось, false - закончилось
+	FromTime float64  `json:"from_time"` // начало интервала (время сессии, с)
+	ToTime   float64  `json:"to_time"`   // точка, на которой изменилось состояние
+	Ratio    float64  `json:"ratio"`     // доля совпадающих пар в окне
+}
+
+// PairStats состояние пары каналов устройства
+type PairStats struct {
+	Kind     string   `json:"kind"`
+	Channels []string `json:"channels"`
+	Active   bool     `json:"active"`
+	Ratio    float64  `json:"ratio"`
+	Pairs    int      `json:"pairs"` // пар точек в окне
+}
+
+// sample последнее значение канала
+type sample struct {
+	t, v  float64
+	valid bool
+}
+
+// match результат сравнения одной пары точек
+type match struct {
+	t     float64
+	equal bool
+}
+
+// pairState окно сравнений одной пары каналов
+type pairState struct {
+	pair    Pair
+	matches []match // по возрастанию времени
+	equal   int
+	active  bool
+	since   float64 // начало активного совпадения
+}
+
+// Detector сравнивает каналы одного устройства попарно по скользящему окну.
+// Время точек - шкала сессии, поэтому устройство без привязки к карте тоже проверяется.
+type Detector struct {
+	cfg   Config
+	last  map[string]sample
+	pairs []*pairState
+}
+
+// NewDetector создает детектор для набора пар каналов
+func NewDetector(cfg Config, pairs []Pair) *Detector {
+	detector := &Detector{
+		cfg:  cfg,
+		last: make(map[string]sample),
+	}
+	for _, pair := range pairs {
+		detector.pairs = append(detector.pairs, &pairState{pair: pair})
+	}
+	return detector
+}
+
+// Observe учитывает точку канала. Возвращает виды активных совпадений, которыми
+// нужно пометить точку, и изменения состояния пар.
+func (d *Detector) Observe(channel string, t, v float64) ([]string, []Change) {
+	current := sample{t: t, v: v, valid: v != -1}
+	d.last[channel] = current
+
+	var kinds []string
+	var changes []Change
+	for _, state := range d.pairs {
+		other, ok := state.counterpart(channel)
+		if !ok {
+			continue
+		}
+
+		if ref := d.last[other]; current.valid && ref.valid && math.Abs(ref.t-t) <= maxPairSkew {
+			state.push(match{t: t, equal: math.Abs(ref.v-v) <= d.cfg.Tolerance})
+		}
+		state.trim(t - d.cfg.Window.Seconds())
+
+		if change, changed := state.evaluate(t, d.cfg.Ratio); changed {
+			changes = append(changes, change)
+		}
+		if state.active && state.marks(channel) && !contains(kinds, state.pair.Kind) {
+			kinds = append(kinds, state.pair.Kind)
+		}
+	}
+	return kinds, changes
+}
+
+// Stats состояние пар каналов
+func (d *Detector) Stats() []PairStats {
+	stats := make([]PairStats, 0, len(d.pairs))
+	for _, state := range d.pairs {
+		stats = append(stats, PairStats{
+			Kind:     state.pair.Kind,
+			Channels: state.pair.Channels,
+			Active:   state.active,
+			Ratio:    state.ratio(),
+			Pairs:    len(state.matches),
+		})
+	}
+	return stats
+}
+
+// counterpart второй канал пары для channel
+func (s *pairState) counterpart(channel string) (string, bool) {
+	switch channel {
+	case s.pair.Channels[0]:
+		return s.pair.Channels[1], true
+	case s.pair.Channels[1]:
+		return s.pair.Channels[0], true
+	}
+	return "", false
+}
+
+// marks сообщает, помечаются ли точки канала при совпадении пары
+func (s *pairState) marks(channel string) bool {
+	return contains(s.pair.Marked, channel)
+}
+
+func (s *pairState) push(m match) {
+	s.matches = append(s.matches, m)
+	if m.equal {
+		s.equal++
+	}
+}
+
+// trim удаляет сравнения старше cutoff
+func (s *pairState) trim(cutoff float64) {
+	drop := 0
+	for drop < len(s.matches) && s.matches[drop].t < cutoff {
+		if s.matches[drop].equal {
+			s.equal--
+		}
+		drop++
+	}
+	s.matches = s.matches[drop:]
+}
+
+func (s *pairState) ratio() float64 {
+	if len(s.matches) == 0 {
+		return 0
+	}
+	return float64(s.equal) / float64(len(s.matches))
+}
+
+// evaluate переключает состояние пары. Совпадение заканчивается, когда доля
+// совпадающих пар опускается на треть ниже порога или сравнивать становится не с чем.
+func (s *pairState) evaluate(t, threshold float64) (Change, bool) {
+	enough := len(s.matches) >= minPairsInWindow
+	ratio := s.ratio()
+
+	switch {
+	case !s.active && enough && ratio >= threshold:
+		s.active = true
+		s.since = s.matches[0].t
+		return s.change(t, ratio), true
+	case s.active && (!enough || ratio < threshold*2/3):
+		s.active = false
+		return s.change(t, ratio), true
+	}
+	return Change{}, false
+}
+
+func (s *pairState) change(t, ratio float64) Change {
+	return Change{
+		Kind:     s.pair.Kind,
+		Channels: s.pair.Channels,
+		Active:   s.active,
+		FromTime: s.since,
+		ToTime:   t,
+		Ratio:    ratio,
+	}
+}
+
+func contains(items []string, item string) bool {
+	for _, candidate := range items {
+		if candidate == item {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,177 @@
+package coincidence
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"CTG_monitor/internal/channels"
+)
+
+// trace канал с точками раз в секунду, сдвинутыми на shift
+type trace struct {
+	channel string
+	shift   float64
+	value   func(t float64) float64
+}
+
+// state вид совпадения и его состояние после изменения
+type state struct {
+	kind   string
+	active bool
+}
+
+// fetal ЧСС плода с вариабельностью
+func fetal(t float64) float64 {
+	return 140 + 10*math.Sin(t/10)
+}
+
+func TestDetector(t *testing.T) {
+	cfg := Config{Window: time.Minute, Tolerance: 2, Ratio: 0.8}
+
+	cases := []struct {
+		name     string
+		duration int
+		traces   []trace
+		changes  []state
+		marks    map[string][]string // виды совпадений, которыми помечались точки канала
+	}{
+		{
+			name:     "разные сердца",
+			duration: 180,
+			traces: []trace{
+				{channel: channels.FetalHeartRate, value: fetal},
+				{channel: channels.FetalHeartRate2, value: func(t float64) float64 { return 150 - 10*math.Sin(t/10) }},
+				{channel: channels.MaternalHeartRate, value: func(float64) float64 { return 80 }},
+			},
+			marks: map[string][]string{},
+		},
+		{
+			name:     "оба датчика записывают одно сердце",
+			duration: 180,
+			traces: []trace{
+				{channel: channels.FetalHeartRate, value: fetal},
+				{channel: channels.FetalHeartRate2, value: func(t float64) float64 { return fetal(t) + 1 }},
+				{channel: channels.MaternalHeartRate, value: func(float64) float64 { return 80 }},
+			},
+			changes: []state{{KindSameHeart, true}},
+			marks: map[string][]string{
+				channels.FetalHeartRate:  {KindSameHeart},
+				channels.FetalHeartRate2: {KindSameHeart},
+			},
+		},
+		{
+			name:     "датчик плода записывает ЧСС матери",
+			duration: 180,
+			traces: []trace{
+				{channel: channels.FetalHeartRate, value: func(float64) float64 { return 85 }},
+				{channel: channels.MaternalHeartRate, value: func(float64) float64 { return 84 }},
+			},
+			changes: []state{{KindMaternalHR, true}},
+			marks: map[string][]string{
+				channels.FetalHeartRate: {KindMaternalHR},
+			},
+		},
+		{
+			name:     "каналы разошлись",
+			duration: 180,
+			traces: []trace{
+				{channel: channels.FetalHeartRate, value: fetal},
+				{channel: channels.FetalHeartRate2, value: func(t float64) float64 {
+					if t < 60 {
+						return fetal(t)
+					}
+					return fetal(t) + 20
+				}},
+			},
+			changes: []state{{KindSameHeart, true}, {KindSameHeart, false}},
+			marks: map[string][]string{
+				channels.FetalHeartRate:  {KindSameHeart},
+				channels.FetalHeartRate2: {KindSameHeart},
+			},
+		},
+		{
+			name:     "потеря сигнала второго датчика",
+			duration: 180,
+			traces: []trace{
+				{channel: channels.FetalHeartRate, value: fetal},
+				{channel: channels.FetalHeartRate2, value: func(t float64) float64 {
+					if t < 60 {
+						return fetal(t)
+					}
+					return -1
+				}},
+			},
+			changes: []state{{KindSameHeart, true}, {KindSameHeart, false}},
+			marks: map[string][]string{
+				channels.FetalHeartRate:  {KindSameHeart},
+				channels.FetalHeartRate2: {KindSameHeart},
+			},
+		},
+		{
+			name:     "точки далеко по времени не сравниваются",
+			duration: 180,
+			traces: []trace{
+				{channel: channels.FetalHeartRate, value: func(float64) float64 { return 140 }},
+				{channel: channels.FetalHeartRate2, shift: 2.5, value: func(float64) float64 { return 140 }},
+			},
+			marks: map[string][]string{},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			detector := NewDetector(cfg, DefaultPairs())
+
+			var changes []state
+			marks := map[string][]string{}
+			for i := 0; i < c.duration; i++ {
+				for _, tr := range c.traces {
+					at := float64(i) + tr.shift
+					kinds, changed := detector.Observe(tr.channel, at, tr.value(at))
+					for _, kind := range kinds {
+						if !contains(marks[tr.channel], kind) {
+							marks[tr.channel] = append(marks[tr.channel], kind)
+						}
+					}
+					for _, change := range changed {
+						if change.FromTime > change.ToTime {
+							t.Errorf("интервал совпадения %.1f-%.1f", change.FromTime, change.ToTime)
+						}
+						changes = append(changes, state{change.Kind, change.Active})
+					}
+				}
+			}
+
+			if !reflect.DeepEqual(changes, c.changes) {
+				t.Errorf("изменения %v, ожидались %v", changes, c.changes)
+			}
+			if !reflect.DeepEqual(marks, c.marks) {
+				t.Errorf("пометки %v, ожидались %v", marks, c.marks)
+			}
+		})
+	}
+}
+
+func TestDetectorStats(t *testing.T) {
+	detector := NewDetector(Config{Window: time.Minute, Tolerance: 2, Ratio: 0.8}, DefaultPairs())
+	for i := 0; i < 30; i++ {
+		detector.Observe(channels.FetalHeartRate, float64(i), 140)
+		detector.Observe(channels.FetalHeartRate2, float64(i), 141)
+	}
+
+	stats := detector.Stats()
+	if len(stats) != len(DefaultPairs()) {
+		t.Fatalf("состояний пар %d", len(stats))
+	}
+	same := stats[0]
+	if same.Kind != KindSameHeart || !same.Active || same.Ratio != 1 || same.Pairs < minPairsInWindow {
+		t.Errorf("пара датчиков плода: %+v", same)
+	}
+	for _, maternal := range stats[1:] {
+		if maternal.Active || maternal.Pairs != 0 {
+			t.Errorf("пара с ЧСС матери без ее точек: %+v", maternal)
+		}
+	}
+}
@@ -2,25 +2,44 @@
 package database
 
 import (
+	"CTG_monitor/internal/channels"
+	"CTG_monitor/internal/chunks"
 	"CTG_monitor/internal/models"
+	"CTG_monitor/internal/rollups"
 	"fmt"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 	"log"
+	"strings"
 )
 
-// RunMigrations выполняет миграции базы данных
-func RunMigrations(db *gorm.DB) error {
+// RunMigrations выполняет миграции базы данных. Точки из прежних колонок
+// переносятся в блоки в кодировке format.
+func RunMigrations(db *gorm.DB, format chunks.Format) error {
 	log.Println("Запуск миграций базы данных...")
 
 	// Автоматические миграции GORM
 	err := db.AutoMigrate(
 		&models.CTGSession{},
+		&models.CTGAlarm{},
+		&models.CTGChunk{},
+		&models.CTGRollup{},
 	)
 
 	if err != nil {
 		return fmt.Errorf("ошибка миграции: %w", err)
 	}
 
+	// Переносим точки из JSONB колонок ctg_sessions в блоки ctg_chunks
+	if err := migrateSeries(db, format); err != nil {
+		return fmt.Errorf("ошибка переноса точек в ctg_chunks: %w", err)
+	}
+
+	// Строим сводки для сессий, записанных до появления ctg_rollups
+	if err := migrateRollups(db); err != nil {
+		return fmt.Errorf("ошибка построения сводок ctg_rollups: %w", err)
+	}
+
 	// Создаем индексы для оптимизации запросов
 	if err := createIndexes(db); err != nil {
 		return fmt.Errorf("ошибка создания индексов: %w", err)
@@ -38,12 +57,11 @@ func createIndexes(db *gorm.DB) error {
 		"CREATE INDEX IF NOT EXISTS idx_ctg_sessions_start_time_desc ON ctg_sessions(start_time DESC)",
 		"CREATE INDEX IF NOT EXISTS idx_ctg_sessions_card_device ON ctg_sessions(card_id, device_id)",
 
-		// GIN индексы для JSONB полей (для быстрых запросов по содержимому)
-		"CREATE INDEX IF NOT EXISTS idx_ctg_sessions_fhr_gin ON ctg_sessions USING GIN (fhr_data)",
-		"CREATE INDEX IF NOT EXISTS idx_ctg_sessions_uc_gin ON ctg_sessions USING GIN (uc_data)",
-
 		// Частичные индексы только для активных сессий
 		"CREATE INDEX IF NOT EXISTS idx_active_sessions ON ctg_sessions(device_id, start_time) WHERE end_time IS NULL",
+
+		// Неснятые тревоги для центрального поста
+		"CREATE INDEX IF NOT EXISTS idx_ctg_alarms_open ON ctg_alarms(device_id, raised_at) WHERE state <> 'cleared'",
 	}
 
 	for _, indexSQL := range indexes {
@@ -56,3 +74,169 @@ func createIndexes(db *gorm.DB) error {
 
 	return nil
 }
+
+// Колонки ctg_sessions, в которых точки хранились до ctg_chunks: ряды основных
+// каналов {points, count, last_time} и channel_data с рядами остальных каналов по имени
+var legacySeriesColumns = []string{"fhr_data", "fhr2_data", "uc_data", "channel_data"}
+
+// legacySeries ряд канала в прежнем формате
+type legacySeries struct {
+	Points []models.CTGPoint `json:"points"`
+}
+
+// legacySession точки сессии в прежних колонках ctg_sessions
+type legacySession struct {
+	ID          uuid.UUID
+	FHRData     *legacySeries            `gorm:"column:fhr_data;serializer:json"`
+	FHR2Data    *legacySeries            `gorm:"column:fhr2_data;serializer:json"`
+	UCData      *legacySeries            `gorm:"column:uc_data;serializer:json"`
+	ChannelData map[string]*legacySeries `gorm:"column:channel_data;serializer:json"`
+}
+
+func (legacySession) TableName() string {
+	return "ctg_sessions"
+}
+
+// series ряды сессии по имени канала
+func (s legacySession) series() map[string][]models.CTGPoint {
+	series := make(map[string][]models.CTGPoint)
+	for name, data := range s.ChannelData {
+		if data != nil {
+			series[name] = data.Points
+		}
+	}
+	if s.FHRData != nil {
+		series[channels.FetalHeartRate] = s.FHRData.Points
+	}
+	if s.FHR2Data != nil {
+		series[channels.FetalHeartRate2] = s.FHR2Data.Points
+	}
+	if s.UCData != nil {
+		series[channels.UterineContractions] = s.UCData.Points
+	}
+	return series
+}
+
+// migrateSeries переносит точки сессий из JSONB колонок ctg_sessions в блоки
+// ctg_chunks и удаляет эти колонки вместе с их GIN индексами. Сессия переносится
+// в транзакции, которая сверяет число записанных точек и очищает ее прежние колонки,
+// поэтому прерванный перенос при следующем запуске продолжается с еще не перенесенных
+// сессий, а колонки удаляются, только когда не осталось ни одной заполненной.
+func migrateSeries(db *gorm.DB, format chunks.Format) error {
+	migrator := db.Migrator()
+	columns := []string{"id"}
+	var filled []string
+	cleared := make(map[string]interface{})
+	for _, column := range legacySeriesColumns {
+		if migrator.HasColumn(&legacySession{}, column) {
+			columns = append(columns, column)
+			filled = append(filled, column+" IS NOT NULL")
+			cleared[column] = nil
+		}
+	}
+	if len(columns) == 1 {
+		return nil
+	}
+
+	log.Printf("Перенос точек сессий из колонок %v в ctg_chunks...", columns[1:])
+	var sessions, points int
+	var batch []legacySession
+	result := db.Select(columns).Where(strings.Join(filled, " OR ")).
+		FindInBatches(&batch, 50, func(_ *gorm.DB, _ int) error {
+			for _, session := range batch {
+				var rows []models.CTGChunk
+				sessionSeries := session.series()
+				for name, series := range sessionSeries {
+					channelRows, err := chunks.Build(session.ID, name, series, format)
+					if err != nil {
+						return fmt.Errorf("сессия %s, канал %s: %w", session.ID, name, err)
+					}
+					rows = append(rows, channelRows...)
+					points += len(series)
+				}
+				err := db.Transaction(func(tx *gorm.DB) error {
+					if err := chunks.Write(tx, rows); err != nil {
+						return err
+					}
+					if err := verifySeries(tx, session.ID, sessionSeries); err != nil {
+						return err
+					}
+					return tx.Model(&legacySession{}).Where("id = ?", session.ID).Updates(cleared).Error
+				})
+				if err != nil {
+					return fmt.Errorf("сессия %s: %w", session.ID, err)
+				}
+				sessions++
+			}
+			return nil
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	// Колонки удаляются, только если точки всех сессий перенесены
+	var remaining int64
+	if err := db.Model(&legacySession{}).Where(strings.Join(filled, " OR ")).Count(&remaining).Error; err != nil {
+		return err
+	}
+	if remaining > 0 {
+		return fmt.Errorf("точки %d сессий не перенесены, колонки %v не удалены", remaining, columns[1:])
+	}
+
+	for _, column := range columns[1:] {
+		if err := migrator.DropColumn(&legacySession{}, column); err != nil {
+			return fmt.Errorf("не удалось удалить колонку %s: %w", column, err)
+		}
+	}
+	log.Printf("✅ Перенесено %d точек %d сессий, колонки %v удалены", points, sessions, columns[1:])
+	return nil
+}
+
+// verifySeries сверяет перенесенные ряды сессии с прежними: из ctg_chunks должны
+// читаться все точки каждого канала (точки с повторяющимся временем не считаются)
+func verifySeries(tx *gorm.DB, sessionID uuid.UUID, series map[string][]models.CTGPoint) error {
+	stored, err := chunks.Load(tx, sessionID, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	for name, points := range series {
+		want := len(chunks.Dedupe(append([]models.CTGPoint(nil), points...)))
+		if got := len(stored[name]); got < want {
+			return fmt.Errorf("канал %s: после переноса прочитано %d точек из %d", name, got, want)
+		}
+	}
+	return nil
+}
+
+// migrateRollups строит сводки по блокам точек сессий, у которых сводок еще нет
+func migrateRollups(db *gorm.DB) error {
+	var sessionIDs []uuid.UUID
+	err := db.Model(&models.CTGChunk{}).
+		Distinct("session_id").
+		Where("session_id NOT IN (?)", db.Model(&models.CTGRollup{}).Distinct("session_id")).
+		Pluck("session_id", &sessionIDs).Error
+	if err != nil {
+		return err
+	}
+	if len(sessionIDs) == 0 {
+		return nil
+	}
+
+	log.Printf("Построение сводок для %d сессий...", len(sessionIDs))
+	for _, sessionID := range sessionIDs {
+		series, err := chunks.Load(db, sessionID, nil, nil, nil)
+		if err != nil {
+			return err
+		}
+		from, to := rollups.Span(series)
+		var rows []models.CTGRollup
+		for name, points := range series {
+			rows = append(rows, rollups.Build(sessionID, name, points, from, to)...)
+		}
+		if err := rollups.Write(db, rows); err != nil {
+			return fmt.Errorf("сессия %s: %w", sessionID, err)
+		}
+	}
+	log.Printf("✅ Сводки построены для %d сессий", len(sessionIDs))
+	return nil
+}
@@ -0,0 +1,121 @@
+package database
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"CTG_monitor/internal/channels"
+	"CTG_monitor/internal/chunks"
+	"CTG_monitor/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testDSNEnv переменная окружения со строкой подключения к PostgreSQL в формате
+// key=value; без нее тесты миграций пропускаются
+const testDSNEnv = "CTG_TEST_DATABASE_DSN"
+
+// openTestDB подключается к PostgreSQL в отдельной схеме, которая удаляется после теста
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv(testDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s не задана, тест миграций пропущен", testDSNEnv)
+	}
+
+	config := &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)}
+	admin, err := gorm.Open(postgres.Open(dsn), config)
+	if err != nil {
+		t.Fatalf("не удалось подключиться к базе данных: %v", err)
+	}
+	schema := "ctg_test_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	if err := admin.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatalf("не удалось создать схему: %v", err)
+	}
+	t.Cleanup(func() {
+		admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+		if sqlDB, err := admin.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	db, err := gorm.Open(postgres.Open(dsn+" search_path="+schema), config)
+	if err != nil {
+		t.Fatalf("не удалось подключиться к схеме %s: %v", schema, err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func TestMigrateLegacySeries(t *testing.T) {
+	db := openTestDB(t)
+
+	// ctg_sessions в прежнем виде: точки в JSONB колонках
+	if err := db.AutoMigrate(&models.CTGSession{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, column := range legacySeriesColumns {
+		if err := db.Exec(fmt.Sprintf("ALTER TABLE ctg_sessions ADD COLUMN %s jsonb", column)).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	raw := 250.0
+	fhr := []models.CTGPoint{{T: 0, V: 140}, {T: 0.25, V: 141, R: &raw, F: models.FlagSpike}, {T: 0.5, V: 142}}
+	// Повтор точки по времени после переотправки: после переноса остается первая
+	withRepeat := append(append([]models.CTGPoint{}, fhr...), models.CTGPoint{T: 0.5, V: 150})
+	uc := []models.CTGPoint{{T: 0, V: 12}, {T: 400, V: 35}}
+	maternal := []models.CTGPoint{{T: 1, V: 82}, {T: 2, V: 83}}
+
+	session := models.CTGSession{CardID: uuid.New(), DeviceID: "CTG-001", StartTime: time.Now().UTC()}
+	if err := db.Create(&session).Error; err != nil {
+		t.Fatal(err)
+	}
+	legacy := legacySession{
+		ID:          session.ID,
+		FHRData:     &legacySeries{Points: withRepeat},
+		UCData:      &legacySeries{Points: uc},
+		ChannelData: map[string]*legacySeries{channels.MaternalHeartRate: {Points: maternal}},
+	}
+	if err := db.Select("fhr_data", "uc_data", "channel_data").Updates(&legacy).Error; err != nil {
+		t.Fatalf("не удалось записать прежние колонки: %v", err)
+	}
+
+	if err := RunMigrations(db, chunks.DefaultFormat); err != nil {
+		t.Fatalf("ошибка миграции: %v", err)
+	}
+
+	series, err := chunks.Load(db, session.ID, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]models.CTGPoint{
+		channels.FetalHeartRate:      fhr,
+		channels.UterineContractions: uc,
+		channels.MaternalHeartRate:   maternal,
+	}
+	if !reflect.DeepEqual(series, want) {
+		t.Errorf("после переноса прочитаны ряды %+v, ожидались %+v", series, want)
+	}
+
+	for _, column := range legacySeriesColumns {
+		if db.Migrator().HasColumn(&legacySession{}, column) {
+			t.Errorf("колонка %s не удалена", column)
+		}
+	}
+
+	// Повторный запуск без прежних колонок ничего не меняет
+	if err := RunMigrations(db, chunks.DefaultFormat); err != nil {
+		t.Fatalf("ошибка повторной миграции: %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+// internal/downsample/lttb.go
+package downsample
+
+import (
+	"math"
+
+	"CTG_monitor/internal/models"
+)
+
+// LTTB прореживает ряд до threshold точек методом Largest-Triangle-Three-Buckets:
+// первая и последняя точки сохраняются, остальные делятся на threshold-2 групп,
+// и из каждой группы берется точка, образующая наибольший треугольник с уже
+// выбранной точкой и средним следующей группы. Пики, надиры и провалы потери
+// сигнала (значение -1) при этом остаются на графике.
+// points упорядочены по времени; при threshold <= 0 или не меньше длины ряда
+// возвращается исходный ряд.
+func LTTB(points []models.CTGPoint, threshold int) []models.CTGPoint {
+	if threshold <= 0 || threshold >= len(points) {
+		return points
+	}
+	if threshold < 3 {
+		return []models.CTGPoint{points[0], points[len(points)-1]}[:threshold]
+	}
+
+	sampled := make([]models.CTGPoint, 0, threshold)
+	sampled = append(sampled, points[0])
+
+	// Размер группы; первая и последняя точки в группы не входят
+	every := float64(len(points)-2) / float64(threshold-2)
+	selected := 0
+	for i := 0; i < threshold-2; i++ {
+		// Среднее следующей группы (для последней группы - последняя точка)
+		nextStart := int(float64(i+1)*every) + 1
+		nextEnd := min(int(float64(i+2)*every)+1, len(points))
+		var avgT, avgV float64
+		for _, point := range points[nextStart:nextEnd] {
+			avgT += point.T
+			avgV += point.V
+		}
+		count := float64(nextEnd - nextStart)
+		avgT /= count
+		avgV /= count
+
+		start := int(float64(i)*every) + 1
+		end := int(float64(i+1)*every) + 1
+		a := points[selected]
+		best, bestArea := start, -1.0
+		for j := start; j < end; j++ {
+			area := math.Abs((a.T-avgT)*(points[j].V-a.V) - (a.T-points[j].T)*(avgV-a.V))
+			if area > bestArea {
+				best, bestArea = j, area
+			}
+		}
+		sampled = append(sampled, points[best])
+		selected = best
+	}
+
+	return append(sampled, points[len(points)-1])
+}
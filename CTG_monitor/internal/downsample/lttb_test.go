@@ -0,0 +1,46 @@
+package downsample
+
+import (
+	"math"
+	"testing"
+
+	"CTG_monitor/internal/models"
+)
+
+func TestLTTB(t *testing.T) {
+	// 20 минут ЧСС 4 Гц: синусоида, одна децелерация и потеря сигнала
+	points := make([]models.CTGPoint, 4800)
+	for i := range points {
+		tm := float64(i) * 0.25
+		points[i] = models.CTGPoint{T: tm, V: 140 + 5*math.Sin(tm/10)}
+		switch {
+		case tm >= 300 && tm < 330:
+			points[i].V = 90
+		case tm >= 600 && tm < 620:
+			points[i].V = -1
+		}
+	}
+
+	sampled := LTTB(points, 500)
+	if len(sampled) != 500 {
+		t.Fatalf("точек после прореживания %d, ожидалось 500", len(sampled))
+	}
+	if sampled[0] != points[0] || sampled[len(sampled)-1] != points[len(points)-1] {
+		t.Errorf("первая и последняя точки не сохранены")
+	}
+	var nadir, loss bool
+	for i, point := range sampled {
+		if i > 0 && point.T <= sampled[i-1].T {
+			t.Fatalf("точки не упорядочены по времени: %v после %v", point.T, sampled[i-1].T)
+		}
+		nadir = nadir || point.V == 90
+		loss = loss || point.V == -1
+	}
+	if !nadir || !loss {
+		t.Errorf("децелерация или потеря сигнала пропали при прореживании: надир %v, потеря %v", nadir, loss)
+	}
+
+	if got := LTTB(points[:100], 500); len(got) != 100 {
+		t.Errorf("короткий ряд изменен: %d точек", len(got))
+	}
+}
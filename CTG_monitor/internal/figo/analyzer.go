@@ -0,0 +1,375 @@
+// internal/figo/analyzer.go
+package figo
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"CTG_monitor/internal/models"
+	"ctg_common/decel"
+)
+
+// Критерии FIGO (2015) для эпизодов ЧСС плода
+const (
+	episodeThreshold   = 15.0             // отклонение от базального ритма, уд/мин
+	episodeMinDuration = 15.0             // минимальная длительность эпизода, с
+	episodeMaxDuration = 10 * 60.0        // более длительное отклонение - смена базального ритма, с
+	recoveryBand       = 5.0              // возврат к базальному ритму, уд/мин
+	maxSignalGap       = 10.0             // потеря сигнала, прерывающая эпизод, с
+	baselineStep       = 10.0             // период пересчета базального ритма, с
+	baselineRound      = 5.0              // базальный ритм сообщается с округлением до 5 уд/мин
+	baselineModeBand   = 10.0             // окрестность моды гистограммы для расчета базального ритма
+	variabilityEpoch   = 60.0             // интервал оценки амплитуды вариабельности, с
+	minEpochSamples    = 10               // минимум точек для оценки амплитуды за интервал
+	minEpochs          = 3                // минимум интервалов для класса вариабельности
+	defaultMinBaseline = 2 * time.Minute  // сколько сигнала без эпизодов нужно для базального ритма
+	defaultWindow      = 10 * time.Minute // окно базального ритма и вариабельности
+)
+
+// Классы вариабельности по FIGO
+const (
+	BandReduced   = "reduced"   // амплитуда менее 5 уд/мин
+	BandNormal    = "normal"    // 5-25 уд/мин
+	BandIncreased = "increased" // более 25 уд/мин (сальтаторный ритм)
+)
+
+// Config параметры анализа
+type Config struct {
+	Window      time.Duration // окно базального ритма и вариабельности (10 мин по FIGO)
+	MinBaseline time.Duration // сигнал без эпизодов, нужный для определения базального ритма
+	Rate        float64       // частота сетки, к которой приводятся точки перед анализом, Гц
+}
+
+// State текущие оценки канала
+type State struct {
+	Baseline      float64 `json:"baseline"`    // базальный ритм (0 - еще не определен)
+	Variability   float64 `json:"variability"` // амплитуда вариабельности, уд/мин
+	Band          string  `json:"band"`        // класс вариабельности
+	Accelerations int     `json:"accelerations"`
+	Decelerations int     `json:"decelerations"`
+
+	DecelerationTypes decel.Counts `json:"deceleration_types"` // децелерации по типам относительно схваток
+
+	// Канал сокращений матки
+	Tone         float64 `json:"tone,omitempty"`         // базальный тонус
+	Contractions int     `json:"contractions,omitempty"` // найдено схваток
+	Frequency    float64 `json:"frequency,omitempty"`    // схваток за последние 10 минут
+	Tachysystole bool    `json:"tachysystole,omitempty"` // более 5 схваток за 10 минут в среднем за 30 минут
+}
+
+// sample точка окна
+type sample struct {
+	t, v    float64
+	episode bool // точка внутри акцелерации или децелерации
+}
+
+// episode незавершенное отклонение от базального ритма
+type episode struct {
+	kind     string
+	start    float64
+	peakT    float64
+	peakV    float64
+	baseline float64
+	lastT    float64
+}
+
+// epoch амплитуда вариабельности за интервал
+type epoch struct {
+	start     float64
+	min, max  float64
+	count     int
+	amplitude float64
+}
+
+// Analyzer пошагово анализирует ЧСС одного плода: базальный ритм, вариабельность,
+// акцелерации и децелерации. Время точек - шкала сессии.
+type Analyzer struct {
+	cfg     Config
+	channel string
+
+	samples    []sample // окно по возрастанию времени
+	lastT      float64
+	hasSample  bool
+	baselineAt float64
+
+	baseline float64 // точный базальный ритм
+	reported float64 // последний сообщенный (округленный)
+
+	current *epoch
+	epochs  []epoch
+	band    string
+
+	episode  *episode
+	lastNear float64 // последняя точка у базального ритма
+
+	state State
+}
+
+// NewAnalyzer создает анализатор канала ЧСС плода
+func NewAnalyzer(cfg Config, channel string) *Analyzer {
+	if cfg.Window <= 0 {
+		cfg.Window = defaultWindow
+	}
+	if cfg.MinBaseline <= 0 {
+		cfg.MinBaseline = defaultMinBaseline
+	}
+	return &Analyzer{cfg: cfg, channel: channel}
+}
+
+// Observe учитывает точку ЧСС (-1 - потеря сигнала) и возвращает завершенные события:
+// эпизоды, смену базального ритма или класса вариабельности
+func (a *Analyzer) Observe(t, v float64) []models.CTGEvent {
+	if a.hasSample && t-a.lastT > maxSignalGap {
+		// Долгая потеря сигнала: незавершенный эпизод не оценить
+		a.episode = nil
+		a.current = nil
+	}
+	if v == -1 {
+		return nil
+	}
+	a.lastT, a.hasSample = t, true
+
+	var events []models.CTGEvent
+	if event, ok := a.trackEpisode(t, v); ok {
+		events = append(events, event)
+	}
+
+	a.samples = append(a.samples, sample{t: t, v: v, episode: a.episode != nil})
+	cutoff := t - a.cfg.Window.Seconds()
+	drop := 0
+	for drop < len(a.samples) && a.samples[drop].t < cutoff {
+		drop++
+	}
+	a.samples = a.samples[drop:]
+
+	if t-a.baselineAt >= baselineStep {
+		a.baselineAt = t
+		if event, ok := a.updateBaseline(t); ok {
+			events = append(events, event)
+		}
+	}
+
+	if event, ok := a.trackVariability(t, v); ok {
+		events = append(events, event)
+	}
+	return events
+}
+
+// State возвращает текущие оценки канала
+func (a *Analyzer) State() State {
+	return a.state
+}
+
+// trackEpisode ведет акцелерацию или децелерацию относительно базального ритма
+func (a *Analyzer) trackEpisode(t, v float64) (models.CTGEvent, bool) {
+	if a.baseline == 0 {
+		return models.CTGEvent{}, false
+	}
+	deviation := v - a.baseline
+
+	if math.Abs(deviation) <= recoveryBand {
+		a.lastNear = t
+		if a.episode != nil {
+			return a.finishEpisode(t)
+		}
+		return models.CTGEvent{}, false
+	}
+
+	kind := models.EventAcceleration
+	if deviation < 0 {
+		kind = models.EventDeceleration
+	}
+
+	if a.episode != nil && a.episode.kind != kind && math.Abs(deviation) >= episodeThreshold {
+		// Переход через базальный ритм без возврата к нему
+		event, ok := a.finishEpisode(t)
+		a.startEpisode(kind, t, v)
+		return event, ok
+	}
+
+	if a.episode == nil {
+		if math.Abs(deviation) >= episodeThreshold {
+			a.startEpisode(kind, t, v)
+		}
+		return models.CTGEvent{}, false
+	}
+
+	a.episode.lastT = t
+	if math.Abs(v-a.episode.baseline) > math.Abs(a.episode.peakV-a.episode.baseline) {
+		a.episode.peakT, a.episode.peakV = t, v
+	}
+	if t-a.episode.start > episodeMaxDuration {
+		// Это уже не эпизод, а новый уровень ЧСС: его учтет базальный ритм
+		a.episode = nil
+	}
+	return models.CTGEvent{}, false
+}
+
+// startEpisode начинает эпизод от последней точки у базального ритма
+func (a *Analyzer) startEpisode(kind string, t, v float64) {
+	start := a.lastNear
+	if start == 0 || t-start > episodeMaxDuration {
+		start = t
+	}
+	a.episode = &episode{
+		kind:     kind,
+		start:    start,
+		peakT:    t,
+		peakV:    v,
+		baseline: a.baseline,
+		lastT:    t,
+	}
+}
+
+// finishEpisode закрывает эпизод; событие возвращается, если эпизод достаточно длинный
+func (a *Analyzer) finishEpisode(end float64) (models.CTGEvent, bool) {
+	ep := a.episode
+	a.episode = nil
+
+	if end-ep.start < episodeMinDuration {
+		return models.CTGEvent{}, false
+	}
+
+	if ep.kind == models.EventAcceleration {
+		a.state.Accelerations++
+	} else {
+		a.state.Decelerations++
+	}
+	return models.CTGEvent{
+		Type:      ep.kind,
+		Channel:   a.channel,
+		Start:     ep.start,
+		End:       end,
+		Peak:      ep.peakT,
+		Value:     ep.peakV,
+		Amplitude: round1(ep.peakV - ep.baseline),
+		Baseline:  round1(ep.baseline),
+	}, true
+}
+
+// updateBaseline пересчитывает базальный ритм по точкам окна вне эпизодов:
+// среднее в окрестности моды гистограммы с шагом 1 уд/мин
+func (a *Analyzer) updateBaseline(t float64) (models.CTGEvent, bool) {
+	quiet := make([]float64, 0, len(a.samples))
+	for _, s := range a.samples {
+		if !s.episode {
+			quiet = append(quiet, s.v)
+		}
+	}
+	if len(a.samples) < 2 || len(quiet) < 2 {
+		return models.CTGEvent{}, false
+	}
+
+	span := a.samples[len(a.samples)-1].t - a.samples[0].t
+	if span <= 0 {
+		return models.CTGEvent{}, false
+	}
+	rate := float64(len(a.samples)-1) / span
+	if float64(len(quiet))/rate < a.cfg.MinBaseline.Seconds() {
+		return models.CTGEvent{}, false
+	}
+
+	histogram := make(map[int]int)
+	mode, modeCount := 0, 0
+	for _, v := range quiet {
+		bin := int(math.Round(v))
+		histogram[bin]++
+		if count := histogram[bin]; count > modeCount || count == modeCount && bin < mode {
+			mode, modeCount = bin, count
+		}
+	}
+
+	sum, count := 0.0, 0
+	for _, v := range quiet {
+		if math.Abs(v-float64(mode)) <= baselineModeBand {
+			sum += v
+			count++
+		}
+	}
+	a.baseline = sum / float64(count)
+	a.state.Baseline = round1(a.baseline)
+
+	rounded := math.Round(a.baseline/baselineRound) * baselineRound
+	if rounded == a.reported {
+		return models.CTGEvent{}, false
+	}
+	a.reported = rounded
+	return models.CTGEvent{
+		Type:     models.EventBaseline,
+		Channel:  a.channel,
+		Start:    t,
+		Value:    rounded,
+		Baseline: a.state.Baseline,
+	}, true
+}
+
+// trackVariability оценивает амплитуду колебаний ЧСС по минутным интервалам вне
+// эпизодов; класс вариабельности - по медиане амплитуд в окне
+func (a *Analyzer) trackVariability(t, v float64) (models.CTGEvent, bool) {
+	if a.current != nil && t-a.current.start >= variabilityEpoch {
+		if a.current.count >= minEpochSamples {
+			a.current.amplitude = a.current.max - a.current.min
+			a.epochs = append(a.epochs, *a.current)
+		}
+		a.current = nil
+	}
+	if a.current == nil {
+		a.current = &epoch{start: t, min: v, max: v}
+	}
+	if a.episode == nil {
+		a.current.min = math.Min(a.current.min, v)
+		a.current.max = math.Max(a.current.max, v)
+		a.current.count++
+	}
+
+	cutoff := t - a.cfg.Window.Seconds()
+	drop := 0
+	for drop < len(a.epochs) && a.epochs[drop].start < cutoff {
+		drop++
+	}
+	a.epochs = a.epochs[drop:]
+	if len(a.epochs) < minEpochs {
+		return models.CTGEvent{}, false
+	}
+
+	amplitudes := make([]float64, len(a.epochs))
+	for i, e := range a.epochs {
+		amplitudes[i] = e.amplitude
+	}
+	sort.Float64s(amplitudes)
+	amplitude := amplitudes[len(amplitudes)/2]
+	a.state.Variability = round1(amplitude)
+
+	band := variabilityBand(amplitude)
+	if band == a.band {
+		return models.CTGEvent{}, false
+	}
+	a.band = band
+	a.state.Band = band
+	return models.CTGEvent{
+		Type:     models.EventVariability,
+		Channel:  a.channel,
+		Start:    t,
+		Value:    a.state.Variability,
+		Baseline: a.state.Baseline,
+		Band:     band,
+	}, true
+}
+
+// variabilityBand класс вариабельности по амплитуде
+func variabilityBand(amplitude float64) string {
+	switch {
+	case amplitude < 5:
+		return BandReduced
+	case amplitude > 25:
+		return BandIncreased
+	default:
+		return BandNormal
+	}
+}
+
+// round1 округляет до 0.1
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
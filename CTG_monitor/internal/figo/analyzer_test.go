@@ -0,0 +1,217 @@
+package figo
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"CTG_monitor/internal/channels"
+	"CTG_monitor/internal/models"
+)
+
+// feed передает точки частоты rate на [from, to) со значениями value(t)
+func feed(observe func(t, v float64) []models.CTGEvent, from, to, rate float64, value func(float64) float64) []models.CTGEvent {
+	var events []models.CTGEvent
+	for i := 0; ; i++ {
+		t := from + float64(i)/rate
+		if t >= to {
+			return events
+		}
+		events = append(events, observe(t, value(t))...)
+	}
+}
+
+// ofType события заданного типа
+func ofType(events []models.CTGEvent, eventType string) []models.CTGEvent {
+	var filtered []models.CTGEvent
+	for _, event := range events {
+		if event.Type == eventType {
+			filtered = append(filtered, event)
+		}
+	}
+	return filtered
+}
+
+// constant сигнал без колебаний
+func constant(v float64) func(float64) float64 {
+	return func(float64) float64 { return v }
+}
+
+// rect сигнал level на [from, to) и base вне этого интервала
+func rect(base, level, from, to float64) func(float64) float64 {
+	return func(t float64) float64 {
+		if t >= from && t < to {
+			return level
+		}
+		return base
+	}
+}
+
+func TestAnalyzerBaseline(t *testing.T) {
+	cases := []struct {
+		name   string
+		value  func(float64) float64
+		until  float64
+		values []float64 // сообщенный базальный ритм по порядку
+		state  float64
+	}{
+		{"базальный ритм после 2 минут сигнала", constant(140), 300, []float64{140}, 140},
+		{"сообщается с округлением до 5", constant(143), 300, []float64{145}, 143},
+		{"меньше 2 минут сигнала", constant(140), 119, nil, 0},
+		{"смена уровня", rect(140, 150, 300, 1200), 1200, []float64{140, 145, 150}, 150},
+		{
+			name: "потеря сигнала не входит в окно",
+			value: func(t float64) float64 {
+				if math.Mod(t, 2) >= 1 {
+					return -1
+				}
+				return 140
+			},
+			until:  300,
+			values: []float64{140},
+			state:  140,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			analyzer := NewAnalyzer(Config{}, channels.FetalHeartRate)
+			events := ofType(feed(analyzer.Observe, 0, c.until, 4, c.value), models.EventBaseline)
+
+			var values []float64
+			for _, event := range events {
+				values = append(values, event.Value)
+				if event.Channel != channels.FetalHeartRate {
+					t.Errorf("событие канала %s", event.Channel)
+				}
+			}
+			if !reflect.DeepEqual(values, c.values) {
+				t.Errorf("базальный ритм %v, ожидался %v", values, c.values)
+			}
+			if state := analyzer.State().Baseline; state != c.state {
+				t.Errorf("оценка базального ритма %.1f, ожидалась %.1f", state, c.state)
+			}
+		})
+	}
+
+	// Первый расчет - на шаге пересчета после 2 минут сигнала
+	analyzer := NewAnalyzer(Config{}, channels.FetalHeartRate)
+	events := feed(analyzer.Observe, 0, 300, 4, constant(140))
+	if len(events) == 0 || events[0].Start != 120 || events[0].Baseline != 140 {
+		t.Errorf("первое событие %+v, ожидался базальный ритм на 120 с", events)
+	}
+}
+
+func TestAnalyzerEpisodes(t *testing.T) {
+	// Эпизоды на фоне ровного ритма 140; базальный ритм определен к 120 с
+	deceleration := models.CTGEvent{Type: models.EventDeceleration, Channel: channels.FetalHeartRate,
+		Start: 199.75, End: 230, Peak: 200, Value: 110, Amplitude: -30, Baseline: 140}
+	acceleration := models.CTGEvent{Type: models.EventAcceleration, Channel: channels.FetalHeartRate,
+		Start: 199.75, End: 230, Peak: 200, Value: 160, Amplitude: 20, Baseline: 140}
+	short := deceleration
+	short.End = 215
+
+	cases := []struct {
+		name  string
+		value func(float64) float64
+		want  []models.CTGEvent
+	}{
+		{"децелерация", rect(140, 110, 200, 230), []models.CTGEvent{deceleration}},
+		{"акцелерация", rect(140, 160, 200, 230), []models.CTGEvent{acceleration}},
+		{"15 с от последней точки у базального ритма", rect(140, 110, 200, 215), []models.CTGEvent{short}},
+		{"короче 15 с - не эпизод", rect(140, 110, 200, 214.5), nil},
+		{"отклонение на 15 - эпизод", rect(140, 125, 200, 230), []models.CTGEvent{func() models.CTGEvent {
+			event := deceleration
+			event.Value, event.Amplitude = 125, -15
+			return event
+		}()}},
+		{"отклонение меньше 15 - не эпизод", rect(140, 126, 200, 230), nil},
+		{
+			name: "пик - наибольшее отклонение",
+			value: func(t float64) float64 {
+				if t >= 210 && t < 215 {
+					return 100
+				}
+				return rect(140, 110, 200, 230)(t)
+			},
+			want: []models.CTGEvent{func() models.CTGEvent {
+				event := deceleration
+				event.Peak, event.Value, event.Amplitude = 210, 100, -40
+				return event
+			}()},
+		},
+		{
+			name: "короткая потеря сигнала не прерывает эпизод",
+			value: func(t float64) float64 {
+				if t >= 210 && t < 218 {
+					return -1
+				}
+				return rect(140, 110, 200, 230)(t)
+			},
+			want: []models.CTGEvent{deceleration},
+		},
+		{
+			name: "потеря сигнала дольше 10 с прерывает эпизод",
+			value: func(t float64) float64 {
+				if t >= 210 && t < 240 {
+					return -1
+				}
+				return rect(140, 110, 200, 230)(t)
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			analyzer := NewAnalyzer(Config{}, channels.FetalHeartRate)
+			events := feed(analyzer.Observe, 0, 300, 4, c.value)
+			episodes := append(ofType(events, models.EventDeceleration), ofType(events, models.EventAcceleration)...)
+			if !reflect.DeepEqual(episodes, c.want) {
+				t.Errorf("эпизоды %+v, ожидались %+v", episodes, c.want)
+			}
+
+			state := analyzer.State()
+			if state.Decelerations != len(ofType(c.want, models.EventDeceleration)) ||
+				state.Accelerations != len(ofType(c.want, models.EventAcceleration)) {
+				t.Errorf("счетчики эпизодов %+v", state)
+			}
+		})
+	}
+}
+
+func TestAnalyzerVariability(t *testing.T) {
+	// Синусоида с периодом 16 с: размах за минуту ровно 2*amplitude
+	cases := []struct {
+		name      string
+		amplitude float64
+		band      string
+	}{
+		{"размах 4 - сниженная", 2, BandReduced},
+		{"размах 5 - нормальная", 2.5, BandNormal},
+		{"размах 25 - нормальная", 12.5, BandNormal},
+		{"размах 26 - сальтаторная", 13, BandIncreased},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			analyzer := NewAnalyzer(Config{}, channels.FetalHeartRate)
+			events := feed(analyzer.Observe, 0, 200, 4, func(t float64) float64 {
+				return 140 + c.amplitude*math.Sin(t*math.Pi/8)
+			})
+
+			// Класс определяется, когда завершены 3 минутных интервала. Дальше при
+			// большом размахе базальный ритм смещается к моде и часть колебаний
+			// становится эпизодами, поэтому запись обрывается вскоре после первой оценки
+			variability := ofType(events, models.EventVariability)
+			if len(variability) != 1 || variability[0].Start != 180 {
+				t.Fatalf("события вариабельности %+v, ожидалось одно на 180 с", variability)
+			}
+			if event := variability[0]; event.Band != c.band || event.Value != 2*c.amplitude {
+				t.Errorf("класс %s с размахом %.1f, ожидался %s с %.1f", event.Band, event.Value, c.band, 2*c.amplitude)
+			}
+			if state := analyzer.State(); state.Band != c.band || state.Variability != 2*c.amplitude {
+				t.Errorf("оценка вариабельности %+v", state)
+			}
+			if episodes := len(ofType(events, models.EventDeceleration)) + len(ofType(events, models.EventAcceleration)); episodes != 0 {
+				t.Errorf("колебания приняты за эпизоды: %d", episodes)
+			}
+		})
+	}
+}
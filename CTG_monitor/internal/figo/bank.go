@@ -0,0 +1,162 @@
+// internal/figo/bank.go
+package figo
+
+import (
+	"sort"
+	"sync"
+
+	"CTG_monitor/internal/channels"
+	"CTG_monitor/internal/models"
+	"ctg_common/decel"
+	"ctg_common/resample"
+)
+
+// Bank хранит анализаторы для каждого устройства и канала ЧСС плода, а также
+// детекторы схваток устройств, по которым определяется тип децелераций.
+// Точки каналов перед анализом приводятся к равномерной сетке.
+type Bank struct {
+	cfg          Config
+	grids        map[channelKey]*resample.Stream
+	analyzers    map[channelKey]*Analyzer
+	contractions map[string]*ContractionTracker
+	mu           sync.Mutex
+}
+
+// channelKey ключ канала конкретного устройства
+type channelKey struct {
+	deviceID string
+	dataType string
+}
+
+// ChannelStats оценки одного канала устройства
+type ChannelStats struct {
+	DeviceID string `json:"device_id"`
+	DataType string `json:"data_type"`
+	State
+}
+
+// NewBank создает банк анализаторов
+func NewBank(cfg Config) *Bank {
+	return &Bank{
+		cfg:          cfg,
+		grids:        make(map[channelKey]*resample.Stream),
+		analyzers:    make(map[channelKey]*Analyzer),
+		contractions: make(map[string]*ContractionTracker),
+	}
+}
+
+// Observe учитывает точку канала устройства: приводит ее к сетке частоты Config.Rate
+// и передает точки сетки анализу (см. Analyzer.Observe и ContractionTracker.Observe).
+// Децелерации ЧСС плода получают тип по схваткам устройства.
+func (b *Bank) Observe(deviceID, dataType string, t, v float64) []models.CTGEvent {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	key := channelKey{deviceID: deviceID, dataType: dataType}
+	grid, exists := b.grids[key]
+	if !exists {
+		grid = resample.NewStream(resample.Config{Rate: b.cfg.Rate})
+		b.grids[key] = grid
+	}
+
+	var events []models.CTGEvent
+	for _, sample := range grid.Add(resample.Sample{T: t, V: v}) {
+		events = append(events, b.observe(key, sample.T, sample.V)...)
+	}
+	return events
+}
+
+// observe передает точку сетки анализу канала
+func (b *Bank) observe(key channelKey, t, v float64) []models.CTGEvent {
+	if key.dataType == channels.UterineContractions {
+		tracker, exists := b.contractions[key.deviceID]
+		if !exists {
+			tracker = NewContractionTracker(b.cfg, key.dataType)
+			b.contractions[key.deviceID] = tracker
+		}
+		return tracker.Observe(t, v)
+	}
+
+	analyzer, exists := b.analyzers[key]
+	if !exists {
+		analyzer = NewAnalyzer(b.cfg, key.dataType)
+		b.analyzers[key] = analyzer
+	}
+
+	events := analyzer.Observe(t, v)
+	for i := range events {
+		if events[i].Type == models.EventDeceleration {
+			b.classify(key.deviceID, &events[i])
+			analyzer.state.DecelerationTypes.Add(events[i].Kind)
+		}
+	}
+	return events
+}
+
+// classify определяет тип децелерации по ближайшей схватке устройства
+func (b *Bank) classify(deviceID string, event *models.CTGEvent) {
+	var contractions []decel.Contraction
+	if tracker, exists := b.contractions[deviceID]; exists {
+		contractions = tracker.Contractions()
+	}
+
+	typed := decel.Classify(decel.Deceleration{
+		Start: event.Start,
+		Nadir: event.Peak,
+		End:   event.End,
+		Depth: -event.Amplitude,
+	}, contractions)
+
+	event.Kind = typed.Type
+	if typed.Paired {
+		event.ContractionPeak = typed.ContractionPeak
+		event.Lag = round1(typed.Lag)
+	}
+}
+
+// ResetDevice забывает историю всех каналов устройства (новый пациент)
+func (b *Bank) ResetDevice(deviceID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for key := range b.grids {
+		if key.deviceID == deviceID {
+			delete(b.grids, key)
+		}
+	}
+	for key := range b.analyzers {
+		if key.deviceID == deviceID {
+			delete(b.analyzers, key)
+		}
+	}
+	delete(b.contractions, deviceID)
+}
+
+// Stats возвращает оценки по всем каналам
+func (b *Bank) Stats() []ChannelStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stats := make([]ChannelStats, 0, len(b.analyzers)+len(b.contractions))
+	for key, analyzer := range b.analyzers {
+		stats = append(stats, ChannelStats{
+			DeviceID: key.deviceID,
+			DataType: key.dataType,
+			State:    analyzer.State(),
+		})
+	}
+	for deviceID, tracker := range b.contractions {
+		stats = append(stats, ChannelStats{
+			DeviceID: deviceID,
+			DataType: channels.UterineContractions,
+			State:    tracker.State(),
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].DeviceID != stats[j].DeviceID {
+			return stats[i].DeviceID < stats[j].DeviceID
+		}
+		return stats[i].DataType < stats[j].DataType
+	})
+	return stats
+}
@@ -0,0 +1,127 @@
+package figo
+
+import (
+	"math"
+	"testing"
+
+	"CTG_monitor/internal/channels"
+	"CTG_monitor/internal/models"
+	"ctg_common/decel"
+)
+
+// observeDevice передает банку 5 минут ЧСС и токограммы устройства с частотой 4 Гц:
+// плавная децелерация до 110 уд/мин с надиром на 210 с и, если задано,
+// схватка с пиком в contractionPeak
+func observeDevice(bank *Bank, deviceID string, contractionPeak float64) []models.CTGEvent {
+	var events []models.CTGEvent
+	for i := 0; i < 5*60*4; i++ {
+		t := float64(i) * 0.25
+		fhr := 140 + 4*math.Sin(t*math.Pi/8)
+		switch {
+		case t >= 160 && t < 210:
+			fhr = 140 - 30*(t-160)/50
+		case t >= 210 && t < 250:
+			fhr = 110 + 30*(t-210)/40
+		}
+		events = append(events, bank.Observe(deviceID, channels.FetalHeartRate, t, math.Round(fhr))...)
+
+		if contractionPeak > 0 {
+			uc := 10.0
+			if rise := 50 * (1 - math.Abs(t-contractionPeak)/40); rise > 0 {
+				uc += rise
+			}
+			events = append(events, bank.Observe(deviceID, channels.UterineContractions, t, math.Round(uc))...)
+		}
+	}
+	return events
+}
+
+func TestBankDecelerationTyping(t *testing.T) {
+	cases := []struct {
+		name            string
+		contractionPeak float64
+		kind            string
+		paired          bool
+	}{
+		{"надир через 30 с после пика схватки - поздняя", 180, decel.Late, true},
+		{"надир на пике схватки - ранняя", 210, decel.Early, true},
+		{"без схваток - вариабельная", 0, decel.Variable, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			bank := NewBank(Config{Rate: 4})
+			events := observeDevice(bank, "CTG-001", c.contractionPeak)
+
+			decelerations := ofType(events, models.EventDeceleration)
+			if len(decelerations) != 1 {
+				t.Fatalf("ожидалась одна децелерация: %+v", decelerations)
+			}
+			event := decelerations[0]
+			if event.Kind != c.kind || math.Abs(event.Peak-210) > 1 {
+				t.Errorf("децелерация %+v, ожидалась %s с надиром на 210 с", event, c.kind)
+			}
+			if c.paired && (math.Abs(event.ContractionPeak-c.contractionPeak) > 1 || math.Abs(event.Lag-(210-c.contractionPeak)) > 1) {
+				t.Errorf("децелерация связана со схваткой %.1f с задержкой %.1f", event.ContractionPeak, event.Lag)
+			}
+			if !c.paired && (event.ContractionPeak != 0 || event.Lag != 0) {
+				t.Errorf("децелерация без схваток связана со схваткой %.1f", event.ContractionPeak)
+			}
+
+			for _, stats := range bank.Stats() {
+				if stats.DataType != channels.FetalHeartRate {
+					continue
+				}
+				want := decel.Counts{}
+				want.Add(c.kind)
+				if stats.DecelerationTypes != want {
+					t.Errorf("счетчики типов децелераций %+v, ожидались %+v", stats.DecelerationTypes, want)
+				}
+			}
+		})
+	}
+}
+
+func TestBankDevices(t *testing.T) {
+	bank := NewBank(Config{Rate: 4})
+	observeDevice(bank, "CTG-002", 180)
+	observeDevice(bank, "CTG-001", 0)
+
+	// Схватки одного устройства не влияют на децелерации другого
+	stats := bank.Stats()
+	if len(stats) != 3 {
+		t.Fatalf("ожидались оценки трех каналов: %+v", stats)
+	}
+	order := []struct{ deviceID, dataType string }{
+		{"CTG-001", channels.FetalHeartRate},
+		{"CTG-002", channels.FetalHeartRate},
+		{"CTG-002", channels.UterineContractions},
+	}
+	for i, want := range order {
+		if stats[i].DeviceID != want.deviceID || stats[i].DataType != want.dataType {
+			t.Errorf("оценки %d: %s/%s, ожидались %s/%s", i, stats[i].DeviceID, stats[i].DataType, want.deviceID, want.dataType)
+		}
+	}
+	if stats[0].DecelerationTypes.Variable != 1 || stats[1].DecelerationTypes.Late != 1 || stats[2].Contractions != 1 {
+		t.Errorf("неверные оценки каналов: %+v", stats)
+	}
+
+	// Новый пациент: история устройства забывается, второе устройство не затрагивается
+	bank.ResetDevice("CTG-002")
+	stats = bank.Stats()
+	if len(stats) != 1 || stats[0].DeviceID != "CTG-001" {
+		t.Errorf("после сброса устройства остались оценки %+v", stats)
+	}
+}
+
+func TestBankResamples(t *testing.T) {
+	// Точки раз в секунду приводятся к сетке 4 Гц: базальный ритм определяется
+	// так же, как по исходной сетке
+	bank := NewBank(Config{Rate: 4})
+	var events []models.CTGEvent
+	for t := 0.0; t < 300; t++ {
+		events = append(events, bank.Observe("CTG-001", channels.FetalHeartRate, t, 140)...)
+	}
+	if baseline := ofType(events, models.EventBaseline); len(baseline) != 1 || baseline[0].Start != 120 || baseline[0].Value != 140 {
+		t.Errorf("базальный ритм %+v, ожидался 140 на 120 с", baseline)
+	}
+}
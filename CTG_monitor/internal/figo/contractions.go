@@ -0,0 +1,187 @@
+// internal/figo/contractions.go
+package figo
+
+import (
+	"CTG_monitor/internal/models"
+	"ctg_common/decel"
+)
+
+// ContractionTracker пошагово находит схватки по токограмме одного устройства,
+// считает их частоту за 10 минут и тахисистолию (более 5 схваток за 10 минут
+// в среднем за 30 минут). Время точек - шкала сессии.
+type ContractionTracker struct {
+	cfg     Config
+	channel string
+
+	samples   []decel.Sample // окно базального тонуса по возрастанию времени
+	firstT    float64
+	lastT     float64
+	hasSample bool
+	toneAt    float64
+	tone      float64
+	hasTone   bool
+
+	current      *decel.Contraction
+	edge         float64             // последняя точка у базального тонуса
+	contractions []decel.Contraction // завершенные схватки за 30 минут
+
+	tachysystoleSince float64
+
+	state State
+}
+
+// NewContractionTracker создает детектор схваток канала сокращений матки
+func NewContractionTracker(cfg Config, channel string) *ContractionTracker {
+	if cfg.Window <= 0 {
+		cfg.Window = defaultWindow
+	}
+	if cfg.MinBaseline <= 0 {
+		cfg.MinBaseline = defaultMinBaseline
+	}
+	return &ContractionTracker{cfg: cfg, channel: channel}
+}
+
+// Observe учитывает точку токограммы (-1 - потеря сигнала) и возвращает завершенные
+// схватки, начало и окончание тахисистолии
+func (c *ContractionTracker) Observe(t, v float64) []models.CTGEvent {
+	if c.hasSample && t-c.lastT > maxSignalGap {
+		// Долгая потеря сигнала: незавершенную схватку не оценить
+		c.current = nil
+		c.edge = t
+	}
+	if v == -1 {
+		return nil
+	}
+	if !c.hasSample {
+		c.firstT, c.edge = t, t
+	}
+	c.lastT, c.hasSample = t, true
+
+	c.samples = append(c.samples, decel.Sample{T: t, V: v})
+	cutoff := t - c.cfg.Window.Seconds()
+	drop := 0
+	for drop < len(c.samples) && c.samples[drop].T < cutoff {
+		drop++
+	}
+	c.samples = c.samples[drop:]
+
+	if t-c.toneAt >= baselineStep && t-c.samples[0].T >= c.cfg.MinBaseline.Seconds() {
+		c.toneAt = t
+		c.tone, c.hasTone = decel.Tone(c.samples), true
+		c.state.Tone = round1(c.tone)
+	}
+
+	var events []models.CTGEvent
+	if event, ok := c.trackContraction(t, v); ok {
+		events = append(events, event)
+	}
+	if event, ok := c.trackFrequency(t); ok {
+		events = append(events, event)
+	}
+	return events
+}
+
+// State возвращает текущие оценки канала
+func (c *ContractionTracker) State() State {
+	return c.state
+}
+
+// Contractions схватки за последние 30 минут, включая незавершенную
+func (c *ContractionTracker) Contractions() []decel.Contraction {
+	contractions := append([]decel.Contraction(nil), c.contractions...)
+	if c.current != nil {
+		current := *c.current
+		current.Offset = c.lastT
+		contractions = append(contractions, current)
+	}
+	return contractions
+}
+
+// trackContraction ведет схватку: начало - последняя точка у базального тонуса перед
+// подъемом на 10 единиц, окончание - возврат к тонусу
+func (c *ContractionTracker) trackContraction(t, v float64) (models.CTGEvent, bool) {
+	if !c.hasTone {
+		return models.CTGEvent{}, false
+	}
+
+	rise := v - c.tone
+	switch {
+	case rise <= decel.ContractionEdge:
+		c.edge = t
+		if c.current != nil {
+			return c.finishContraction(t)
+		}
+	case c.current == nil && rise >= decel.ContractionAmplitude:
+		c.current = &decel.Contraction{Onset: c.edge, Peak: t, Amplitude: rise, Tone: c.tone}
+	case c.current != nil && rise > c.current.Amplitude:
+		c.current.Peak, c.current.Amplitude = t, rise
+	}
+	return models.CTGEvent{}, false
+}
+
+// finishContraction закрывает схватку; событие возвращается, если схватка достаточно длинная
+func (c *ContractionTracker) finishContraction(offset float64) (models.CTGEvent, bool) {
+	contraction := *c.current
+	c.current = nil
+
+	contraction.Offset = offset
+	if contraction.Duration() < decel.ContractionMin {
+		return models.CTGEvent{}, false
+	}
+	c.contractions = append(c.contractions, contraction)
+	c.state.Contractions++
+
+	return models.CTGEvent{
+		Type:      models.EventContraction,
+		Channel:   c.channel,
+		Start:     contraction.Onset,
+		End:       contraction.Offset,
+		Peak:      contraction.Peak,
+		Value:     round1(contraction.Tone + contraction.Amplitude),
+		Amplitude: round1(contraction.Amplitude),
+		Baseline:  round1(contraction.Tone),
+		Frequency: c.frequency(offset),
+	}, true
+}
+
+// trackFrequency пересчитывает частоту схваток за 10 минут и переключает тахисистолию
+func (c *ContractionTracker) trackFrequency(t float64) (models.CTGEvent, bool) {
+	drop := 0
+	for drop < len(c.contractions) && c.contractions[drop].Peak < t-decel.TachysystoleWindow {
+		drop++
+	}
+	c.contractions = c.contractions[drop:]
+
+	c.state.Frequency = c.frequency(t)
+	if t-c.firstT < decel.TachysystoleWindow {
+		return models.CTGEvent{}, false
+	}
+
+	frequency, active := decel.Tachysystole(c.contractions, t)
+	if active == c.state.Tachysystole {
+		return models.CTGEvent{}, false
+	}
+	c.state.Tachysystole = active
+
+	event := models.CTGEvent{
+		Type:      models.EventTachysystole,
+		Channel:   c.channel,
+		Start:     t,
+		Value:     round1(frequency),
+		Frequency: c.state.Frequency,
+	}
+	if active {
+		c.tachysystoleSince = t
+	} else {
+		event.Start, event.End = c.tachysystoleSince, t
+	}
+	return event, true
+}
+
+// frequency частота схваток за последние 10 минут (0, пока записи меньше 10 минут)
+func (c *ContractionTracker) frequency(t float64) float64 {
+	if t-c.firstT < decel.FrequencyWindow {
+		return 0
+	}
+	return round1(decel.Frequency(c.contractions, t-decel.FrequencyWindow, t))
+}
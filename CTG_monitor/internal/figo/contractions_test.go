@@ -0,0 +1,133 @@
+package figo
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"CTG_monitor/internal/channels"
+	"CTG_monitor/internal/models"
+	"ctg_common/decel"
+)
+
+func TestContractionTracker(t *testing.T) {
+	// Токограмма 1 Гц с тонусом 10; тонус определен к 120 с
+	contraction := models.CTGEvent{Type: models.EventContraction, Channel: channels.UterineContractions,
+		Start: 199, End: 260, Peak: 200, Value: 30, Amplitude: 20, Baseline: 10}
+	shortest := contraction
+	shortest.End = 229
+
+	cases := []struct {
+		name  string
+		value func(float64) float64
+		want  []models.CTGEvent
+	}{
+		{"схватка", rect(10, 30, 200, 260), []models.CTGEvent{contraction}},
+		{"30 с от последней точки у тонуса", rect(10, 30, 200, 229), []models.CTGEvent{shortest}},
+		{"короче 30 с - не схватка", rect(10, 30, 200, 228), nil},
+		{"подъем на 10 - схватка", rect(10, 20, 200, 260), []models.CTGEvent{func() models.CTGEvent {
+			event := contraction
+			event.Value, event.Amplitude = 20, 10
+			return event
+		}()}},
+		{"подъем меньше 10 - не схватка", rect(10, 19, 200, 260), nil},
+		{"до определения тонуса", rect(10, 30, 60, 110), nil},
+		{
+			name: "пик - наибольший подъем",
+			value: func(t float64) float64 {
+				if t >= 220 && t < 225 {
+					return 50
+				}
+				return rect(10, 30, 200, 260)(t)
+			},
+			want: []models.CTGEvent{func() models.CTGEvent {
+				event := contraction
+				event.Peak, event.Value, event.Amplitude = 220, 50, 40
+				return event
+			}()},
+		},
+		{
+			name: "потеря сигнала дольше 10 с прерывает схватку",
+			value: func(t float64) float64 {
+				if t >= 220 && t < 240 {
+					return -1
+				}
+				return rect(10, 30, 200, 260)(t)
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			tracker := NewContractionTracker(Config{}, channels.UterineContractions)
+			events := feed(tracker.Observe, 0, 300, 1, c.value)
+			if !reflect.DeepEqual(events, c.want) {
+				t.Errorf("события %+v, ожидались %+v", events, c.want)
+			}
+			if state := tracker.State(); state.Contractions != len(c.want) || state.Tone != 10 {
+				t.Errorf("оценки канала %+v", state)
+			}
+		})
+	}
+
+	// Незавершенная схватка доступна для типизации децелераций
+	tracker := NewContractionTracker(Config{}, channels.UterineContractions)
+	feed(tracker.Observe, 0, 230, 1, rect(10, 30, 200, 260))
+	want := []decel.Contraction{{Onset: 199, Peak: 200, Offset: 229, Amplitude: 20, Tone: 10}}
+	if got := tracker.Contractions(); !reflect.DeepEqual(got, want) {
+		t.Errorf("схватки %+v, ожидались %+v", got, want)
+	}
+}
+
+func TestTachysystole(t *testing.T) {
+	// Схватки по 60 с с подъемом на 20 и пиком на 50 с каждой сотни - 6 за 10 минут.
+	// Тонус 10 с медленными колебаниями ±1.
+	frequent := func(t float64) float64 {
+		uc := 10 + math.Sin(t*math.Pi/30)
+		if rise := 20 * (1 - math.Abs(math.Mod(t, 100)-50)/30); rise > 0 {
+			uc += rise
+		}
+		return math.Round(uc)
+	}
+
+	tracker := NewContractionTracker(Config{}, channels.UterineContractions)
+	events := feed(tracker.Observe, 0, 31*60, 1, frequent)
+
+	contractions := ofType(events, models.EventContraction)
+	if len(contractions) < 17 {
+		t.Fatalf("найдено мало схваток: %d", len(contractions))
+	}
+	for _, contraction := range contractions {
+		duration := contraction.End - contraction.Start
+		if math.Abs(math.Mod(contraction.Peak, 100)-50) > 2 || math.Abs(contraction.Amplitude-20) > 3 ||
+			math.Abs(contraction.Baseline-10) > 2 || duration < 40 || duration > 55 {
+			t.Errorf("неверная схватка: %+v", contraction)
+		}
+		// Частота сообщается, когда записано 10 минут
+		if want := contraction.End >= decel.FrequencyWindow; (contraction.Frequency > 0) != want {
+			t.Errorf("схватка на %.0f с с частотой %.1f", contraction.Peak, contraction.Frequency)
+		}
+	}
+
+	// Тахисистолия оценивается после 30 минут записи
+	tachysystole := ofType(events, models.EventTachysystole)
+	if len(tachysystole) != 1 || tachysystole[0].Start != decel.TachysystoleWindow || tachysystole[0].End != 0 ||
+		tachysystole[0].Value <= decel.TachysystoleLimit || tachysystole[0].Frequency != 6 {
+		t.Fatalf("ожидалось начало тахисистолии на 30 минуте: %+v", tachysystole)
+	}
+
+	// Последняя схватка еще не закончилась и в частоту не входит
+	if state := tracker.State(); !state.Tachysystole || state.Frequency != 5 || state.Contractions != len(contractions) {
+		t.Errorf("неверные оценки канала: %+v", state)
+	}
+
+	// Без схваток частота падает, и тахисистолия заканчивается
+	events = feed(tracker.Observe, 31*60, 60*60, 1, constant(10))
+	tachysystole = ofType(events, models.EventTachysystole)
+	if len(tachysystole) != 1 || tachysystole[0].Start != decel.TachysystoleWindow ||
+		tachysystole[0].End <= tachysystole[0].Start || tachysystole[0].Value > decel.TachysystoleLimit {
+		t.Fatalf("ожидалось окончание тахисистолии: %+v", tachysystole)
+	}
+	if state := tracker.State(); state.Tachysystole || state.Frequency != 0 {
+		t.Errorf("неверные оценки канала: %+v", state)
+	}
+}
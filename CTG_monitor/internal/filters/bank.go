@@ -0,0 +1,127 @@
+// internal/filters/bank.go
+package filters
+
+import (
+	"log"
+	"sync"
+)
+
+// Bank хранит отдельные экземпляры фильтров для каждого устройства и канала,
+// чтобы история одного пациента не влияла на обработку другого
+type Bank struct {
+	chains  map[string][]string // dataType -> названия фильтров по порядку
+	filters map[channelKey][]SignalFilter
+	mu      sync.Mutex
+}
+
+// channelKey ключ канала конкретного устройства
+type channelKey struct {
+	deviceID string
+	dataType string
+}
+
+// ChannelStats статистика фильтров одного канала устройства
+type ChannelStats struct {
+	DeviceID string        `json:"device_id"`
+	DataType string        `json:"data_type"`
+	Filters  []FilterStats `json:"filters"`
+}
+
+// NewBank создает банк фильтров с цепочками для каждого типа данных.
+// Неизвестные фильтры пропускаются с предупреждением.
+func NewBank(chains map[string][]string) *Bank {
+	validChains := make(map[string][]string, len(chains))
+	for dataType, chain := range chains {
+		for _, name := range chain {
+			if _, ok := factories[name]; !ok {
+				log.Printf("Фильтр %s для %s не найден, пропускаем", name, dataType)
+				continue
+			}
+			validChains[dataType] = append(validChains[dataType], name)
+		}
+		log.Printf("Цепочка фильтров для %s: %v", dataType, validChains[dataType])
+	}
+
+	return &Bank{
+		chains:  validChains,
+		filters: make(map[channelKey][]SignalFilter),
+	}
+}
+
+// Fork создает банк с теми же цепочками фильтров и пустой историей
+func (b *Bank) Fork() *Bank {
+	return &Bank{
+		chains:  b.chains,
+		filters: make(map[channelKey][]SignalFilter),
+	}
+}
+
+// Apply прогоняет значение через цепочку фильтров канала.
+// Возвращает итоговое значение и названия сработавших фильтров.
+func (b *Bank) Apply(deviceID, dataType string, value float64) (float64, []string) {
+	chain := b.getChain(deviceID, dataType)
+
+	var triggered []string
+	for i, filter := range chain {
+		if filter.Detect(value) {
+			value = filter.Correct(value)
+			triggered = append(triggered, b.chains[dataType][i])
+		}
+	}
+	return value, triggered
+}
+
+// getChain возвращает фильтры канала, создавая их при первом обращении
+func (b *Bank) getChain(deviceID, dataType string) []SignalFilter {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	key := channelKey{deviceID: deviceID, dataType: dataType}
+	if chain, exists := b.filters[key]; exists {
+		return chain
+	}
+
+	names := b.chains[dataType]
+	chain := make([]SignalFilter, 0, len(names))
+	for _, name := range names {
+		filter, _ := New(name)
+		chain = append(chain, filter)
+	}
+	b.filters[key] = chain
+	return chain
+}
+
+// ResetDevice сбрасывает историю всех фильтров устройства
+func (b *Bank) ResetDevice(deviceID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for key, chain := range b.filters {
+		if key.deviceID != deviceID {
+			continue
+		}
+		for _, filter := range chain {
+			filter.Reset()
+		}
+	}
+}
+
+// Stats возвращает статистику фильтров по всем каналам
+func (b *Bank) Stats() []ChannelStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stats := make([]ChannelStats, 0, len(b.filters))
+	for key, chain := range b.filters {
+		channelStats := ChannelStats{
+			DeviceID: key.deviceID,
+			DataType: key.dataType,
+			Filters:  make([]FilterStats, 0, len(chain)),
+		}
+		for _, filter := range chain {
+			channelStats.Filters = append(channelStats.Filters, filter.Stats())
+		}
+		stats = append(stats, channelStats)
+	}
+	return stats
+}
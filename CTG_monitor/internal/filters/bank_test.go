@@ -0,0 +1,94 @@
+package filters
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestIsolatedSpike(t *testing.T) {
+	cases := []struct {
+		name  string
+		chain []string
+		want  string // фильтр, который должен сработать на выбросе
+	}{
+		{"hampel", []string{FilterHampel}, FilterHampel},
+		{"spike", []string{FilterSpike}, FilterSpike},
+		{"с фильтром допплера", []string{FilterHampel, FilterDoppler}, FilterHampel},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			bank := NewBank(map[string][]string{"fetal_heart_rate": c.chain})
+
+			// ЧСС 140±2 с одним выбросом 200 и потерей сигнала
+			const spikeAt = 20
+			trace := make([]float64, 40)
+			for i := range trace {
+				trace[i] = 140 + 2*math.Sin(float64(i))
+			}
+			trace[spikeAt] = 200
+			trace[30] = -1
+
+			for i, value := range trace {
+				clean, triggered := bank.Apply("CTG-001", "fetal_heart_rate", value)
+				if i != spikeAt {
+					if clean != value || len(triggered) != 0 {
+						t.Errorf("точка %d (%.2f) изменена: %.2f, %v", i, value, clean, triggered)
+					}
+					continue
+				}
+				if !reflect.DeepEqual(triggered, []string{c.want}) {
+					t.Errorf("выброс не помечен фильтром %s: %v", c.want, triggered)
+				}
+				if math.Abs(clean-140) > 2 {
+					t.Errorf("выброс заменен на %.2f, ожидалось значение около 140", clean)
+				}
+			}
+		})
+	}
+}
+
+func TestHampelFollowsLevelShift(t *testing.T) {
+	filter := NewHampelFilter()
+	for i := 0; i < 20; i++ {
+		filter.Detect(140)
+	}
+
+	// Устойчивая брадикардия: выбросами считаются только первые точки, пока медиана
+	// окна не перейдет на новый уровень
+	replaced := 0
+	for i := 0; i < 20; i++ {
+		if filter.Detect(100) {
+			replaced++
+			if i > filter.windowSize/2 {
+				t.Fatalf("точка %d нового уровня все еще считается выбросом", i)
+			}
+		}
+	}
+	if replaced == 0 || replaced > filter.windowSize/2+1 {
+		t.Errorf("заменено %d точек нового уровня", replaced)
+	}
+}
+
+func TestSpikeFollowsLevelShift(t *testing.T) {
+	filter := NewSpikeDetectionFilter()
+	for i := 0; i < 10; i++ {
+		filter.Detect(140)
+	}
+
+	// Устойчивая брадикардия: спайком считается только первая точка нового уровня
+	for i := 0; i < 10; i++ {
+		if filter.Detect(100) && i > 0 {
+			t.Fatalf("точка %d нового уровня считается спайком", i)
+		}
+	}
+	if stats := filter.Stats(); stats.Detected != 1 {
+		t.Errorf("обнаружено спайков %d, ожидался 1", stats.Detected)
+	}
+}
+
+func TestUnknownFilter(t *testing.T) {
+	if _, err := New("median"); err == nil {
+		t.Error("создан неизвестный фильтр")
+	}
+}
@@ -0,0 +1,101 @@
+// internal/filters/doppler.go
+package filters
+
+import (
+	"math"
+	"sync"
+)
+
+// DopplerFilter исправляет ошибки удвоения и деления пополам ЧСС,
+// характерные для допплеровских датчиков (счет по двум или половине ударов)
+type DopplerFilter struct {
+	history     []float64
+	historySize int
+	minHistory  int
+
+	// Допустимое расхождение с опорной ЧСС после коррекции, уд/мин
+	tolerance float64
+
+	// Последняя коррекция: множитель 2 (деление пополам) или 0.5 (удвоение)
+	lastFactor float64
+
+	// Статистика
+	totalProcessed int
+	corrected      int
+
+	mu sync.Mutex
+}
+
+// NewDopplerFilter создает фильтр коррекции удвоения/деления пополам
+func NewDopplerFilter() *DopplerFilter {
+	return &DopplerFilter{
+		history:     make([]float64, 0, 20),
+		historySize: 20,
+		minHistory:  8,
+		tolerance:   12.0,
+		lastFactor:  1.0,
+	}
+}
+
+// Detect проверяет, не является ли значение половиной или удвоением опорной ЧСС
+func (df *DopplerFilter) Detect(value float64) bool {
+	df.mu.Lock()
+	defer df.mu.Unlock()
+
+	df.totalProcessed++
+	df.lastFactor = 1.0
+
+	if isSignalLoss(value) {
+		return false
+	}
+
+	if len(df.history) >= df.minHistory {
+		reference := median(df.history)
+
+		switch {
+		case value < reference*0.65 && math.Abs(value*2-reference) <= df.tolerance:
+			df.lastFactor = 2.0
+		case value > reference*1.5 && math.Abs(value/2-reference) <= df.tolerance:
+			df.lastFactor = 0.5
+		}
+	}
+
+	detected := df.lastFactor != 1.0
+	if detected {
+		df.corrected++
+	}
+
+	// В историю попадает уже исправленное значение
+	df.history = append(df.history, value*df.lastFactor)
+	if len(df.history) > df.historySize {
+		df.history = df.history[1:]
+	}
+
+	return detected
+}
+
+// Correct возвращает значение, приведенное к опорной частоте
+func (df *DopplerFilter) Correct(value float64) float64 {
+	df.mu.Lock()
+	defer df.mu.Unlock()
+	return value * df.lastFactor
+}
+
+// Reset очищает историю фильтра
+func (df *DopplerFilter) Reset() {
+	df.mu.Lock()
+	defer df.mu.Unlock()
+	df.history = df.history[:0]
+	df.lastFactor = 1.0
+}
+
+// Stats возвращает статистику фильтра
+func (df *DopplerFilter) Stats() FilterStats {
+	df.mu.Lock()
+	defer df.mu.Unlock()
+	return FilterStats{
+		Name:      FilterDoppler,
+		Processed: df.totalProcessed,
+		Detected:  df.corrected,
+	}
+}
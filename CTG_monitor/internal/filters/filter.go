@@ -0,0 +1,71 @@
+// internal/filters/filter.go
+package filters
+
+import (
+	"fmt"
+	"sort"
+)
+
+// SignalFilter фильтр артефактов для одного канала одного устройства
+type SignalFilter interface {
+	// Detect добавляет значение в историю и сообщает, является ли оно артефактом
+	Detect(value float64) bool
+	// Correct возвращает исправленное значение для обнаруженного артефакта
+	Correct(value float64) float64
+	// Reset очищает историю (например, при начале новой сессии)
+	Reset()
+	// Stats возвращает статистику работы фильтра
+	Stats() FilterStats
+}
+
+// FilterStats статистика работы фильтра
+type FilterStats struct {
+	Name      string `json:"name"`
+	Processed int    `json:"processed"`
+	Detected  int    `json:"detected"`
+}
+
+// Названия доступных фильтров
+const (
+	FilterSpike   = "spike"
+	FilterHampel  = "hampel"
+	FilterDoppler = "doppler"
+)
+
+// factories конструкторы фильтров по названию
+var factories = map[string]func() SignalFilter{
+	FilterSpike:   func() SignalFilter { return NewSpikeDetectionFilter() },
+	FilterHampel:  func() SignalFilter { return NewHampelFilter() },
+	FilterDoppler: func() SignalFilter { return NewDopplerFilter() },
+}
+
+// New создает фильтр по названию
+func New(name string) (SignalFilter, error) {
+	factory, ok := factories[name]
+	if !ok {
+		return nil, fmt.Errorf("неизвестный фильтр: %s", name)
+	}
+	return factory(), nil
+}
+
+// median вычисляет медиану (исходный срез не изменяется)
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2.0
+	}
+	return sorted[mid]
+}
+
+// isSignalLoss значение -1 означает потерю сигнала
+func isSignalLoss(value float64) bool {
+	return value == -1
+}
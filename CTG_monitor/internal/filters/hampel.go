@@ -0,0 +1,104 @@
+// internal/filters/hampel.go
+package filters
+
+import (
+	"math"
+	"sync"
+)
+
+// HampelFilter медианный фильтр Хампеля: точка считается выбросом,
+// если отклоняется от медианы окна больше чем на threshold * MAD
+type HampelFilter struct {
+	window     []float64
+	windowSize int
+
+	// Параметры детекции
+	threshold    float64 // количество масштабированных MAD
+	minDeviation float64 // минимальное отклонение, чтобы не срабатывать на плоском сигнале
+
+	replacement float64 // медиана окна для последнего выброса
+
+	// Статистика
+	totalProcessed int
+	outliers       int
+
+	mu sync.Mutex
+}
+
+// madScale приводит MAD к стандартному отклонению нормального распределения
+const madScale = 1.4826
+
+// NewHampelFilter создает фильтр Хампеля
+func NewHampelFilter() *HampelFilter {
+	return &HampelFilter{
+		window:       make([]float64, 0, 9),
+		windowSize:   9,
+		threshold:    3.0,
+		minDeviation: 5.0,
+	}
+}
+
+// Detect проверяет значение относительно медианы предыдущих точек.
+// В окно попадает исходное значение: единичный выброс медиану не смещает, а при
+// устойчивом изменении уровня (брадикардия, тахикардия) медиана догоняет сигнал
+// через половину окна, и новые точки перестают считаться выбросами.
+func (hf *HampelFilter) Detect(value float64) bool {
+	hf.mu.Lock()
+	defer hf.mu.Unlock()
+
+	hf.totalProcessed++
+
+	// Потеря сигнала не является выбросом и не попадает в окно
+	if isSignalLoss(value) {
+		return false
+	}
+
+	isOutlier := false
+	if len(hf.window) >= hf.windowSize/2+1 {
+		m := median(hf.window)
+		deviations := make([]float64, len(hf.window))
+		for i, v := range hf.window {
+			deviations[i] = math.Abs(v - m)
+		}
+		sigma := madScale * median(deviations)
+
+		deviation := math.Abs(value - m)
+		isOutlier = deviation > hf.minDeviation && deviation > hf.threshold*sigma
+		if isOutlier {
+			hf.outliers++
+			hf.replacement = m
+		}
+	}
+
+	hf.window = append(hf.window, value)
+	if len(hf.window) > hf.windowSize {
+		hf.window = hf.window[1:]
+	}
+
+	return isOutlier
+}
+
+// Correct заменяет выброс медианой окна до него
+func (hf *HampelFilter) Correct(value float64) float64 {
+	hf.mu.Lock()
+	defer hf.mu.Unlock()
+	return hf.replacement
+}
+
+// Reset очищает окно фильтра
+func (hf *HampelFilter) Reset() {
+	hf.mu.Lock()
+	defer hf.mu.Unlock()
+	hf.window = hf.window[:0]
+}
+
+// Stats возвращает статистику фильтра
+func (hf *HampelFilter) Stats() FilterStats {
+	hf.mu.Lock()
+	defer hf.mu.Unlock()
+	return FilterStats{
+		Name:      FilterHampel,
+		Processed: hf.totalProcessed,
+		Detected:  hf.outliers,
+	}
+}
@@ -0,0 +1,143 @@
+// internal/filters/spike.go
+package filters
+
+import (
+	"math"
+	"sync"
+)
+
+// SpikeDetectionFilter фильтр единичных выбросов: точка сравнивается с несколькими
+// предыдущими. Прежняя версия проверяла предпоследнюю точку по следующим за ней
+// значениям, которых в потоке реального времени еще нет, поэтому проверка
+// выполняется по предшествующему контексту с теми же порогами.
+type SpikeDetectionFilter struct {
+	buffer     []float64
+	bufferSize int
+
+	// Параметры детекции спайков
+	spikeDeviation float64 // минимальное отклонение от среднего контекста
+	contextWindow  int     // сколько предыдущих точек составляют контекст
+	minZScore      float64 // отклонение в стандартных отклонениях контекста
+
+	replacement float64 // значение для последнего обнаруженного спайка
+
+	// Статистика
+	totalProcessed int
+	spikesDetected int
+
+	mu sync.Mutex
+}
+
+// NewSpikeDetectionFilter создает новый фильтр спайков
+func NewSpikeDetectionFilter() *SpikeDetectionFilter {
+	return &SpikeDetectionFilter{
+		buffer:         make([]float64, 0, 3),
+		bufferSize:     3,
+		spikeDeviation: 8.0,
+		contextWindow:  3,
+		minZScore:      2.0,
+	}
+}
+
+// Detect проверяет значение относительно предыдущих точек. Спайк - точка,
+// отклонившаяся от стабильного контекста больше spikeDeviation и больше чем на
+// minZScore стандартных отклонений, при этом последняя точка контекста не выброс.
+// В буфер попадает исходное значение: при устойчивом изменении уровня контекст
+// перестает быть стабильным, и новые точки выбросами не считаются.
+func (sf *SpikeDetectionFilter) Detect(value float64) bool {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+
+	sf.totalProcessed++
+
+	// Потеря сигнала не является спайком и не попадает в контекст
+	if isSignalLoss(value) {
+		return false
+	}
+
+	isSpike := false
+	if len(sf.buffer) >= sf.contextWindow {
+		context := sf.buffer[len(sf.buffer)-sf.contextWindow:]
+		contextMean := sf.calculateMean(context)
+		contextStd := sf.calculateStd(context, contextMean)
+		deviation := math.Abs(value - contextMean)
+
+		isDeviantFromContext := deviation > sf.spikeDeviation
+		isContextStable := math.Abs(context[0]-context[len(context)-1]) < sf.spikeDeviation/2.0
+
+		isStatisticallySignificant := true
+		if contextStd > 0 {
+			isStatisticallySignificant = deviation/contextStd > sf.minZScore
+		}
+
+		// Изолированность: последняя точка контекста близка к среднему
+		lastBefore := context[len(context)-1]
+		isIsolated := math.Abs(lastBefore-contextMean) < deviation/2.0
+
+		isSpike = isDeviantFromContext && isContextStable && isStatisticallySignificant && isIsolated
+		if isSpike {
+			sf.spikesDetected++
+			// Продолжаем сигнал от последней точки с небольшой поправкой на тренд
+			trend := lastBefore - context[len(context)-2]
+			sf.replacement = lastBefore + trend*0.1
+		}
+	}
+
+	sf.buffer = append(sf.buffer, value)
+	if len(sf.buffer) > sf.bufferSize {
+		sf.buffer = sf.buffer[1:]
+	}
+
+	return isSpike
+}
+
+// Correct заменяет спайк продолжением предыдущих точек
+func (sf *SpikeDetectionFilter) Correct(value float64) float64 {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	return sf.replacement
+}
+
+// Reset очищает буфер фильтра
+func (sf *SpikeDetectionFilter) Reset() {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	sf.buffer = sf.buffer[:0]
+}
+
+// Stats возвращает статистику фильтра
+func (sf *SpikeDetectionFilter) Stats() FilterStats {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	return FilterStats{
+		Name:      FilterSpike,
+		Processed: sf.totalProcessed,
+		Detected:  sf.spikesDetected,
+	}
+}
+
+// calculateMean вычисляет среднее значение
+func (sf *SpikeDetectionFilter) calculateMean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// calculateStd вычисляет стандартное отклонение
+func (sf *SpikeDetectionFilter) calculateStd(values []float64, mean float64) float64 {
+	if len(values) <= 1 {
+		return 0
+	}
+
+	variance := 0.0
+	for _, v := range values {
+		variance += math.Pow(v-mean, 2)
+	}
+	return math.Sqrt(variance / float64(len(values)-1))
+}
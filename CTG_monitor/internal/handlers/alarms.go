@@ -0,0 +1,205 @@
+// internal/handlers/alarms.go
+package handlers
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"CTG_monitor/internal/alarms"
+	"CTG_monitor/internal/channels"
+	"CTG_monitor/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// alarmTick период проверки таймеров эскалации
+const alarmTick = time.Second
+
+// AlarmManager связывает движок тревог с БД и подписчиками: каждое изменение
+// тревоги сохраняется в ctg_alarms и рассылается в StreamAlarms
+type AlarmManager struct {
+	engine         *alarms.Engine
+	db             *gorm.DB
+	sessionManager *SessionManager
+	grpcStreamer   *GRPCStreamer
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewAlarmManager создает менеджер тревог и запускает таймеры эскалации
+func NewAlarmManager(db *gorm.DB, engine *alarms.Engine, sessionManager *SessionManager, grpcStreamer *GRPCStreamer) *AlarmManager {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	manager := &AlarmManager{
+		engine:         engine,
+		db:             db,
+		sessionManager: sessionManager,
+		grpcStreamer:   grpcStreamer,
+		ctx:            ctx,
+		cancel:         cancel,
+	}
+	grpcStreamer.SetAlarmSnapshot(engine.Open)
+
+	manager.wg.Add(1)
+	go manager.escalationWorker()
+
+	log.Println("Движок клинических тревог запущен")
+	return manager
+}
+
+// Stop останавливает таймеры эскалации
+func (m *AlarmManager) Stop() {
+	m.cancel()
+	m.wg.Wait()
+	log.Println("Движок клинических тревог остановлен")
+}
+
+// RestoreAlarms разбирает тревоги, оставшиеся неснятыми до перезапуска: тревоги
+// продолженных сессий возвращаются в движок, остальные снимаются и рассылаются.
+// Вызывается после восстановления сессий.
+func (m *AlarmManager) RestoreAlarms() (restored, cleared int, err error) {
+	var stale []models.CTGAlarm
+	if err := m.db.Where("state <> ?", models.AlarmCleared).Order("raised_at").Find(&stale).Error; err != nil {
+		return 0, 0, err
+	}
+
+	resumed := make(map[uuid.UUID]bool)
+	for _, session := range m.sessionManager.GetAllActiveSessions() {
+		resumed[session.ID] = true
+	}
+
+	now := time.Now().UTC()
+	for _, alarm := range stale {
+		if alarm.SessionID != nil && resumed[*alarm.SessionID] && m.engine.Restore(alarm) {
+			restored++
+			continue
+		}
+
+		alarm.State = models.AlarmCleared
+		alarm.ClearedAt = &now
+		if err := m.db.Save(&alarm).Error; err != nil {
+			log.Printf("Не удалось снять тревогу %s: %v", alarm.ID, err)
+			continue
+		}
+		m.grpcStreamer.BroadcastAlarm(alarm)
+		cleared++
+	}
+	if restored > 0 || cleared > 0 {
+		log.Printf("Тревоги после перезапуска: восстановлено %d, снято %d", restored, cleared)
+	}
+	return restored, cleared, nil
+}
+
+// observeSample проверяет правила по точке канала ЧСС плода
+func (m *AlarmManager) observeSample(deviceID string, channel channels.Channel, point models.CTGPoint) {
+	if channel.Fetus == 0 {
+		return
+	}
+	m.publish(m.engine.ObserveSample(deviceID, channel.Name, point.T, point.V, time.Now().UTC()))
+}
+
+// observeEvent проверяет правила по событию анализа
+func (m *AlarmManager) observeEvent(deviceID string, event models.CTGEvent) {
+	m.publish(m.engine.ObserveEvent(deviceID, event, time.Now().UTC()))
+}
+
+// resetDevice снимает тревоги устройства, начавшего передавать сигнал нового пациента
+func (m *AlarmManager) resetDevice(deviceID string) {
+	m.publish(m.engine.ResetDevice(deviceID, time.Now().UTC()))
+}
+
+// Acknowledge подтверждает тревогу от имени by
+func (m *AlarmManager) Acknowledge(id uuid.UUID, by string) (models.CTGAlarm, error) {
+	alarm, changed, err := m.engine.Acknowledge(id, by, time.Now().UTC())
+	if err != nil {
+		return models.CTGAlarm{}, err
+	}
+	if changed {
+		m.publish([]models.CTGAlarm{alarm})
+	}
+	return alarm, nil
+}
+
+// AcknowledgeDevice подтверждает все поднятые тревоги устройства
+func (m *AlarmManager) AcknowledgeDevice(deviceID, by string) []models.CTGAlarm {
+	changes := m.engine.AcknowledgeDevice(deviceID, by, time.Now().UTC())
+	m.publish(changes)
+	return changes
+}
+
+// Open возвращает неснятые тревоги
+func (m *AlarmManager) Open() []models.CTGAlarm {
+	return m.engine.Open()
+}
+
+// History возвращает тревоги из БД, начиная с последних
+func (m *AlarmManager) History(deviceID, state string, limit int) ([]models.CTGAlarm, error) {
+	query := m.db.Order("raised_at DESC").Limit(limit)
+	if deviceID != "" {
+		query = query.Where("device_id = ?", deviceID)
+	}
+	if state != "" {
+		query = query.Where("state = ?", state)
+	}
+
+	var history []models.CTGAlarm
+	if err := query.Find(&history).Error; err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// escalationWorker периодически эскалирует неподтвержденные тревоги
+func (m *AlarmManager) escalationWorker() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(alarmTick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			m.publish(m.engine.Escalate(now.UTC()))
+		case <-m.ctx.Done():
+			return
+		}
+	}
+}
+
+// publish сохраняет и рассылает изменения тревог. Новая тревога привязывается
+// к активной сессии устройства.
+func (m *AlarmManager) publish(changes []models.CTGAlarm) {
+	for _, alarm := range changes {
+		if alarm.SessionID == nil {
+			if session := m.sessionManager.GetActiveSession(alarm.DeviceID); session != nil {
+				sessionID := session.ID
+				alarm.SessionID = &sessionID
+				m.engine.AttachSession(alarm.ID, sessionID)
+			}
+		}
+
+		switch {
+		case alarm.State == models.AlarmRaised && alarm.Escalations == 0:
+			log.Printf("Тревога %s [%s] %s/%s: %s", alarm.Rule, alarm.Priority, alarm.DeviceID, alarm.Channel, alarm.Message)
+		case alarm.State == models.AlarmRaised:
+			log.Printf("Тревога %s %s/%s не подтверждена, эскалация %d, приоритет %s",
+				alarm.Rule, alarm.DeviceID, alarm.Channel, alarm.Escalations, alarm.Priority)
+		case alarm.State == models.AlarmAcknowledged:
+			log.Printf("Тревога %s %s/%s подтверждена: %s", alarm.Rule, alarm.DeviceID, alarm.Channel, alarm.AcknowledgedBy)
+		default:
+			log.Printf("Тревога %s %s/%s снята", alarm.Rule, alarm.DeviceID, alarm.Channel)
+		}
+
+		if err := m.db.Save(&alarm).Error; err != nil {
+			log.Printf("Не удалось сохранить тревогу %s: %v", alarm.ID, err)
+		}
+		if alarm.State == models.AlarmRaised && alarm.Escalations == 0 && alarm.SessionID != nil {
+			m.sessionManager.countAlarm(*alarm.SessionID)
+		}
+		m.grpcStreamer.BroadcastAlarm(alarm)
+	}
+}
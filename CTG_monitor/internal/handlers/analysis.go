@@ -0,0 +1,98 @@
+// internal/handlers/analysis.go
+package handlers
+
+import (
+	"log"
+	"math"
+
+	"CTG_monitor/internal/channels"
+	"CTG_monitor/internal/figo"
+	"CTG_monitor/internal/models"
+	"ctg_common/decel"
+)
+
+// analyzeSample прогоняет точку ЧСС плода через анализ по FIGO, а точку сокращений
+// матки - через поиск схваток. События сохраняются в сессии устройства и
+// рассылаются подписчикам вместе с потоком точек; по событиям и точкам ЧСС плода
+// проверяются правила клинических тревог и критерии теста по протоколу.
+func (p *MQTTStreamProcessor) analyzeSample(deviceID string, channel channels.Channel, point models.CTGPoint) {
+	if channel.Fetus == 0 && channel.Name != channels.UterineContractions {
+		return
+	}
+
+	events := p.figoBank.Observe(deviceID, channel.Name, point.T, point.V)
+	for _, event := range events {
+		switch event.Type {
+		case models.EventDeceleration:
+			log.Printf("%s (%s) %s/%s: %.1f-%.1f с, надир %.0f уд/мин (%+.0f от базального ритма %.0f), от пика схватки %+.0f с",
+				event.Type, event.Kind, deviceID, event.Channel, event.Start, event.End, event.Value, event.Amplitude, event.Baseline, event.Lag)
+		case models.EventContraction:
+			log.Printf("%s %s: %.1f-%.1f с, пик %.0f (+%.0f над тонусом %.0f), схваток за 10 минут: %.1f",
+				event.Type, deviceID, event.Start, event.End, event.Value, event.Amplitude, event.Baseline, event.Frequency)
+		case models.EventTachysystole:
+			if event.End == 0 {
+				log.Printf("Тахисистолия %s с %.1f с: %.1f схваток за 10 минут в среднем за 30 минут", deviceID, event.Start, event.Value)
+			} else {
+				log.Printf("Тахисистолия %s закончилась: %.1f-%.1f с", deviceID, event.Start, event.End)
+			}
+		case models.EventAcceleration:
+			log.Printf("%s %s/%s: %.1f-%.1f с, пик %.0f уд/мин (%+.0f от базального ритма %.0f)",
+				event.Type, deviceID, event.Channel, event.Start, event.End, event.Value, event.Amplitude, event.Baseline)
+		default:
+			log.Printf("%s %s/%s: %.1f %s", event.Type, deviceID, event.Channel, event.Value, event.Band)
+		}
+
+		sessionID := p.sessionManager.RouteEvent(deviceID, event)
+		p.grpcStreamer.BroadcastAnalysisEvent(deviceID, sessionID, event)
+		p.alarms.observeEvent(deviceID, event)
+	}
+	p.alarms.observeSample(deviceID, channel, point)
+
+	// Тест по протоколу завершается, когда критерии выполнены или время вышло
+	if session := p.sessionManager.observeProtocol(deviceID, events, point.T); session != nil {
+		log.Printf("Сессия %s устройства %s завершена по протоколу %s: %s",
+			session.ID, deviceID, session.Protocol, session.ProtocolResult.Outcome)
+	}
+}
+
+// GetAnalysisStats возвращает текущие оценки анализа по каналам ЧСС плода и сокращений матки
+func (p *MQTTStreamProcessor) GetAnalysisStats() []figo.ChannelStats {
+	return p.figoBank.Stats()
+}
+
+// summarizeContractions сводка схваток по событиям интервала; частота - на длину токограммы
+func summarizeContractions(events []models.CTGEvent, uc []models.CTGPoint) *ContractionSummary {
+	if len(uc) == 0 {
+		return nil
+	}
+
+	summary := &ContractionSummary{}
+	var contractions []decel.Contraction
+	for _, event := range events {
+		switch event.Type {
+		case models.EventContraction:
+			contractions = append(contractions, decel.Contraction{
+				Onset:     event.Start,
+				Peak:      event.Peak,
+				Offset:    event.End,
+				Amplitude: event.Amplitude,
+			})
+			summary.MeanDuration += event.End - event.Start
+			summary.MeanAmplitude += event.Amplitude
+		case models.EventTachysystole:
+			if event.End == 0 {
+				summary.Tachysystole++
+			}
+		}
+	}
+
+	summary.Count = len(contractions)
+	if summary.Count > 0 {
+		summary.MeanDuration = math.Round(summary.MeanDuration/float64(summary.Count)*10) / 10
+		summary.MeanAmplitude = math.Round(summary.MeanAmplitude/float64(summary.Count)*10) / 10
+	}
+	// Пик на последней точке тоже входит в интервал
+	frequency := decel.Frequency(contractions, uc[0].T, math.Nextafter(uc[len(uc)-1].T, math.Inf(1)))
+	summary.Frequency = math.Round(frequency*10) / 10
+	return summary
+}
@@ -0,0 +1,184 @@
+// internal/handlers/backfill.go
+package handlers
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"CTG_monitor/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// backfillTopicSegment часть топика medical/ctg/backfill/<type>/<device>,
+// в который устройство выгружает накопленные без связи данные
+const backfillTopicSegment = "backfill"
+
+// backfillTargetTTL сколько найденная для выгрузки сессия используется без повторного поиска
+const backfillTargetTTL = 5 * time.Second
+
+// backfillTarget сессия, в которую вставляются выгружаемые точки устройства
+type backfillTarget struct {
+	sessionID uuid.UUID // uuid.Nil - устройство без карты
+	startTime time.Time
+	endTime   *time.Time
+	segments  []models.CTGSegment
+	resolved  time.Time
+	closed    bool // сессия не активна, ее буфер удаляется после выгрузки
+}
+
+// covers проверяет, что точка с временем измерения ts относится к сессии
+func (bt *backfillTarget) covers(ts time.Time, now time.Time) bool {
+	if now.Sub(bt.resolved) > backfillTargetTTL {
+		return false
+	}
+	if ts.IsZero() || bt.sessionID == uuid.Nil {
+		return true
+	}
+	return !ts.Before(bt.startTime) && (bt.endTime == nil || !ts.After(*bt.endTime))
+}
+
+// segment сегмент, к которому относится точка: по времени измерения,
+// а без него - по time_sec начала сегмента. nil, если сегментов нет.
+func (bt *backfillTarget) segment(timeSec float64, ts time.Time) *models.CTGSegment {
+	var found *models.CTGSegment
+	for i := range bt.segments {
+		segment := &bt.segments[i]
+		if ts.IsZero() && segment.DeviceTimeSec > timeSec {
+			continue
+		}
+		if !ts.IsZero() && segment.StartTime.After(ts) {
+			break
+		}
+		found = segment
+	}
+	return found
+}
+
+// FindBackfillTarget ищет сессию устройства для выгружаемой точки. При известном
+// времени измерения ts выбирается сессия, шедшая в этот момент, иначе активная
+// или последняя сессия устройства. Без сессии точка ждет привязки устройства.
+func (sm *SessionManager) FindBackfillTarget(deviceID string, ts time.Time) (*backfillTarget, error) {
+	now := time.Now()
+
+	sm.sessionsLock.RLock()
+	if session := sm.activeSessions[deviceID]; session != nil && (ts.IsZero() || !ts.Before(session.StartTime)) {
+		target := &backfillTarget{
+			sessionID: session.ID,
+			startTime: session.StartTime,
+			segments:  session.Segments,
+			resolved:  now,
+		}
+		sm.sessionsLock.RUnlock()
+		return target, nil
+	}
+	sm.sessionsLock.RUnlock()
+
+	query := sm.db.Select("id", "start_time", "end_time", "segments").
+		Where("device_id = ?", deviceID)
+	if !ts.IsZero() {
+		query = query.Where("start_time <= ? AND (end_time IS NULL OR end_time >= ?)", ts, ts)
+	}
+
+	var session models.CTGSession
+	err := query.Order("start_time DESC").First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		sm.sessionsLock.RLock()
+		defer sm.sessionsLock.RUnlock()
+
+		target := &backfillTarget{resolved: now}
+		if device := sm.unassigned[deviceID]; device != nil {
+			target.segments = device.segments
+		}
+		return target, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &backfillTarget{
+		sessionID: session.ID,
+		startTime: session.StartTime,
+		endTime:   session.EndTime,
+		segments:  session.Segments,
+		resolved:  now,
+		closed:    true,
+	}, nil
+}
+
+// RouteBackfillPoint передает выгруженную точку в буфер сессии для вставки по времени.
+// Для устройства без карты (sessionID = uuid.Nil) точка ждет привязки.
+func (sm *SessionManager) RouteBackfillPoint(deviceID string, sessionID uuid.UUID, dataType string, point models.CTGPoint, seq uint64) {
+	if sessionID != uuid.Nil {
+		sm.dataBuffer.AddBackfillPoint(sessionID, dataType, point, seq)
+		return
+	}
+
+	sm.sessionsLock.Lock()
+	defer sm.sessionsLock.Unlock()
+
+	if session := sm.activeSessions[deviceID]; session != nil {
+		// Устройство успели привязать, пока искали сессию
+		sm.dataBuffer.AddBackfillPoint(session.ID, dataType, point, seq)
+		return
+	}
+
+	sm.appendUnassignedLocked(deviceID, pendingPoint{dataType: dataType, point: point, seq: seq, backfill: true})
+}
+
+// processBackfill вставляет выгруженную устройством точку в сессию по времени.
+// Точка минует буфер упорядочивания и не попадает в живой поток gRPC.
+func (p *MQTTStreamProcessor) processBackfill(stream *deviceStream, data *models.MedicalData) {
+	var ts time.Time
+	if data.Timestamp > 0 {
+		ts = time.Unix(0, data.Timestamp).UTC()
+	}
+
+	target := stream.backfill
+	if target == nil || !target.covers(ts, time.Now()) {
+		p.releaseBackfillTarget(stream)
+
+		var err error
+		target, err = p.sessionManager.FindBackfillTarget(data.DeviceID, ts)
+		if err != nil {
+			// Запись остается в спуле и будет обработана после перезапуска
+			log.Printf("Ошибка поиска сессии для выгрузки %s: %v", data.DeviceID, err)
+			return
+		}
+		stream.backfill = target
+	}
+
+	point := p.cleanSample(p.backfillBank, data, models.FlagBackfill)
+	segment := target.segment(data.TimeSec, ts)
+	if segment != nil && p.segments.Policy != SegmentPolicyOff {
+		point.T = data.TimeSec + segment.Offset
+	}
+
+	// Абсолютное время: по часам устройства (без новых наблюдений - задержка
+	// выгрузки не относится к часам) или по началу сегмента
+	switch {
+	case !ts.IsZero():
+		wall, _ := p.clockBank.ToServer(data.DeviceID, ts)
+		point.W = wall.UnixMilli()
+	case segment != nil:
+		point.W = segment.Anchor().Add(secondsDuration(point.T)).UnixMilli()
+	case !target.startTime.IsZero():
+		point.W = target.startTime.Add(secondsDuration(point.T)).UnixMilli()
+	}
+
+	p.sessionManager.RouteBackfillPoint(data.DeviceID, target.sessionID, data.DataType, point, data.Seq)
+}
+
+// releaseBackfillTarget забывает сессию для выгрузки. Буфер завершенной сессии
+// записывается в БД и удаляется, живые сессии продолжают писать в свой буфер.
+func (p *MQTTStreamProcessor) releaseBackfillTarget(stream *deviceStream) {
+	target := stream.backfill
+	if target == nil {
+		return
+	}
+	stream.backfill = nil
+	if target.closed {
+		p.dataBuffer.RemoveSessionBuffer(target.sessionID, nil)
+	}
+}
@@ -0,0 +1,49 @@
+// internal/handlers/coincidence.go
+package handlers
+
+import (
+	"log"
+
+	"CTG_monitor/internal/coincidence"
+	"CTG_monitor/internal/models"
+	"github.com/google/uuid"
+)
+
+// coincidenceFlags пометки точек для видов совпадения каналов
+var coincidenceFlags = map[string]models.SampleFlags{
+	coincidence.KindSameHeart:  models.FlagSameHeart,
+	coincidence.KindMaternalHR: models.FlagMaternalHR,
+}
+
+// checkCoincidence сравнивает точку с парными каналами устройства (второй плод,
+// ЧСС матери). Возвращает пометки точки; о начале и конце совпадения сообщает подписчикам.
+func (p *MQTTStreamProcessor) checkCoincidence(deviceID, dataType string, point models.CTGPoint) models.SampleFlags {
+	kinds, changes := p.coincidenceBank.Observe(deviceID, dataType, point.T, point.V)
+
+	var flags models.SampleFlags
+	for _, kind := range kinds {
+		flags |= coincidenceFlags[kind]
+	}
+
+	for _, change := range changes {
+		if change.Active {
+			log.Printf("Предупреждение %s: каналы %v устройства %s совпадают в %.0f%% точек с %.1f с",
+				change.Kind, change.Channels, deviceID, change.Ratio*100, change.FromTime)
+		} else {
+			log.Printf("Совпадение %s каналов %v устройства %s закончилось на %.1f с",
+				change.Kind, change.Channels, deviceID, change.ToTime)
+		}
+
+		sessionID := uuid.Nil
+		if session := p.sessionManager.GetActiveSession(deviceID); session != nil {
+			sessionID = session.ID
+		}
+		p.grpcStreamer.BroadcastCoincidence(deviceID, sessionID, change)
+	}
+	return flags
+}
+
+// GetCoincidenceStats возвращает состояние пар каналов ЧСС по устройствам
+func (p *MQTTStreamProcessor) GetCoincidenceStats() []coincidence.DeviceStats {
+	return p.coincidenceBank.Stats()
+}
@@ -4,12 +4,17 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
-	"strconv"
+	"math"
 	"sync"
 	"time"
 
+	"CTG_monitor/internal/channels"
+	"CTG_monitor/internal/chunks"
 	"CTG_monitor/internal/models"
+	"CTG_monitor/internal/rollups"
+	"CTG_monitor/internal/spool"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -17,28 +22,58 @@ import (
 // DataBuffer управляет буферизацией данных для записи в БД
 type DataBuffer struct {
 	db             *gorm.DB
+	spool          *spool.Spool       // подтверждаем записи спула после коммита в БД
+	channels       *channels.Registry // записываются только точки зарегистрированных каналов
+	format         chunks.Format      // кодировка кадров в блоках точек
 	sessionBuffers map[uuid.UUID]*SessionDataBuffer
 	mu             sync.RWMutex
 	ctx            context.Context
 	cancel         context.CancelFunc
 	wg             sync.WaitGroup
+	flushWg        sync.WaitGroup // незавершенные асинхронные флаши
+	closed         bool           // идет финальный флаш, новые флаши не запускаются (под mu)
+
+	// Вызывается после записи в БД точек, выгруженных устройством задним числом
+	onBackfill func(update SessionUpdate)
+}
+
+// SessionUpdate сведения о точках, вставленных в уже записанную часть сессии
+type SessionUpdate struct {
+	SessionID uuid.UUID
+	Reason    string
+	Points    int
+	FromTime  float64
+	ToTime    float64
 }
 
 // SessionDataBuffer буфер для одной сессии
 type SessionDataBuffer struct {
 	SessionID uuid.UUID
-	FHRBuffer []models.CTGPoint
-	UCBuffer  []models.CTGPoint
+	Series    map[string][]models.CTGPoint // точки по каналам
+	Seqs      []uint64                     // номера записей спула для точек в буфере
+	Events    []models.CTGEvent            // результаты анализа, записываются вместе с точками
 	LastFlush time.Time
-	mu        sync.Mutex
+
+	// Исторические точки, которые вставляются по времени, а не дописываются в конец
+	Backfill     map[string][]models.CTGPoint
+	BackfillSeqs []uint64
+	mu           sync.Mutex
+
+	flushMu      sync.Mutex // флаши одной сессии выполняются по очереди
+	flushPending bool
 }
 
-// NewDataBuffer создает новый буфер данных
-func NewDataBuffer(db *gorm.DB) *DataBuffer {
+// NewDataBuffer создает новый буфер данных.
+// ingestSpool может быть nil, тогда подтверждения записи не отправляются.
+// Точки пишутся в блоки ctg_chunks кадрами в кодировке format.
+func NewDataBuffer(db *gorm.DB, ingestSpool *spool.Spool, registry *channels.Registry, format chunks.Format) *DataBuffer {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	buffer := &DataBuffer{
 		db:             db,
+		spool:          ingestSpool,
+		channels:       registry,
+		format:         format,
 		sessionBuffers: make(map[uuid.UUID]*SessionDataBuffer),
 		ctx:            ctx,
 		cancel:         cancel,
@@ -52,8 +87,13 @@ func NewDataBuffer(db *gorm.DB) *DataBuffer {
 	return buffer
 }
 
-// AddDataPoint добавляет точку данных в буфер
-func (db *DataBuffer) AddDataPoint(sessionID uuid.UUID, dataType string, value, timeSec float64) {
+// SetBackfillCallback устанавливает колбэк, вызываемый после записи исторических точек
+func (db *DataBuffer) SetBackfillCallback(onBackfill func(update SessionUpdate)) {
+	db.onBackfill = onBackfill
+}
+
+// getSessionBuffer возвращает буфер сессии, создавая его при первом обращении
+func (db *DataBuffer) getSessionBuffer(sessionID uuid.UUID) *SessionDataBuffer {
 	db.mu.RLock()
 	sessionBuffer, exists := db.sessionBuffers[sessionID]
 	db.mu.RUnlock()
@@ -61,129 +101,248 @@ func (db *DataBuffer) AddDataPoint(sessionID uuid.UUID, dataType string, value,
 	if !exists {
 		db.mu.Lock()
 		if sessionBuffer, exists = db.sessionBuffers[sessionID]; !exists {
-			sessionBuffer = &SessionDataBuffer{
-				SessionID: sessionID,
-				FHRBuffer: make([]models.CTGPoint, 0, 500),
-				UCBuffer:  make([]models.CTGPoint, 0, 500),
-				LastFlush: time.Now(),
-			}
+			sessionBuffer = newSessionDataBuffer(sessionID)
 			db.sessionBuffers[sessionID] = sessionBuffer
 		}
 		db.mu.Unlock()
 	}
+	return sessionBuffer
+}
+
+// newSessionDataBuffer создает пустой буфер сессии
+func newSessionDataBuffer(sessionID uuid.UUID) *SessionDataBuffer {
+	return &SessionDataBuffer{
+		SessionID: sessionID,
+		Series:    make(map[string][]models.CTGPoint),
+		Backfill:  make(map[string][]models.CTGPoint),
+		LastFlush: time.Now(),
+	}
+}
+
+// AddDataPoint добавляет точку данных в буфер.
+// seq - номер записи спула, подтверждается после записи точки в БД.
+func (db *DataBuffer) AddDataPoint(sessionID uuid.UUID, dataType string, point models.CTGPoint, seq uint64) {
+	if _, ok := db.channels.Lookup(dataType); !ok {
+		// Канал не зарегистрирован, запись спула больше не нужна
+		log.Printf("Канал %s не зарегистрирован, точка не сохраняется", dataType)
+		db.ack(seq)
+		return
+	}
+
+	sessionBuffer := db.getSessionBuffer(sessionID)
 
 	sessionBuffer.mu.Lock()
 	defer sessionBuffer.mu.Unlock()
 
-	point := models.CTGPoint{
-		T: timeSec,
-		V: value,
+	sessionBuffer.Series[dataType] = append(sessionBuffer.Series[dataType], point)
+	if seq != 0 {
+		sessionBuffer.Seqs = append(sessionBuffer.Seqs, seq)
 	}
 
-	switch dataType {
-	case "fetal_heart_rate":
-		sessionBuffer.FHRBuffer = append(sessionBuffer.FHRBuffer, point)
-	case "uterine_contractions":
-		sessionBuffer.UCBuffer = append(sessionBuffer.UCBuffer, point)
+	totalPoints := countPoints(sessionBuffer.Series)
+	timeSinceFlush := time.Since(sessionBuffer.LastFlush)
+
+	if (totalPoints >= 100 || timeSinceFlush > 30*time.Second) && !sessionBuffer.flushPending {
+		sessionBuffer.flushPending = true
+		db.flushAsync(sessionBuffer)
 	}
+}
 
-	totalPoints := len(sessionBuffer.FHRBuffer) + len(sessionBuffer.UCBuffer)
-	timeSinceFlush := time.Since(sessionBuffer.LastFlush)
+// AddEvent добавляет событие анализа сигнала, оно будет записано со следующим флашем
+func (db *DataBuffer) AddEvent(sessionID uuid.UUID, event models.CTGEvent) {
+	sessionBuffer := db.getSessionBuffer(sessionID)
+
+	sessionBuffer.mu.Lock()
+	defer sessionBuffer.mu.Unlock()
+
+	sessionBuffer.Events = append(sessionBuffer.Events, event)
+}
+
+// AddBackfillPoint добавляет историческую точку, которая будет вставлена
+// в ряд сессии по времени (сессия может быть уже завершена)
+func (db *DataBuffer) AddBackfillPoint(sessionID uuid.UUID, dataType string, point models.CTGPoint, seq uint64) {
+	if _, ok := db.channels.Lookup(dataType); !ok {
+		log.Printf("Канал %s не зарегистрирован, точка не сохраняется", dataType)
+		db.ack(seq)
+		return
+	}
+
+	sessionBuffer := db.getSessionBuffer(sessionID)
 
-	if totalPoints >= 100 || timeSinceFlush > 30*time.Second {
-		go db.flushSessionAsync(sessionID)
+	sessionBuffer.mu.Lock()
+	defer sessionBuffer.mu.Unlock()
+
+	sessionBuffer.Backfill[dataType] = append(sessionBuffer.Backfill[dataType], point)
+	if seq != 0 {
+		sessionBuffer.BackfillSeqs = append(sessionBuffer.BackfillSeqs, seq)
+	}
+}
+
+// ack подтверждает записи спула
+func (db *DataBuffer) ack(seqs ...uint64) {
+	if db.spool == nil {
+		return
+	}
+	if err := db.spool.Ack(seqs...); err != nil {
+		log.Printf("Ошибка подтверждения записей спула: %v", err)
 	}
 }
 
 // FlushAll флашит все буферы
 func (db *DataBuffer) FlushAll() {
-	db.mu.RLock()
-	var sessionIDs []uuid.UUID
-	for sessionID := range db.sessionBuffers {
-		sessionIDs = append(sessionIDs, sessionID)
+	for _, sessionBuffer := range db.snapshotBuffers() {
+		db.flushBuffer(sessionBuffer)
 	}
-	db.mu.RUnlock()
+}
 
-	for _, sessionID := range sessionIDs {
-		db.flushSessionAsync(sessionID)
+// snapshotBuffers возвращает текущие буферы сессий
+func (db *DataBuffer) snapshotBuffers() []*SessionDataBuffer {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	buffers := make([]*SessionDataBuffer, 0, len(db.sessionBuffers))
+	for _, sessionBuffer := range db.sessionBuffers {
+		buffers = append(buffers, sessionBuffer)
 	}
+	return buffers
 }
 
-// flushSessionAsync асинхронно флашит буфер сессии
-func (db *DataBuffer) flushSessionAsync(sessionID uuid.UUID) {
+// flushAsync запускает флаш буфера в отдельной горутине. После начала финального
+// флаша новые флаши не запускаются: остаток буфера запишет finalFlush.
+func (db *DataBuffer) flushAsync(sessionBuffer *SessionDataBuffer) {
 	db.mu.RLock()
-	sessionBuffer, exists := db.sessionBuffers[sessionID]
-	db.mu.RUnlock()
-
-	if !exists {
+	defer db.mu.RUnlock()
+	if db.closed {
 		return
 	}
+	db.flushWg.Add(1)
+	go func() {
+		defer db.flushWg.Done()
+		db.flushBuffer(sessionBuffer)
+	}()
+}
 
-	sessionBuffer.mu.Lock()
+// flushBuffer записывает накопленные точки сессии в БД.
+// При ошибке точки возвращаются в буфер и будут записаны при следующем флаше.
+func (db *DataBuffer) flushBuffer(sessionBuffer *SessionDataBuffer) bool {
+	sessionBuffer.flushMu.Lock()
+	defer sessionBuffer.flushMu.Unlock()
 
-	// Копируем данные для флаша
-	fhrPoints := make([]models.CTGPoint, len(sessionBuffer.FHRBuffer))
-	copy(fhrPoints, sessionBuffer.FHRBuffer)
-	ucPoints := make([]models.CTGPoint, len(sessionBuffer.UCBuffer))
-	copy(ucPoints, sessionBuffer.UCBuffer)
+	sessionBuffer.mu.Lock()
 
-	// Очищаем буферы
-	sessionBuffer.FHRBuffer = sessionBuffer.FHRBuffer[:0]
-	sessionBuffer.UCBuffer = sessionBuffer.UCBuffer[:0]
+	// Забираем данные для флаша
+	series := sessionBuffer.Series
+	seqs := sessionBuffer.Seqs
+	events := sessionBuffer.Events
+	backfill := sessionBuffer.Backfill
+	backfillSeqs := sessionBuffer.BackfillSeqs
+
+	sessionBuffer.Series = make(map[string][]models.CTGPoint, len(series))
+	sessionBuffer.Seqs = nil
+	sessionBuffer.Events = nil
+	sessionBuffer.Backfill = make(map[string][]models.CTGPoint)
+	sessionBuffer.BackfillSeqs = nil
 	sessionBuffer.LastFlush = time.Now()
+	sessionBuffer.flushPending = false
 
 	sessionBuffer.mu.Unlock()
 
-	if len(fhrPoints) == 0 && len(ucPoints) == 0 {
-		return
-	}
+	sessionID := sessionBuffer.SessionID
 
 	// Записываем в БД
-	if err := db.writeToDatabase(sessionID, fhrPoints, ucPoints); err != nil {
-		log.Printf("❌ Ошибка записи в БД для сессии %s: %v", sessionID, err)
-	} else {
-		log.Printf("💾 Записано в БД: сессия %s, FHR=%d, UC=%d точек",
-			sessionID, len(fhrPoints), len(ucPoints))
+	if livePoints := countPoints(series); livePoints > 0 || len(events) > 0 {
+		if err := db.writeToDatabase(sessionID, series, events); err != nil {
+			log.Printf("❌ Ошибка записи в БД для сессии %s: %v, %d точек будут записаны повторно",
+				sessionID, err, livePoints+countPoints(backfill))
+
+			// Возвращаем точки в начало буфера, сохраняя порядок
+			sessionBuffer.mu.Lock()
+			requeueSeries(sessionBuffer.Series, series)
+			sessionBuffer.Seqs = append(seqs, sessionBuffer.Seqs...)
+			sessionBuffer.Events = append(events, sessionBuffer.Events...)
+			sessionBuffer.requeueBackfill(backfill, backfillSeqs)
+			sessionBuffer.mu.Unlock()
+			return false
+		}
+
+		db.ack(seqs...)
+		log.Printf("💾 Записано в БД: сессия %s, %s, событий: %d", sessionID, describeSeries(series), len(events))
 	}
+
+	if backfillPoints := countPoints(backfill); backfillPoints > 0 {
+		if err := db.writeBackfill(sessionID, backfill); err != nil {
+			log.Printf("❌ Ошибка вставки исторических точек для сессии %s: %v, %d точек будут записаны повторно",
+				sessionID, err, backfillPoints)
+
+			sessionBuffer.mu.Lock()
+			sessionBuffer.requeueBackfill(backfill, backfillSeqs)
+			sessionBuffer.mu.Unlock()
+			return false
+		}
+
+		db.ack(backfillSeqs...)
+
+		update := SessionUpdate{
+			SessionID: sessionID,
+			Reason:    "backfill",
+			Points:    backfillPoints,
+		}
+		update.FromTime, update.ToTime = pointsRange(backfill)
+		log.Printf("💾 Вставлено задним числом: сессия %s, %s (%.2f-%.2f с)",
+			sessionID, describeSeries(backfill), update.FromTime, update.ToTime)
+		if db.onBackfill != nil {
+			db.onBackfill(update)
+		}
+	}
+	return true
+}
+
+// requeueSeries возвращает незаписанные точки в начало рядов буфера
+func requeueSeries(buffer, points map[string][]models.CTGPoint) {
+	for name, channelPoints := range points {
+		buffer[name] = append(channelPoints, buffer[name]...)
+	}
+}
+
+// requeueBackfill возвращает незаписанные исторические точки в буфер, вызывается под mu
+func (sb *SessionDataBuffer) requeueBackfill(backfill map[string][]models.CTGPoint, seqs []uint64) {
+	requeueSeries(sb.Backfill, backfill)
+	sb.BackfillSeqs = append(seqs, sb.BackfillSeqs...)
 }
 
-// writeToDatabase записывает данные в БД пакетно
-func (db *DataBuffer) writeToDatabase(sessionID uuid.UUID, fhrPoints, ucPoints []models.CTGPoint) error {
+// pointsRange возвращает минимальное и максимальное время точек
+func pointsRange(series map[string][]models.CTGPoint) (float64, float64) {
+	from, to := math.Inf(1), math.Inf(-1)
+	for _, points := range series {
+		for _, point := range points {
+			from = math.Min(from, point.T)
+			to = math.Max(to, point.T)
+		}
+	}
+	return from, to
+}
+
+// writeToDatabase записывает данные в БД пакетно: точки каналов дописываются кадрами
+// в блоки ctg_chunks, затем пересчитываются их сводки, события анализа дописываются
+// в events сессии. Блоки пишутся первыми: если дальнейшая запись не удастся,
+// повторно записанные точки отбрасываются при чтении, а сводки пересчитываются заново.
+func (db *DataBuffer) writeToDatabase(sessionID uuid.UUID, series map[string][]models.CTGPoint, events []models.CTGEvent) error {
+	if err := db.writeChunks(sessionID, series); err != nil {
+		return err
+	}
+	if err := db.writeRollups(sessionID, series); err != nil {
+		return err
+	}
+
 	updates := make(map[string]interface{})
+	updates["last_data_at"] = time.Now().UTC()
 
-	if len(fhrPoints) > 0 {
-		fhrJSON, _ := json.Marshal(fhrPoints)
-		// формируем строковое представление последнего времени
-		lastTimeStr := strconv.FormatFloat(fhrPoints[len(fhrPoints)-1].T, 'f', -1, 64)
-
-		updates["fhr_data"] = gorm.Expr(
-			`jsonb_set(
-       jsonb_set(
-         jsonb_set(fhr_data,
-           '{points}', COALESCE(fhr_data->'points','[]'::jsonb)||?::jsonb),
-         '{count}', (COALESCE((fhr_data->>'count')::int,0)+?)::text::jsonb),
-       '{last_time}', ?::text::jsonb)`,
-			string(fhrJSON),
-			len(fhrPoints),
-			lastTimeStr,
-		)
-	}
-
-	if len(ucPoints) > 0 {
-		ucJSON, _ := json.Marshal(ucPoints)
-		lastTimeUC := strconv.FormatFloat(ucPoints[len(ucPoints)-1].T, 'f', -1, 64)
-
-		updates["uc_data"] = gorm.Expr(
-			`jsonb_set(
-       jsonb_set(
-         jsonb_set(uc_data,
-           '{points}', COALESCE(uc_data->'points','[]'::jsonb) || ?::jsonb),
-         '{count}', (COALESCE((uc_data->>'count')::int, 0) + ?)::text::jsonb),
-       '{last_time}', ?::text::jsonb)`,
-			string(ucJSON),
-			len(ucPoints),
-			lastTimeUC,
-		)
+	if len(events) > 0 {
+		eventsJSON, err := json.Marshal(events)
+		if err != nil {
+			return err
+		}
+		updates["events"] = gorm.Expr("COALESCE(events, '[]'::jsonb) || ?::jsonb", string(eventsJSON))
 	}
 
 	return db.db.Model(&models.CTGSession{}).
@@ -191,15 +350,109 @@ func (db *DataBuffer) writeToDatabase(sessionID uuid.UUID, fhrPoints, ucPoints [
 		Updates(updates).Error
 }
 
-// RemoveSessionBuffer удаляет буфер завершенной сессии
-func (db *DataBuffer) RemoveSessionBuffer(sessionID uuid.UUID) {
+// writeBackfill записывает исторические точки в блоки по их времени. При чтении
+// из точек с одинаковым временем остается записанная первой, поэтому повторная
+// выгрузка и повтор после частичной ошибки безопасны.
+func (db *DataBuffer) writeBackfill(sessionID uuid.UUID, backfill map[string][]models.CTGPoint) error {
+	if err := db.writeChunks(sessionID, backfill); err != nil {
+		return err
+	}
+	if err := db.writeRollups(sessionID, backfill); err != nil {
+		return err
+	}
+	return db.db.Model(&models.CTGSession{}).
+		Where("id = ?", sessionID).
+		Update("last_data_at", time.Now().UTC()).Error
+}
+
+// writeChunks дописывает точки зарегистрированных каналов в блоки одним запросом
+func (db *DataBuffer) writeChunks(sessionID uuid.UUID, series map[string][]models.CTGPoint) error {
+	var rows []models.CTGChunk
+	for _, name := range sortedSeries(series) {
+		if _, ok := db.channels.Lookup(name); !ok {
+			continue
+		}
+		channelRows, err := chunks.Build(sessionID, name, series[name], db.format)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		rows = append(rows, channelRows...)
+	}
+	return chunks.Write(db.db, rows)
+}
+
+// writeRollups пересчитывает сводки интервалов, в которые попали записанные точки.
+// Сводки строятся по всем точкам интервалов: уже записанным в блоки и только что
+// записанным, поэтому повторная запись тех же точек их не искажает.
+func (db *DataBuffer) writeRollups(sessionID uuid.UUID, series map[string][]models.CTGPoint) error {
+	var names []string
+	for _, name := range sortedSeries(series) {
+		if _, ok := db.channels.Lookup(name); ok {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	from, to := rollups.Span(series)
+	stored, err := chunks.Load(db.db, sessionID, names, &from, &to)
+	if err != nil {
+		return err
+	}
+
+	var rows []models.CTGRollup
+	for _, name := range names {
+		points := append(append([]models.CTGPoint{}, stored[name]...), series[name]...)
+		rows = append(rows, rollups.Build(sessionID, name, chunks.Dedupe(points), from, to)...)
+	}
+	if err := rollups.Write(db.db, rows); err != nil {
+		return fmt.Errorf("сводки: %w", err)
+	}
+	return nil
+}
+
+// AttachSession заранее создает буфер для сессии, восстановленной после перезапуска
+func (db *DataBuffer) AttachSession(sessionID uuid.UUID) {
 	db.mu.Lock()
 	defer db.mu.Unlock()
 
 	if _, exists := db.sessionBuffers[sessionID]; exists {
-		// Финальный флаш перед удалением
-		go db.flushSessionAsync(sessionID)
-		delete(db.sessionBuffers, sessionID)
+		return
+	}
+	db.sessionBuffers[sessionID] = newSessionDataBuffer(sessionID)
+}
+
+// RemoveSessionBuffer удаляет буфер завершенной сессии и дописывает его остаток в БД.
+// onFlushed (может быть nil) вызывается, когда все точки сессии записаны.
+func (db *DataBuffer) RemoveSessionBuffer(sessionID uuid.UUID, onFlushed func()) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.closed {
+		// Буфер остается на месте и записывается финальным флашем
+		log.Printf("Буфер сессии %s не удален: Data Buffer останавливается", sessionID)
+		return
+	}
+	sessionBuffer, exists := db.sessionBuffers[sessionID]
+	delete(db.sessionBuffers, sessionID)
+
+	// Финальный флаш удаленного буфера: повторяем, пока БД не примет данные.
+	// Если сервис остановится раньше, точки останутся в спуле, а onFlushed не вызывается.
+	db.flushWg.Add(1)
+	go func() {
+		defer db.flushWg.Done()
+		for attempt := 1; exists && !db.flushBuffer(sessionBuffer); attempt++ {
+			select {
+			case <-time.After(time.Duration(attempt) * time.Second):
+			case <-db.ctx.Done():
+				return
+			}
+		}
+		if onFlushed != nil {
+			onFlushed()
+		}
+	}()
+	if exists {
 		log.Printf("Удален буфер сессии: %s", sessionID)
 	}
 }
@@ -225,18 +478,17 @@ func (db *DataBuffer) autoFlushWorker() {
 
 // flushOldBuffers флашит буферы, которые давно не флашились
 func (db *DataBuffer) flushOldBuffers() {
-	db.mu.RLock()
-	var sessionsToFlush []uuid.UUID
-
-	for sessionID, sessionBuffer := range db.sessionBuffers {
-		if time.Since(sessionBuffer.LastFlush) > 15*time.Second {
-			sessionsToFlush = append(sessionsToFlush, sessionID)
+	for _, sessionBuffer := range db.snapshotBuffers() {
+		sessionBuffer.mu.Lock()
+		stale := time.Since(sessionBuffer.LastFlush) > 15*time.Second && !sessionBuffer.flushPending
+		if stale {
+			sessionBuffer.flushPending = true
 		}
-	}
-	db.mu.RUnlock()
+		sessionBuffer.mu.Unlock()
 
-	for _, sessionID := range sessionsToFlush {
-		go db.flushSessionAsync(sessionID)
+		if stale {
+			db.flushAsync(sessionBuffer)
+		}
 	}
 }
 
@@ -244,19 +496,13 @@ func (db *DataBuffer) flushOldBuffers() {
 func (db *DataBuffer) finalFlush() {
 	log.Println("🔄 Финальный флаш буферов...")
 
-	db.mu.RLock()
-	var sessionIDs []uuid.UUID
-	for sessionID := range db.sessionBuffers {
-		sessionIDs = append(sessionIDs, sessionID)
-	}
-	db.mu.RUnlock()
-
-	for _, sessionID := range sessionIDs {
-		db.flushSessionAsync(sessionID)
-	}
+	// Запрещаем новые флаши, дожидаемся уже запущенных и синхронно записываем остаток
+	db.mu.Lock()
+	db.closed = true
+	db.mu.Unlock()
+	db.flushWg.Wait()
+	db.FlushAll()
 
-	// Ждем завершения всех операций
-	time.Sleep(2 * time.Second)
 	log.Println("Финальный флаш завершен")
 }
 
@@ -4,17 +4,27 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
 	"time"
 
+	"CTG_monitor/internal/coincidence"
+	"CTG_monitor/internal/models"
 	pb "CTG_monitor/proto"
+	"ctg_common/codec"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
 )
 
 type GRPCStreamer struct {
 	pb.UnimplementedCTGStreamServiceServer
 
+	sessionManager *SessionManager
+
 	batchClients map[string]*BatchSubscriber
 	mu           sync.RWMutex
 
@@ -22,6 +32,13 @@ type GRPCStreamer struct {
 	batchMu     sync.RWMutex
 	subscribers map[string]*StreamSubscriber
 
+	eventSubscribers    map[string]*EventSubscriber
+	analysisSubscribers map[string]*AnalysisSubscriber
+	alarmSubscribers    map[string]*AlarmSubscriber
+
+	// alarmSnapshot возвращает неснятые тревоги для новых подписчиков StreamAlarms
+	alarmSnapshot func() []models.CTGAlarm
+
 	batchTicker *time.Ticker
 
 	ctx    context.Context
@@ -33,11 +50,36 @@ type GRPCStreamer struct {
 type BatchSubscriber struct {
 	ID        string
 	DeviceIDs []string
+	Packed    bool // точки батча в компактной кодировке
 	Channel   chan []*pb.CTGDataResponse
 	Stream    pb.CTGStreamService_StreamBatchCTGDataServer
 	Context   context.Context
 }
 
+// EventSubscriber подписчик на события сессий
+type EventSubscriber struct {
+	ID        string
+	DeviceIDs []string
+	Channel   chan *pb.SessionEvent
+}
+
+// AnalysisSubscriber подписчик на результаты анализа сигнала
+type AnalysisSubscriber struct {
+	ID        string
+	DeviceIDs []string
+	DataTypes []string
+	Channel   chan *pb.AnalysisEvent
+}
+
+// AlarmSubscriber подписчик на клинические тревоги
+type AlarmSubscriber struct {
+	ID        string
+	DeviceIDs []string
+	DataTypes []string
+	Channel   chan *pb.AlarmEvent
+	Dropped   chan struct{} // закрывается, когда клиент отключен за переполнение канала
+}
+
 type StreamSubscriber struct {
 	ID        string
 	DeviceIDs []string
@@ -48,16 +90,20 @@ type StreamSubscriber struct {
 }
 
 // NewGRPCStreamer создает новый батчевый стример
-func NewGRPCStreamer() *GRPCStreamer {
+func NewGRPCStreamer(sessionManager *SessionManager) *GRPCStreamer {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	streamer := &GRPCStreamer{
-		subscribers:  make(map[string]*StreamSubscriber),
-		batchClients: make(map[string]*BatchSubscriber),
-		batchBuffer:  make(map[string][]*pb.CTGDataResponse),
-		batchTicker:  time.NewTicker(4 * time.Minute),
-		ctx:          ctx,
-		cancel:       cancel,
+		sessionManager:      sessionManager,
+		subscribers:         make(map[string]*StreamSubscriber),
+		eventSubscribers:    make(map[string]*EventSubscriber),
+		analysisSubscribers: make(map[string]*AnalysisSubscriber),
+		alarmSubscribers:    make(map[string]*AlarmSubscriber),
+		batchClients:        make(map[string]*BatchSubscriber),
+		batchBuffer:         make(map[string][]*pb.CTGDataResponse),
+		batchTicker:         time.NewTicker(4 * time.Minute),
+		ctx:                 ctx,
+		cancel:              cancel,
 	}
 
 	streamer.wg.Add(1)
@@ -76,6 +122,7 @@ func (gs *GRPCStreamer) StreamBatchCTGData(req *pb.StreamRequest, stream pb.CTGS
 	subscriber := &BatchSubscriber{
 		ID:        clientID,
 		DeviceIDs: req.DeviceIds,
+		Packed:    req.Packed,
 		Channel:   make(chan []*pb.CTGDataResponse, 1000),
 		Stream:    stream,
 		Context:   stream.Context(),
@@ -106,6 +153,16 @@ func (gs *GRPCStreamer) StreamBatchCTGData(req *pb.StreamRequest, stream pb.CTGS
 				Data:      batch,
 				Timestamp: time.Now().Unix(),
 				Count:     int32(len(batch)),
+				DeviceId:  batch[0].DeviceId,
+			}
+			if subscriber.Packed {
+				packed, err := packBatch(batch)
+				if err != nil {
+					log.Printf("Ошибка упаковки батча клиенту %s: %v", clientID, err)
+					continue
+				}
+				batchResponse.Data = nil
+				batchResponse.Packed = packed
 			}
 
 			if err := stream.Send(batchResponse); err != nil {
@@ -127,6 +184,10 @@ func (gs *GRPCStreamer) BroadcastCTGData(data *pb.CTGDataResponse) {
 	gs.mu.RLock()
 
 	for clientID, subscriber := range gs.subscribers {
+		// Каждому клиенту отправляем только данные его устройств
+		if len(subscriber.DeviceIDs) > 0 && !gs.containsDevice(subscriber.DeviceIDs, data.DeviceId) {
+			continue
+		}
 		select {
 		case subscriber.Channel <- data:
 		default:
@@ -224,6 +285,42 @@ func (gs *GRPCStreamer) broadcastBatch(deviceID string, batch []*pb.CTGDataRespo
 	}
 }
 
+// packBatch упаковывает точки батча по каналам в кодировке pkg/codec
+func packBatch(batch []*pb.CTGDataResponse) ([]*pb.PackedSeries, error) {
+	type series struct {
+		packed *pb.PackedSeries
+		times  []float64
+		values []float64
+		walls  []int64
+	}
+	var order []*series
+	byType := make(map[string]*series)
+	for _, data := range batch {
+		s := byType[data.DataType]
+		if s == nil {
+			s = &series{packed: &pb.PackedSeries{DataType: data.DataType, Fetus: data.Fetus}}
+			byType[data.DataType] = s
+			order = append(order, s)
+		}
+		s.times = append(s.times, data.TimeSec)
+		s.values = append(s.values, data.Value)
+		s.walls = append(s.walls, data.Timestamp)
+	}
+
+	packed := make([]*pb.PackedSeries, 0, len(order))
+	for _, s := range order {
+		samples, err := codec.Append(nil, s.times, s.values, codec.Options{})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", s.packed.DataType, err)
+		}
+		s.packed.Count = int32(len(s.times))
+		s.packed.Samples = samples
+		s.packed.Timestamps = codec.AppendInts(nil, s.walls)
+		packed = append(packed, s.packed)
+	}
+	return packed, nil
+}
+
 // containsDevice проверяет наличие устройства в списке
 func (gs *GRPCStreamer) containsDevice(devices []string, deviceID string) bool {
 	for _, device := range devices {
@@ -253,6 +350,396 @@ func (gs *GRPCStreamer) GetBufferStatus() map[string]int {
 	return status
 }
 
+// BindDevice привязывает устройство, передающее данные без медкарты, к карте пациента
+func (gs *GRPCStreamer) BindDevice(ctx context.Context, req *pb.BindDeviceRequest) (*pb.BindDeviceResponse, error) {
+	if req.DeviceId == "" {
+		return nil, status.Error(codes.InvalidArgument, "не указан device_id")
+	}
+	cardID, err := uuid.Parse(req.CardId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "неверный ID медицинской карты: %v", err)
+	}
+
+	result, err := gs.sessionManager.BindDevice(req.DeviceId, cardID, req.KeepData)
+	if err != nil {
+		if errors.Is(err, ErrDeviceHasActiveSession) {
+			return nil, status.Error(codes.AlreadyExists, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &pb.BindDeviceResponse{
+		SessionId:       result.Session.ID.String(),
+		KeptPoints:      int32(result.KeptPoints),
+		DiscardedPoints: int32(result.DiscardedPoints),
+	}, nil
+}
+
+// GetSessionData возвращает точки каналов сессии за интервал, прореженные до max_points
+func (gs *GRPCStreamer) GetSessionData(ctx context.Context, req *pb.SessionDataRequest) (*pb.SessionDataResponse, error) {
+	sessionID, err := uuid.Parse(req.SessionId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "неверный ID сессии: %v", err)
+	}
+	if req.MaxPoints < 0 || req.MaxPoints > 0 && req.MaxPoints < minMaxPoints {
+		return nil, status.Errorf(codes.InvalidArgument, "max_points должен быть 0 или не меньше %d", minMaxPoints)
+	}
+
+	data, err := gs.sessionManager.GetSessionSeries(sessionID, req.DataTypes, TimeRange{From: req.From, To: req.To})
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidTimeRange), errors.Is(err, ErrUnknownChannel):
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, status.Error(codes.NotFound, "сессия не найдена")
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	response := &pb.SessionDataResponse{
+		SessionId: data.Session.ID.String(),
+		DeviceId:  data.Session.DeviceID,
+	}
+	sampled := downsampleSeries(data.Series, int(req.MaxPoints))
+	for _, channel := range gs.sessionManager.dataBuffer.channels.All() {
+		points := sampled[channel.Name]
+		if len(points) == 0 {
+			continue
+		}
+		series := &pb.SessionSeries{
+			DataType:     channel.Name,
+			Fetus:        int32(channel.Fetus),
+			SourcePoints: int32(len(data.Series[channel.Name])),
+		}
+		batch := make([]*pb.CTGDataResponse, len(points))
+		for i, point := range points {
+			batch[i] = &pb.CTGDataResponse{
+				DataType:  channel.Name,
+				Value:     point.V,
+				TimeSec:   point.T,
+				RawValue:  point.Raw(),
+				Flags:     point.F.Names(),
+				Timestamp: point.W,
+				Fetus:     series.Fetus,
+			}
+		}
+		if req.Packed {
+			packed, err := packBatch(batch)
+			if err != nil {
+				return nil, status.Error(codes.Internal, err.Error())
+			}
+			series.Packed = packed[0]
+		} else {
+			series.Data = batch
+		}
+		response.Series = append(response.Series, series)
+	}
+	return response, nil
+}
+
+// StreamSessionEvents передает события сессий выбранных устройств
+func (gs *GRPCStreamer) StreamSessionEvents(req *pb.StreamRequest, stream pb.CTGStreamService_StreamSessionEventsServer) error {
+	clientID := fmt.Sprintf("event_client_%d", time.Now().UnixNano())
+	log.Printf("Новый клиент событий сессий подключен: %s, устройства: %v", clientID, req.DeviceIds)
+
+	subscriber := &EventSubscriber{
+		ID:        clientID,
+		DeviceIDs: req.DeviceIds,
+		Channel:   make(chan *pb.SessionEvent, 100),
+	}
+
+	gs.mu.Lock()
+	gs.eventSubscribers[clientID] = subscriber
+	gs.mu.Unlock()
+
+	defer func() {
+		gs.mu.Lock()
+		delete(gs.eventSubscribers, clientID)
+		gs.mu.Unlock()
+		log.Printf("Клиент событий сессий отключен: %s", clientID)
+	}()
+
+	for {
+		select {
+		case event := <-subscriber.Channel:
+			if err := stream.Send(event); err != nil {
+				log.Printf("Ошибка отправки события клиенту %s: %v", clientID, err)
+				return err
+			}
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
+
+// BroadcastSessionUpdate сообщает подписчикам об изменении уже записанных данных сессии
+func (gs *GRPCStreamer) BroadcastSessionUpdate(update SessionUpdate) {
+	var deviceIDs []string
+	if err := gs.sessionManager.db.Model(&models.CTGSession{}).
+		Where("id = ?", update.SessionID).
+		Pluck("device_id", &deviceIDs).Error; err != nil {
+		log.Printf("Не удалось определить устройство сессии %s: %v", update.SessionID, err)
+	}
+	var deviceID string
+	if len(deviceIDs) > 0 {
+		deviceID = deviceIDs[0]
+	}
+
+	gs.broadcastEvent(&pb.SessionEvent{
+		SessionId: update.SessionID.String(),
+		DeviceId:  deviceID,
+		Type:      "updated",
+		Reason:    update.Reason,
+		Points:    int32(update.Points),
+		FromTime:  update.FromTime,
+		ToTime:    update.ToTime,
+		Timestamp: time.Now().Unix(),
+	})
+}
+
+// BroadcastCoincidence предупреждает подписчиков о начале или конце совпадения
+// каналов ЧСС устройства. sessionID пуст, если устройство не привязано к карте.
+func (gs *GRPCStreamer) BroadcastCoincidence(deviceID string, sessionID uuid.UUID, change coincidence.Change) {
+	event := &pb.SessionEvent{
+		DeviceId:  deviceID,
+		Type:      "warning",
+		Reason:    change.Kind,
+		FromTime:  change.FromTime,
+		ToTime:    change.ToTime,
+		Timestamp: time.Now().Unix(),
+		Channels:  change.Channels,
+	}
+	if !change.Active {
+		event.Type = "warning_cleared"
+	}
+	if sessionID != uuid.Nil {
+		event.SessionId = sessionID.String()
+	}
+	gs.broadcastEvent(event)
+}
+
+// broadcastEvent рассылает событие подписчикам устройства
+func (gs *GRPCStreamer) broadcastEvent(event *pb.SessionEvent) {
+	deviceID := event.DeviceId
+
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+
+	for clientID, subscriber := range gs.eventSubscribers {
+		if len(subscriber.DeviceIDs) > 0 && !gs.containsDevice(subscriber.DeviceIDs, deviceID) {
+			continue
+		}
+		select {
+		case subscriber.Channel <- event:
+		default:
+			log.Printf("Канал событий клиента %s переполнен", clientID)
+		}
+	}
+}
+
+// StreamAnalysisEvents передает результаты анализа сигнала выбранных устройств и каналов
+func (gs *GRPCStreamer) StreamAnalysisEvents(req *pb.StreamRequest, stream pb.CTGStreamService_StreamAnalysisEventsServer) error {
+	clientID := fmt.Sprintf("analysis_client_%d", time.Now().UnixNano())
+	log.Printf("Новый клиент анализа сигнала подключен: %s, устройства: %v", clientID, req.DeviceIds)
+
+	subscriber := &AnalysisSubscriber{
+		ID:        clientID,
+		DeviceIDs: req.DeviceIds,
+		DataTypes: req.DataTypes,
+		Channel:   make(chan *pb.AnalysisEvent, 100),
+	}
+
+	gs.mu.Lock()
+	gs.analysisSubscribers[clientID] = subscriber
+	gs.mu.Unlock()
+
+	defer func() {
+		gs.mu.Lock()
+		delete(gs.analysisSubscribers, clientID)
+		gs.mu.Unlock()
+		log.Printf("Клиент анализа сигнала отключен: %s", clientID)
+	}()
+
+	for {
+		select {
+		case event := <-subscriber.Channel:
+			if err := stream.Send(event); err != nil {
+				log.Printf("Ошибка отправки результата анализа клиенту %s: %v", clientID, err)
+				return err
+			}
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
+
+// BroadcastAnalysisEvent рассылает результат анализа сигнала устройства.
+// sessionID пуст, если устройство не привязано к карте.
+func (gs *GRPCStreamer) BroadcastAnalysisEvent(deviceID string, sessionID uuid.UUID, event models.CTGEvent) {
+	message := &pb.AnalysisEvent{
+		DeviceId:        deviceID,
+		DataType:        event.Channel,
+		Type:            event.Type,
+		Start:           event.Start,
+		End:             event.End,
+		Peak:            event.Peak,
+		Value:           event.Value,
+		Amplitude:       event.Amplitude,
+		Baseline:        event.Baseline,
+		Band:            event.Band,
+		Kind:            event.Kind,
+		ContractionPeak: event.ContractionPeak,
+		Lag:             event.Lag,
+		Frequency:       event.Frequency,
+		Timestamp:       time.Now().Unix(),
+	}
+	if sessionID != uuid.Nil {
+		message.SessionId = sessionID.String()
+	}
+
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+
+	for clientID, subscriber := range gs.analysisSubscribers {
+		if len(subscriber.DeviceIDs) > 0 && !gs.containsDevice(subscriber.DeviceIDs, deviceID) {
+			continue
+		}
+		if len(subscriber.DataTypes) > 0 && !gs.containsDataType(subscriber.DataTypes, event.Channel) {
+			continue
+		}
+		select {
+		case subscriber.Channel <- message:
+		default:
+			log.Printf("Канал результатов анализа клиента %s переполнен", clientID)
+		}
+	}
+}
+
+// SetAlarmSnapshot устанавливает источник неснятых тревог для новых подписчиков
+func (gs *GRPCStreamer) SetAlarmSnapshot(snapshot func() []models.CTGAlarm) {
+	gs.mu.Lock()
+	gs.alarmSnapshot = snapshot
+	gs.mu.Unlock()
+}
+
+// StreamAlarms передает клинические тревоги выбранных устройств и каналов:
+// сначала неснятые на момент подключения, затем изменения
+func (gs *GRPCStreamer) StreamAlarms(req *pb.StreamRequest, stream pb.CTGStreamService_StreamAlarmsServer) error {
+	clientID := fmt.Sprintf("alarm_client_%d", time.Now().UnixNano())
+	log.Printf("Новый клиент тревог подключен: %s, устройства: %v", clientID, req.DeviceIds)
+
+	subscriber := &AlarmSubscriber{
+		ID:        clientID,
+		DeviceIDs: req.DeviceIds,
+		DataTypes: req.DataTypes,
+		Channel:   make(chan *pb.AlarmEvent, 100),
+		Dropped:   make(chan struct{}),
+	}
+
+	// Подписка и снимок под одной блокировкой: изменение, разосланное после
+	// снимка, не потеряется, а до него - уже учтено в снимке
+	gs.mu.Lock()
+	gs.alarmSubscribers[clientID] = subscriber
+	var snapshot []models.CTGAlarm
+	if gs.alarmSnapshot != nil {
+		snapshot = gs.alarmSnapshot()
+	}
+	gs.mu.Unlock()
+
+	defer func() {
+		gs.mu.Lock()
+		delete(gs.alarmSubscribers, clientID)
+		gs.mu.Unlock()
+		log.Printf("Клиент тревог отключен: %s", clientID)
+	}()
+
+	for _, alarm := range snapshot {
+		if !gs.alarmWanted(subscriber, alarm) {
+			continue
+		}
+		message := alarmMessage(alarm)
+		message.Snapshot = true
+		if err := stream.Send(message); err != nil {
+			log.Printf("Ошибка отправки тревоги клиенту %s: %v", clientID, err)
+			return err
+		}
+	}
+
+	for {
+		select {
+		case alarm := <-subscriber.Channel:
+			if err := stream.Send(alarm); err != nil {
+				log.Printf("Ошибка отправки тревоги клиенту %s: %v", clientID, err)
+				return err
+			}
+		case <-subscriber.Dropped:
+			return status.Error(codes.Unavailable, "клиент не успевает принимать тревоги, переподключитесь")
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
+
+// BroadcastAlarm рассылает изменение тревоги. Тревоги нельзя терять, поэтому клиент
+// с переполненным каналом отключается: при переподключении он получит снимок заново.
+func (gs *GRPCStreamer) BroadcastAlarm(alarm models.CTGAlarm) {
+	message := alarmMessage(alarm)
+
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	for clientID, subscriber := range gs.alarmSubscribers {
+		if !gs.alarmWanted(subscriber, alarm) {
+			continue
+		}
+		select {
+		case subscriber.Channel <- message:
+		default:
+			log.Printf("Канал тревог клиента %s переполнен, клиент отключен", clientID)
+			delete(gs.alarmSubscribers, clientID)
+			if subscriber.Dropped != nil {
+				close(subscriber.Dropped)
+			}
+		}
+	}
+}
+
+// alarmWanted проверяет, подписан ли клиент на устройство и канал тревоги
+func (gs *GRPCStreamer) alarmWanted(subscriber *AlarmSubscriber, alarm models.CTGAlarm) bool {
+	if len(subscriber.DeviceIDs) > 0 && !gs.containsDevice(subscriber.DeviceIDs, alarm.DeviceID) {
+		return false
+	}
+	return len(subscriber.DataTypes) == 0 || gs.containsDataType(subscriber.DataTypes, alarm.Channel)
+}
+
+// alarmMessage переводит тревогу в сообщение gRPC
+func alarmMessage(alarm models.CTGAlarm) *pb.AlarmEvent {
+	message := &pb.AlarmEvent{
+		AlarmId:        alarm.ID.String(),
+		DeviceId:       alarm.DeviceID,
+		DataType:       alarm.Channel,
+		Rule:           alarm.Rule,
+		Priority:       alarm.Priority,
+		State:          alarm.State,
+		Message:        alarm.Message,
+		Value:          alarm.Value,
+		SessionTime:    alarm.SessionTime,
+		RaisedAt:       alarm.RaisedAt.UnixMilli(),
+		AcknowledgedBy: alarm.AcknowledgedBy,
+		Escalations:    int32(alarm.Escalations),
+	}
+	if alarm.SessionID != nil {
+		message.SessionId = alarm.SessionID.String()
+	}
+	if alarm.AcknowledgedAt != nil {
+		message.AcknowledgedAt = alarm.AcknowledgedAt.UnixMilli()
+	}
+	if alarm.ClearedAt != nil {
+		message.ClearedAt = alarm.ClearedAt.UnixMilli()
+	}
+	return message
+}
+
 // Stop останавливает стример
 func (gs *GRPCStreamer) Stop() {
 	log.Println("Остановка gRPC Batch Streamer...")
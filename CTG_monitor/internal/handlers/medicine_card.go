@@ -5,8 +5,12 @@ import (
 	"context"
 	"github.com/google/uuid"
 	"log"
+	"sort"
 	"time"
 
+	"CTG_monitor/internal/channels"
+	"CTG_monitor/internal/chunks"
+	"CTG_monitor/internal/coincidence"
 	"CTG_monitor/internal/models"
 	medpb "CTG_monitor/proto"
 	"google.golang.org/grpc"
@@ -39,8 +43,8 @@ func CloseMedicalRecordsClient() error {
 	return nil
 }
 
-// sendSessionToMedicalRecordsGRPC отправляет сессию через gRPC
-func sendSessionToMedicalRecordsGRPC(session *models.CTGSession) error {
+// sendSessionToMedicalRecordsGRPC отправляет сессию с точками каналов через gRPC
+func sendSessionToMedicalRecordsGRPC(session *models.CTGSession, series map[string][]models.CTGPoint) error {
 	if medicalRecordsClient == nil {
 		return nil // Клиент не инициализирован
 	}
@@ -48,23 +52,14 @@ func sendSessionToMedicalRecordsGRPC(session *models.CTGSession) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	// Преобразуем FHR данные
-	var fhrPoints []*medpb.CTGDataPoint
-	for _, point := range session.FHRData.Points {
-		fhrPoints = append(fhrPoints, &medpb.CTGDataPoint{
-			TimeSec: point.T,
-			Value:   point.V,
-		})
-	}
+	fhrPoints := exportPoints(series[channels.FetalHeartRate])
+	fhr2Points := exportPoints(series[channels.FetalHeartRate2])
+	ucPoints := exportPoints(series[channels.UterineContractions])
 
-	// Преобразуем UC данные
-	var ucPoints []*medpb.CTGDataPoint
-	for _, point := range session.UCData.Points {
-		ucPoints = append(ucPoints, &medpb.CTGDataPoint{
-			TimeSec: point.T,
-			Value:   point.V,
-		})
-	}
+	// Интервалы, когда датчики ЧСС записывали одно сердце или ЧСС матери
+	coincidences := append(
+		coincidenceIntervals(channels.FetalHeartRate, series[channels.FetalHeartRate]),
+		coincidenceIntervals(channels.FetalHeartRate2, series[channels.FetalHeartRate2])...)
 
 	// Вычисляем продолжительность
 	var duration int32
@@ -84,10 +79,15 @@ func sendSessionToMedicalRecordsGRPC(session *models.CTGSession) error {
 		UcData:          ucPoints,
 		TotalFhrPoints:  int32(len(fhrPoints)),
 		TotalUcPoints:   int32(len(ucPoints)),
+		Fhr2Data:        fhr2Points,
+		TotalFhr2Points: int32(len(fhr2Points)),
+		Coincidences:    coincidences,
+		ProtocolResult:  exportProtocolResult(session.ProtocolResult),
+		Summary:         exportSummary(session.Summary),
 	}
 
-	log.Printf("Отправка сессии %s в медкарты через gRPC: FHR=%d, UC=%d точек",
-		session.ID.String(), len(fhrPoints), len(ucPoints))
+	log.Printf("Отправка сессии %s в медкарты через gRPC: FHR=%d, FHR2=%d, UC=%d точек, совпадений каналов: %d",
+		session.ID.String(), len(fhrPoints), len(fhr2Points), len(ucPoints), len(coincidences))
 
 	// Отправляем запрос
 	response, err := medicalRecordsClient.SaveCTGSession(ctx, request)
@@ -106,7 +106,98 @@ func sendSessionToMedicalRecordsGRPC(session *models.CTGSession) error {
 	return nil
 }
 
+// exportPoints преобразует точки ряда для сервиса медкарт
+func exportPoints(points []models.CTGPoint) []*medpb.CTGDataPoint {
+	var exported []*medpb.CTGDataPoint
+	for _, point := range points {
+		exported = append(exported, &medpb.CTGDataPoint{
+			TimeSec:  point.T,
+			Value:    point.V,
+			RawValue: point.Raw(),
+			Flags:    point.F.Names(),
+		})
+	}
+	return exported
+}
+
+// exportProtocolResult преобразует заключение теста для сервиса медкарт (nil - сессия без протокола)
+func exportProtocolResult(result *models.ProtocolResult) *medpb.ProtocolResult {
+	if result == nil {
+		return nil
+	}
+	return &medpb.ProtocolResult{
+		Protocol:          result.Protocol,
+		Outcome:           result.Outcome,
+		Reason:            result.Reason,
+		DurationSeconds:   result.Duration,
+		Accelerations:     int32(result.Accelerations),
+		Contractions:      int32(result.Contractions),
+		LateDecelerations: int32(result.LateDecelerations),
+		Summary:           result.Summary,
+	}
+}
+
+// exportSummary преобразует итоги сессии для сервиса медкарт (nil - итоги не вычислены)
+func exportSummary(summary *models.SessionSummary) *medpb.SessionSummary {
+	if summary == nil {
+		return nil
+	}
+	return &medpb.SessionSummary{
+		DurationSeconds:        summary.Duration,
+		Baseline:               summary.Baseline,
+		Variability:            summary.Variability,
+		VariabilityBand:        summary.VariabilityBand,
+		Accelerations:          int32(summary.Accelerations),
+		EarlyDecelerations:     int32(summary.Decelerations.Early),
+		LateDecelerations:      int32(summary.Decelerations.Late),
+		VariableDecelerations:  int32(summary.Decelerations.Variable),
+		ProlongedDecelerations: int32(summary.Decelerations.Prolonged),
+		Contractions:           int32(summary.Contractions),
+		ContractionFrequency:   summary.ContractionFrequency,
+		SignalQuality:          summary.SignalQuality,
+		SpikesCorrected:        int32(summary.SpikesCorrected),
+		Alarms:                 int32(summary.Alarms),
+	}
+}
+
+// coincidenceIntervalGap перерыв между помеченными точками, после которого начинается новый интервал, с
+const coincidenceIntervalGap = 5.0
+
+// coincidenceIntervals собирает интервалы помеченных совпадением точек канала
+func coincidenceIntervals(dataType string, points []models.CTGPoint) []*medpb.CoincidenceInterval {
+	var intervals []*medpb.CoincidenceInterval
+	for kind, flag := range map[string]models.SampleFlags{
+		coincidence.KindSameHeart:  models.FlagSameHeart,
+		coincidence.KindMaternalHR: models.FlagMaternalHR,
+	} {
+		var current *medpb.CoincidenceInterval
+		for _, point := range points {
+			if point.F&flag == 0 {
+				continue
+			}
+			if current != nil && point.T-current.ToTime <= coincidenceIntervalGap {
+				current.ToTime = point.T
+				continue
+			}
+			current = &medpb.CoincidenceInterval{
+				Kind:     kind,
+				DataType: dataType,
+				FromTime: point.T,
+				ToTime:   point.T,
+			}
+			intervals = append(intervals, current)
+		}
+	}
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i].FromTime < intervals[j].FromTime })
+	return intervals
+}
+
 func SendSessionToMedicalRecords(sessionID uuid.UUID) {
+	if medicalRecordsClient == nil {
+		log.Printf("Клиент медкарт не инициализирован, сессия %s не отправлена", sessionID)
+		return
+	}
+
 	log.Printf("Начинаем отправку сессии %s в медкарты", sessionID)
 
 	db := database.GetDB()
@@ -121,14 +212,20 @@ func SendSessionToMedicalRecords(sessionID uuid.UUID) {
 		return
 	}
 
-	// 2. Извлечь точки из JSONB полей
-	fhrCount := len(session.FHRData.Points)
-	ucCount := len(session.UCData.Points)
+	// 2. Собрать точки из блоков ctg_chunks
+	series, err := chunks.Load(db, session.ID, nil, nil, nil)
+	if err != nil {
+		log.Printf("Ошибка получения точек сессии %s: %v", sessionID, err)
+		return
+	}
+	fhrCount := len(series[channels.FetalHeartRate])
+	fhr2Count := len(series[channels.FetalHeartRate2])
+	ucCount := len(series[channels.UterineContractions])
 
-	log.Printf("Данные сессии %s: FHR=%d точек, UC=%d точек", sessionID, fhrCount, ucCount)
+	log.Printf("Данные сессии %s: FHR=%d точек, FHR2=%d точек, UC=%d точек", sessionID, fhrCount, fhr2Count, ucCount)
 
 	// 3. Отправить через существующий gRPC клиент
-	if err := sendSessionToMedicalRecordsGRPC(&session); err != nil {
+	if err := sendSessionToMedicalRecordsGRPC(&session, series); err != nil {
 		log.Printf("Ошибка отправки сессии %s в медкарты: %v", sessionID, err)
 	} else {
 		log.Printf("Сессия %s успешно отправлена в медкарты", sessionID)
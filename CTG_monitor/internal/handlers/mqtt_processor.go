@@ -5,58 +5,49 @@ package handlers
 import (
 	"context"
 	"encoding/json"
-	"github.com/google/uuid"
+	"fmt"
 	"log"
-	"math"
+	"regexp"
 	"strings"
 	"sync"
 	"time"
 
+	"CTG_monitor/internal/channels"
+	"CTG_monitor/internal/clock"
+	"CTG_monitor/internal/coincidence"
+	"CTG_monitor/internal/figo"
+	"CTG_monitor/internal/filters"
 	"CTG_monitor/internal/models"
+	"CTG_monitor/internal/quality"
+	"CTG_monitor/internal/reorder"
+	"CTG_monitor/internal/spool"
 	pb "CTG_monitor/proto"
 	mqtt "github.com/eclipse/paho.mqtt.golang"
 )
 
-// SpikeDetectionFilter специальный фильтр для детекции единичных выбросов
-type SpikeDetectionFilter struct {
-	fhrBuffer  []float64
-	ucBuffer   []float64
-	bufferSize int
-
-	// Параметры детекции спайков
-	spikeDeviation  float64
-	contextWindow   int
-	spikeConfidence float64
-
-	// Статистика
-	totalProcessed int
-	spikesDetected int
-
-	mu sync.RWMutex
-}
-
-// NewSpikeDetectionFilter создает новый фильтр спайков
-func NewSpikeDetectionFilter() *SpikeDetectionFilter {
-	return &SpikeDetectionFilter{
-		fhrBuffer:       make([]float64, 0, 20),
-		ucBuffer:        make([]float64, 0, 20),
-		bufferSize:      20,
-		spikeDeviation:  8.0,
-		contextWindow:   3,
-		spikeConfidence: 0.7,
-	}
-}
-
 // MQTTStreamProcessor обрабатывает потоковые данные от MQTT
 type MQTTStreamProcessor struct {
 	// Компоненты
-	sessionManager *SessionManager
-	grpcStreamer   *GRPCStreamer
-	dataBuffer     *DataBuffer
-	spikeFilter    *SpikeDetectionFilter
+	sessionManager  *SessionManager
+	grpcStreamer    *GRPCStreamer
+	dataBuffer      *DataBuffer
+	channels        *channels.Registry
+	filterBank      *filters.Bank
+	backfillBank    *filters.Bank // отдельная история фильтров для выгружаемых данных
+	reorderBank     *reorder.Bank
+	clockBank       *clock.Bank
+	coincidenceBank *coincidence.Bank
+	figoBank        *figo.Bank
+	qualityBank     *quality.Bank
+	alarms          *AlarmManager
+	spool           *spool.Spool
+	segments        SegmentConfig
+
+	// Потоки устройств: у каждого устройства своя очередь и свой воркер
+	deviceStreams map[string]*deviceStream
+	streamsMu     sync.Mutex
 
 	// Каналы для потоковой обработки
-	dataChannel chan *models.MedicalData
 	grpcChannel chan *pb.CTGDataResponse
 
 	// Управление
@@ -66,40 +57,111 @@ type MQTTStreamProcessor struct {
 	mu     sync.RWMutex
 }
 
+// deviceStream очередь данных одного устройства
+type deviceStream struct {
+	deviceID    string
+	dataChannel chan *models.MedicalData
+	timeBase    *timeBase
+	backfill    *backfillTarget // сессия для выгружаемых точек (последний поиск)
+}
+
+// deviceIDPattern допустимый формат идентификатора устройства (varchar(100) в БД)
+var deviceIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.:-]{0,99}$`)
+
 // NewMQTTStreamProcessor создает новый процессор потоковых данных
 func NewMQTTStreamProcessor(
 	sessionManager *SessionManager,
 	grpcStreamer *GRPCStreamer,
 	dataBuffer *DataBuffer,
+	registry *channels.Registry,
+	filterBank *filters.Bank,
+	reorderBank *reorder.Bank,
+	clockBank *clock.Bank,
+	coincidenceBank *coincidence.Bank,
+	figoBank *figo.Bank,
+	qualityBank *quality.Bank,
+	alarms *AlarmManager,
+	ingestSpool *spool.Spool,
+	segments SegmentConfig,
 ) *MQTTStreamProcessor {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	processor := &MQTTStreamProcessor{
-		sessionManager: sessionManager,
-		grpcStreamer:   grpcStreamer,
-		dataBuffer:     dataBuffer,
-		spikeFilter:    NewSpikeDetectionFilter(),
-		dataChannel:    make(chan *models.MedicalData, 1000),
-		grpcChannel:    make(chan *pb.CTGDataResponse, 1000),
-		ctx:            ctx,
-		cancel:         cancel,
+		sessionManager:  sessionManager,
+		grpcStreamer:    grpcStreamer,
+		dataBuffer:      dataBuffer,
+		channels:        registry,
+		filterBank:      filterBank,
+		backfillBank:    filterBank.Fork(),
+		reorderBank:     reorderBank,
+		clockBank:       clockBank,
+		coincidenceBank: coincidenceBank,
+		figoBank:        figoBank,
+		qualityBank:     qualityBank,
+		alarms:          alarms,
+		spool:           ingestSpool,
+		segments:        segments,
+		deviceStreams:   make(map[string]*deviceStream),
+		grpcChannel:     make(chan *pb.CTGDataResponse, 1000),
+		ctx:             ctx,
+		cancel:          cancel,
 	}
 
 	// Запуск воркеров
-	processor.wg.Add(3)
-	go processor.dataWorker()
+	processor.wg.Add(2)
 	go processor.grpcWorker()
 	go processor.bufferWorker()
 
-	log.Println("MQTT Stream Processor со СПЕЦИАЛЬНОЙ фильтрацией единичных выбросов запущен")
+	log.Println("MQTT Stream Processor с фильтрацией артефактов по каналам устройств запущен")
 	return processor
 }
 
-// HandleIncomingMQTT главный обработчик MQTT сообщений
+// HandleIncomingMQTT главный обработчик MQTT сообщений.
+// Сообщение сначала записывается в спул и подтверждается только после записи в БД.
 func (p *MQTTStreamProcessor) HandleIncomingMQTT(topic string, payload []byte) {
+	var seq uint64
+	if p.spool != nil {
+		var err error
+		if seq, err = p.spool.Append(topic, payload); err != nil {
+			log.Printf("Не удалось записать сообщение в спул: %v", err)
+		}
+	}
+
+	p.dispatch(topic, payload, seq, time.Now())
+}
+
+// ReplaySpool повторно обрабатывает сообщения, не записанные в БД до перезапуска.
+// Вызывается до подписки на MQTT.
+func (p *MQTTStreamProcessor) ReplaySpool() {
+	if p.spool == nil {
+		return
+	}
+
+	records := p.spool.Pending()
+	if len(records) == 0 {
+		return
+	}
+
+	log.Printf("Восстановление из спула: %d сообщений", len(records))
+	for _, record := range records {
+		p.dispatch(record.Topic, record.Payload, record.Seq, time.Time{})
+	}
+}
+
+// dispatch разбирает сообщение и передает его в поток устройства.
+// received - время приема сообщения (пусто для сообщений из спула).
+func (p *MQTTStreamProcessor) dispatch(topic string, payload []byte, seq uint64, received time.Time) {
 	parts := strings.Split(topic, "/")
-	if len(parts) != 4 {
+
+	// medical/ctg/backfill/<type>/<device> - выгрузка накопленных устройством данных
+	backfill := len(parts) == 5 && parts[2] == backfillTopicSegment
+	if backfill {
+		parts = append(parts[:2], parts[3:]...)
+	}
+
+	if len(parts) != 4 || parts[0] != "medical" || parts[1] != "ctg" {
 		log.Printf("Неверный формат топика: %s", topic)
+		p.ackSpool(seq)
 		return
 	}
 
@@ -109,282 +171,297 @@ func (p *MQTTStreamProcessor) HandleIncomingMQTT(topic string, payload []byte) {
 	var data models.MedicalData
 	if err := json.Unmarshal(payload, &data); err != nil {
 		log.Printf("Ошибка парсинга MQTT payload: %v", err)
+		p.ackSpool(seq)
+		return
+	}
+
+	deviceID, err := resolveDeviceID(parts[3], data.DeviceID)
+	if err != nil {
+		log.Printf("Сообщение из топика %s отклонено: %v", topic, err)
+		p.ackSpool(seq)
 		return
 	}
 
-	data.DeviceID = p.sessionManager.GetAllDevices()[0]
+	data.Seq = seq
+	data.Received = received
+	data.Backfill = data.Backfill || backfill
+
+	data.DeviceID = deviceID
 	if data.DataType == "" {
 		data.DataType = dataType
 	}
 
-	select {
-	case p.dataChannel <- &data:
-	default:
-		log.Printf("Канал данных переполнен, пропускаем сообщение")
+	if _, ok := p.channels.Lookup(data.DataType); !ok {
+		log.Printf("Канал %s не зарегистрирован, сообщение из топика %s отклонено", data.DataType, topic)
+		p.ackSpool(seq)
+		return
 	}
-}
 
-// MessageHandler обработчик MQTT сообщений (глобальная функция)
-func MessageHandler(client mqtt.Client, msg mqtt.Message) {
-	log.Printf("MQTT сообщение получено: %s", msg.Topic())
-}
+	stream := p.getDeviceStream(deviceID)
+	if stream == nil {
+		return
+	}
 
-// dataWorker обрабатывает входящие данные
-func (p *MQTTStreamProcessor) dataWorker() {
-	defer p.wg.Done()
-	for {
+	// При переполнении ждем, а не отбрасываем данные: MQTT брокер придержит сообщения
+	select {
+	case stream.dataChannel <- &data:
+	default:
+		log.Printf("Канал данных устройства %s переполнен, ожидаем освобождения", deviceID)
 		select {
-		case data := <-p.dataChannel:
-			p.processData(data)
+		case stream.dataChannel <- &data:
 		case <-p.ctx.Done():
-			log.Println("Data worker остановлен")
-			return
+			// Сообщение останется в спуле и будет обработано после перезапуска
 		}
 	}
 }
 
-// processData обрабатывает одну точку данных со специальной фильтрацией спайков
-func (p *MQTTStreamProcessor) processData(data *models.MedicalData) {
-	session := p.sessionManager.GetActiveSession(data.DeviceID)
-	if session == nil {
-		cardID := uuid.New()
-		var err error
-		session, err = p.sessionManager.StartSession(cardID, data.DeviceID)
-		if err != nil {
-			log.Printf("Ошибка создания автосессии для %s: %v", data.DeviceID, err)
-			return
-		}
-		log.Printf("Автоматически создана сессия для устройства: %s", data.DeviceID)
+// ackSpool подтверждает запись спула, которую не нужно сохранять в БД
+func (p *MQTTStreamProcessor) ackSpool(seq uint64) {
+	if p.spool == nil || seq == 0 {
+		return
 	}
-
-	originalValue := data.Value
-
-	isSpike := p.spikeFilter.DetectSingleSpike(data.DataType, data.Value)
-
-	if isSpike {
-		interpolatedValue := p.spikeFilter.InterpolateValue(data.DataType)
-		data.Value = interpolatedValue
-		log.Printf("ЕДИНИЧНЫЙ ВЫБРОС обнаружен и исправлен %s: %.2f -> %.2f",
-			data.DataType, originalValue, interpolatedValue)
+	if err := p.spool.Ack(seq); err != nil {
+		log.Printf("Ошибка подтверждения записи спула %d: %v", seq, err)
 	}
+}
 
-	if !p.isValidDataRange(data) {
-		data.Value = -1
-		log.Printf("Значение вне допустимого диапазона %s: %.2f -> -1",
-			data.DataType, originalValue)
+// resolveDeviceID определяет устройство по сегменту топика и полю payload.
+// Если указаны оба, они должны совпадать.
+func resolveDeviceID(topicDeviceID, payloadDeviceID string) (string, error) {
+	deviceID := topicDeviceID
+	if deviceID == "" {
+		deviceID = payloadDeviceID
 	}
-
-	// 4. Отправляем в gRPC стрим
-	grpcData := &pb.CTGDataResponse{
-		DeviceId: data.DeviceID,
-		DataType: data.DataType,
-		Value:    data.Value,
-		TimeSec:  data.TimeSec,
+	if payloadDeviceID != "" && payloadDeviceID != deviceID {
+		return "", fmt.Errorf("device_id в payload (%s) не совпадает с топиком (%s)", payloadDeviceID, deviceID)
 	}
-
-	select {
-	case p.grpcChannel <- grpcData:
-	default:
-		log.Printf("gRPC канал переполнен для устройства %s", data.DeviceID)
+	if !deviceIDPattern.MatchString(deviceID) {
+		return "", fmt.Errorf("недопустимый идентификатор устройства %q", deviceID)
 	}
-
-	// 5. Добавляем в буфер для записи в БД
-	p.dataBuffer.AddDataPoint(session.ID, data.DataType, data.Value, data.TimeSec)
+	return deviceID, nil
 }
 
-func (sf *SpikeDetectionFilter) DetectSingleSpike(dataType string, value float64) bool {
-	sf.mu.Lock()
-	defer sf.mu.Unlock()
-
-	sf.totalProcessed++
-
-	var buffer *[]float64
-	switch dataType {
-	case "fetal_heart_rate":
-		buffer = &sf.fhrBuffer
-	case "uterine_contractions":
-		buffer = &sf.ucBuffer
-	default:
-		return false
-	}
-
-	// Добавляем новое значение
-	*buffer = append(*buffer, value)
-	if len(*buffer) > sf.bufferSize {
-		*buffer = (*buffer)[1:]
-	}
+// getDeviceStream возвращает поток устройства, создавая его при первом сообщении
+func (p *MQTTStreamProcessor) getDeviceStream(deviceID string) *deviceStream {
+	p.streamsMu.Lock()
+	defer p.streamsMu.Unlock()
 
-	if len(*buffer) < 7 {
-		return false
+	if p.ctx.Err() != nil {
+		return nil
 	}
 
-	analyzeIndex := len(*buffer) - 2
-	if analyzeIndex < sf.contextWindow {
-		return false
-	}
-
-	currentValue := (*buffer)[analyzeIndex]
-
-	// Анализируем контекст вокруг точки
-	beforeValues := make([]float64, 0, sf.contextWindow)
-	afterValues := make([]float64, 0, sf.contextWindow)
-
-	for i := analyzeIndex - sf.contextWindow; i < analyzeIndex; i++ {
-		if i >= 0 {
-			beforeValues = append(beforeValues, (*buffer)[i])
+	stream, exists := p.deviceStreams[deviceID]
+	if !exists {
+		stream = &deviceStream{
+			deviceID:    deviceID,
+			dataChannel: make(chan *models.MedicalData, 1000),
+			timeBase:    newTimeBase(),
 		}
-	}
-
-	for i := analyzeIndex + 1; i <= analyzeIndex+sf.contextWindow && i < len(*buffer); i++ {
-		afterValues = append(afterValues, (*buffer)[i])
-	}
+		p.deviceStreams[deviceID] = stream
 
-	if len(beforeValues) < 2 || len(afterValues) < 2 {
-		return false
+		p.wg.Add(1)
+		go p.deviceWorker(stream)
+		log.Printf("Создан поток обработки для устройства %s", deviceID)
 	}
+	return stream
+}
 
-	beforeMean := sf.calculateMean(beforeValues)
-	afterMean := sf.calculateMean(afterValues)
-	contextMean := (beforeMean + afterMean) / 2.0
-
-	contextStd := sf.calculateStd(append(beforeValues, afterValues...), contextMean)
+// GetFilterStats возвращает статистику фильтров артефактов по каналам устройств
+func (p *MQTTStreamProcessor) GetFilterStats() []filters.ChannelStats {
+	return p.filterBank.Stats()
+}
 
-	deviation := math.Abs(currentValue - contextMean)
+// GetReorderStats возвращает счетчики буферов упорядочивания по каналам устройств
+func (p *MQTTStreamProcessor) GetReorderStats() []reorder.ChannelStats {
+	return p.reorderBank.Stats()
+}
 
-	isDeviantFromContext := deviation > sf.spikeDeviation
+// GetClockStats возвращает оценки смещения и дрейфа часов устройств
+func (p *MQTTStreamProcessor) GetClockStats() []clock.DeviceStats {
+	return p.clockBank.Stats()
+}
 
-	beforeAfterDiff := math.Abs(beforeMean - afterMean)
-	isContextStable := beforeAfterDiff < sf.spikeDeviation/2.0
+// GetDeviceStreamIDs возвращает устройства, от которых поступают данные
+func (p *MQTTStreamProcessor) GetDeviceStreamIDs() []string {
+	p.streamsMu.Lock()
+	defer p.streamsMu.Unlock()
 
-	isStatisticallySignificant := true
-	if contextStd > 0 {
-		zScore := deviation / contextStd
-		isStatisticallySignificant = zScore > 2.0
+	deviceIDs := make([]string, 0, len(p.deviceStreams))
+	for deviceID := range p.deviceStreams {
+		deviceIDs = append(deviceIDs, deviceID)
 	}
+	return deviceIDs
+}
+
+// MessageHandler обработчик MQTT сообщений (глобальная функция)
+func MessageHandler(client mqtt.Client, msg mqtt.Message) {
+	log.Printf("MQTT сообщение получено: %s", msg.Topic())
+}
 
-	isIsolatedSpike := sf.checkIsolation(beforeValues, afterValues, currentValue)
+// reorderTick период проверки точек, слишком долго ожидающих в буфере упорядочивания
+const reorderTick = 250 * time.Millisecond
 
-	isSpike := isDeviantFromContext && isContextStable && isStatisticallySignificant && isIsolatedSpike
+// deviceWorker последовательно обрабатывает данные одного устройства.
+// Точки сначала проходят буфер упорядочивания по TimeSec.
+func (p *MQTTStreamProcessor) deviceWorker(stream *deviceStream) {
+	defer p.wg.Done()
 
-	if isSpike {
-		sf.spikesDetected++
-		log.Printf("ДЕТЕКЦИЯ СПАЙКА %s:")
-		log.Printf("Значение: %.2f, Контекст: %.2f (отклонение: %.2f)")
-		log.Printf("До спайка: %.2f, После спайка: %.2f (разность: %.2f)")
-		log.Printf("Z-score: %.2f, Изолированный: %v")
+	ticker := time.NewTicker(reorderTick)
+	defer ticker.Stop()
 
-		// Обновляем статистику
-		if sf.totalProcessed%100 == 0 {
-			log.Printf("Статистика фильтрации: %d/%d (%.1f%% спайков)",
-				sf.spikesDetected, sf.totalProcessed,
-				float64(sf.spikesDetected)/float64(sf.totalProcessed)*100)
+	for {
+		select {
+		case data := <-stream.dataChannel:
+			if data.Backfill {
+				// Исторические точки минуют живой конвейер
+				p.processBackfill(stream, data)
+				continue
+			}
+			ready, duplicate := p.reorderBank.Push(data, time.Now())
+			if duplicate {
+				p.ackSpool(data.Seq)
+			}
+			p.processSamples(stream, ready)
+		case <-ticker.C:
+			p.processSamples(stream, p.reorderBank.FlushDevice(stream.deviceID, time.Now()))
+			if stream.backfill != nil && time.Since(stream.backfill.resolved) > backfillTargetTTL {
+				p.releaseBackfillTarget(stream)
+			}
+		case <-p.ctx.Done():
+			p.processSamples(stream, p.reorderBank.DrainDevice(stream.deviceID))
+			log.Printf("Поток устройства %s остановлен", stream.deviceID)
+			return
 		}
 	}
-
-	return isSpike
 }
 
-// InterpolateValue создает интерполированное значение вместо спайка
-func (sf *SpikeDetectionFilter) InterpolateValue(dataType string) float64 {
-	sf.mu.RLock()
-	defer sf.mu.RUnlock()
-
-	var buffer []float64
-	switch dataType {
-	case "fetal_heart_rate":
-		buffer = sf.fhrBuffer
-	case "uterine_contractions":
-		buffer = sf.ucBuffer
-	default:
-		return -1
+// processSamples обрабатывает точки, выпущенные буфером упорядочивания
+func (p *MQTTStreamProcessor) processSamples(stream *deviceStream, samples []reorder.Sample) {
+	for _, sample := range samples {
+		p.processData(stream, sample)
 	}
+}
 
-	if len(buffer) < 4 {
-		return -1
+// processData обрабатывает одну точку данных со специальной фильтрацией спайков.
+// Опоздавшие точки (Late) не проходят фильтры, чтобы не нарушать их историю.
+func (p *MQTTStreamProcessor) processData(stream *deviceStream, sample reorder.Sample) {
+	data := sample.Data
+
+	// Новый сигнал устройства (нет ни сессии, ни данных без карты): история фильтров
+	// относится к предыдущему пациенту
+	if p.sessionManager.isDeviceIdle(data.DeviceID) {
+		p.filterBank.ResetDevice(data.DeviceID)
+		p.coincidenceBank.ResetDevice(data.DeviceID)
+		p.figoBank.ResetDevice(data.DeviceID)
+		p.qualityBank.ResetDevice(data.DeviceID)
+		p.alarms.resetDevice(data.DeviceID)
+	}
+
+	// Время точки на шкале сессии с учетом сбросов time_sec устройства
+	sessionTime, ok := p.applyTimeBase(stream, data, sample.Reset)
+	if !ok {
+		p.ackSpool(data.Seq)
+		return
 	}
 
-	// Берем 2 точки до спайка и 2 точки после для интерполяции
-	analyzeIndex := len(buffer) - 2 // Предпоследняя точка (спайк)
-
-	if analyzeIndex < 2 || analyzeIndex >= len(buffer)-2 {
-		return -1
+	bank := p.filterBank
+	var flags models.SampleFlags
+	if sample.Late {
+		bank = nil
+		flags |= models.FlagLate
 	}
+	point := p.cleanSample(bank, data, flags)
+	point.T = sessionTime
+	point.W = p.wallTime(stream, data, sessionTime).UnixMilli()
+	if !sample.Late {
+		point.F |= p.checkCoincidence(data.DeviceID, data.DataType, point)
+	}
+	flags = point.F
+	originalValue := point.Raw()
 
-	// Линейная интерполяция между соседними стабильными точками
-	beforeValue := buffer[analyzeIndex-1]
-	afterValue := buffer[analyzeIndex+1]
-
-	// Простая линейная интерполяция
-	interpolated := (beforeValue + afterValue) / 2.0
-
-	// Дополнительно учитываем тренд
-	if analyzeIndex >= 3 && analyzeIndex < len(buffer)-2 {
-		trendBefore := buffer[analyzeIndex-1] - buffer[analyzeIndex-2]
-		trendAfter := buffer[analyzeIndex+2] - buffer[analyzeIndex+1]
-		avgTrend := (trendBefore + trendAfter) / 2.0
+	// 4. Добавляем в буфер сессии для записи в БД; без сессии точка ждет привязки к карте
+	assigned := p.sessionManager.RouteDataPoint(data.DeviceID, data.DataType, point, data.Seq)
 
-		// Корректируем интерполяцию с учетом тренда
-		interpolated += avgTrend * 0.1 // Небольшая коррекция на тренд
+	channel, _ := p.channels.Lookup(data.DataType)
+	if !sample.Late {
+		p.observeQuality(data.DeviceID, channel, point)
+		p.analyzeSample(data.DeviceID, channel, point)
 	}
 
-	return interpolated
-}
-
-// calculateMean вычисляет среднее значение
-func (sf *SpikeDetectionFilter) calculateMean(values []float64) float64 {
-	if len(values) == 0 {
-		return 0
+	// 5. Отправляем в gRPC стрим (непривязанные устройства тоже видны в реальном времени)
+	grpcData := &pb.CTGDataResponse{
+		DeviceId:   data.DeviceID,
+		DataType:   data.DataType,
+		Value:      data.Value,
+		TimeSec:    sessionTime,
+		RawValue:   originalValue,
+		Flags:      flags.Names(),
+		Unassigned: !assigned,
+		Timestamp:  point.W,
+		Fetus:      int32(channel.Fetus),
 	}
 
-	sum := 0.0
-	for _, v := range values {
-		sum += v
+	select {
+	case p.grpcChannel <- grpcData:
+	default:
+		log.Printf("gRPC канал переполнен для устройства %s, ожидаем освобождения", data.DeviceID)
+		select {
+		case p.grpcChannel <- grpcData:
+		case <-p.ctx.Done():
+		}
 	}
-	return sum / float64(len(values))
 }
 
-// calculateStd вычисляет стандартное отклонение
-func (sf *SpikeDetectionFilter) calculateStd(values []float64, mean float64) float64 {
-	if len(values) <= 1 {
-		return 0
-	}
+// cleanSample проверяет значение точки: помечает потерю сигнала, прогоняет через
+// фильтры артефактов (bank может быть nil) и проверяет диапазон.
+// Возвращает точку с исходным time_sec; data.Value заменяется очищенным значением.
+func (p *MQTTStreamProcessor) cleanSample(bank *filters.Bank, data *models.MedicalData, flags models.SampleFlags) models.CTGPoint {
+	originalValue := data.Value
 
-	variance := 0.0
-	for _, v := range values {
-		variance += math.Pow(v-mean, 2)
+	if originalValue == -1 {
+		flags |= models.FlagSignalLoss
 	}
-	return math.Sqrt(variance / float64(len(values)-1))
-}
 
-// checkIsolation проверяет, является ли спайк изолированным (соседние точки не спайки)
-func (sf *SpikeDetectionFilter) checkIsolation(beforeValues, afterValues []float64, spikeValue float64) bool {
-	if len(beforeValues) == 0 || len(afterValues) == 0 {
-		return false
+	if bank != nil {
+		if cleanValue, triggered := bank.Apply(data.DeviceID, data.DataType, data.Value); len(triggered) > 0 {
+			data.Value = cleanValue
+			for _, name := range triggered {
+				flags |= filterFlags[name]
+			}
+			log.Printf("Артефакт %v обнаружен и исправлен %s/%s: %.2f -> %.2f",
+				triggered, data.DeviceID, data.DataType, originalValue, cleanValue)
+		}
 	}
 
-	lastBefore := beforeValues[len(beforeValues)-1]
-	firstAfter := afterValues[0]
-
-	allContext := append(beforeValues, afterValues...)
-	contextMean := sf.calculateMean(allContext)
+	if !p.isValidDataRange(data) {
+		data.Value = -1
+		flags |= models.FlagOutOfRange
+		log.Printf("Значение вне допустимого диапазона %s: %.2f -> -1",
+			data.DataType, originalValue)
+	}
 
-	beforeDeviation := math.Abs(lastBefore - contextMean)
-	afterDeviation := math.Abs(firstAfter - contextMean)
-	spikeDeviation := math.Abs(spikeValue - contextMean)
+	point := models.CTGPoint{
+		T: data.TimeSec,
+		V: data.Value,
+		F: flags,
+	}
+	if data.Value != originalValue {
+		point.R = &originalValue
+	}
+	return point
+}
 
-	return beforeDeviation < spikeDeviation/2.0 && afterDeviation < spikeDeviation/2.0
+// filterFlags флаги точки для сработавших фильтров артефактов
+var filterFlags = map[string]models.SampleFlags{
+	filters.FilterSpike:   models.FlagSpike,
+	filters.FilterHampel:  models.FlagOutlier,
+	filters.FilterDoppler: models.FlagDoppler,
 }
 
-// isValidDataRange базовая проверка диапазонов
+// isValidDataRange проверка по допустимому диапазону канала
 func (p *MQTTStreamProcessor) isValidDataRange(data *models.MedicalData) bool {
-	switch data.DataType {
-	case "fetal_heart_rate":
-		return data.Value == -1 || (data.Value >= 50 && data.Value <= 220)
-	case "uterine_contractions":
-		return data.Value == -1 || (data.Value >= -5 && data.Value <= 150)
-	default:
-		return true
-	}
+	channel, ok := p.channels.Lookup(data.DataType)
+	return !ok || channel.InRange(data.Value)
 }
 
 // grpcWorker отправляет данные в gRPC стрим
@@ -425,9 +502,11 @@ func (p *MQTTStreamProcessor) bufferWorker() {
 // Stop останавливает процессор
 func (p *MQTTStreamProcessor) Stop() {
 	log.Println("Остановка MQTT Stream Processor...")
+	// Под блокировкой, чтобы после отмены не создавались новые потоки устройств
+	p.streamsMu.Lock()
 	p.cancel()
+	p.streamsMu.Unlock()
 	p.wg.Wait()
-	close(p.dataChannel)
 	close(p.grpcChannel)
 	log.Println("MQTT Stream Processor остановлен")
 }
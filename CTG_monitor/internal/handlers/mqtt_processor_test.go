@@ -0,0 +1,1202 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"CTG_monitor/internal/alarms"
+	"CTG_monitor/internal/channels"
+	"CTG_monitor/internal/chunks"
+	"CTG_monitor/internal/clock"
+	"CTG_monitor/internal/coincidence"
+	"CTG_monitor/internal/figo"
+	"CTG_monitor/internal/filters"
+	"CTG_monitor/internal/models"
+	"CTG_monitor/internal/protocols"
+	"CTG_monitor/internal/quality"
+	"CTG_monitor/internal/reorder"
+	"CTG_monitor/internal/spool"
+	pb "CTG_monitor/proto"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newDryRunDB создает GORM без подключения к Postgres: запросы только собираются
+func newDryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{
+		DSN: "host=127.0.0.1 user=test password=test dbname=test port=5432 sslmode=disable",
+	}), &gorm.Config{
+		DryRun:                 true,
+		SkipDefaultTransaction: true,
+		DisableAutomaticPing:   true,
+		Logger:                 logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("не удалось создать dry-run БД: %v", err)
+	}
+	return db
+}
+
+// fakeCTGStream собирает всё, что сервер отправил клиенту StreamCTGData
+type fakeCTGStream struct {
+	grpc.ServerStream
+	ctx context.Context
+
+	mu       sync.Mutex
+	received []*pb.CTGDataResponse
+}
+
+func (s *fakeCTGStream) Context() context.Context { return s.ctx }
+
+func (s *fakeCTGStream) Send(data *pb.CTGDataResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.received = append(s.received, data)
+	return nil
+}
+
+func (s *fakeCTGStream) countByDevice() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := make(map[string]int)
+	for _, data := range s.received {
+		counts[data.DeviceId]++
+	}
+	return counts
+}
+
+// testPipeline процессор с зависимостями и подписанным gRPC клиентом
+type testPipeline struct {
+	db             *gorm.DB
+	spool          *spool.Spool
+	dataBuffer     *DataBuffer
+	sessionManager *SessionManager
+	grpcStreamer   *GRPCStreamer
+	alarmManager   *AlarmManager
+	processor      *MQTTStreamProcessor
+	stream         *fakeCTGStream
+}
+
+func newTestPipeline(t *testing.T, segments SegmentConfig) *testPipeline {
+	t.Helper()
+
+	db := newDryRunDB(t)
+	ingestSpool, err := spool.Open(spool.Config{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("не удалось открыть спул: %v", err)
+	}
+
+	registry := channels.NewRegistry(channels.Defaults(), map[string][]string{
+		channels.FetalHeartRate:      {filters.FilterHampel, filters.FilterDoppler},
+		channels.UterineContractions: {filters.FilterHampel},
+	})
+	dataBuffer := NewDataBuffer(db, ingestSpool, registry, chunks.DefaultFormat)
+	sessionManager := NewSessionManager(db, dataBuffer)
+	grpcStreamer := NewGRPCStreamer(sessionManager)
+	filterBank := filters.NewBank(registry.FilterChains())
+	reorderBank := reorder.NewBank(reorder.Config{
+		Window:         time.Second,
+		MaxHold:        200 * time.Millisecond,
+		History:        30 * time.Second,
+		ResetThreshold: 5 * time.Second,
+	})
+	clockBank := clock.NewBank(clock.Config{Window: 10 * time.Minute})
+	coincidenceBank := coincidence.NewBank(coincidence.Config{
+		Window:    time.Minute,
+		Tolerance: 5,
+		Ratio:     0.8,
+	}, coincidence.DefaultPairs())
+	alarmManager := NewAlarmManager(db, alarms.NewEngine(alarms.Config{
+		RateSustain: 30 * time.Second,
+		SignalLoss:  20 * time.Second,
+	}), sessionManager, grpcStreamer)
+	processor := NewMQTTStreamProcessor(sessionManager, grpcStreamer, dataBuffer, registry, filterBank,
+		reorderBank, clockBank, coincidenceBank, figo.NewBank(figo.Config{}),
+		quality.NewBank(quality.Config{Window: time.Minute, Interval: 10 * time.Second}), alarmManager, ingestSpool, segments)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &fakeCTGStream{ctx: ctx}
+	go grpcStreamer.StreamCTGData(&pb.StreamRequest{}, stream)
+
+	t.Cleanup(func() {
+		cancel()
+		processor.Stop()
+		alarmManager.Stop()
+		grpcStreamer.Stop()
+		dataBuffer.Stop()
+		ingestSpool.Close()
+	})
+
+	// Ждем регистрации подписчика
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		grpcStreamer.mu.RLock()
+		subscribed := len(grpcStreamer.subscribers) == 1
+		grpcStreamer.mu.RUnlock()
+		if subscribed {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("подписчик gRPC не зарегистрирован")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return &testPipeline{
+		db:             db,
+		spool:          ingestSpool,
+		dataBuffer:     dataBuffer,
+		sessionManager: sessionManager,
+		grpcStreamer:   grpcStreamer,
+		alarmManager:   alarmManager,
+		processor:      processor,
+		stream:         stream,
+	}
+}
+
+// waitForPoints ждет, пока клиент получит указанное число точек по каждому устройству
+func (tp *testPipeline) waitForPoints(t *testing.T, devices, perDevice int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		counts := tp.stream.countByDevice()
+		done := len(counts) == devices
+		for _, count := range counts {
+			done = done && count == perDevice
+		}
+		if done {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("получены не все точки: %v", counts)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestMQTTStreamProcessorConcurrentDevices(t *testing.T) {
+	tp := newTestPipeline(t, SegmentConfig{Policy: SegmentPolicyBoundary, MaxGap: 5 * time.Minute})
+	processor, sessionManager, stream := tp.processor, tp.sessionManager, tp.stream
+	dataBuffer, ingestSpool := tp.dataBuffer, tp.spool
+
+	const devices = 5
+	const samples = 50
+
+	var wg sync.WaitGroup
+	for d := 0; d < devices; d++ {
+		deviceID := fmt.Sprintf("CTG-DEVICE-%03d", d)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < samples; i++ {
+				for _, dataType := range []string{"fetal_heart_rate", "uterine_contractions"} {
+					value := 140.0
+					if dataType == "uterine_contractions" {
+						value = 20.0
+					}
+					payload, _ := json.Marshal(models.MedicalData{
+						DeviceID: deviceID,
+						DataType: dataType,
+						Value:    value,
+						TimeSec:  float64(i) * 0.25,
+					})
+					processor.HandleIncomingMQTT("medical/ctg/"+dataType+"/"+deviceID, payload)
+					// QoS 1: брокер может доставить сообщение повторно
+					if i%10 == 0 {
+						processor.HandleIncomingMQTT("medical/ctg/"+dataType+"/"+deviceID, payload)
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Сообщение с чужим device_id в payload должно быть отклонено
+	spoofed, _ := json.Marshal(models.MedicalData{DeviceID: "CTG-DEVICE-000", Value: 140})
+	processor.HandleIncomingMQTT("medical/ctg/fetal_heart_rate/CTG-DEVICE-001", spoofed)
+	processor.HandleIncomingMQTT("medical/ctg/fetal_heart_rate/#", spoofed)
+
+	tp.waitForPoints(t, devices, samples*2)
+
+	// Повторные доставки отброшены буфером упорядочивания
+	duplicates := 0
+	for _, channel := range processor.GetReorderStats() {
+		duplicates += channel.Duplicates
+	}
+	if want := devices * 2 * samples / 10; duplicates != want {
+		t.Fatalf("ожидалось %d дублей, получено %d", want, duplicates)
+	}
+
+	// Без привязки к карте сессии не создаются, данные ждут привязки
+	if count := sessionManager.GetActiveSessionCount(); count != 0 {
+		t.Fatalf("сессии созданы без привязки к карте: %d", count)
+	}
+	stream.mu.Lock()
+	for _, data := range stream.received {
+		if !data.Unassigned {
+			t.Fatalf("точка непривязанного устройства %s не помечена", data.DeviceId)
+		}
+	}
+	stream.mu.Unlock()
+	unassigned := sessionManager.GetUnassignedDevices()
+	if len(unassigned) != devices {
+		t.Fatalf("ожидалось %d непривязанных устройств, получено %d", devices, len(unassigned))
+	}
+	for _, device := range unassigned {
+		if device.PendingPoints != samples*2 {
+			t.Fatalf("устройство %s: ожидалось %d точек, получено %d",
+				device.DeviceID, samples*2, device.PendingPoints)
+		}
+	}
+
+	// Четные устройства сохраняют накопленные данные, нечетные отбрасывают
+	for d := 0; d < devices; d++ {
+		deviceID := fmt.Sprintf("CTG-DEVICE-%03d", d)
+		keep := d%2 == 0
+		result, err := sessionManager.BindDevice(deviceID, uuid.New(), keep)
+		if err != nil {
+			t.Fatalf("не удалось привязать %s: %v", deviceID, err)
+		}
+		if keep && result.KeptPoints != samples*2 || !keep && result.DiscardedPoints != samples*2 {
+			t.Fatalf("%s: неверный результат привязки %+v", deviceID, result)
+		}
+	}
+	if _, err := sessionManager.BindDevice("CTG-DEVICE-000", uuid.New(), true); !errors.Is(err, ErrDeviceHasActiveSession) {
+		t.Fatalf("повторная привязка должна завершиться ошибкой, получено %v", err)
+	}
+	if len(sessionManager.GetUnassignedDevices()) != 0 {
+		t.Fatal("после привязки остались непривязанные устройства")
+	}
+
+	sessions := sessionManager.GetAllActiveSessions()
+	if len(sessions) != devices {
+		t.Fatalf("ожидалось %d активных сессий, получено %d", devices, len(sessions))
+	}
+
+	seenSessions := make(map[string]bool)
+	for _, session := range sessions {
+		if seenSessions[session.ID.String()] {
+			t.Fatalf("сессия %s общая для нескольких устройств", session.ID)
+		}
+		seenSessions[session.ID.String()] = true
+	}
+
+	if streams := processor.GetDeviceStreamIDs(); len(streams) != devices {
+		t.Fatalf("ожидалось %d потоков устройств, получено %d", devices, len(streams))
+	}
+
+	// После записи в БД и отказа от данных все сообщения спула должны быть подтверждены
+	dataBuffer.FlushAll()
+	if pending := ingestSpool.PendingCount(); pending != 0 {
+		t.Fatalf("в спуле осталось %d неподтвержденных сообщений", pending)
+	}
+}
+
+func TestTimeBaseResetStartsSegment(t *testing.T) {
+	for _, policy := range []string{SegmentPolicyBoundary, SegmentPolicySplit} {
+		t.Run(policy, func(t *testing.T) {
+			tp := newTestPipeline(t, SegmentConfig{Policy: policy, MaxGap: 5 * time.Minute})
+			stored := make(chan uuid.UUID, 10)
+			tp.sessionManager.SetStoredCallback(func(sessionID uuid.UUID) { stored <- sessionID })
+
+			const deviceID = "CTG-DEVICE-RESET"
+			bound, err := tp.sessionManager.BindDevice(deviceID, uuid.New(), false)
+			if err != nil {
+				t.Fatalf("не удалось привязать устройство: %v", err)
+			}
+
+			// 10 секунд сигнала, затем монитор перезапускается и time_sec снова идет с нуля
+			var times []float64
+			for i := 0; i < 40; i++ {
+				times = append(times, float64(i)*0.25)
+			}
+			for i := 0; i < 20; i++ {
+				times = append(times, float64(i)*0.25)
+			}
+			for _, timeSec := range times {
+				for _, dataType := range []string{"fetal_heart_rate", "uterine_contractions"} {
+					payload, _ := json.Marshal(models.MedicalData{DataType: dataType, Value: 140, TimeSec: timeSec})
+					tp.processor.HandleIncomingMQTT("medical/ctg/"+dataType+"/"+deviceID, payload)
+				}
+			}
+			tp.waitForPoints(t, 1, len(times)*2)
+
+			// На шкале сессии время каждого канала возрастает
+			if policy == SegmentPolicyBoundary {
+				last := map[string]float64{}
+				tp.stream.mu.Lock()
+				for _, data := range tp.stream.received {
+					if prev, ok := last[data.DataType]; ok && data.TimeSec <= prev {
+						t.Errorf("%s: время %.2f после %.2f", data.DataType, data.TimeSec, prev)
+					}
+					last[data.DataType] = data.TimeSec
+				}
+				tp.stream.mu.Unlock()
+			}
+
+			session := tp.sessionManager.GetActiveSession(deviceID)
+			switch policy {
+			case SegmentPolicyBoundary:
+				if session.ID != bound.Session.ID {
+					t.Fatal("сессия не должна меняться при записи границы сегмента")
+				}
+				if len(session.Segments) != 2 || session.Segments[1].Reason != models.SegmentTimeReset {
+					t.Fatalf("ожидалась граница сегмента после сброса: %+v", session.Segments)
+				}
+				if session.Segments[1].Offset < 9.75 {
+					t.Fatalf("смещение второго сегмента %.2f перекрывает первый", session.Segments[1].Offset)
+				}
+			case SegmentPolicySplit:
+				if session.ContinuesFrom == nil || *session.ContinuesFrom != bound.Session.ID {
+					t.Fatalf("ожидалось продолжение сессии %s, получено %+v", bound.Session.ID, session.ContinuesFrom)
+				}
+				if session.CardID != bound.Session.CardID {
+					t.Fatal("продолжение сессии должно относиться к той же карте")
+				}
+				if len(session.Segments) != 1 || session.Segments[0].Reason != models.SegmentTimeReset {
+					t.Fatalf("неверные сегменты продолжения: %+v", session.Segments)
+				}
+				// Закрытая часть передается дальше один раз, после записи ее точек
+				select {
+				case sessionID := <-stored:
+					if sessionID != bound.Session.ID {
+						t.Errorf("передана сессия %s, ожидалась %s", sessionID, bound.Session.ID)
+					}
+				case <-time.After(2 * time.Second):
+					t.Fatal("закрытая часть сессии не передана")
+				}
+			}
+			if len(stored) != 0 {
+				t.Errorf("лишняя передача сессии: %s", <-stored)
+			}
+		})
+	}
+}
+
+func TestBackfillBypassesLiveStream(t *testing.T) {
+	tp := newTestPipeline(t, SegmentConfig{Policy: SegmentPolicyBoundary, MaxGap: 5 * time.Minute})
+
+	updates := make(chan SessionUpdate, 1)
+	tp.dataBuffer.SetBackfillCallback(func(update SessionUpdate) { updates <- update })
+
+	const deviceID = "CTG-DEVICE-BACKFILL"
+	bound, err := tp.sessionManager.BindDevice(deviceID, uuid.New(), false)
+	if err != nil {
+		t.Fatalf("не удалось привязать устройство: %v", err)
+	}
+
+	// Живой сигнал с 60-й секунды, затем выгрузка первой минуты, пропущенной без связи
+	const live, backfill = 20, 40
+	for i := 0; i < live; i++ {
+		payload, _ := json.Marshal(models.MedicalData{Value: 140, TimeSec: 60 + float64(i)*0.25})
+		tp.processor.HandleIncomingMQTT("medical/ctg/fetal_heart_rate/"+deviceID, payload)
+	}
+	for i := 0; i < backfill; i++ {
+		payload, _ := json.Marshal(models.MedicalData{Value: 135, TimeSec: float64(i) * 0.25})
+		tp.processor.HandleIncomingMQTT("medical/ctg/backfill/fetal_heart_rate/"+deviceID, payload)
+	}
+	tp.waitForPoints(t, 1, live)
+
+	// Выгруженные точки не попадают в живой поток
+	time.Sleep(2 * reorderTick)
+	if counts := tp.stream.countByDevice(); counts[deviceID] != live {
+		t.Fatalf("ожидалось %d живых точек, получено %d", live, counts[deviceID])
+	}
+
+	sessionBuffer := tp.dataBuffer.getSessionBuffer(bound.Session.ID)
+	sessionBuffer.mu.Lock()
+	queued := len(sessionBuffer.Backfill[channels.FetalHeartRate])
+	for _, point := range sessionBuffer.Backfill[channels.FetalHeartRate] {
+		if point.F&models.FlagBackfill == 0 {
+			t.Errorf("точка t=%.2f не помечена как выгруженная", point.T)
+		}
+	}
+	sessionBuffer.mu.Unlock()
+	if queued != backfill {
+		t.Fatalf("ожидалось %d выгруженных точек в буфере, получено %d", backfill, queued)
+	}
+
+	tp.dataBuffer.FlushAll()
+	select {
+	case update := <-updates:
+		if update.SessionID != bound.Session.ID || update.Points != backfill ||
+			update.FromTime != 0 || update.ToTime != float64(backfill-1)*0.25 {
+			t.Fatalf("неверное событие обновления сессии: %+v", update)
+		}
+	default:
+		t.Fatal("событие обновления сессии не отправлено")
+	}
+	if pending := tp.spool.PendingCount(); pending != 0 {
+		t.Fatalf("в спуле осталось %d неподтвержденных сообщений", pending)
+	}
+}
+
+func TestWallClockTimestamps(t *testing.T) {
+	tp := newTestPipeline(t, SegmentConfig{Policy: SegmentPolicyBoundary, MaxGap: 5 * time.Minute})
+
+	// Часы устройства отстают от сервера на 3 секунды
+	const deviceID = "CTG-DEVICE-CLOCK"
+	const skew = 3 * time.Second
+	const samples = 20
+	start := time.Now()
+	for i := 0; i < samples; i++ {
+		payload, _ := json.Marshal(models.MedicalData{
+			Value:     140,
+			TimeSec:   float64(i) * 0.25,
+			Timestamp: time.Now().Add(-skew).UnixNano(),
+		})
+		tp.processor.HandleIncomingMQTT("medical/ctg/fetal_heart_rate/"+deviceID, payload)
+	}
+	tp.waitForPoints(t, 1, samples)
+
+	tp.stream.mu.Lock()
+	for _, data := range tp.stream.received {
+		wall := time.UnixMilli(data.Timestamp)
+		if wall.Before(start.Add(-100*time.Millisecond)) || wall.After(time.Now()) {
+			t.Errorf("t=%.2f: абсолютное время %s вне интервала приема", data.TimeSec, wall)
+		}
+	}
+	tp.stream.mu.Unlock()
+
+	stats := tp.processor.GetClockStats()
+	if len(stats) != 1 || stats[0].OffsetMs < 3000 || stats[0].OffsetMs > 3100 {
+		t.Fatalf("неверная оценка смещения часов: %+v", stats)
+	}
+
+	// Выборка по абсолютному времени совпадает с выборкой по времени сессии
+	startTime := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	session := &models.CTGSession{
+		StartTime: startTime,
+		Segments: []models.CTGSegment{
+			{Index: 0, StartTime: startTime, Reason: models.SegmentStart},
+			{Index: 1, StartTime: startTime.Add(time.Minute), DeviceTimeSec: 0, Offset: 60, Reason: models.SegmentTimeReset},
+		},
+	}
+	var points []models.CTGPoint
+	for i := 0; i < 120; i++ {
+		points = append(points, models.CTGPoint{T: float64(i), V: 140})
+	}
+	from, to := 50.0, 70.0
+	fromTime, toTime := startTime.Add(50*time.Second), startTime.Add(70*time.Second)
+	relative := selectPoints(points, session, TimeRange{From: &from, To: &to})
+	wallClock := selectPoints(points, session, TimeRange{FromTime: &fromTime, ToTime: &toTime})
+	if len(relative) != 21 || len(wallClock) != len(relative) {
+		t.Fatalf("ожидалась 21 точка, по времени сессии %d, по абсолютному времени %d",
+			len(relative), len(wallClock))
+	}
+	for i := range relative {
+		if relative[i] != wallClock[i] {
+			t.Fatalf("выборки различаются: %+v и %+v", relative[i], wallClock[i])
+		}
+	}
+	if err := (TimeRange{From: &from, ToTime: &toTime}).Validate(); !errors.Is(err, ErrInvalidTimeRange) {
+		t.Fatalf("смешанный интервал должен быть отклонен, получено %v", err)
+	}
+}
+
+func TestChannelRegistryRouting(t *testing.T) {
+	tp := newTestPipeline(t, SegmentConfig{Policy: SegmentPolicyBoundary, MaxGap: 5 * time.Minute})
+
+	// Собираем блоки точек и сводки, которые буфер отправляет в БД
+	var chunksMu sync.Mutex
+	written := make(map[string]int)
+	var rollupRows []models.CTGRollup
+	tp.db.Callback().Create().After("gorm:create").Register("test:capture", func(tx *gorm.DB) {
+		chunksMu.Lock()
+		defer chunksMu.Unlock()
+		// CreateInBatches передает часть среза, а не указатель
+		if rows, ok := tx.Statement.Dest.([]models.CTGRollup); ok {
+			rollupRows = append(rollupRows, rows...)
+			return
+		}
+		rows, ok := tx.Statement.Dest.(*[]models.CTGChunk)
+		if !ok {
+			return
+		}
+		for _, row := range *rows {
+			points, err := chunks.Decode(row)
+			if err != nil {
+				t.Errorf("блок %s не разбирается: %v", row.Channel, err)
+			}
+			written[row.Channel] += len(points)
+		}
+	})
+
+	const deviceID = "CTG-DEVICE-CHANNELS"
+	if _, err := tp.sessionManager.BindDevice(deviceID, uuid.New(), false); err != nil {
+		t.Fatalf("не удалось привязать устройство: %v", err)
+	}
+
+	values := map[string]float64{
+		channels.FetalHeartRate:    140,
+		channels.MaternalHeartRate: 80,
+		channels.FetalMovement:     1,
+		"blood_glucose":            5.5, // не зарегистрирован
+	}
+	const samples = 8
+	for i := 0; i < samples; i++ {
+		for dataType, value := range values {
+			payload, _ := json.Marshal(models.MedicalData{Value: value, TimeSec: float64(i) * 0.25})
+			tp.processor.HandleIncomingMQTT("medical/ctg/"+dataType+"/"+deviceID, payload)
+		}
+	}
+	tp.waitForPoints(t, 1, samples*3)
+
+	tp.stream.mu.Lock()
+	for _, data := range tp.stream.received {
+		if data.DataType == "blood_glucose" {
+			t.Errorf("точка незарегистрированного канала передана клиенту")
+		}
+	}
+	tp.stream.mu.Unlock()
+
+	tp.dataBuffer.FlushAll()
+	chunksMu.Lock()
+	for _, name := range []string{channels.FetalHeartRate, channels.MaternalHeartRate, channels.FetalMovement} {
+		if written[name] != samples {
+			t.Errorf("в блоки канала %s записано %d точек, ожидалось %d", name, written[name], samples)
+		}
+	}
+	if written["blood_glucose"] != 0 {
+		t.Errorf("точки незарегистрированного канала записаны в БД")
+	}
+	// 8 точек по 0.25 с: две секундные сводки ЧСС плода по 4 точки
+	var seconds []models.CTGRollup
+	for _, row := range rollupRows {
+		if row.Channel == channels.FetalHeartRate && row.Resolution == 1 {
+			seconds = append(seconds, row)
+		}
+	}
+	if len(seconds) != 2 {
+		t.Fatalf("секундных сводок ЧСС плода %d, ожидалось 2", len(seconds))
+	}
+	for i, row := range seconds {
+		if row.Bucket != int64(i) || row.Valid != 4 || row.Count != 4 || row.Mean != 140 || row.Min != 140 || row.Max != 140 {
+			t.Errorf("сводка %d: %+v", i, row)
+		}
+	}
+	chunksMu.Unlock()
+	if pending := tp.spool.PendingCount(); pending != 0 {
+		t.Fatalf("в спуле осталось %d неподтвержденных сообщений", pending)
+	}
+}
+
+func TestTwinCoincidenceWarning(t *testing.T) {
+	tp := newTestPipeline(t, SegmentConfig{Policy: SegmentPolicyBoundary, MaxGap: 5 * time.Minute})
+
+	events := make(chan *pb.SessionEvent, 10)
+	tp.grpcStreamer.mu.Lock()
+	tp.grpcStreamer.eventSubscribers["test"] = &EventSubscriber{ID: "test", Channel: events}
+	tp.grpcStreamer.mu.Unlock()
+
+	const deviceID = "CTG-DEVICE-TWINS"
+	bound, err := tp.sessionManager.BindDevice(deviceID, uuid.New(), false)
+	if err != nil {
+		t.Fatalf("не удалось привязать устройство: %v", err)
+	}
+
+	// 10 с оба датчика на одном сердце, затем второй датчик находит второй плод
+	const same, total = 40, 120
+	for i := 0; i < total; i++ {
+		fhr := 140.0 + float64(i%3)
+		fhr2 := fhr + 1
+		if i >= same {
+			fhr2 = 125
+		}
+		for dataType, value := range map[string]float64{
+			channels.FetalHeartRate:  fhr,
+			channels.FetalHeartRate2: fhr2,
+		} {
+			payload, _ := json.Marshal(models.MedicalData{Value: value, TimeSec: float64(i) * 0.25})
+			tp.processor.HandleIncomingMQTT("medical/ctg/"+dataType+"/"+deviceID, payload)
+		}
+	}
+	tp.waitForPoints(t, 1, total*2)
+
+	var warning, cleared *pb.SessionEvent
+	for warning == nil || cleared == nil {
+		select {
+		case event := <-events:
+			switch event.Type {
+			case "warning":
+				warning = event
+			case "warning_cleared":
+				cleared = event
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("нет события о совпадении каналов: warning=%v cleared=%v", warning, cleared)
+		}
+	}
+	if warning.Reason != coincidence.KindSameHeart || warning.SessionId != bound.Session.ID.String() {
+		t.Errorf("неверное предупреждение: %+v", warning)
+	}
+	if cleared.ToTime <= warning.ToTime {
+		t.Errorf("совпадение закончилось раньше, чем началось: %v, %v", warning.ToTime, cleared.ToTime)
+	}
+
+	tp.stream.mu.Lock()
+	defer tp.stream.mu.Unlock()
+	flagged := 0
+	for _, data := range tp.stream.received {
+		marked := false
+		for _, flag := range data.Flags {
+			marked = marked || flag == "same_heart"
+		}
+		if marked {
+			flagged++
+			if data.TimeSec < warning.ToTime || data.TimeSec > cleared.ToTime {
+				t.Errorf("точка %s %.2f помечена вне интервала совпадения", data.DataType, data.TimeSec)
+			}
+		}
+		if data.DataType == channels.FetalHeartRate2 && data.Fetus != 2 {
+			t.Errorf("ЧСС второго плода передана с номером плода %d", data.Fetus)
+		}
+	}
+	if flagged == 0 {
+		t.Error("нет точек, помеченных совпадением каналов")
+	}
+}
+
+func TestFIGOAnalysisEvents(t *testing.T) {
+	// Сквозная проверка: точки из MQTT доходят до анализа FIGO, а события - до
+	// подписчиков с сессией устройства. Сам анализ проверяется в пакете figo.
+	tp := newTestPipeline(t, SegmentConfig{Policy: SegmentPolicyBoundary, MaxGap: 5 * time.Minute})
+
+	events := make(chan *pb.AnalysisEvent, 1000)
+	tp.grpcStreamer.mu.Lock()
+	tp.grpcStreamer.analysisSubscribers["test"] = &AnalysisSubscriber{ID: "test", Channel: events}
+	tp.grpcStreamer.mu.Unlock()
+
+	const deviceID = "CTG-DEVICE-FIGO"
+	bound, err := tp.sessionManager.BindDevice(deviceID, uuid.New(), false)
+	if err != nil {
+		t.Fatalf("не удалось привязать устройство: %v", err)
+	}
+
+	// 5 минут 4 Гц: базальный ритм 140 с колебаниями ±4, плавная децелерация до
+	// 110 уд/мин с надиром на 210 с и схватка с пиком на 180 с - поздняя децелерация
+	const samples = 5 * 60 * 4
+	for i := 0; i < samples; i++ {
+		timeSec := float64(i) * 0.25
+		fhr := 140 + 4*math.Sin(timeSec*math.Pi/8)
+		switch {
+		case timeSec >= 160 && timeSec < 210:
+			fhr = 140 - 30*(timeSec-160)/50
+		case timeSec >= 210 && timeSec < 250:
+			fhr = 110 + 30*(timeSec-210)/40
+		}
+		uc := 10.0
+		if rise := 50 * (1 - math.Abs(timeSec-180)/40); rise > 0 {
+			uc += rise
+		}
+
+		payload, _ := json.Marshal(models.MedicalData{Value: math.Round(fhr), TimeSec: timeSec})
+		tp.processor.HandleIncomingMQTT("medical/ctg/fetal_heart_rate/"+deviceID, payload)
+		payload, _ = json.Marshal(models.MedicalData{Value: math.Round(uc), TimeSec: timeSec})
+		tp.processor.HandleIncomingMQTT("medical/ctg/uterine_contractions/"+deviceID, payload)
+
+		// Канал потокового клиента при переполнении теряет точки: отправляем по минуте
+		if (i+1)%(60*4) == 0 {
+			tp.waitForPoints(t, 1, 2*(i+1))
+		}
+	}
+
+	received := collectAnalysisEvents(events)
+	for eventType, typed := range received {
+		for _, event := range typed {
+			if event.SessionId != bound.Session.ID.String() || event.DeviceId != deviceID {
+				t.Errorf("событие %s без сессии устройства: %+v", eventType, event)
+			}
+		}
+	}
+	if baseline := received[models.EventBaseline]; len(baseline) != 1 || baseline[0].Value != 140 {
+		t.Errorf("ожидался базальный ритм 140: %v", baseline)
+	}
+	if variability := received[models.EventVariability]; len(variability) != 1 || variability[0].Band != figo.BandNormal {
+		t.Errorf("ожидалась нормальная вариабельность: %v", variability)
+	}
+	if contractions := received[models.EventContraction]; len(contractions) != 1 || math.Abs(contractions[0].Peak-180) > 1 {
+		t.Errorf("ожидалась одна схватка с пиком на 180 с: %v", contractions)
+	}
+	decelerations := received[models.EventDeceleration]
+	if len(decelerations) != 1 || decelerations[0].Kind != "late" || math.Abs(decelerations[0].Lag-30) > 1 {
+		t.Fatalf("ожидалась поздняя децелерация через 30 с после пика схватки: %v", decelerations)
+	}
+
+	stats := tp.processor.GetAnalysisStats()
+	if len(stats) != 2 {
+		t.Fatalf("ожидались оценки двух каналов: %+v", stats)
+	}
+	for _, channelStats := range stats {
+		if channelStats.DataType == channels.FetalHeartRate && channelStats.DecelerationTypes.Late != 1 ||
+			channelStats.DataType == channels.UterineContractions && channelStats.Contractions != 1 {
+			t.Errorf("неверные оценки канала: %+v", channelStats)
+		}
+	}
+}
+
+// collectAnalysisEvents собирает события анализа по типам, пока они поступают
+func collectAnalysisEvents(events chan *pb.AnalysisEvent) map[string][]*pb.AnalysisEvent {
+	received := make(map[string][]*pb.AnalysisEvent)
+	for {
+		select {
+		case event := <-events:
+			received[event.Type] = append(received[event.Type], event)
+		case <-time.After(500 * time.Millisecond):
+			return received
+		}
+	}
+}
+
+func TestProtocolSessions(t *testing.T) {
+	tp := newTestPipeline(t, SegmentConfig{Policy: SegmentPolicyBoundary, MaxGap: 5 * time.Minute})
+
+	stopped := make(chan *models.CTGSession, 10)
+	tp.sessionManager.SetCallbacks(nil, func(session *models.CTGSession) { stopped <- session })
+
+	if _, err := tp.sessionManager.StartSession(uuid.New(), "CTG-DEVICE-X", "oct"); !errors.Is(err, ErrUnknownProtocol) {
+		t.Fatalf("ожидалась ошибка неизвестного протокола: %v", err)
+	}
+
+	const nstDevice = "CTG-DEVICE-NST"
+	nst, err := tp.sessionManager.StartSession(uuid.New(), nstDevice, protocols.NST)
+	if err != nil {
+		t.Fatalf("не удалось начать НСТ: %v", err)
+	}
+
+	// 21 минута ЧСС 1 Гц: базальный ритм 140 с колебаниями ±4 и акцелерации
+	// до 170 уд/мин на 5, 10 и 15 минутах
+	const samples = 21 * 60
+	for i := 0; i < samples; i++ {
+		timeSec := float64(i)
+		value := 140 + 4*math.Sin(timeSec*math.Pi/8)
+		for _, peak := range []float64{300, 600, 900} {
+			if rise := 30 * (1 - math.Abs(timeSec-peak)/20); rise > 0 {
+				value = 140 + rise
+			}
+		}
+		payload, _ := json.Marshal(models.MedicalData{Value: math.Round(value), TimeSec: timeSec})
+		tp.processor.HandleIncomingMQTT("medical/ctg/fetal_heart_rate/"+nstDevice, payload)
+	}
+	tp.waitForPoints(t, 1, samples)
+
+	// Реактивный НСТ завершается сам по истечении минимальных 20 минут
+	var session *models.CTGSession
+	select {
+	case session = <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("НСТ не завершился")
+	}
+	result := session.ProtocolResult
+	if session.ID != nst.ID || result == nil || result.Outcome != protocols.OutcomeReactive || result.Reason != protocols.ReasonCriteriaMet {
+		t.Fatalf("ожидался реактивный НСТ по критериям: %+v", result)
+	}
+	if result.Accelerations != 3 || math.Abs(result.Duration-20*60) > 1 {
+		t.Errorf("неверное заключение НСТ: %+v", result)
+	}
+	if summary := session.Summary; summary == nil || summary.Accelerations != 3 || summary.Baseline != 140 ||
+		summary.VariabilityBand != figo.BandNormal || summary.SignalQuality != 100 || summary.Decelerations.Total() != 0 {
+		t.Errorf("неверные итоги сессии НСТ: %+v", summary)
+	}
+	if tp.sessionManager.GetActiveSession(nstDevice) != nil {
+		t.Error("сессия НСТ осталась активной")
+	}
+
+	// СТ, остановленный раньше минимальной длительности, не дает заключения
+	cst, err := tp.sessionManager.StartSession(uuid.New(), "CTG-DEVICE-CST", protocols.CST)
+	if err != nil {
+		t.Fatalf("не удалось начать СТ: %v", err)
+	}
+	if _, err := tp.sessionManager.StopSession(cst.ID); err != nil {
+		t.Fatalf("не удалось остановить СТ: %v", err)
+	}
+	session = <-stopped
+	if result := session.ProtocolResult; result == nil || result.Outcome != protocols.OutcomeIncomplete || result.Reason != protocols.ReasonManual {
+		t.Errorf("ожидалось незавершенное заключение СТ: %+v", result)
+	}
+
+	// Зависшая сессия закрывается так же, как остановленная: с заключением и итогами
+	stale, err := tp.sessionManager.StartSession(uuid.New(), "CTG-DEVICE-STALE", protocols.NST)
+	if err != nil {
+		t.Fatalf("не удалось начать НСТ: %v", err)
+	}
+	tp.sessionManager.sessionsLock.Lock()
+	stale.StartTime = stale.StartTime.Add(-25 * time.Hour)
+	tp.sessionManager.sessionsLock.Unlock()
+	tp.sessionManager.CleanupInactiveSessions()
+	select {
+	case session = <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("зависшая сессия не завершена")
+	}
+	if session.ID != stale.ID || session.EndTime == nil || session.ProtocolResult == nil || session.Summary == nil {
+		t.Errorf("зависшая сессия закрыта без заключения или итогов: %+v", session)
+	}
+	if tp.sessionManager.GetActiveSession("CTG-DEVICE-STALE") != nil {
+		t.Error("зависшая сессия осталась активной")
+	}
+}
+
+// fakeAlarmStream собирает тревоги, отправленные клиенту StreamAlarms
+type fakeAlarmStream struct {
+	grpc.ServerStream
+	ctx context.Context
+
+	mu       sync.Mutex
+	received []*pb.AlarmEvent
+}
+
+func (s *fakeAlarmStream) Context() context.Context { return s.ctx }
+
+func (s *fakeAlarmStream) Send(alarm *pb.AlarmEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.received = append(s.received, alarm)
+	return nil
+}
+
+func TestAlarmLifecycle(t *testing.T) {
+	tp := newTestPipeline(t, SegmentConfig{Policy: SegmentPolicyBoundary, MaxGap: 5 * time.Minute})
+
+	changes := make(chan *pb.AlarmEvent, 100)
+	tp.grpcStreamer.mu.Lock()
+	tp.grpcStreamer.alarmSubscribers["test"] = &AlarmSubscriber{ID: "test", Channel: changes}
+	tp.grpcStreamer.mu.Unlock()
+
+	const deviceID = "CTG-DEVICE-ALARM"
+	sent := 0
+	send := func(from, to int, value func(i int) float64) {
+		for i := from; i < to; i++ {
+			payload, _ := json.Marshal(models.MedicalData{Value: value(i), TimeSec: float64(i)})
+			tp.processor.HandleIncomingMQTT("medical/ctg/fetal_heart_rate/"+deviceID, payload)
+			sent++
+		}
+		tp.waitForPoints(t, 1, sent)
+	}
+
+	// ЧСС 1 Гц: минута нормы, плавное снижение до 100 и полторы минуты брадикардии
+	send(0, 170, func(i int) float64 {
+		return math.Max(100, 140-2*math.Max(0, float64(i-60)))
+	})
+
+	raised := <-changes
+	if raised.Rule != alarms.RuleBradycardia || raised.State != models.AlarmRaised || raised.Priority != alarms.PriorityHigh {
+		t.Fatalf("ожидалась тревога брадикардии: %+v", raised)
+	}
+	// Ниже 110 с 76-й секунды, тревога - через 30 с
+	if raised.SessionTime < 105 || raised.SessionTime > 108 {
+		t.Errorf("тревога поднята не вовремя: %.0f с", raised.SessionTime)
+	}
+
+	// Новый подписчик получает неснятую тревогу первой
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &fakeAlarmStream{ctx: ctx}
+	done := make(chan struct{})
+	go func() {
+		tp.grpcStreamer.StreamAlarms(&pb.StreamRequest{DeviceIds: []string{deviceID}}, stream)
+		close(done)
+	}()
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	<-done
+	if len(stream.received) != 1 || !stream.received[0].Snapshot || stream.received[0].AlarmId != raised.AlarmId {
+		t.Fatalf("ожидался снимок неснятой тревоги: %+v", stream.received)
+	}
+
+	// Без подтверждения тревога эскалируется
+	alarmID := uuid.MustParse(raised.AlarmId)
+	tp.alarmManager.publish(tp.alarmManager.engine.Escalate(time.Now().Add(3 * time.Minute)))
+	if escalated := <-changes; escalated.Escalations != 1 || escalated.State != models.AlarmRaised {
+		t.Fatalf("ожидалась эскалация: %+v", escalated)
+	}
+
+	alarm, err := tp.alarmManager.Acknowledge(alarmID, "Иванова А.П.")
+	if err != nil || alarm.State != models.AlarmAcknowledged || alarm.AcknowledgedBy != "Иванова А.П." {
+		t.Fatalf("тревога не подтверждена: %+v, %v", alarm, err)
+	}
+	if acknowledged := <-changes; acknowledged.State != models.AlarmAcknowledged || acknowledged.AcknowledgedAt == 0 {
+		t.Fatalf("ожидалось подтверждение: %+v", acknowledged)
+	}
+	if escalated := tp.alarmManager.engine.Escalate(time.Now().Add(time.Hour)); len(escalated) != 0 {
+		t.Errorf("подтвержденная тревога эскалирована: %+v", escalated)
+	}
+
+	// Возврат к норме снимает тревогу
+	send(170, 240, func(i int) float64 {
+		return math.Min(140, 100+2*float64(i-170))
+	})
+	cleared := <-changes
+	if cleared.AlarmId != raised.AlarmId || cleared.State != models.AlarmCleared || cleared.ClearedAt == 0 {
+		t.Fatalf("ожидалось снятие тревоги: %+v", cleared)
+	}
+	if open := tp.alarmManager.Open(); len(open) != 0 {
+		t.Errorf("остались неснятые тревоги: %+v", open)
+	}
+	if _, err := tp.alarmManager.Acknowledge(alarmID, "Иванова А.П."); !errors.Is(err, alarms.ErrAlarmNotFound) {
+		t.Errorf("снятая тревога подтверждена: %v", err)
+	}
+
+	// Клиент, не успевающий принимать тревоги, отключается, а не теряет их молча
+	slow := &AlarmSubscriber{ID: "slow", Channel: make(chan *pb.AlarmEvent), Dropped: make(chan struct{})}
+	tp.grpcStreamer.mu.Lock()
+	tp.grpcStreamer.alarmSubscribers[slow.ID] = slow
+	tp.grpcStreamer.mu.Unlock()
+	tp.grpcStreamer.BroadcastAlarm(alarm)
+	select {
+	case <-slow.Dropped:
+	default:
+		t.Error("клиент с переполненным каналом не отключен")
+	}
+	tp.grpcStreamer.mu.RLock()
+	_, subscribed := tp.grpcStreamer.alarmSubscribers[slow.ID]
+	tp.grpcStreamer.mu.RUnlock()
+	if subscribed {
+		t.Error("отключенный клиент остался в подписчиках")
+	}
+}
+
+func TestSignalQuality(t *testing.T) {
+	tp := newTestPipeline(t, SegmentConfig{Policy: SegmentPolicyBoundary, MaxGap: 5 * time.Minute})
+
+	stopped := make(chan *models.CTGSession, 1)
+	tp.sessionManager.SetCallbacks(nil, func(session *models.CTGSession) { stopped <- session })
+
+	events := make(chan *pb.AnalysisEvent, 1000)
+	tp.grpcStreamer.mu.Lock()
+	tp.grpcStreamer.analysisSubscribers["test"] = &AnalysisSubscriber{ID: "test", Channel: events}
+	tp.grpcStreamer.mu.Unlock()
+
+	const deviceID = "CTG-DEVICE-QUALITY"
+	session, err := tp.sessionManager.StartSession(uuid.New(), deviceID, "")
+	if err != nil {
+		t.Fatalf("не удалось начать сессию: %v", err)
+	}
+
+	// Две минуты ЧСС 1 Гц с потерей сигнала с 60-й по 89-ю секунду
+	const samples = 120
+	for i := 0; i < samples; i++ {
+		value := 140.0
+		if i >= 60 && i < 90 {
+			value = -1
+		}
+		payload, _ := json.Marshal(models.MedicalData{Value: value, TimeSec: float64(i)})
+		tp.processor.HandleIncomingMQTT("medical/ctg/fetal_heart_rate/"+deviceID, payload)
+	}
+	// Точки без сигнала клиенту не отправляются
+	tp.waitForPoints(t, 1, samples-30)
+
+	received := collectAnalysisEvents(events)
+	losses := received[models.EventSignalLoss]
+	if len(losses) != 2 || losses[0].Start != 60 || losses[0].End != 0 || losses[1].Start != 60 || losses[1].End != 90 {
+		t.Fatalf("ожидались начало и окончание потери сигнала: %v", losses)
+	}
+	reports := received[models.EventQuality]
+	if len(reports) == 0 {
+		t.Fatal("индекс качества не разослан")
+	}
+	for _, report := range reports {
+		if report.SessionId != session.ID.String() || report.Band != quality.Band(report.Value) {
+			t.Errorf("неверный индекс качества: %+v", report)
+		}
+	}
+	// В минутном окне на 90-й секунде сигнала нет в половине точек
+	for _, report := range reports {
+		if report.End == 90 && (report.Value != 50.8 || report.Band != quality.BandFair) {
+			t.Errorf("ожидался индекс качества fair после потери: %+v", report)
+		}
+	}
+
+	stats := tp.processor.GetQualityStats()
+	if len(stats) != 1 || stats[0].InLoss || stats[0].LossEpisodes != 1 || stats[0].Band != quality.BandFair {
+		t.Errorf("неверная оценка качества канала: %+v", stats)
+	}
+
+	if _, err := tp.sessionManager.StopSession(session.ID); err != nil {
+		t.Fatalf("не удалось остановить сессию: %v", err)
+	}
+	summary, ok := (<-stopped).SignalQuality[channels.FetalHeartRate]
+	if !ok || summary.Points != samples || summary.Valid != 90 || summary.ValidPercent != 75 {
+		t.Fatalf("неверная сводка качества сессии: %+v", summary)
+	}
+	if len(summary.LossEpisodes) != 1 || summary.LossEpisodes[0] != (models.LossEpisode{Start: 60, End: 90}) || summary.LossSeconds != 30 {
+		t.Errorf("неверные эпизоды потери сигнала: %+v", summary)
+	}
+}
+
+func TestResolveDeviceID(t *testing.T) {
+	cases := []struct {
+		topic, payload string
+		want           string
+		wantErr        bool
+	}{
+		{"CTG-001", "", "CTG-001", false},
+		{"CTG-001", "CTG-001", "CTG-001", false},
+		{"", "CTG-002", "CTG-002", false},
+		{"CTG-001", "CTG-002", "", true},
+		{"+", "", "", true},
+		{"", "", "", true},
+	}
+
+	for _, c := range cases {
+		got, err := resolveDeviceID(c.topic, c.payload)
+		if (err != nil) != c.wantErr || got != c.want {
+			t.Errorf("resolveDeviceID(%q, %q) = %q, %v", c.topic, c.payload, got, err)
+		}
+	}
+}
+
+func TestDeviceAndSessionQueries(t *testing.T) {
+	tp := newTestPipeline(t, SegmentConfig{Policy: SegmentPolicyBoundary, MaxGap: 5 * time.Minute})
+
+	const bound, pending = "CTG-DEVICE-BOUND", "CTG-DEVICE-PENDING"
+	result, err := tp.sessionManager.BindDevice(bound, uuid.New(), false)
+	if err != nil {
+		t.Fatalf("не удалось привязать устройство: %v", err)
+	}
+	for _, deviceID := range []string{bound, pending} {
+		payload, _ := json.Marshal(models.MedicalData{Value: 140, TimeSec: 0})
+		tp.processor.HandleIncomingMQTT("medical/ctg/"+channels.FetalHeartRate+"/"+deviceID, payload)
+	}
+	tp.waitForPoints(t, 2, 1)
+
+	devices := tp.sessionManager.GetDevices()
+	if len(devices) != 2 || devices[0].DeviceID != bound || devices[1].DeviceID != pending {
+		t.Fatalf("неверный список устройств: %+v", devices)
+	}
+	if devices[0].Status != DeviceActive || devices[0].Session == nil || devices[0].Session.ID != result.Session.ID {
+		t.Errorf("привязанное устройство: %+v", devices[0])
+	}
+	if devices[1].Status != DeviceUnassigned || devices[1].Pending == nil || devices[1].Pending.PendingPoints != 1 {
+		t.Errorf("устройство без карты: %+v", devices[1])
+	}
+	if _, ok := tp.sessionManager.GetDevice("CTG-DEVICE-UNKNOWN"); ok {
+		t.Errorf("найдено неизвестное устройство")
+	}
+
+	// Курсор страницы восстанавливает ключ последней сессии
+	start := time.Date(2023, 9, 1, 10, 0, 0, 123456789, time.UTC)
+	id := uuid.New()
+	cursor := encodeSessionCursor(start, id)
+	if gotStart, gotID, err := decodeSessionCursor(cursor); err != nil || !gotStart.Equal(start) || gotID != id {
+		t.Errorf("курсор %q разобран как %v %v: %v", cursor, gotStart, gotID, err)
+	}
+
+	invalid := []SessionFilter{
+		{Cursor: "не курсор"},
+		{Status: "paused"},
+		{Sort: "device_id"},
+		{FromTime: &start, ToTime: &time.Time{}},
+	}
+	for _, filter := range invalid {
+		if _, err := tp.sessionManager.ListSessions(filter); !errors.Is(err, ErrInvalidCursor) && !errors.Is(err, ErrInvalidSessionFilter) {
+			t.Errorf("фильтр %+v принят: %v", filter, err)
+		}
+	}
+	if _, err := tp.sessionManager.ListSessions(SessionFilter{Status: SessionActive, DeviceID: bound, Cursor: cursor, Sort: SortStartTimeAsc}); err != nil {
+		t.Errorf("не удалось выполнить запрос сессий: %v", err)
+	}
+}
+
+func TestCleanSampleFlagsOutlier(t *testing.T) {
+	tp := newTestPipeline(t, SegmentConfig{Policy: SegmentPolicyBoundary, MaxGap: 5 * time.Minute})
+	bank := filters.NewBank(map[string][]string{channels.FetalHeartRate: {filters.FilterHampel}})
+
+	const spikeAt = 12
+	for i := 0; i < 20; i++ {
+		value := 140.0 + float64(i%2)
+		if i == spikeAt {
+			value = 200
+		}
+		data := &models.MedicalData{DeviceID: "CTG-DEVICE-SPIKE", DataType: channels.FetalHeartRate, Value: value, TimeSec: float64(i) * 0.25}
+		point := tp.processor.cleanSample(bank, data, 0)
+		if i != spikeAt {
+			if point.F != 0 || point.R != nil {
+				t.Errorf("точка %d изменена: %+v", i, point)
+			}
+			continue
+		}
+		if point.F != models.FlagOutlier || point.R == nil || *point.R != 200 || point.V > 141 {
+			t.Errorf("выброс не исправлен или не помечен: %+v", point)
+		}
+	}
+}
+
+func TestRemoveSessionBufferFlushesFirst(t *testing.T) {
+	ingestSpool, err := spool.Open(spool.Config{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("не удалось открыть спул: %v", err)
+	}
+	defer ingestSpool.Close()
+	registry := channels.NewRegistry(channels.Defaults(), nil)
+	dataBuffer := NewDataBuffer(newDryRunDB(t), ingestSpool, registry, chunks.DefaultFormat)
+	defer dataBuffer.Stop()
+
+	sessionID := uuid.New()
+	dataBuffer.AttachSession(sessionID)
+	for i := 0; i < 100; i++ {
+		seq, err := ingestSpool.Append("medical/ctg/fetal_heart_rate/CTG-001", []byte("{}"))
+		if err != nil {
+			t.Fatalf("не удалось записать в спул: %v", err)
+		}
+		dataBuffer.AddDataPoint(sessionID, channels.FetalHeartRate, models.CTGPoint{T: float64(i), V: 140}, seq)
+	}
+
+	// Колбэк вызывается только после записи всех точек сессии
+	pending := make(chan int, 1)
+	dataBuffer.RemoveSessionBuffer(sessionID, func() { pending <- ingestSpool.PendingCount() })
+	select {
+	case count := <-pending:
+		if count != 0 {
+			t.Errorf("колбэк вызван до записи: в спуле %d неподтвержденных записей", count)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("колбэк записи сессии не вызван")
+	}
+}
+
+func TestFinalFlushStopsNewFlushes(t *testing.T) {
+	registry := channels.NewRegistry(channels.Defaults(), nil)
+	dataBuffer := NewDataBuffer(newDryRunDB(t), nil, registry, chunks.DefaultFormat)
+
+	// Точки поступают во время остановки: асинхронные флаши не должны запускаться
+	// одновременно с ожиданием уже запущенных
+	sessionID := uuid.New()
+	var wg sync.WaitGroup
+	for worker := 0; worker < 4; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 2000; i++ {
+				dataBuffer.AddDataPoint(sessionID, channels.FetalHeartRate, models.CTGPoint{T: float64(i), V: 140}, 0)
+			}
+		}()
+	}
+	dataBuffer.Stop()
+	wg.Wait()
+
+	// После остановки флаши не запускаются, а буфер завершенной сессии остается
+	// для записи из спула после перезапуска
+	stopped := uuid.New()
+	for i := 0; i < 200; i++ {
+		dataBuffer.AddDataPoint(stopped, channels.FetalHeartRate, models.CTGPoint{T: float64(i), V: 140}, 0)
+	}
+	dataBuffer.RemoveSessionBuffer(stopped, func() { t.Error("колбэк записи вызван после остановки") })
+	time.Sleep(50 * time.Millisecond)
+
+	sessionBuffer := dataBuffer.getSessionBuffer(stopped)
+	sessionBuffer.mu.Lock()
+	defer sessionBuffer.mu.Unlock()
+	if points := countPoints(sessionBuffer.Series); points != 200 {
+		t.Errorf("в буфере %d точек из 200", points)
+	}
+}
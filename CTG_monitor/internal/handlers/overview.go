@@ -0,0 +1,104 @@
+// internal/handlers/overview.go
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"CTG_monitor/internal/downsample"
+	"CTG_monitor/internal/models"
+	"CTG_monitor/internal/rollups"
+	"github.com/google/uuid"
+)
+
+// ErrUnknownChannel канал не зарегистрирован
+var ErrUnknownChannel = errors.New("неизвестный канал")
+
+// defaultOverviewWidth ширина графика обзора, точек, если не задана в запросе
+const defaultOverviewWidth = 1000
+
+// minMaxPoints наименьший предел точек на канал при прореживании: первая, последняя и одна между ними
+const minMaxPoints = 3
+
+// SessionOverview ряды сессии на интервале в разрешении, подобранном под ширину графика
+type SessionOverview struct {
+	Session    *models.CTGSession
+	From       float64                       // Начало интервала, с от начала сессии
+	To         float64                       // Конец интервала, с от начала сессии
+	Resolution int                           // Длительность интервала сводки, с; rollups.Raw - исходные точки
+	Rollups    map[string][]models.CTGRollup // Сводки по каналам
+	Points     map[string][]models.CTGPoint  // Исходные точки по каналам (при rollups.Raw)
+}
+
+// GetSessionOverview возвращает ряды каналов names (пусто - все каналы) на интервале
+// [from, to] времени сессии, показываемом на width пикселей. Пустые границы - от начала
+// сессии до ее окончания (для активной - до текущего момента).
+func (sm *SessionManager) GetSessionOverview(sessionID uuid.UUID, names []string, from, to *float64, width int) (*SessionOverview, error) {
+	if err := sm.checkChannels(names); err != nil {
+		return nil, err
+	}
+	if err := (TimeRange{From: from, To: to}).Validate(); err != nil {
+		return nil, err
+	}
+
+	session, err := sm.GetSession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	overview := &SessionOverview{Session: session}
+	if from != nil {
+		overview.From = *from
+	}
+	if to != nil {
+		overview.To = *to
+	} else {
+		end := time.Now()
+		if session.EndTime != nil {
+			end = *session.EndTime
+		}
+		overview.To = end.Sub(session.StartTime).Seconds()
+	}
+	if overview.To < overview.From {
+		return nil, fmt.Errorf("%w: from больше длительности сессии", ErrInvalidTimeRange)
+	}
+
+	overview.Resolution = rollups.Pick(overview.To-overview.From, width)
+	if overview.Resolution == rollups.Raw {
+		data, err := sm.GetSessionSeries(sessionID, names, TimeRange{From: &overview.From, To: &overview.To})
+		if err != nil {
+			return nil, err
+		}
+		overview.Points = data.Series
+		return overview, nil
+	}
+
+	overview.Rollups, err = rollups.Load(sm.db, sessionID, names, overview.Resolution, overview.From, overview.To)
+	if err != nil {
+		return nil, err
+	}
+	return overview, nil
+}
+
+// checkChannels проверяет, что каналы зарегистрированы
+func (sm *SessionManager) checkChannels(names []string) error {
+	for _, name := range names {
+		if _, ok := sm.dataBuffer.channels.Lookup(name); !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownChannel, name)
+		}
+	}
+	return nil
+}
+
+// downsampleSeries прореживает ряды каналов до maxPoints точек методом LTTB (0 - без прореживания)
+func downsampleSeries(series map[string][]models.CTGPoint, maxPoints int) map[string][]models.CTGPoint {
+	if maxPoints <= 0 {
+		return series
+	}
+	sampled := make(map[string][]models.CTGPoint, len(series))
+	for name, points := range series {
+		sampled[name] = downsample.LTTB(points, maxPoints)
+	}
+	return sampled
+}
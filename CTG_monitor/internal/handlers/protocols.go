@@ -0,0 +1,99 @@
+// internal/handlers/protocols.go
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"CTG_monitor/internal/models"
+	"CTG_monitor/internal/protocols"
+	"gorm.io/gorm"
+)
+
+// ErrUnknownProtocol протокол теста не поддерживается
+var ErrUnknownProtocol = errors.New("неизвестный протокол теста, поддерживаются: " + strings.Join(protocols.Names(), ", "))
+
+// startProtocolLocked начинает тест сессии, запущенной по протоколу; вызывается под sessionsLock.
+// Оценка, перенесенная из предыдущей сессии, продолжается. Сессия, восстановленная после
+// перезапуска, продолжает тест с длительностью elapsed и своими событиями анализа.
+func (sm *SessionManager) startProtocolLocked(session *models.CTGSession, elapsed float64) {
+	if session.Protocol == "" || sm.evaluators[session.ID] != nil {
+		return
+	}
+	protocol, ok := protocols.Lookup(session.Protocol)
+	if !ok {
+		log.Printf("Сессия %s: неизвестный протокол %q, тест не проводится", session.ID, session.Protocol)
+		return
+	}
+
+	evaluator := protocols.NewEvaluator(protocol)
+	evaluator.Resume(elapsed, session.Events)
+	sm.evaluators[session.ID] = evaluator
+	log.Printf("Сессия %s: %s, от %s до %s", session.ID, protocol.Title, protocol.MinDuration, protocol.MaxDuration)
+}
+
+// observeProtocol передает события анализа и время точки тесту активной сессии
+// устройства. Если критерии выполнены или время вышло, сессия завершается с
+// заключением и возвращается вызывающему для отправки в медкарты.
+func (sm *SessionManager) observeProtocol(deviceID string, events []models.CTGEvent, t float64) *models.CTGSession {
+	sm.sessionsLock.Lock()
+	defer sm.sessionsLock.Unlock()
+
+	session := sm.activeSessions[deviceID]
+	if session == nil {
+		return nil
+	}
+	evaluator := sm.evaluators[session.ID]
+	if evaluator == nil {
+		return nil
+	}
+
+	for _, event := range events {
+		evaluator.Observe(event)
+	}
+	reason, done := evaluator.Advance(t)
+	if !done {
+		return nil
+	}
+
+	result := evaluator.Result(reason, time.Now().UTC())
+	session.ProtocolResult = &result
+	stopped, err := sm.stopSessionLocked(session.ID)
+	if err != nil {
+		log.Printf("Не удалось завершить сессию %s по протоколу: %v", session.ID, err)
+		return nil
+	}
+	return stopped
+}
+
+// finishProtocolLocked записывает заключение теста завершаемой сессии; вызывается под sessionsLock.
+// Сессия, остановленная вручную, получает заключение на момент остановки.
+func (sm *SessionManager) finishProtocolLocked(session *models.CTGSession, now time.Time) error {
+	evaluator := sm.evaluators[session.ID]
+	if evaluator == nil {
+		return nil
+	}
+	delete(sm.evaluators, session.ID)
+
+	if session.ProtocolResult == nil {
+		result := evaluator.Result(protocols.ReasonManual, now)
+		session.ProtocolResult = &result
+	}
+	result := session.ProtocolResult
+	log.Printf("Сессия %s: %s (%s, %s)", session.ID, result.Summary, result.Outcome, result.Reason)
+
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	if err := sm.db.Model(&models.CTGSession{}).
+		Where("id = ?", session.ID).
+		Update("protocol_result", gorm.Expr("?::jsonb", string(resultJSON))).Error; err != nil {
+		return fmt.Errorf("не удалось сохранить заключение теста сессии %s: %w", session.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+// internal/handlers/quality.go
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"CTG_monitor/internal/channels"
+	"CTG_monitor/internal/models"
+	"CTG_monitor/internal/quality"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// observeQuality оценивает качество сигнала канала. Индекс качества и эпизоды
+// потери сигнала сохраняются в сессии устройства и рассылаются подписчикам анализа.
+func (p *MQTTStreamProcessor) observeQuality(deviceID string, channel channels.Channel, point models.CTGPoint) {
+	if !quality.Tracked(channel) {
+		return
+	}
+
+	for _, event := range p.qualityBank.Observe(deviceID, channel.Name, point.T, point.V, quality.Corrected(point.F)) {
+		switch {
+		case event.Type == models.EventSignalLoss && event.End == 0:
+			log.Printf("Потеря сигнала %s/%s с %.1f с", deviceID, event.Channel, event.Start)
+		case event.Type == models.EventSignalLoss:
+			log.Printf("Сигнал %s/%s восстановлен: потеря %.1f-%.1f с", deviceID, event.Channel, event.Start, event.End)
+		case event.Band == quality.BandPoor:
+			log.Printf("Низкое качество сигнала %s/%s: индекс %.1f%%", deviceID, event.Channel, event.Value)
+		}
+
+		sessionID := p.sessionManager.RouteEvent(deviceID, event)
+		p.grpcStreamer.BroadcastAnalysisEvent(deviceID, sessionID, event)
+	}
+}
+
+// GetQualityStats возвращает текущие оценки качества сигнала по каналам устройств
+func (p *MQTTStreamProcessor) GetQualityStats() []quality.ChannelStats {
+	return p.qualityBank.Stats()
+}
+
+// countQualityLocked учитывает точку в сводке качества сигнала сессии; вызывается под sessionsLock
+func (sm *SessionManager) countQualityLocked(sessionID uuid.UUID, dataType string, point models.CTGPoint) {
+	channel, ok := sm.dataBuffer.channels.Lookup(dataType)
+	if !ok || !quality.Tracked(channel) {
+		return
+	}
+
+	accumulators := sm.signalQuality[sessionID]
+	if accumulators == nil {
+		accumulators = make(map[string]*quality.Accumulator)
+		sm.signalQuality[sessionID] = accumulators
+	}
+	accumulator := accumulators[dataType]
+	if accumulator == nil {
+		accumulator = &quality.Accumulator{}
+		accumulators[dataType] = accumulator
+	}
+	accumulator.Add(point)
+}
+
+// finishQualityLocked записывает сводку качества сигнала завершаемой сессии; вызывается под sessionsLock.
+// Сводка строится по точкам, принятым после начала сессии; для сессии, продолженной
+// после перезапуска сервиса, - по последнему часу записи до него и новым точкам.
+// Выгруженные устройством точки в нее не входят.
+func (sm *SessionManager) finishQualityLocked(session *models.CTGSession) error {
+	accumulators := sm.signalQuality[session.ID]
+	delete(sm.signalQuality, session.ID)
+	if len(accumulators) == 0 {
+		return nil
+	}
+
+	summary := make(map[string]models.ChannelQuality, len(accumulators))
+	for dataType, accumulator := range accumulators {
+		summary[dataType] = accumulator.Result()
+	}
+	session.SignalQuality = summary
+
+	summaryJSON, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+	if err := sm.db.Model(&models.CTGSession{}).
+		Where("id = ?", session.ID).
+		Update("signal_quality", gorm.Expr("?::jsonb", string(summaryJSON))).Error; err != nil {
+		return fmt.Errorf("не удалось сохранить качество сигнала сессии %s: %w", session.ID, err)
+	}
+	return nil
+}
+
+// summarizeQuality сводка качества сигнала по точкам каналов интервала
+func summarizeQuality(series map[string][]models.CTGPoint, registry *channels.Registry) map[string]models.ChannelQuality {
+	summary := make(map[string]models.ChannelQuality)
+	for name, points := range series {
+		if channel, ok := registry.Lookup(name); ok && quality.Tracked(channel) && len(points) > 0 {
+			summary[name] = quality.Summarize(points)
+		}
+	}
+	return summary
+}
@@ -1,16 +1,32 @@
 package handlers
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
-	_ "CTG_monitor/internal/models"
+	"CTG_monitor/internal/alarms"
+	"CTG_monitor/internal/archive"
+	"CTG_monitor/internal/channels"
+	"CTG_monitor/internal/clock"
+	"CTG_monitor/internal/coincidence"
+	"CTG_monitor/internal/figo"
+	"CTG_monitor/internal/filters"
+	"CTG_monitor/internal/models"
+	"CTG_monitor/internal/quality"
+	"CTG_monitor/internal/reorder"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	swaggerfiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"gorm.io/gorm"
 )
 
 // @title CTG Monitor API
@@ -31,6 +47,15 @@ import (
 // @tag.name sessions
 // @tag.description Управление сессиями мониторинга
 
+// @tag.name cards
+// @tag.description История сессий медицинских карт
+
+// @tag.name devices
+// @tag.description Устройства КТГ и привязка к медицинским картам
+
+// @tag.name channels
+// @tag.description Каналы данных мониторинга
+
 // @tag.name monitoring
 // @tag.description Мониторинг состояния сервиса
 
@@ -39,6 +64,7 @@ type RESTAPIServer struct {
 	sessionManager *SessionManager
 	grpcStreamer   *GRPCStreamer
 	mqttProcessor  *MQTTStreamProcessor
+	alarmManager   *AlarmManager
 }
 
 // SessionRequest запрос для создания сессии
@@ -46,6 +72,7 @@ type RESTAPIServer struct {
 type SessionRequest struct {
 	CardID   string `json:"card_id" binding:"required" example:"550e8400-e29b-41d4-a716-446655440000"` // UUID медицинской карты пациента
 	DeviceID string `json:"device_id" binding:"required" example:"CTG-DEVICE-001"`                     // Идентификатор устройства КТГ
+	Protocol string `json:"protocol,omitempty" example:"nst" enums:"nst,cst"`                          // Протокол теста; без протокола - мониторинг без ограничения по времени
 }
 
 // SessionResponse ответ с информацией о сессии
@@ -58,15 +85,81 @@ type SessionResponse struct {
 	StartTime time.Time  `json:"start_time" example:"2023-09-01T10:00:00Z"`                 // Время начала сессии
 	EndTime   *time.Time `json:"end_time,omitempty" example:"2023-09-01T11:30:00Z"`         // Время окончания сессии (если завершена)
 	Duration  int        `json:"duration" example:"5400"`                                   // Продолжительность в секундах
+
+	Protocol       string                 `json:"protocol,omitempty" example:"nst"` // Протокол теста
+	ProtocolResult *models.ProtocolResult `json:"protocol_result,omitempty"`        // Заключение теста (после завершения)
+	Summary        *models.SessionSummary `json:"summary,omitempty"`                // Итоги сессии (после завершения)
 }
 
 // SessionDataResponse данные КТГ для сессии
-// @Description Данные мониторинга КТГ, собранные во время сессии
+// @Description Данные мониторинга КТГ, собранные во время сессии. Время точки t - секунды от начала сессии, w - абсолютное время UTC (мс Unix)
 type SessionDataResponse struct {
-	SessionID   string      `json:"session_id" example:"550e8400-e29b-41d4-a716-446655440001"` // UUID сессии
-	FHRData     interface{} `json:"fhr_data"`                                                  // Данные частоты сердечных сокращений плода
-	UCData      interface{} `json:"uc_data"`                                                   // Данные маточных сокращений
-	TotalPoints int         `json:"total_points" example:"1250"`                               // Общее количество точек данных
+	SessionID    string                           `json:"session_id" example:"550e8400-e29b-41d4-a716-446655440001"` // UUID сессии
+	DeviceID     string                           `json:"device_id" example:"CTG-DEVICE-001"`                        // Идентификатор устройства
+	StartTime    time.Time                        `json:"start_time" example:"2023-09-01T10:00:00Z"`                 // Время начала сессии
+	Segments     []models.CTGSegment              `json:"segments,omitempty"`                                        // Сегменты шкалы времени
+	FHRData      []models.CTGPoint                `json:"fhr_data"`                                                  // Данные частоты сердечных сокращений плода
+	FHR2Data     []models.CTGPoint                `json:"fhr2_data,omitempty"`                                       // ЧСС второго плода (двойня)
+	UCData       []models.CTGPoint                `json:"uc_data"`                                                   // Данные маточных сокращений
+	Channels     map[string][]models.CTGPoint     `json:"channels,omitempty"`                                        // Остальные каналы по имени (см. /channels)
+	Events       []models.CTGEvent                `json:"events"`                                                    // Результаты анализа ЧСС плода и схваток по FIGO
+	Contractions *ContractionSummary              `json:"contractions,omitempty"`                                    // Сводка схваток за интервал (если есть токограмма)
+	Quality      map[string]models.ChannelQuality `json:"quality"`                                                   // Доля точек с сигналом и эпизоды потери сигнала за интервал по каналам
+	TotalPoints  int                              `json:"total_points" example:"1250"`                               // Общее количество точек данных
+	SourcePoints int                              `json:"source_points" example:"21600"`                             // Точек в интервале до прореживания
+	MaxPoints    int                              `json:"max_points,omitempty" example:"1000"`                       // Максимум точек на канал из запроса
+}
+
+// SessionRollupsResponse ряды сессии для обзорного графика
+// @Description Ряды каналов на интервале в разрешении, подобранном под ширину графика: сводки (min/max/mean за интервал resolution секунд) или исходные точки при resolution=0
+type SessionRollupsResponse struct {
+	SessionID  string                       `json:"session_id" example:"550e8400-e29b-41d4-a716-446655440001"` // UUID сессии
+	From       float64                      `json:"from" example:"0"`                                          // Начало интервала, секунды от начала сессии
+	To         float64                      `json:"to" example:"5400"`                                         // Конец интервала, секунды от начала сессии
+	Width      int                          `json:"width" example:"1000"`                                      // Ширина графика, точек
+	Resolution int                          `json:"resolution" example:"1"`                                    // Длительность интервала сводки, с (0 - исходные точки)
+	Rollups    map[string][]RollupBucket    `json:"rollups,omitempty"`                                         // Сводки по каналам
+	Points     map[string][]models.CTGPoint `json:"points,omitempty"`                                          // Исходные точки по каналам (при resolution=0)
+}
+
+// RollupBucket сводка канала за интервал
+// @Description Минимум, максимум и среднее по точкам с сигналом за интервал [t, t+resolution); без сигнала -1
+type RollupBucket struct {
+	T     float64 `json:"t" example:"60"`       // Начало интервала, секунды от начала сессии
+	Min   float64 `json:"min" example:"132"`    // Минимум
+	Max   float64 `json:"max" example:"151"`    // Максимум
+	Mean  float64 `json:"mean" example:"140.5"` // Среднее
+	Valid int     `json:"valid" example:"236"`  // Точек с сигналом
+	Count int     `json:"count" example:"240"`  // Всего точек
+}
+
+// ContractionSummary сводка схваток за интервал
+// @Description Количество и частота схваток, эпизоды тахисистолии
+type ContractionSummary struct {
+	Count         int     `json:"count" example:"12"`           // Количество схваток
+	Frequency     float64 `json:"frequency" example:"3.5"`      // Схваток за 10 минут в среднем за интервал
+	MeanDuration  float64 `json:"mean_duration" example:"65.2"` // Средняя длительность схватки, с
+	MeanAmplitude float64 `json:"mean_amplitude" example:"42"`  // Средний подъем над тонусом
+	Tachysystole  int     `json:"tachysystole" example:"0"`     // Эпизодов тахисистолии
+}
+
+// SessionDetailResponse сессия со служебными данными
+// @Description Сессия мониторинга КТГ: сегменты шкалы времени, продолжение после сброса шкалы, качество сигнала и число событий анализа
+type SessionDetailResponse struct {
+	SessionResponse
+	Segments      []models.CTGSegment              `json:"segments,omitempty"`                                                      // Сегменты шкалы времени
+	ContinuesFrom *string                          `json:"continues_from,omitempty" example:"550e8400-e29b-41d4-a716-446655440002"` // Предыдущая сессия, если эта открыта как продолжение
+	LastDataAt    *time.Time                       `json:"last_data_at,omitempty" example:"2023-09-01T11:29:58Z"`                   // Последняя запись точек в БД
+	SignalQuality map[string]models.ChannelQuality `json:"signal_quality,omitempty"`                                                // Качество сигнала по каналам (после завершения)
+	EventCount    int                              `json:"event_count" example:"42"`                                                // Событий анализа (см. /sessions/{session_id}/data)
+}
+
+// SessionListResponse страница списка сессий
+// @Description Сессии по фильтру; следующая страница запрашивается с cursor=next_cursor
+type SessionListResponse struct {
+	Sessions   []SessionResponse `json:"sessions"`                                       // Сессии страницы
+	Count      int               `json:"count" example:"50"`                             // Количество сессий на странице
+	NextCursor string            `json:"next_cursor,omitempty" example:"MjAyMy0wOS0wMV"` // Курсор следующей страницы (нет на последней)
 }
 
 // CardSessionsResponse сессии для медицинской карты
@@ -77,21 +170,63 @@ type CardSessionsResponse struct {
 	Count    int               `json:"count" example:"5"`                                      // Количество сессий
 }
 
+// BindDeviceRequest запрос на привязку устройства к медицинской карте
+// @Description Привязка устройства, передающего данные без медкарты, к карте пациента
+type BindDeviceRequest struct {
+	CardID   string `json:"card_id" binding:"required" example:"550e8400-e29b-41d4-a716-446655440000"` // UUID медицинской карты пациента
+	KeepData bool   `json:"keep_data" example:"true"`                                                  // Сохранить данные, полученные до привязки
+}
+
+// BindDeviceResponse результат привязки устройства
+// @Description Созданная сессия и судьба данных, полученных до привязки
+type BindDeviceResponse struct {
+	Session         SessionResponse `json:"session"`                      // Созданная сессия
+	KeptPoints      int             `json:"kept_points" example:"1200"`   // Точек перенесено в сессию
+	DiscardedPoints int             `json:"discarded_points" example:"0"` // Точек отброшено
+}
+
+// UnassignedDevicesResponse список устройств без привязки к карте
+// @Description Устройства, передающие данные без привязки к медицинской карте
+type UnassignedDevicesResponse struct {
+	Devices []UnassignedDeviceInfo `json:"devices"`           // Непривязанные устройства
+	Count   int                    `json:"count" example:"1"` // Количество устройств
+}
+
+// AcknowledgeAlarmRequest подтверждение тревоги
+// @Description Кто подтверждает тревогу (врач или акушерка центрального поста)
+type AcknowledgeAlarmRequest struct {
+	AcknowledgedBy string `json:"acknowledged_by" binding:"required" example:"Иванова А.П."` // Кто подтвердил тревогу
+}
+
+// AlarmsResponse список тревог
+// @Description Клинические тревоги: неснятые или история из БД
+type AlarmsResponse struct {
+	Alarms []models.CTGAlarm `json:"alarms"`            // Тревоги
+	Count  int               `json:"count" example:"1"` // Количество тревог
+}
+
 // DevicesResponse список устройств
-// @Description Список всех доступных устройств КТГ
+// @Description Список всех известных устройств КТГ и их статус
 type DevicesResponse struct {
-	Devices []string `json:"devices" example:"CTG-DEVICE-001,CTG-DEVICE-002"` // Список идентификаторов устройств
-	Count   int      `json:"count" example:"2"`                               // Количество устройств
+	Devices []DeviceStatusResponse `json:"devices"`           // Устройства в порядке идентификаторов
+	Count   int                    `json:"count" example:"2"` // Количество устройств
 }
 
 // DeviceStatusResponse статус устройства
-// @Description Текущий статус устройства КТГ
+// @Description Текущий статус устройства КТГ. Последняя сессия, часы, качество сигнала и тревоги заполняются только в /devices/{device_id}/status
 type DeviceStatusResponse struct {
-	DeviceID  string     `json:"device_id" example:"CTG-DEVICE-001"`                                  // Идентификатор устройства
-	Status    string     `json:"status" example:"active" enums:"active,idle"`                         // Статус устройства
-	SessionID *string    `json:"session_id,omitempty" example:"550e8400-e29b-41d4-a716-446655440001"` // UUID активной сессии (если есть)
-	StartTime *time.Time `json:"start_time,omitempty" example:"2023-09-01T10:00:00Z"`                 // Время начала активной сессии
-	Duration  *int       `json:"duration,omitempty" example:"3600"`                                   // Продолжительность активной сессии в секундах
+	DeviceID   string                `json:"device_id" example:"CTG-DEVICE-001"`                                  // Идентификатор устройства
+	Status     string                `json:"status" example:"active" enums:"active,unassigned,idle"`              // Статус устройства: идет сессия, данные без карты, простой
+	SessionID  *string               `json:"session_id,omitempty" example:"550e8400-e29b-41d4-a716-446655440001"` // UUID активной сессии (если есть)
+	CardID     *string               `json:"card_id,omitempty" example:"550e8400-e29b-41d4-a716-446655440000"`    // UUID медицинской карты активной сессии
+	StartTime  *time.Time            `json:"start_time,omitempty" example:"2023-09-01T10:00:00Z"`                 // Время начала активной сессии
+	Duration   *int                  `json:"duration,omitempty" example:"3600"`                                   // Продолжительность активной сессии в секундах
+	Unassigned *UnassignedDeviceInfo `json:"unassigned,omitempty"`                                                // Данные, ожидающие привязки к карте
+
+	LastSession *SessionResponse       `json:"last_session,omitempty"` // Последняя сессия устройства
+	Clock       *clock.Stats           `json:"clock,omitempty"`        // Оценка часов устройства
+	Quality     []quality.ChannelStats `json:"quality,omitempty"`      // Качество сигнала по каналам
+	OpenAlarms  []models.CTGAlarm      `json:"open_alarms,omitempty"`  // Неснятые тревоги
 }
 
 // HealthResponse состояние сервиса
@@ -117,6 +252,55 @@ type ActiveSessionsResponse struct {
 	Count    int               `json:"count" example:"3"` // Количество активных сессий
 }
 
+// FilterStatsResponse статистика фильтров артефактов
+// @Description Статистика фильтров артефактов по каналам устройств
+type FilterStatsResponse struct {
+	Channels []filters.ChannelStats `json:"channels"`          // Статистика по каналам устройств
+	Count    int                    `json:"count" example:"4"` // Количество каналов
+}
+
+// ReorderStatsResponse счетчики буферов упорядочивания
+// @Description Переупорядоченные, дублирующиеся и опоздавшие точки по каналам устройств
+type ReorderStatsResponse struct {
+	Channels []reorder.ChannelStats `json:"channels"`          // Счетчики по каналам устройств
+	Count    int                    `json:"count" example:"4"` // Количество каналов
+}
+
+// ChannelsResponse зарегистрированные каналы данных
+// @Description Каналы, которые принимает и хранит сервис
+type ChannelsResponse struct {
+	Channels []channels.Channel `json:"channels"`          // Описание каналов
+	Count    int                `json:"count" example:"9"` // Количество каналов
+}
+
+// ClockStatsResponse оценки часов устройств
+// @Description Смещение и дрейф часов устройств относительно сервера
+type ClockStatsResponse struct {
+	Devices []clock.DeviceStats `json:"devices"`           // Оценки по устройствам
+	Count   int                 `json:"count" example:"2"` // Количество устройств
+}
+
+// CoincidenceStatsResponse состояние пар каналов ЧСС устройств
+// @Description Сравнение каналов ЧСС плодов между собой и с ЧСС матери
+type CoincidenceStatsResponse struct {
+	Devices []coincidence.DeviceStats `json:"devices"`           // Пары каналов по устройствам
+	Count   int                       `json:"count" example:"2"` // Количество устройств
+}
+
+// AnalysisStatsResponse текущие оценки ЧСС плода
+// @Description Базальный ритм, вариабельность и число эпизодов по каналам ЧСС плода, схватки по каналу сокращений матки
+type AnalysisStatsResponse struct {
+	Channels []figo.ChannelStats `json:"channels"`          // Оценки по каналам устройств
+	Count    int                 `json:"count" example:"2"` // Количество каналов
+}
+
+// QualityStatsResponse текущее качество сигнала
+// @Description Индекс качества сигнала и эпизоды потери по каналам устройств
+type QualityStatsResponse struct {
+	Channels []quality.ChannelStats `json:"channels"`          // Оценки по каналам устройств
+	Count    int                    `json:"count" example:"3"` // Количество каналов
+}
+
 // ErrorResponse стандартный ответ об ошибке
 // @Description Стандартная структура ответа об ошибке
 type ErrorResponse struct {
@@ -136,11 +320,13 @@ func NewRESTAPIServer(
 	sessionManager *SessionManager,
 	grpcStreamer *GRPCStreamer,
 	mqttProcessor *MQTTStreamProcessor,
+	alarmManager *AlarmManager,
 ) *RESTAPIServer {
 	return &RESTAPIServer{
 		sessionManager: sessionManager,
 		grpcStreamer:   grpcStreamer,
 		mqttProcessor:  mqttProcessor,
+		alarmManager:   alarmManager,
 	}
 }
 
@@ -172,29 +358,51 @@ func (api *RESTAPIServer) SetupRoutes() *gin.Engine {
 	{
 		sessions.POST("/start", api.StartSession)
 		sessions.POST("/stop/:session_id", api.StopSession)
-		//sessions.GET("/active", api.GetActiveSessions)
-		//sessions.GET("/:session_id", api.GetSession)
-		//sessions.GET("/:session_id/data", api.GetSessionData)
+		sessions.GET("", api.GetSessions)
+		sessions.GET("/active", api.GetActiveSessions)
+		sessions.GET("/:session_id", api.GetSession)
+		sessions.GET("/:session_id/data", api.GetSessionData)
+		sessions.GET("/:session_id/archive", api.GetSessionArchive)
+		sessions.GET("/:session_id/rollups", api.GetSessionRollups)
 	}
 
 	// === МЕДИЦИНСКИЕ КАРТЫ ===
-	//cards := api_group.Group("/cards")
-	//{
-	//	cards.GET("/:card_id/sessions", api.GetCardSessions)
-	//}
+	cards := api_group.Group("/cards")
+	{
+		cards.GET("/:card_id/sessions", api.GetCardSessions)
+	}
 
 	// === УСТРОЙСТВА ===
-	//devices := api_group.Group("/devices")
-	//{
-	//	devices.GET("/", api.GetDevices)
-	//	devices.GET("/:device_id/status", api.GetDeviceStatus)
-	//}
+	devices := api_group.Group("/devices")
+	{
+		devices.GET("", api.GetDevices)
+		devices.GET("/:device_id/status", api.GetDeviceStatus)
+		devices.GET("/unassigned", api.GetUnassignedDevices)
+		devices.POST("/:device_id/bind", api.BindDevice)
+		devices.POST("/:device_id/alarms/acknowledge", api.AcknowledgeDeviceAlarms)
+	}
+
+	// === КЛИНИЧЕСКИЕ ТРЕВОГИ ===
+	alarmsGroup := api_group.Group("/alarms")
+	{
+		alarmsGroup.GET("", api.GetAlarms)
+		alarmsGroup.POST("/:alarm_id/acknowledge", api.AcknowledgeAlarm)
+	}
+
+	// === КАНАЛЫ ДАННЫХ ===
+	api_group.GET("/channels", api.GetChannels)
 
 	// === МОНИТОРИНГ СЕРВИСА ===
 	monitoring := api_group.Group("/monitoring")
 	{
 		monitoring.GET("/health", api.HealthCheck)
 		monitoring.POST("/cleanup", api.CleanupSessions)
+		monitoring.GET("/filters", api.GetFilterStats)
+		monitoring.GET("/reorder", api.GetReorderStats)
+		monitoring.GET("/clocks", api.GetClockStats)
+		monitoring.GET("/coincidence", api.GetCoincidenceStats)
+		monitoring.GET("/analysis", api.GetAnalysisStats)
+		monitoring.GET("/quality", api.GetQualityStats)
 	}
 
 	return r
@@ -202,7 +410,7 @@ func (api *RESTAPIServer) SetupRoutes() *gin.Engine {
 
 // StartSession запускает новую сессию мониторинга
 // @Summary Запуск новой сессии мониторинга КТГ
-// @Description Создает новую сессию мониторинга КТГ для указанной медицинской карты и устройства
+// @Description Создает новую сессию мониторинга КТГ для указанной медицинской карты и устройства. Сессия с протоколом nst (нестрессовый тест, 20-40 минут, реактивность по акцелерациям) или cst (стрессовый тест, 10-60 минут, поздние децелерации на схватки) завершается сама, когда критерии выполнены или время вышло, и сохраняет заключение
 // @Tags sessions
 // @Accept json
 // @Produce json
@@ -241,8 +449,15 @@ func (api *RESTAPIServer) StartSession(c *gin.Context) {
 	}
 
 	// Создание сессии
-	session, err := api.sessionManager.StartSession(cardID, req.DeviceID)
+	session, err := api.sessionManager.StartSession(cardID, req.DeviceID, req.Protocol)
 	if err != nil {
+		if errors.Is(err, ErrUnknownProtocol) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Неизвестный протокол теста",
+				Details: err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "Не удалось создать сессию",
 			Details: err.Error(),
@@ -257,6 +472,7 @@ func (api *RESTAPIServer) StartSession(c *gin.Context) {
 		Status:    "active",
 		StartTime: session.StartTime,
 		Duration:  0,
+		Protocol:  session.Protocol,
 	}
 
 	c.JSON(http.StatusOK, SuccessResponse{
@@ -293,26 +509,818 @@ func (api *RESTAPIServer) StopSession(c *gin.Context) {
 		return
 	}
 
-	duration := 0
-	if session.EndTime != nil {
-		duration = int(session.EndTime.Sub(session.StartTime).Seconds())
+	response := newSessionResponse(session)
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Сессия успешно завершена",
+		Data:    response,
+	})
+}
+
+// GetSessions список сессий
+// @Summary Список сессий
+// @Description Возвращает страницу сессий с отбором по статусу, медицинской карте, устройству и интервалу дат. В интервал попадают сессии, пересекающие его (активные - до текущего момента). Страницы упорядочены по времени начала; следующая страница запрашивается с cursor из next_cursor того же запроса
+// @Tags sessions
+// @Produce json
+// @Param status query string false "Статус сессии" Enums(active, stopped)
+// @Param card_id query string false "UUID медицинской карты" format(uuid)
+// @Param device_id query string false "Идентификатор устройства"
+// @Param from_time query string false "Начало интервала дат, RFC3339" format(date-time)
+// @Param to_time query string false "Конец интервала дат, RFC3339" format(date-time)
+// @Param sort query string false "Порядок: start_time - с первых, -start_time - с последних" Enums(start_time, -start_time) default(-start_time)
+// @Param limit query int false "Размер страницы (не больше 500)" default(50)
+// @Param cursor query string false "Курсор следующей страницы"
+// @Success 200 {object} SessionListResponse "Страница сессий"
+// @Failure 400 {object} ErrorResponse "Неверные параметры фильтра или курсор"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Router /sessions [get]
+func (api *RESTAPIServer) GetSessions(c *gin.Context) {
+	filter := SessionFilter{
+		Status:   c.Query("status"),
+		DeviceID: c.Query("device_id"),
+		Sort:     c.Query("sort"),
+		Cursor:   c.Query("cursor"),
+	}
+	if value := c.Query("card_id"); value != "" {
+		cardID, err := uuid.Parse(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Неверный ID медицинской карты",
+			})
+			return
+		}
+		filter.CardID = &cardID
+	}
+	timeRange, err := parseTimeRange(c)
+	if err == nil && (timeRange.From != nil || timeRange.To != nil) {
+		err = errors.New("поддерживаются только from_time/to_time")
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Неверный интервал времени",
+			Details: err.Error(),
+		})
+		return
+	}
+	filter.FromTime, filter.ToTime = timeRange.FromTime, timeRange.ToTime
+	if value := c.Query("limit"); value != "" {
+		if filter.Limit, err = strconv.Atoi(value); err != nil || filter.Limit <= 0 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Неверный параметр limit",
+			})
+			return
+		}
+	}
+
+	page, err := api.sessionManager.ListSessions(filter)
+	if err != nil {
+		if errors.Is(err, ErrInvalidSessionFilter) || errors.Is(err, ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Неверные параметры списка сессий",
+				Details: err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Не удалось получить список сессий",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, SessionListResponse{
+		Sessions:   newSessionResponses(page.Sessions),
+		Count:      len(page.Sessions),
+		NextCursor: page.NextCursor,
+	})
+}
+
+// GetActiveSessions активные сессии
+// @Summary Активные сессии
+// @Description Возвращает сессии, идущие сейчас, в порядке начала
+// @Tags sessions
+// @Produce json
+// @Success 200 {object} ActiveSessionsResponse "Активные сессии"
+// @Router /sessions/active [get]
+func (api *RESTAPIServer) GetActiveSessions(c *gin.Context) {
+	sessions := api.sessionManager.GetAllActiveSessions()
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].StartTime.Before(sessions[j].StartTime) })
+	c.JSON(http.StatusOK, ActiveSessionsResponse{
+		Sessions: newSessionResponses(sessions),
+		Count:    len(sessions),
+	})
+}
+
+// GetSession сессия по ID
+// @Summary Сессия мониторинга
+// @Description Возвращает сессию: статус, длительность, протокол и итоги, сегменты шкалы времени и качество сигнала. Точки и события анализа - в /sessions/{session_id}/data
+// @Tags sessions
+// @Produce json
+// @Param session_id path string true "UUID сессии" format(uuid)
+// @Success 200 {object} SessionDetailResponse "Сессия"
+// @Failure 400 {object} ErrorResponse "Неверный ID сессии"
+// @Failure 404 {object} ErrorResponse "Сессия не найдена"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Router /sessions/{session_id} [get]
+func (api *RESTAPIServer) GetSession(c *gin.Context) {
+	sessionID, err := uuid.Parse(c.Param("session_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Неверный ID сессии",
+		})
+		return
+	}
+
+	session, err := api.sessionManager.GetSession(sessionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "Сессия не найдена",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Не удалось получить сессию",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	response := SessionDetailResponse{
+		SessionResponse: newSessionResponse(session),
+		Segments:        session.Segments,
+		LastDataAt:      session.LastDataAt,
+		SignalQuality:   session.SignalQuality,
+		EventCount:      len(session.Events),
+	}
+	if session.ContinuesFrom != nil {
+		continuesFrom := session.ContinuesFrom.String()
+		response.ContinuesFrom = &continuesFrom
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetCardSessions история сессий медицинской карты
+// @Summary Сессии медицинской карты
+// @Description Возвращает все сессии медицинской карты, начиная с последней
+// @Tags cards
+// @Produce json
+// @Param card_id path string true "UUID медицинской карты" format(uuid)
+// @Success 200 {object} CardSessionsResponse "Сессии карты"
+// @Failure 400 {object} ErrorResponse "Неверный ID медицинской карты"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Router /cards/{card_id}/sessions [get]
+func (api *RESTAPIServer) GetCardSessions(c *gin.Context) {
+	cardID, err := uuid.Parse(c.Param("card_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Неверный ID медицинской карты",
+		})
+		return
+	}
+
+	sessions, err := api.sessionManager.GetSessionsByCardID(cardID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Не удалось получить сессии медицинской карты",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, CardSessionsResponse{
+		CardID:   cardID.String(),
+		Sessions: newSessionResponses(sessions),
+		Count:    len(sessions),
+	})
+}
+
+// GetSessionData данные сессии за интервал времени
+// @Summary Данные КТГ сессии
+// @Description Возвращает точки ЧСС плода и маточных сокращений, события анализа ЧСС плода и схваток, сводку схваток, долю точек с сигналом и эпизоды потери сигнала по каналам. Интервал задается временем сессии (from/to, секунды) или абсолютным временем (from_time/to_time, RFC3339), но не обоими способами сразу. При max_points ряды каналов, в которых точек больше, прореживаются методом LTTB (Largest-Triangle-Three-Buckets); сводка схваток и качество сигнала считаются по всем точкам
+// @Tags sessions
+// @Produce json
+// @Param session_id path string true "UUID сессии" format(uuid)
+// @Param channels query string false "Каналы через запятую (по умолчанию все)" example(fetal_heart_rate,uterine_contractions)
+// @Param max_points query int false "Максимум точек на канал (0 - без прореживания, иначе не меньше 3)"
+// @Param from query number false "Начало интервала, секунды от начала сессии"
+// @Param to query number false "Конец интервала, секунды от начала сессии"
+// @Param from_time query string false "Начало интервала, RFC3339" format(date-time)
+// @Param to_time query string false "Конец интервала, RFC3339" format(date-time)
+// @Success 200 {object} SessionDataResponse "Данные сессии"
+// @Failure 400 {object} ErrorResponse "Неверный ID сессии, интервал, канал или max_points"
+// @Failure 404 {object} ErrorResponse "Сессия не найдена"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Router /sessions/{session_id}/data [get]
+func (api *RESTAPIServer) GetSessionData(c *gin.Context) {
+	sessionID, err := uuid.Parse(c.Param("session_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Неверный ID сессии",
+		})
+		return
+	}
+
+	timeRange, err := parseTimeRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Неверный интервал времени",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	maxPoints, err := strconv.Atoi(c.DefaultQuery("max_points", "0"))
+	if err != nil || maxPoints < 0 || maxPoints > 0 && maxPoints < minMaxPoints {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: fmt.Sprintf("max_points должен быть 0 или целым числом не меньше %d", minMaxPoints),
+		})
+		return
+	}
+
+	data, err := api.sessionManager.GetSessionSeries(sessionID, parseChannels(c), timeRange)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidTimeRange):
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Неверный интервал времени",
+				Details: err.Error(),
+			})
+		case errors.Is(err, ErrUnknownChannel):
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Неизвестный канал",
+				Details: err.Error(),
+			})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "Сессия не найдена",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error:   "Не удалось получить данные сессии",
+				Details: err.Error(),
+			})
+		}
+		return
 	}
 
+	response := SessionDataResponse{
+		SessionID:    data.Session.ID.String(),
+		DeviceID:     data.Session.DeviceID,
+		StartTime:    data.Session.StartTime,
+		Segments:     data.Session.Segments,
+		FHRData:      []models.CTGPoint{},
+		UCData:       []models.CTGPoint{},
+		Events:       data.Events,
+		Quality:      summarizeQuality(data.Series, api.sessionManager.dataBuffer.channels),
+		SourcePoints: countPoints(data.Series),
+		MaxPoints:    maxPoints,
+	}
+	if uc := data.Series[channels.UterineContractions]; len(uc) > 0 {
+		response.Contractions = summarizeContractions(data.Events, uc)
+	}
+	series := downsampleSeries(data.Series, maxPoints)
+	response.TotalPoints = countPoints(series)
+	for name, points := range series {
+		switch name {
+		case channels.FetalHeartRate:
+			response.FHRData = points
+		case channels.FetalHeartRate2:
+			response.FHR2Data = points
+		case channels.UterineContractions:
+			response.UCData = points
+		default:
+			if response.Channels == nil {
+				response.Channels = make(map[string][]models.CTGPoint)
+			}
+			response.Channels[name] = points
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetSessionArchive выгружает архив сессии
+// @Summary Архив сессии
+// @Description Возвращает файл архива сессии: данные сессии (JSON) и точки всех каналов в компактной двоичной кодировке (pkg/codec)
+// @Tags sessions
+// @Produce octet-stream
+// @Param session_id path string true "UUID сессии" format(uuid)
+// @Success 200 {file} file "Архив сессии (.ctga)"
+// @Failure 400 {object} ErrorResponse "Неверный ID сессии"
+// @Failure 404 {object} ErrorResponse "Сессия не найдена"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Router /sessions/{session_id}/archive [get]
+func (api *RESTAPIServer) GetSessionArchive(c *gin.Context) {
+	sessionID, err := uuid.Parse(c.Param("session_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Неверный ID сессии",
+		})
+		return
+	}
+
+	data, err := api.sessionManager.GetSessionData(sessionID, TimeRange{})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "Сессия не найдена",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Не удалось получить данные сессии",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := archive.Write(&buf, data.Session, data.Series, api.sessionManager.dataBuffer.format); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Не удалось сформировать архив сессии",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="ctg-%s%s"`, sessionID, archive.Extension))
+	c.Data(http.StatusOK, "application/octet-stream", buf.Bytes())
+}
+
+// GetSessionRollups ряды сессии для обзорного графика
+// @Summary Обзор сессии в подобранном разрешении
+// @Description Возвращает ряды каналов на интервале, сведенные до ширины графика: самые крупные сводки (1, 10 или 60 с), которых на точку графика приходится не меньше одной. Если интервал короче ширины графика в секундах, возвращаются исходные точки
+// @Tags sessions
+// @Produce json
+// @Param session_id path string true "UUID сессии" format(uuid)
+// @Param channels query string false "Каналы через запятую (по умолчанию все)" example(fetal_heart_rate,uterine_contractions)
+// @Param from query number false "Начало интервала, секунды от начала сессии (по умолчанию 0)"
+// @Param to query number false "Конец интервала, секунды от начала сессии (по умолчанию длительность сессии)"
+// @Param width query int false "Ширина графика, точек" default(1000)
+// @Success 200 {object} SessionRollupsResponse "Ряды сессии"
+// @Failure 400 {object} ErrorResponse "Неверный ID сессии, интервал, ширина или канал"
+// @Failure 404 {object} ErrorResponse "Сессия не найдена"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Router /sessions/{session_id}/rollups [get]
+func (api *RESTAPIServer) GetSessionRollups(c *gin.Context) {
+	sessionID, err := uuid.Parse(c.Param("session_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Неверный ID сессии",
+		})
+		return
+	}
+
+	timeRange, err := parseTimeRange(c)
+	if err != nil || timeRange.IsWallClock() {
+		details := "поддерживаются только from/to"
+		if err != nil {
+			details = err.Error()
+		}
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Неверный интервал времени",
+			Details: details,
+		})
+		return
+	}
+
+	width := defaultOverviewWidth
+	if value := c.Query("width"); value != "" {
+		if width, err = strconv.Atoi(value); err != nil || width <= 0 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Ширина графика должна быть положительным целым числом",
+			})
+			return
+		}
+	}
+
+	overview, err := api.sessionManager.GetSessionOverview(sessionID, parseChannels(c), timeRange.From, timeRange.To, width)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidTimeRange):
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Неверный интервал времени",
+				Details: err.Error(),
+			})
+		case errors.Is(err, ErrUnknownChannel):
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Неизвестный канал",
+				Details: err.Error(),
+			})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "Сессия не найдена",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error:   "Не удалось получить данные сессии",
+				Details: err.Error(),
+			})
+		}
+		return
+	}
+
+	response := SessionRollupsResponse{
+		SessionID:  sessionID.String(),
+		From:       overview.From,
+		To:         overview.To,
+		Width:      width,
+		Resolution: overview.Resolution,
+		Points:     overview.Points,
+	}
+	if overview.Rollups != nil {
+		response.Rollups = make(map[string][]RollupBucket, len(overview.Rollups))
+		for name, rows := range overview.Rollups {
+			buckets := make([]RollupBucket, len(rows))
+			for i, row := range rows {
+				buckets[i] = RollupBucket{
+					T:     row.Start(),
+					Min:   row.Min,
+					Max:   row.Max,
+					Mean:  row.Mean,
+					Valid: row.Valid,
+					Count: row.Count,
+				}
+			}
+			response.Rollups[name] = buckets
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// newSessionResponse описание сессии для API; длительность активной сессии - до текущего момента
+func newSessionResponse(session *models.CTGSession) SessionResponse {
 	response := SessionResponse{
 		SessionID: session.ID.String(),
 		CardID:    session.CardID.String(),
 		DeviceID:  session.DeviceID,
-		Status:    "stopped",
+		Status:    SessionActive,
 		StartTime: session.StartTime,
 		EndTime:   session.EndTime,
-		Duration:  duration,
+		Duration:  int(time.Since(session.StartTime).Seconds()),
+
+		Protocol:       session.Protocol,
+		ProtocolResult: session.ProtocolResult,
+		Summary:        session.Summary,
+	}
+	if session.EndTime != nil {
+		response.Status = SessionStopped
+		response.Duration = int(session.EndTime.Sub(session.StartTime).Seconds())
+	}
+	return response
+}
+
+// newSessionResponses описания сессий для API в том же порядке
+func newSessionResponses(sessions []*models.CTGSession) []SessionResponse {
+	responses := make([]SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		responses = append(responses, newSessionResponse(session))
+	}
+	return responses
+}
+
+// newDeviceStatusResponse статус устройства для API
+func newDeviceStatusResponse(device DeviceSummary) DeviceStatusResponse {
+	response := DeviceStatusResponse{
+		DeviceID:   device.DeviceID,
+		Status:     device.Status,
+		Unassigned: device.Pending,
+	}
+	if session := device.Session; session != nil {
+		sessionID, cardID, startTime := session.ID.String(), session.CardID.String(), session.StartTime
+		duration := int(time.Since(startTime).Seconds())
+		response.SessionID = &sessionID
+		response.CardID = &cardID
+		response.StartTime = &startTime
+		response.Duration = &duration
+	}
+	return response
+}
+
+// parseChannels разбирает список каналов через запятую из параметра channels
+func parseChannels(c *gin.Context) []string {
+	var names []string
+	for _, name := range strings.Split(c.Query("channels"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// parseTimeRange разбирает параметры интервала from/to и from_time/to_time
+func parseTimeRange(c *gin.Context) (TimeRange, error) {
+	var timeRange TimeRange
+	for name, target := range map[string]**float64{"from": &timeRange.From, "to": &timeRange.To} {
+		if value := c.Query(name); value != "" {
+			seconds, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return timeRange, fmt.Errorf("%s: %w", name, err)
+			}
+			*target = &seconds
+		}
+	}
+	for name, target := range map[string]**time.Time{"from_time": &timeRange.FromTime, "to_time": &timeRange.ToTime} {
+		if value := c.Query(name); value != "" {
+			wall, err := time.Parse(time.RFC3339Nano, value)
+			if err != nil {
+				return timeRange, fmt.Errorf("%s: %w", name, err)
+			}
+			*target = &wall
+		}
+	}
+	return timeRange, nil
+}
+
+// GetDevices список устройств
+// @Summary Устройства КТГ
+// @Description Возвращает устройства, которые записывали сессии, ведут сессию сейчас или передают данные без медицинской карты, и их статус
+// @Tags devices
+// @Produce json
+// @Success 200 {object} DevicesResponse "Устройства"
+// @Router /devices [get]
+func (api *RESTAPIServer) GetDevices(c *gin.Context) {
+	devices := api.sessionManager.GetDevices()
+	response := DevicesResponse{
+		Devices: make([]DeviceStatusResponse, 0, len(devices)),
+		Count:   len(devices),
+	}
+	for _, device := range devices {
+		response.Devices = append(response.Devices, newDeviceStatusResponse(device))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetDeviceStatus статус устройства
+// @Summary Статус устройства КТГ
+// @Description Возвращает статус устройства, активную и последнюю сессии, данные без привязки к карте, оценку часов, качество сигнала по каналам и неснятые тревоги
+// @Tags devices
+// @Produce json
+// @Param device_id path string true "Идентификатор устройства"
+// @Success 200 {object} DeviceStatusResponse "Статус устройства"
+// @Failure 404 {object} ErrorResponse "Устройство не найдено"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Router /devices/{device_id}/status [get]
+func (api *RESTAPIServer) GetDeviceStatus(c *gin.Context) {
+	deviceID := c.Param("device_id")
+	device, ok := api.sessionManager.GetDevice(deviceID)
+	if !ok {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error: "Устройство не найдено",
+		})
+		return
+	}
+	response := newDeviceStatusResponse(device)
+
+	page, err := api.sessionManager.ListSessions(SessionFilter{DeviceID: deviceID, Limit: 1})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Не удалось получить сессии устройства",
+			Details: err.Error(),
+		})
+		return
+	}
+	if len(page.Sessions) > 0 {
+		last := newSessionResponse(page.Sessions[0])
+		response.LastSession = &last
 	}
 
+	for _, stats := range api.mqttProcessor.GetClockStats() {
+		if stats.DeviceID == deviceID {
+			response.Clock = &stats.Stats
+			break
+		}
+	}
+	for _, stats := range api.mqttProcessor.GetQualityStats() {
+		if stats.DeviceID == deviceID {
+			response.Quality = append(response.Quality, stats)
+		}
+	}
+	for _, alarm := range api.alarmManager.Open() {
+		if alarm.DeviceID == deviceID {
+			response.OpenAlarms = append(response.OpenAlarms, alarm)
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetUnassignedDevices список устройств без привязки к карте
+// @Summary Устройства без привязки к медицинской карте
+// @Description Возвращает устройства, которые передают данные без активной сессии, и объем накопленных данных
+// @Tags devices
+// @Produce json
+// @Success 200 {object} UnassignedDevicesResponse "Непривязанные устройства"
+// @Router /devices/unassigned [get]
+func (api *RESTAPIServer) GetUnassignedDevices(c *gin.Context) {
+	devices := api.sessionManager.GetUnassignedDevices()
+	c.JSON(http.StatusOK, UnassignedDevicesResponse{
+		Devices: devices,
+		Count:   len(devices),
+	})
+}
+
+// BindDevice привязывает устройство к медицинской карте
+// @Summary Привязка устройства к медицинской карте
+// @Description Начинает сессию для устройства, передающего данные без карты. При keep_data=true данные, полученные до привязки, сохраняются в сессии, иначе отбрасываются
+// @Tags devices
+// @Accept json
+// @Produce json
+// @Param device_id path string true "Идентификатор устройства"
+// @Param request body BindDeviceRequest true "Карта пациента и судьба накопленных данных"
+// @Success 200 {object} SuccessResponse{data=BindDeviceResponse} "Устройство привязано"
+// @Failure 400 {object} ErrorResponse "Неверный формат данных"
+// @Failure 409 {object} ErrorResponse "У устройства уже есть активная сессия"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Router /devices/{device_id}/bind [post]
+func (api *RESTAPIServer) BindDevice(c *gin.Context) {
+	var req BindDeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Неверный формат данных",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	cardID, err := uuid.Parse(req.CardID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Неверный ID медицинской карты",
+		})
+		return
+	}
+
+	result, err := api.sessionManager.BindDevice(c.Param("device_id"), cardID, req.KeepData)
+	if err != nil {
+		if errors.Is(err, ErrDeviceHasActiveSession) {
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Error:   "У устройства уже есть активная сессия",
+				Details: err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Не удалось привязать устройство",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	session := result.Session
 	c.JSON(http.StatusOK, SuccessResponse{
-		Message: "Сессия успешно завершена",
-		Data:    response,
+		Message: "Устройство привязано к медицинской карте",
+		Data: BindDeviceResponse{
+			Session: SessionResponse{
+				SessionID: session.ID.String(),
+				CardID:    session.CardID.String(),
+				DeviceID:  session.DeviceID,
+				Status:    "active",
+				StartTime: session.StartTime,
+			},
+			KeptPoints:      result.KeptPoints,
+			DiscardedPoints: result.DiscardedPoints,
+		},
+	})
+}
+
+// GetAlarms возвращает клинические тревоги
+// @Summary Клинические тревоги
+// @Description Без параметра history возвращает неснятые тревоги из памяти движка. При history=true возвращает тревоги из БД, начиная с последних, с фильтром по состоянию (raised, acknowledged, cleared)
+// @Tags alarms
+// @Produce json
+// @Param device_id query string false "Идентификатор устройства"
+// @Param history query bool false "Читать историю из БД"
+// @Param state query string false "Состояние тревоги (только для истории)"
+// @Param limit query int false "Максимум тревог истории" default(100)
+// @Success 200 {object} AlarmsResponse "Тревоги"
+// @Failure 400 {object} ErrorResponse "Неверные параметры"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Router /alarms [get]
+func (api *RESTAPIServer) GetAlarms(c *gin.Context) {
+	deviceID := c.Query("device_id")
+
+	if c.Query("history") != "true" {
+		open := make([]models.CTGAlarm, 0)
+		for _, alarm := range api.alarmManager.Open() {
+			if deviceID == "" || alarm.DeviceID == deviceID {
+				open = append(open, alarm)
+			}
+		}
+		c.JSON(http.StatusOK, AlarmsResponse{Alarms: open, Count: len(open)})
+		return
+	}
+
+	state := c.Query("state")
+	switch state {
+	case "", models.AlarmRaised, models.AlarmAcknowledged, models.AlarmCleared:
+	default:
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Неверное состояние тревоги",
+			Details: "ожидается raised, acknowledged или cleared",
+		})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Неверный параметр limit",
+		})
+		return
+	}
+
+	history, err := api.alarmManager.History(deviceID, state, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Не удалось получить историю тревог",
+			Details: err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, AlarmsResponse{Alarms: history, Count: len(history)})
+}
+
+// AcknowledgeAlarm подтверждает тревогу
+// @Summary Подтверждение тревоги
+// @Description Переводит поднятую тревогу в состояние acknowledged и останавливает ее эскалацию. Повторное подтверждение возвращает тревогу без изменений
+// @Tags alarms
+// @Accept json
+// @Produce json
+// @Param alarm_id path string true "ID тревоги"
+// @Param request body AcknowledgeAlarmRequest true "Кто подтверждает тревогу"
+// @Success 200 {object} SuccessResponse{data=models.CTGAlarm} "Тревога подтверждена"
+// @Failure 400 {object} ErrorResponse "Неверный формат данных"
+// @Failure 404 {object} ErrorResponse "Тревога не найдена или уже снята"
+// @Router /alarms/{alarm_id}/acknowledge [post]
+func (api *RESTAPIServer) AcknowledgeAlarm(c *gin.Context) {
+	alarmID, err := uuid.Parse(c.Param("alarm_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Неверный ID тревоги",
+		})
+		return
+	}
+
+	var req AcknowledgeAlarmRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Неверный формат данных",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	alarm, err := api.alarmManager.Acknowledge(alarmID, req.AcknowledgedBy)
+	if err != nil {
+		if errors.Is(err, alarms.ErrAlarmNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error:   "Тревога не найдена",
+				Details: err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Не удалось подтвердить тревогу",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Тревога подтверждена",
+		Data:    alarm,
+	})
+}
+
+// AcknowledgeDeviceAlarms подтверждает все тревоги устройства
+// @Summary Подтверждение тревог устройства
+// @Description Переводит все поднятые тревоги устройства в состояние acknowledged и возвращает подтвержденные
+// @Tags alarms
+// @Accept json
+// @Produce json
+// @Param device_id path string true "Идентификатор устройства"
+// @Param request body AcknowledgeAlarmRequest true "Кто подтверждает тревоги"
+// @Success 200 {object} SuccessResponse{data=AlarmsResponse} "Тревоги подтверждены"
+// @Failure 400 {object} ErrorResponse "Неверный формат данных"
+// @Router /devices/{device_id}/alarms/acknowledge [post]
+func (api *RESTAPIServer) AcknowledgeDeviceAlarms(c *gin.Context) {
+	var req AcknowledgeAlarmRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Неверный формат данных",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	acknowledged := api.alarmManager.AcknowledgeDevice(c.Param("device_id"), req.AcknowledgedBy)
+	if acknowledged == nil {
+		acknowledged = make([]models.CTGAlarm, 0)
+	}
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Тревоги устройства подтверждены",
+		Data:    AlarmsResponse{Alarms: acknowledged, Count: len(acknowledged)},
 	})
-	go SendSessionToMedicalRecords(sessionID)
 }
 
 // HealthCheck проверка здоровья сервиса
@@ -345,3 +1353,108 @@ func (api *RESTAPIServer) CleanupSessions(c *gin.Context) {
 		ActiveSessions: api.sessionManager.GetActiveSessionCount(),
 	})
 }
+
+// GetFilterStats статистика фильтров артефактов
+// @Summary Статистика фильтров артефактов
+// @Description Возвращает количество обработанных и исправленных точек для каждого фильтра по каналам устройств
+// @Tags monitoring
+// @Produce json
+// @Success 200 {object} FilterStatsResponse "Статистика фильтров"
+// @Router /monitoring/filters [get]
+func (api *RESTAPIServer) GetFilterStats(c *gin.Context) {
+	stats := api.mqttProcessor.GetFilterStats()
+	c.JSON(http.StatusOK, FilterStatsResponse{
+		Channels: stats,
+		Count:    len(stats),
+	})
+}
+
+// GetChannels список каналов данных
+// @Summary Зарегистрированные каналы данных
+// @Description Возвращает каналы с единицами измерения, допустимым диапазоном, частотой и цепочкой фильтров. Сообщения незарегистрированных каналов отклоняются
+// @Tags channels
+// @Produce json
+// @Success 200 {object} ChannelsResponse "Каналы данных"
+// @Router /channels [get]
+func (api *RESTAPIServer) GetChannels(c *gin.Context) {
+	all := api.mqttProcessor.channels.All()
+	c.JSON(http.StatusOK, ChannelsResponse{
+		Channels: all,
+		Count:    len(all),
+	})
+}
+
+// GetClockStats оценки часов устройств
+// @Summary Смещение и дрейф часов устройств
+// @Description Возвращает для каждого устройства оценку смещения его часов относительно сервера и скорость их ухода. Оценка строится по меткам времени измерений
+// @Tags monitoring
+// @Produce json
+// @Success 200 {object} ClockStatsResponse "Оценки часов устройств"
+// @Router /monitoring/clocks [get]
+func (api *RESTAPIServer) GetClockStats(c *gin.Context) {
+	stats := api.mqttProcessor.GetClockStats()
+	c.JSON(http.StatusOK, ClockStatsResponse{
+		Devices: stats,
+		Count:   len(stats),
+	})
+}
+
+// GetCoincidenceStats состояние пар каналов ЧСС
+// @Summary Совпадение каналов ЧСС
+// @Description Возвращает для каждого устройства долю совпадающих точек в парах ЧСС плода 1 и 2, ЧСС плода и ЧСС матери. Активное совпадение означает, что датчики записывают одно сердце или ЧСС матери
+// @Tags monitoring
+// @Produce json
+// @Success 200 {object} CoincidenceStatsResponse "Состояние пар каналов"
+// @Router /monitoring/coincidence [get]
+func (api *RESTAPIServer) GetCoincidenceStats(c *gin.Context) {
+	stats := api.mqttProcessor.GetCoincidenceStats()
+	c.JSON(http.StatusOK, CoincidenceStatsResponse{
+		Devices: stats,
+		Count:   len(stats),
+	})
+}
+
+// GetAnalysisStats текущие оценки ЧСС плода
+// @Summary Анализ ЧСС плода по FIGO
+// @Description Возвращает для каждого канала ЧСС плода текущий базальный ритм, амплитуду и класс вариабельности, количество акцелераций и децелераций по типам с начала наблюдения; для канала сокращений матки - тонус, количество схваток, частоту за 10 минут и признак тахисистолии
+// @Tags monitoring
+// @Produce json
+// @Success 200 {object} AnalysisStatsResponse "Оценки по каналам"
+// @Router /monitoring/analysis [get]
+func (api *RESTAPIServer) GetAnalysisStats(c *gin.Context) {
+	stats := api.mqttProcessor.GetAnalysisStats()
+	c.JSON(http.StatusOK, AnalysisStatsResponse{
+		Channels: stats,
+		Count:    len(stats),
+	})
+}
+
+// GetQualityStats текущее качество сигнала
+// @Summary Качество сигнала
+// @Description Возвращает для каждого канала сигнала устройства индекс качества (доля точек скользящего окна с сигналом без исправлений фильтрами), класс good/fair/poor, признак текущей потери сигнала и число эпизодов потери с начала наблюдения. Запись класса poor интерпретировать нельзя
+// @Tags monitoring
+// @Produce json
+// @Success 200 {object} QualityStatsResponse "Оценки по каналам"
+// @Router /monitoring/quality [get]
+func (api *RESTAPIServer) GetQualityStats(c *gin.Context) {
+	stats := api.mqttProcessor.GetQualityStats()
+	c.JSON(http.StatusOK, QualityStatsResponse{
+		Channels: stats,
+		Count:    len(stats),
+	})
+}
+
+// GetReorderStats счетчики буферов упорядочивания
+// @Summary Статистика упорядочивания точек
+// @Description Возвращает для каждого канала устройства количество переставленных, дублирующихся, конфликтующих и опоздавших точек
+// @Tags monitoring
+// @Produce json
+// @Success 200 {object} ReorderStatsResponse "Счетчики буферов упорядочивания"
+// @Router /monitoring/reorder [get]
+func (api *RESTAPIServer) GetReorderStats(c *gin.Context) {
+	stats := api.mqttProcessor.GetReorderStats()
+	c.JSON(http.StatusOK, ReorderStatsResponse{
+		Channels: stats,
+		Count:    len(stats),
+	})
+}
@@ -0,0 +1,310 @@
+// internal/handlers/segments.go
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"CTG_monitor/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Политики обработки сброса шкалы времени устройства или долгого перерыва
+const (
+	SegmentPolicySplit    = "split"   // закрыть сессию и открыть сессию-продолжение
+	SegmentPolicyBoundary = "segment" // продолжить сессию, записав границу сегмента
+	SegmentPolicyOff      = "off"     // не отслеживать (точки пишутся с исходным time_sec)
+)
+
+// SegmentConfig правило разбиения сессии на сегменты
+type SegmentConfig struct {
+	Policy string
+	MaxGap time.Duration // перерыв (по time_sec или часам сервера), начинающий новый сегмент
+}
+
+// timeBase шкала времени устройства: переводит time_sec в время сессии
+// и обнаруживает сбросы и перерывы. Используется только воркером устройства.
+type timeBase struct {
+	ownerID uuid.UUID // сессия, к которой относится шкала (uuid.Nil - устройство без карты)
+	owned   bool
+
+	epoch    int     // номер шкалы устройства, растет при каждой границе
+	offset   float64 // смещение time_sec текущего сегмента
+	lastT    float64 // последнее время на шкале сессии
+	lastWall time.Time
+	anchor   time.Time // время сервера, соответствующее T = 0 текущего сегмента
+
+	channels map[string]*channelBase
+}
+
+// channelBase состояние шкалы одного канала
+type channelBase struct {
+	epoch       int
+	lastTimeSec float64
+	seen        bool
+	absorbReset bool // граница уже начата по перерыву, сброс этого канала ее не повторяет
+}
+
+func newTimeBase() *timeBase {
+	return &timeBase{channels: make(map[string]*channelBase)}
+}
+
+// detect проверяет точку канала на сброс шкалы и перерыв.
+// Возвращает причину новой границы (пусто, если граница не нужна) и stale=true
+// для точки, оставшейся от предыдущей шкалы после начала нового сегмента.
+func (tb *timeBase) detect(dataType string, timeSec float64, reset bool, now time.Time, maxGap time.Duration) (string, bool) {
+	channel := tb.channels[dataType]
+	if channel == nil {
+		channel = &channelBase{epoch: tb.epoch}
+		tb.channels[dataType] = channel
+	}
+
+	var reason string
+	switch {
+	case reset && channel.absorbReset:
+	case reset:
+		channel.epoch++
+		reason = models.SegmentTimeReset
+	case channel.seen && timeSec-channel.lastTimeSec > maxGap.Seconds():
+		channel.epoch++
+		reason = models.SegmentGap
+	case !tb.lastWall.IsZero() && now.Sub(tb.lastWall) > maxGap:
+		// Устройство молчало: новый сегмент сразу для всех каналов
+		for _, other := range tb.channels {
+			other.epoch = tb.epoch + 1
+			other.absorbReset = other != channel
+		}
+		reason = models.SegmentGap
+	}
+	if !reset {
+		channel.absorbReset = false
+	}
+	channel.lastTimeSec = timeSec
+	channel.seen = true
+
+	switch {
+	case channel.epoch < tb.epoch:
+		return "", true
+	case channel.epoch > tb.epoch:
+		tb.epoch = channel.epoch
+		return reason, false
+	default:
+		return "", false
+	}
+}
+
+// rebase переносит шкалу на новую сессию (или на устройство без карты)
+func (tb *timeBase) rebase(ownerID uuid.UUID, last *models.CTGSegment) {
+	tb.ownerID = ownerID
+	tb.owned = true
+	tb.offset = 0
+	tb.anchor = time.Time{}
+	if last != nil {
+		tb.offset = last.Offset
+		tb.anchor = last.Anchor()
+	}
+	tb.lastT = 0
+	tb.lastWall = time.Time{}
+}
+
+// place переводит time_sec точки на шкалу сессии
+func (tb *timeBase) place(timeSec float64, now time.Time) float64 {
+	t := timeSec + tb.offset
+	if t > tb.lastT {
+		tb.lastT = t
+	}
+	tb.lastWall = now
+	return t
+}
+
+// continuationOffset смещение нового сегмента той же сессии: сегмент продолжает
+// шкалу сессии после паузы, измеренной по часам сервера
+func (tb *timeBase) continuationOffset(timeSec float64, now time.Time) float64 {
+	pause := 0.0
+	if !tb.lastWall.IsZero() {
+		pause = now.Sub(tb.lastWall).Seconds()
+	}
+	return tb.lastT + pause - timeSec
+}
+
+// deviceTimeline возвращает владельца данных устройства (активную сессию или uuid.Nil)
+// и последний сегмент его шкалы времени
+func (sm *SessionManager) deviceTimeline(deviceID string) (uuid.UUID, *models.CTGSegment) {
+	sm.sessionsLock.RLock()
+	defer sm.sessionsLock.RUnlock()
+
+	var segments []models.CTGSegment
+	ownerID := uuid.Nil
+	if session := sm.activeSessions[deviceID]; session != nil {
+		ownerID = session.ID
+		segments = session.Segments
+	} else if device := sm.unassigned[deviceID]; device != nil {
+		segments = device.segments
+	}
+
+	if len(segments) == 0 {
+		return ownerID, nil
+	}
+	last := segments[len(segments)-1]
+	return ownerID, &last
+}
+
+// AddSegment добавляет сегмент к активной сессии устройства (ownerID)
+// или к данным устройства без карты (ownerID = uuid.Nil).
+// Возвращает номер добавленного сегмента.
+func (sm *SessionManager) AddSegment(deviceID string, ownerID uuid.UUID, segment models.CTGSegment) (int, error) {
+	sm.sessionsLock.Lock()
+	defer sm.sessionsLock.Unlock()
+
+	if ownerID == uuid.Nil {
+		device := sm.getUnassignedLocked(deviceID)
+		segment.Index = len(device.segments)
+		device.segments = append(device.segments, segment)
+		return segment.Index, nil
+	}
+
+	session := sm.activeSessions[deviceID]
+	if session == nil || session.ID != ownerID {
+		return 0, fmt.Errorf("активная сессия %s устройства %s не найдена", ownerID, deviceID)
+	}
+
+	segment.Index = len(session.Segments)
+	segments := append(session.Segments, segment)
+	if err := sm.saveSegments(session.ID, segments); err != nil {
+		return 0, err
+	}
+	session.Segments = segments
+	return segment.Index, nil
+}
+
+// SplitSession закрывает сессию и открывает сессию-продолжение для той же карты,
+// первым сегментом которой становится segment. Закрытая сессия передается дальше
+// (SetStoredCallback), как только записан остаток ее буфера.
+func (sm *SessionManager) SplitSession(sessionID uuid.UUID, segment models.CTGSegment) (*models.CTGSession, error) {
+	sm.sessionsLock.Lock()
+	defer sm.sessionsLock.Unlock()
+
+	// Тест по протоколу продолжается в сессии-продолжении
+	evaluator := sm.evaluators[sessionID]
+	delete(sm.evaluators, sessionID)
+
+	previous, err := sm.stopSessionLocked(sessionID)
+	if err != nil {
+		if evaluator != nil {
+			sm.evaluators[sessionID] = evaluator
+		}
+		return nil, err
+	}
+
+	continuation := newSession(previous.CardID, previous.DeviceID)
+	continuation.ContinuesFrom = &previous.ID
+	continuation.Protocol = previous.Protocol
+	if evaluator != nil {
+		sm.evaluators[continuation.ID] = evaluator
+	}
+	segment.Index = 0
+	continuation.Segments = []models.CTGSegment{segment}
+
+	if _, err := sm.startSessionLocked(continuation); err != nil {
+		delete(sm.evaluators, continuation.ID)
+		return nil, fmt.Errorf("не удалось открыть продолжение сессии %s: %w", sessionID, err)
+	}
+	return continuation, nil
+}
+
+// saveSegments записывает сегменты сессии в БД
+func (sm *SessionManager) saveSegments(sessionID uuid.UUID, segments []models.CTGSegment) error {
+	segmentsJSON, err := json.Marshal(segments)
+	if err != nil {
+		return err
+	}
+	if err := sm.db.Model(&models.CTGSession{}).
+		Where("id = ?", sessionID).
+		Update("segments", gorm.Expr("?::jsonb", string(segmentsJSON))).Error; err != nil {
+		return fmt.Errorf("не удалось сохранить сегменты сессии %s: %w", sessionID, err)
+	}
+	return nil
+}
+
+// applyTimeBase размещает точку на шкале времени сессии устройства, при необходимости
+// начиная новый сегмент или сессию-продолжение. Возвращает время точки в сессии
+// и false, если точка осталась от предыдущей шкалы и должна быть отброшена.
+func (p *MQTTStreamProcessor) applyTimeBase(stream *deviceStream, data *models.MedicalData, reset bool) (float64, bool) {
+	tb := stream.timeBase
+	now := time.Now()
+
+	if p.segments.Policy == SegmentPolicyOff {
+		if tb.anchor.IsZero() {
+			tb.anchor = now.Add(-time.Duration(data.TimeSec * float64(time.Second)))
+		}
+		return data.TimeSec, true
+	}
+
+	ownerID, last := p.sessionManager.deviceTimeline(data.DeviceID)
+	if !tb.owned || tb.ownerID != ownerID {
+		tb.rebase(ownerID, last)
+		if last == nil {
+			segment := models.CTGSegment{
+				StartTime:     now.UTC(),
+				DeviceTimeSec: data.TimeSec,
+				Reason:        models.SegmentStart,
+			}
+			p.addSegment(data.DeviceID, ownerID, segment)
+			tb.anchor = segment.Anchor()
+		}
+	}
+
+	reason, stale := tb.detect(data.DataType, data.TimeSec, reset, now, p.segments.MaxGap)
+	if stale {
+		log.Printf("Точка %s/%s t=%.2f осталась от предыдущей шкалы времени, отброшена",
+			data.DeviceID, data.DataType, data.TimeSec)
+		return 0, false
+	}
+
+	if reason == models.SegmentTimeReset {
+		// Устройство перезапустилось: его часы могли быть переустановлены
+		p.clockBank.ResetDevice(data.DeviceID)
+	}
+
+	if reason != "" {
+		segment := models.CTGSegment{
+			StartTime:     now.UTC(),
+			DeviceTimeSec: data.TimeSec,
+			Reason:        reason,
+		}
+
+		if p.segments.Policy == SegmentPolicySplit && ownerID != uuid.Nil {
+			// Новая сессия начинает шкалу с нуля
+			segment.Offset = -data.TimeSec
+			continuation, err := p.sessionManager.SplitSession(ownerID, segment)
+			if err != nil {
+				log.Printf("Ошибка разделения сессии %s: %v", ownerID, err)
+			} else {
+				log.Printf("Сессия %s устройства %s разделена (%s), продолжение: %s",
+					ownerID, data.DeviceID, reason, continuation.ID)
+				tb.rebase(continuation.ID, &segment)
+			}
+		} else {
+			segment.Offset = tb.continuationOffset(data.TimeSec, now)
+			tb.offset = segment.Offset
+			tb.anchor = segment.Anchor()
+			index := p.addSegment(data.DeviceID, ownerID, segment)
+			log.Printf("Новый сегмент %d устройства %s (%s): time_sec=%.2f, смещение %.2f",
+				index, data.DeviceID, reason, data.TimeSec, segment.Offset)
+		}
+	}
+
+	return tb.place(data.TimeSec, now), true
+}
+
+// addSegment записывает сегмент, ошибки только журналируются
+func (p *MQTTStreamProcessor) addSegment(deviceID string, ownerID uuid.UUID, segment models.CTGSegment) int {
+	index, err := p.sessionManager.AddSegment(deviceID, ownerID, segment)
+	if err != nil {
+		log.Printf("Ошибка записи сегмента устройства %s: %v", deviceID, err)
+	}
+	return index
+}
@@ -0,0 +1,40 @@
+// internal/handlers/series.go
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"CTG_monitor/internal/models"
+)
+
+// sortedSeries имена каналов с точками в стабильном порядке
+func sortedSeries(series map[string][]models.CTGPoint) []string {
+	names := make([]string, 0, len(series))
+	for name, points := range series {
+		if len(points) > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// countPoints общее количество точек во всех каналах
+func countPoints(series map[string][]models.CTGPoint) int {
+	total := 0
+	for _, points := range series {
+		total += len(points)
+	}
+	return total
+}
+
+// describeSeries количество точек по каналам для журнала
+func describeSeries(series map[string][]models.CTGPoint) string {
+	parts := make([]string, 0, len(series))
+	for _, name := range sortedSeries(series) {
+		parts = append(parts, fmt.Sprintf("%s=%d", name, len(series[name])))
+	}
+	return strings.Join(parts, ", ")
+}
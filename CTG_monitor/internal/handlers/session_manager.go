@@ -7,7 +7,11 @@ import (
 	"sync"
 	"time"
 
+	"CTG_monitor/internal/chunks"
 	"CTG_monitor/internal/models"
+	"CTG_monitor/internal/protocols"
+	"CTG_monitor/internal/quality"
+	"CTG_monitor/internal/summary"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -19,9 +23,22 @@ type SessionManager struct {
 	sessionsLock   sync.RWMutex
 	dataBuffer     *DataBuffer
 
+	// Устройства, передающие данные без привязки к медкарте (защищено sessionsLock)
+	unassigned map[string]*unassignedDevice
+
+	// Тесты сессий, запущенных по протоколу (защищено sessionsLock)
+	evaluators map[uuid.UUID]*protocols.Evaluator
+
+	// Сводки качества сигнала активных сессий по каналам (защищено sessionsLock)
+	signalQuality map[uuid.UUID]map[string]*quality.Accumulator
+
+	// Итоги активных сессий по событиям анализа и тревогам (защищено sessionsLock)
+	tallies map[uuid.UUID]*summary.Tally
+
 	// Callbacks для уведомления о событиях сессий
-	onSessionStart func(session *models.CTGSession)
-	onSessionStop  func(session *models.CTGSession)
+	onSessionStart  func(session *models.CTGSession)
+	onSessionStop   func(session *models.CTGSession)
+	onSessionStored func(sessionID uuid.UUID)
 }
 
 // NewSessionManager создает новый менеджер сессий
@@ -30,6 +47,10 @@ func NewSessionManager(db *gorm.DB, dataBuffer *DataBuffer) *SessionManager {
 		db:             db,
 		activeSessions: make(map[string]*models.CTGSession),
 		dataBuffer:     dataBuffer,
+		unassigned:     make(map[string]*unassignedDevice),
+		evaluators:     make(map[uuid.UUID]*protocols.Evaluator),
+		signalQuality:  make(map[uuid.UUID]map[string]*quality.Accumulator),
+		tallies:        make(map[uuid.UUID]*summary.Tally),
 	}
 
 	log.Println("Session Manager инициализирован")
@@ -42,32 +63,58 @@ func (sm *SessionManager) SetCallbacks(onStart, onStop func(session *models.CTGS
 	sm.onSessionStop = onStop
 }
 
-// StartSession создает и запускает новую сессию мониторинга
-func (sm *SessionManager) StartSession(cardID uuid.UUID, deviceID string) (*models.CTGSession, error) {
+// SetStoredCallback устанавливает колбэк, вызываемый, когда завершенная сессия
+// целиком записана в БД (например, для отправки в медкарты)
+func (sm *SessionManager) SetStoredCallback(onStored func(sessionID uuid.UUID)) {
+	sm.onSessionStored = onStored
+}
+
+// StartSession создает и запускает новую сессию мониторинга. Сессия с протоколом
+// (protocols.NST, protocols.CST) завершается сама по критериям или времени теста;
+// пустой протокол - мониторинг без ограничения.
+// Данные, накопленные устройством до начала сессии, отбрасываются
+// (чтобы сохранить их, используйте BindDevice).
+func (sm *SessionManager) StartSession(cardID uuid.UUID, deviceID, protocol string) (*models.CTGSession, error) {
+	if _, ok := protocols.Lookup(protocol); protocol != "" && !ok {
+		return nil, ErrUnknownProtocol
+	}
+
 	sm.sessionsLock.Lock()
 	defer sm.sessionsLock.Unlock()
 
-	// Проверяем, нет ли уже активной сессии для этого устройства
-	if existing := sm.activeSessions[deviceID]; existing != nil {
-		return nil, fmt.Errorf("активная сессия уже существует для устройства %s", deviceID)
+	session := newSession(cardID, deviceID)
+	session.Protocol = protocol
+	session, err := sm.startSessionLocked(session)
+	if err != nil {
+		return nil, err
+	}
+
+	if device := sm.unassigned[deviceID]; device != nil {
+		delete(sm.unassigned, deviceID)
+		sm.dataBuffer.ack(pendingSeqs(device.points)...)
+		log.Printf("Отброшено %d точек устройства %s, полученных до начала сессии",
+			len(device.points), deviceID)
 	}
+	return session, nil
+}
 
-	// Создаем новую сессию
-	session := &models.CTGSession{
+// newSession подготавливает новую сессию без записи в БД
+func newSession(cardID uuid.UUID, deviceID string) *models.CTGSession {
+	return &models.CTGSession{
 		ID:        uuid.New(),
 		CardID:    cardID,
 		DeviceID:  deviceID,
 		StartTime: time.Now().UTC(),
-		FHRData: models.CTGTimeSeries{
-			Points:   []models.CTGPoint{},
-			Count:    0,
-			LastTime: 0,
-		},
-		UCData: models.CTGTimeSeries{
-			Points:   []models.CTGPoint{},
-			Count:    0,
-			LastTime: 0,
-		},
+	}
+}
+
+// startSessionLocked сохраняет и запускает сессию, вызывается под sessionsLock
+func (sm *SessionManager) startSessionLocked(session *models.CTGSession) (*models.CTGSession, error) {
+	deviceID := session.DeviceID
+
+	// Проверяем, нет ли уже активной сессии для этого устройства
+	if existing := sm.activeSessions[deviceID]; existing != nil {
+		return nil, fmt.Errorf("активная сессия уже существует для устройства %s", deviceID)
 	}
 
 	// Сохраняем в БД
@@ -77,6 +124,7 @@ func (sm *SessionManager) StartSession(cardID uuid.UUID, deviceID string) (*mode
 
 	// Добавляем в активные сессии
 	sm.activeSessions[deviceID] = session
+	sm.startProtocolLocked(session, 0)
 
 	// Уведомляем о начале сессии
 	if sm.onSessionStart != nil {
@@ -84,7 +132,7 @@ func (sm *SessionManager) StartSession(cardID uuid.UUID, deviceID string) (*mode
 	}
 
 	log.Printf("Запущена сессия %s для устройства %s, карта %s",
-		session.ID.String(), deviceID, cardID.String())
+		session.ID.String(), deviceID, session.CardID.String())
 
 	return session, nil
 }
@@ -93,14 +141,15 @@ func (sm *SessionManager) StartSession(cardID uuid.UUID, deviceID string) (*mode
 func (sm *SessionManager) StopSession(sessionID uuid.UUID) (*models.CTGSession, error) {
 	sm.sessionsLock.Lock()
 	defer sm.sessionsLock.Unlock()
+	return sm.stopSessionLocked(sessionID)
+}
 
+// stopSessionLocked завершает сессию, вызывается под sessionsLock
+func (sm *SessionManager) stopSessionLocked(sessionID uuid.UUID) (*models.CTGSession, error) {
 	// Ищем активную сессию
-	var targetDeviceID string
 	var targetSession *models.CTGSession
-
-	for deviceID, session := range sm.activeSessions {
+	for _, session := range sm.activeSessions {
 		if session.ID == sessionID {
-			targetDeviceID = deviceID
 			targetSession = session
 			break
 		}
@@ -110,28 +159,197 @@ func (sm *SessionManager) StopSession(sessionID uuid.UUID) (*models.CTGSession,
 		return nil, fmt.Errorf("активная сессия %s не найдена", sessionID.String())
 	}
 
-	// Устанавливаем время завершения
-	now := time.Now().UTC()
-	targetSession.EndTime = &now
+	if err := sm.finishSessionLocked(targetSession, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+
+	log.Printf("✅ Завершена сессия %s для устройства %s", sessionID.String(), targetSession.DeviceID)
+	return targetSession, nil
+}
+
+// finishSessionLocked закрывает сессию временем endTime: записывает заключение теста,
+// сводку качества сигнала и итоги, убирает сессию из активных и дописывает остаток
+// ее буфера. Через него проходит любое завершение сессии; вызывается под sessionsLock.
+func (sm *SessionManager) finishSessionLocked(session *models.CTGSession, endTime time.Time) error {
+	if err := sm.db.Model(session).Update("end_time", endTime).Error; err != nil {
+		return fmt.Errorf("не удалось обновить сессию в БД: %w", err)
+	}
+	session.EndTime = &endTime
 
-	// Обновляем в БД
-	if err := sm.db.Model(targetSession).Update("end_time", now).Error; err != nil {
-		return nil, fmt.Errorf("не удалось обновить сессию в БД: %w", err)
+	// Заключение теста, если сессия шла по протоколу
+	if err := sm.finishProtocolLocked(session, endTime); err != nil {
+		log.Printf("Ошибка завершения теста: %v", err)
+	}
+	if err := sm.finishQualityLocked(session); err != nil {
+		log.Printf("Ошибка сводки качества сигнала: %v", err)
+	}
+	if err := sm.finishSummaryLocked(session); err != nil {
+		log.Printf("Ошибка итогов сессии: %v", err)
 	}
 
 	// Удаляем из активных сессий
-	delete(sm.activeSessions, targetDeviceID)
+	if sm.activeSessions[session.DeviceID] == session {
+		delete(sm.activeSessions, session.DeviceID)
+	}
 
-	// Очищаем буфер данных для этой сессии
-	sm.dataBuffer.RemoveSessionBuffer(sessionID)
+	// Очищаем буфер данных для этой сессии; сессия передается дальше после записи остатка
+	var onFlushed func()
+	if onStored := sm.onSessionStored; onStored != nil {
+		sessionID := session.ID
+		onFlushed = func() { onStored(sessionID) }
+	}
+	sm.dataBuffer.RemoveSessionBuffer(session.ID, onFlushed)
 
 	// Уведомляем о завершении сессии
 	if sm.onSessionStop != nil {
-		sm.onSessionStop(targetSession)
+		sm.onSessionStop(session)
 	}
+	return nil
+}
 
-	log.Printf("✅ Завершена сессия %s для устройства %s", sessionID.String(), targetDeviceID)
-	return targetSession, nil
+// Политики восстановления незавершенных сессий при старте
+const (
+	RestorePolicyAuto   = "auto"   // продолжить, если перерыв в данных меньше допустимого
+	RestorePolicyResume = "resume" // всегда продолжать
+	RestorePolicyClose  = "close"  // всегда закрывать
+)
+
+// RestoreActiveSessions загружает из БД сессии без end_time, оставшиеся после
+// перезапуска сервиса, и продолжает или закрывает их согласно политике.
+// Возвращает ID закрытых сессий.
+func (sm *SessionManager) RestoreActiveSessions(policy string, maxGap time.Duration) ([]uuid.UUID, error) {
+	var sessions []*models.CTGSession
+	if err := sm.db.Select("id", "card_id", "device_id", "start_time", "end_time", "last_data_at", "segments", "protocol", "events").
+		Where("end_time IS NULL").
+		Order("start_time DESC").
+		Find(&sessions).Error; err != nil {
+		return nil, fmt.Errorf("не удалось загрузить незавершенные сессии: %w", err)
+	}
+
+	switch policy {
+	case RestorePolicyAuto, RestorePolicyResume, RestorePolicyClose:
+	default:
+		log.Printf("Неизвестная политика восстановления сессий %q, используем %s", policy, RestorePolicyAuto)
+		policy = RestorePolicyAuto
+	}
+
+	sm.sessionsLock.Lock()
+	defer sm.sessionsLock.Unlock()
+
+	var closed []uuid.UUID
+	resumed := 0
+	now := time.Now().UTC()
+
+	for _, session := range sessions {
+		lastActivity := session.StartTime
+		if session.LastDataAt != nil {
+			lastActivity = *session.LastDataAt
+		}
+		gap := now.Sub(lastActivity)
+
+		// Сессии отсортированы от новых к старым: на устройство продолжаем только самую свежую
+		_, deviceBusy := sm.activeSessions[session.DeviceID]
+		resume := !deviceBusy && (policy == RestorePolicyResume ||
+			(policy == RestorePolicyAuto && gap <= maxGap))
+
+		// Тест продолжается с длительностью записи до перезапуска
+		sm.restoreSessionStateLocked(session, lastActivity.Sub(session.StartTime).Seconds())
+
+		if resume {
+			sm.restoreQualityLocked(session)
+			sm.activeSessions[session.DeviceID] = session
+			sm.dataBuffer.AttachSession(session.ID)
+			resumed++
+			log.Printf("♻️ Восстановлена сессия %s для устройства %s (перерыв в данных %s)",
+				session.ID, session.DeviceID, gap.Round(time.Second))
+			continue
+		}
+
+		// Закрываем временем последних данных, а не временем перезапуска
+		endTime := lastActivity.UTC()
+		if evaluator := sm.evaluators[session.ID]; evaluator != nil {
+			result := evaluator.Result(protocols.ReasonInterrupted, endTime)
+			session.ProtocolResult = &result
+		}
+		if err := sm.finishSessionLocked(session, endTime); err != nil {
+			log.Printf("Не удалось закрыть незавершенную сессию %s: %v", session.ID, err)
+			sm.forgetSessionStateLocked(session.ID)
+			continue
+		}
+		closed = append(closed, session.ID)
+
+		reason := "перерыв в данных " + gap.Round(time.Second).String()
+		if deviceBusy {
+			reason = "у устройства есть более новая сессия"
+		} else if policy == RestorePolicyClose {
+			reason = "политика " + RestorePolicyClose
+		}
+		log.Printf("Закрыта незавершенная сессия %s для устройства %s: %s",
+			session.ID, session.DeviceID, reason)
+	}
+
+	log.Printf("Восстановление сессий (политика %s): найдено %d, продолжено %d, закрыто %d",
+		policy, len(sessions), resumed, len(closed))
+	return closed, nil
+}
+
+// restoreSessionStateLocked восстанавливает состояние сессии, прерванной перезапуском:
+// тест по протоколу и итоги - по сохраненным событиям анализа и тревогам.
+// Вызывается под sessionsLock.
+func (sm *SessionManager) restoreSessionStateLocked(session *models.CTGSession, elapsed float64) {
+	sm.startProtocolLocked(session, elapsed)
+
+	tally := sm.tallyLocked(session.ID)
+	for _, event := range session.Events {
+		tally.Observe(event)
+	}
+	var alarms int64
+	if err := sm.db.Model(&models.CTGAlarm{}).Where("session_id = ?", session.ID).Count(&alarms).Error; err != nil {
+		log.Printf("Не удалось подсчитать тревоги сессии %s: %v", session.ID, err)
+	}
+	for range alarms {
+		tally.CountAlarm()
+	}
+}
+
+// restoreWindow последние секунды записи, по которым восстанавливается сводка
+// качества сигнала продолжаемой сессии
+const restoreWindow = 60 * 60.0
+
+// restoreQualityLocked восстанавливает сводку качества сигнала продолжаемой сессии
+// по последним restoreWindow секундам записанных точек. Вызывается под sessionsLock.
+func (sm *SessionManager) restoreQualityLocked(session *models.CTGSession) {
+	var tail struct{ Last *float64 }
+	if err := sm.db.Model(&models.CTGChunk{}).Select("MAX(to_time) AS last").
+		Where("session_id = ?", session.ID).Scan(&tail).Error; err != nil {
+		log.Printf("Сводка качества сигнала сессии %s не восстановлена: %v", session.ID, err)
+		return
+	}
+	if tail.Last == nil {
+		return
+	}
+
+	from := *tail.Last - restoreWindow
+	series, err := chunks.Load(sm.db, session.ID, nil, &from, nil)
+	if err != nil {
+		log.Printf("Сводка качества сигнала сессии %s не восстановлена: %v", session.ID, err)
+		return
+	}
+	for _, name := range sortedSeries(series) {
+		for _, point := range series[name] {
+			// Блоки на краю окна содержат и более ранние точки
+			if point.T >= from {
+				sm.countQualityLocked(session.ID, name, point)
+			}
+		}
+	}
+}
+
+// forgetSessionStateLocked отбрасывает состояние сессии без записи; вызывается под sessionsLock
+func (sm *SessionManager) forgetSessionStateLocked(sessionID uuid.UUID) {
+	delete(sm.evaluators, sessionID)
+	delete(sm.signalQuality, sessionID)
+	delete(sm.tallies, sessionID)
 }
 
 // GetActiveSession возвращает активную сессию для устройства
@@ -224,26 +442,26 @@ func (sm *SessionManager) CleanupInactiveSessions() {
 	sm.sessionsLock.Lock()
 	defer sm.sessionsLock.Unlock()
 
-	var sessionsToRemove []string
+	var stale []*models.CTGSession
 	threshold := time.Now().Add(-24 * time.Hour)
 
-	for deviceID, session := range sm.activeSessions {
+	for _, session := range sm.activeSessions {
 		if session.StartTime.Before(threshold) {
-			now := time.Now().UTC()
-			session.EndTime = &now
-			sm.db.Model(session).Update("end_time", now)
-
-			sessionsToRemove = append(sessionsToRemove, deviceID)
-			log.Printf("Принудительно завершена зависшая сессия: %s", session.ID.String())
+			stale = append(stale, session)
 		}
 	}
 
-	// Удаляем зависшие сессии
-	for _, deviceID := range sessionsToRemove {
-		delete(sm.activeSessions, deviceID)
+	cleaned := 0
+	for _, session := range stale {
+		if err := sm.finishSessionLocked(session, time.Now().UTC()); err != nil {
+			log.Printf("Не удалось завершить зависшую сессию %s: %v", session.ID, err)
+			continue
+		}
+		cleaned++
+		log.Printf("Принудительно завершена зависшая сессия: %s", session.ID.String())
 	}
 
-	if len(sessionsToRemove) > 0 {
-		log.Printf("Очищено %d зависших сессий", len(sessionsToRemove))
+	if cleaned > 0 {
+		log.Printf("Очищено %d зависших сессий", cleaned)
 	}
 }
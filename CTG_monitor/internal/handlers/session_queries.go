@@ -0,0 +1,219 @@
+// internal/handlers/session_queries.go
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"CTG_monitor/internal/models"
+	"github.com/google/uuid"
+)
+
+// Статусы сессии в API
+const (
+	SessionActive  = "active"
+	SessionStopped = "stopped"
+)
+
+// Порядок списка сессий: по времени начала, по умолчанию с последних
+const (
+	SortStartTimeAsc  = "start_time"
+	SortStartTimeDesc = "-start_time"
+)
+
+// Состояния устройства
+const (
+	DeviceActive     = "active"     // Идет сессия
+	DeviceUnassigned = "unassigned" // Передает данные без привязки к карте
+	DeviceIdle       = "idle"       // Нет активной сессии и данных без карты
+)
+
+// Ограничения размера страницы списка сессий
+const (
+	defaultSessionPage = 50
+	maxSessionPage     = 500
+)
+
+// ErrInvalidCursor курсор страницы поврежден или выдан для другого порядка
+var ErrInvalidCursor = errors.New("неверный курсор страницы")
+
+// ErrInvalidSessionFilter неверные условия отбора сессий
+var ErrInvalidSessionFilter = errors.New("неверный фильтр сессий")
+
+// SessionFilter условия отбора и порядок списка сессий. Пустые поля не ограничивают выборку.
+type SessionFilter struct {
+	Status   string     // SessionActive или SessionStopped
+	CardID   *uuid.UUID // Медицинская карта
+	DeviceID string     // Устройство
+	FromTime *time.Time // Сессии, закончившиеся не раньше (активные - всегда)
+	ToTime   *time.Time // Сессии, начавшиеся не позже
+	Sort     string     // SortStartTimeAsc или SortStartTimeDesc (по умолчанию)
+	Cursor   string     // Курсор следующей страницы из SessionPage
+	Limit    int        // Размер страницы (по умолчанию defaultSessionPage)
+}
+
+// SessionPage страница списка сессий
+type SessionPage struct {
+	Sessions   []*models.CTGSession
+	NextCursor string // Пусто на последней странице
+}
+
+// DeviceSummary состояние устройства
+type DeviceSummary struct {
+	DeviceID string
+	Status   string             // DeviceActive, DeviceUnassigned или DeviceIdle
+	Session  *models.CTGSession // Активная сессия (DeviceActive)
+	Pending  *UnassignedDeviceInfo
+}
+
+// ListSessions возвращает страницу сессий по фильтру. Страницы отбираются по ключу
+// (start_time, id), поэтому новые сессии не сдвигают уже выданные страницы.
+func (sm *SessionManager) ListSessions(filter SessionFilter) (*SessionPage, error) {
+	if filter.Sort == "" {
+		filter.Sort = SortStartTimeDesc
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultSessionPage
+	}
+	filter.Limit = min(filter.Limit, maxSessionPage)
+	if filter.FromTime != nil && filter.ToTime != nil && filter.FromTime.After(*filter.ToTime) {
+		return nil, fmt.Errorf("%w: from_time позже to_time", ErrInvalidSessionFilter)
+	}
+
+	query := sm.db.Model(&models.CTGSession{})
+	switch filter.Status {
+	case "":
+	case SessionActive:
+		query = query.Where("end_time IS NULL")
+	case SessionStopped:
+		query = query.Where("end_time IS NOT NULL")
+	default:
+		return nil, fmt.Errorf("%w: статус %q", ErrInvalidSessionFilter, filter.Status)
+	}
+	if filter.CardID != nil {
+		query = query.Where("card_id = ?", *filter.CardID)
+	}
+	if filter.DeviceID != "" {
+		query = query.Where("device_id = ?", filter.DeviceID)
+	}
+	if filter.FromTime != nil {
+		query = query.Where("end_time IS NULL OR end_time >= ?", *filter.FromTime)
+	}
+	if filter.ToTime != nil {
+		query = query.Where("start_time <= ?", *filter.ToTime)
+	}
+
+	var direction, compare string
+	switch filter.Sort {
+	case SortStartTimeAsc:
+		direction, compare = "ASC", ">"
+	case SortStartTimeDesc:
+		direction, compare = "DESC", "<"
+	default:
+		return nil, fmt.Errorf("%w: порядок %q", ErrInvalidSessionFilter, filter.Sort)
+	}
+	if filter.Cursor != "" {
+		startTime, id, err := decodeSessionCursor(filter.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("(start_time, id) "+compare+" (?, ?)", startTime, id)
+	}
+
+	// Лишняя сессия показывает, что есть следующая страница
+	var sessions []*models.CTGSession
+	if err := query.Order("start_time " + direction).Order("id " + direction).
+		Limit(filter.Limit + 1).
+		Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+
+	page := &SessionPage{Sessions: sessions}
+	if len(sessions) > filter.Limit {
+		page.Sessions = sessions[:filter.Limit]
+		last := page.Sessions[filter.Limit-1]
+		page.NextCursor = encodeSessionCursor(last.StartTime, last.ID)
+	}
+	return page, nil
+}
+
+// encodeSessionCursor курсор страницы: ключ последней выданной сессии
+func encodeSessionCursor(startTime time.Time, id uuid.UUID) string {
+	key := startTime.UTC().Format(time.RFC3339Nano) + "|" + id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(key))
+}
+
+// decodeSessionCursor разбирает курсор encodeSessionCursor
+func decodeSessionCursor(cursor string) (time.Time, uuid.UUID, error) {
+	key, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalidCursor
+	}
+	startValue, idValue, ok := strings.Cut(string(key), "|")
+	if !ok {
+		return time.Time{}, uuid.Nil, ErrInvalidCursor
+	}
+	startTime, err := time.Parse(time.RFC3339Nano, startValue)
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalidCursor
+	}
+	id, err := uuid.Parse(idValue)
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalidCursor
+	}
+	return startTime, id, nil
+}
+
+// GetDevices возвращает состояние всех известных устройств: записанных в сессиях,
+// с активной сессией и передающих данные без карты, в порядке идентификаторов
+func (sm *SessionManager) GetDevices() []DeviceSummary {
+	known := make(map[string]bool)
+	for _, deviceID := range sm.GetAllDevices() {
+		known[deviceID] = true
+	}
+	active := make(map[string]*models.CTGSession)
+	for _, session := range sm.GetAllActiveSessions() {
+		active[session.DeviceID] = session
+		known[session.DeviceID] = true
+	}
+	pending := make(map[string]UnassignedDeviceInfo)
+	for _, info := range sm.GetUnassignedDevices() {
+		pending[info.DeviceID] = info
+		known[info.DeviceID] = true
+	}
+
+	devices := make([]DeviceSummary, 0, len(known))
+	for deviceID := range known {
+		devices = append(devices, deviceSummary(deviceID, active[deviceID], pending))
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].DeviceID < devices[j].DeviceID })
+	return devices
+}
+
+// GetDevice возвращает состояние устройства; ok=false, если устройство неизвестно
+func (sm *SessionManager) GetDevice(deviceID string) (DeviceSummary, bool) {
+	for _, device := range sm.GetDevices() {
+		if device.DeviceID == deviceID {
+			return device, true
+		}
+	}
+	return DeviceSummary{}, false
+}
+
+// deviceSummary определяет состояние устройства
+func deviceSummary(deviceID string, session *models.CTGSession, pending map[string]UnassignedDeviceInfo) DeviceSummary {
+	device := DeviceSummary{DeviceID: deviceID, Status: DeviceIdle}
+	switch info, ok := pending[deviceID]; {
+	case session != nil:
+		device.Status = DeviceActive
+		device.Session = session
+	case ok:
+		device.Status = DeviceUnassigned
+		device.Pending = &info
+	}
+	return device
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"CTG_monitor/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testDSNEnv переменная окружения со строкой подключения к PostgreSQL в формате
+// key=value; без нее тесты запросов к базе пропускаются
+const testDSNEnv = "CTG_TEST_DATABASE_DSN"
+
+// openTestDB подключается к PostgreSQL в отдельной схеме, которая удаляется после теста
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv(testDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s не задана, тест с базой данных пропущен", testDSNEnv)
+	}
+
+	config := &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)}
+	admin, err := gorm.Open(postgres.Open(dsn), config)
+	if err != nil {
+		t.Fatalf("не удалось подключиться к базе данных: %v", err)
+	}
+	schema := "ctg_test_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	if err := admin.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatalf("не удалось создать схему: %v", err)
+	}
+	t.Cleanup(func() {
+		admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+		if sqlDB, err := admin.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	db, err := gorm.Open(postgres.Open(dsn+" search_path="+schema), config)
+	if err != nil {
+		t.Fatalf("не удалось подключиться к схеме %s: %v", schema, err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if err := db.AutoMigrate(&models.CTGSession{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// listAll проходит все страницы списка и возвращает сессии в порядке выдачи
+func listAll(t *testing.T, sm *SessionManager, filter SessionFilter) []uuid.UUID {
+	t.Helper()
+	var ids []uuid.UUID
+	for pages := 1; ; pages++ {
+		page, err := sm.ListSessions(filter)
+		if err != nil {
+			t.Fatalf("страница %d: %v", pages, err)
+		}
+		for _, session := range page.Sessions {
+			ids = append(ids, session.ID)
+		}
+		if page.NextCursor == "" {
+			return ids
+		}
+		if len(page.Sessions) != filter.Limit {
+			t.Fatalf("страница %d: %d сессий из %d, но есть курсор", pages, len(page.Sessions), filter.Limit)
+		}
+		if pages > 100 {
+			t.Fatal("курсор не продвигается")
+		}
+		filter.Cursor = page.NextCursor
+	}
+}
+
+func TestListSessionsPaging(t *testing.T) {
+	db := openTestDB(t)
+	sm := NewSessionManager(db, nil)
+
+	// 23 сессии, по три с одинаковым временем начала: порядок внутри группы
+	// задает id. Каждая четвертая активна, остальные длились 30 минут.
+	base := time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC)
+	sessions := make([]models.CTGSession, 23)
+	for i := range sessions {
+		start := base.Add(time.Duration(i/3) * 10 * time.Minute)
+		sessions[i] = models.CTGSession{ID: uuid.New(), CardID: uuid.New(), DeviceID: "CTG-001", StartTime: start}
+		if i%4 != 0 {
+			end := start.Add(30 * time.Minute)
+			sessions[i].EndTime = &end
+		}
+	}
+	if err := db.Create(&sessions).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// Ожидаемый порядок по (start_time, id); uuid в PostgreSQL сравниваются побайтно,
+	// как и их шестнадцатеричная запись
+	sort.Slice(sessions, func(i, j int) bool {
+		if !sessions[i].StartTime.Equal(sessions[j].StartTime) {
+			return sessions[i].StartTime.Before(sessions[j].StartTime)
+		}
+		return sessions[i].ID.String() < sessions[j].ID.String()
+	})
+
+	from := base.Add(60 * time.Minute) // ровно конец сессий, начатых в 8:30
+	to := base.Add(40 * time.Minute)
+	cases := []struct {
+		name   string
+		filter SessionFilter
+		match  func(models.CTGSession) bool
+	}{
+		{"все сессии", SessionFilter{}, func(models.CTGSession) bool { return true }},
+		{"активные", SessionFilter{Status: SessionActive}, func(s models.CTGSession) bool { return s.EndTime == nil }},
+		{"завершенные", SessionFilter{Status: SessionStopped}, func(s models.CTGSession) bool { return s.EndTime != nil }},
+		{"закончившиеся не раньше from_time и активные", SessionFilter{FromTime: &from}, func(s models.CTGSession) bool {
+			return s.EndTime == nil || !s.EndTime.Before(from)
+		}},
+		{"начавшиеся не позже to_time", SessionFilter{ToTime: &to}, func(s models.CTGSession) bool {
+			return !s.StartTime.After(to)
+		}},
+	}
+	for _, c := range cases {
+		var want []uuid.UUID
+		for _, session := range sessions {
+			if c.match(session) {
+				want = append(want, session.ID)
+			}
+		}
+		reversed := make([]uuid.UUID, len(want))
+		for i, id := range want {
+			reversed[len(want)-1-i] = id
+		}
+
+		for _, order := range []struct {
+			sort string
+			want []uuid.UUID
+		}{{SortStartTimeAsc, want}, {SortStartTimeDesc, reversed}} {
+			// Страницы меньше выборки и страница ровно во всю выборку: на последней
+			// странице курсора нет
+			for _, limit := range []int{5, len(want)} {
+				filter := c.filter
+				filter.Sort, filter.Limit = order.sort, limit
+				t.Run(c.name+"/"+order.sort, func(t *testing.T) {
+					if got := listAll(t, sm, filter); !reflect.DeepEqual(got, order.want) {
+						t.Errorf("по %d на странице: %v, ожидались %v", limit, got, order.want)
+					}
+				})
+			}
+		}
+	}
+
+	// Сессии, начатые во время обхода, не сдвигают следующие страницы списка с последних
+	page, err := sm.ListSessions(SessionFilter{Limit: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	late := models.CTGSession{CardID: uuid.New(), DeviceID: "CTG-002", StartTime: base.Add(24 * time.Hour)}
+	if err := db.Create(&late).Error; err != nil {
+		t.Fatal(err)
+	}
+	var got, want []uuid.UUID
+	for _, session := range page.Sessions {
+		got = append(got, session.ID)
+	}
+	got = append(got, listAll(t, sm, SessionFilter{Limit: 5, Cursor: page.NextCursor})...)
+	for i := len(sessions) - 1; i >= 0; i-- {
+		want = append(want, sessions[i].ID)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("после новой сессии: %v, ожидались %v", got, want)
+	}
+}
@@ -0,0 +1,65 @@
+// internal/handlers/summary.go
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"CTG_monitor/internal/models"
+	"CTG_monitor/internal/summary"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// tallyLocked возвращает итоги активной сессии; вызывается под sessionsLock
+func (sm *SessionManager) tallyLocked(sessionID uuid.UUID) *summary.Tally {
+	tally := sm.tallies[sessionID]
+	if tally == nil {
+		tally = &summary.Tally{}
+		sm.tallies[sessionID] = tally
+	}
+	return tally
+}
+
+// countAlarm учитывает поднятую тревогу в итогах сессии, если она еще активна
+func (sm *SessionManager) countAlarm(sessionID uuid.UUID) {
+	sm.sessionsLock.Lock()
+	defer sm.sessionsLock.Unlock()
+
+	for _, session := range sm.activeSessions {
+		if session.ID == sessionID {
+			sm.tallyLocked(sessionID).CountAlarm()
+			return
+		}
+	}
+}
+
+// finishSummaryLocked записывает итоги завершаемой сессии; вызывается под sessionsLock
+// после сводки качества сигнала. Как и сводка качества, итоги учитывают события
+// и тревоги после начала сессии (или перезапуска сервиса).
+func (sm *SessionManager) finishSummaryLocked(session *models.CTGSession) error {
+	tally := sm.tallies[session.ID]
+	delete(sm.tallies, session.ID)
+	if tally == nil {
+		tally = &summary.Tally{}
+	}
+
+	duration := session.EndTime.Sub(session.StartTime).Seconds()
+	result := tally.Result(duration, session.SignalQuality)
+	session.Summary = &result
+	log.Printf("Итоги сессии %s: %.0f с, базальный ритм %.0f, акцелераций %d, децелераций %d, схваток %d, тревог %d",
+		session.ID, result.Duration, result.Baseline, result.Accelerations, result.Decelerations.Total(),
+		result.Contractions, result.Alarms)
+
+	summaryJSON, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	if err := sm.db.Model(&models.CTGSession{}).
+		Where("id = ?", session.ID).
+		Update("summary", gorm.Expr("?::jsonb", string(summaryJSON))).Error; err != nil {
+		return fmt.Errorf("не удалось сохранить итоги сессии %s: %w", session.ID, err)
+	}
+	return nil
+}
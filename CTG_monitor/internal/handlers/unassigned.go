@@ -0,0 +1,201 @@
+// internal/handlers/unassigned.go
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"CTG_monitor/internal/models"
+	"github.com/google/uuid"
+)
+
+// maxUnassignedPoints ограничение точек, накопленных устройством без карты
+// (около часа данных по двум каналам при 4 Гц). Самые старые точки отбрасываются.
+const maxUnassignedPoints = 30000
+
+// ErrDeviceHasActiveSession устройство уже привязано к активной сессии
+var ErrDeviceHasActiveSession = errors.New("у устройства уже есть активная сессия")
+
+// unassignedDevice данные устройства, которое передает сигнал без привязки к медкарте
+type unassignedDevice struct {
+	points    []pendingPoint
+	events    []models.CTGEvent // результаты анализа сигнала до привязки
+	segments  []models.CTGSegment
+	firstSeen time.Time
+	lastSeen  time.Time
+	dropped   int
+}
+
+// pendingPoint точка, ожидающая привязки устройства к карте
+type pendingPoint struct {
+	dataType string
+	point    models.CTGPoint
+	seq      uint64 // запись спула подтверждается после записи в БД или отказа от данных
+	backfill bool   // выгруженная точка, вставляется в ряд сессии по времени
+}
+
+// UnassignedDeviceInfo состояние непривязанного устройства
+type UnassignedDeviceInfo struct {
+	DeviceID      string    `json:"device_id" example:"CTG-DEVICE-001"`        // Идентификатор устройства
+	PendingPoints int       `json:"pending_points" example:"1200"`             // Точек ожидает привязки
+	DroppedPoints int       `json:"dropped_points" example:"0"`                // Отброшено из-за переполнения
+	FirstSeen     time.Time `json:"first_seen" example:"2023-09-01T10:00:00Z"` // Первая точка без карты
+	LastSeen      time.Time `json:"last_seen" example:"2023-09-01T10:05:00Z"`  // Последняя точка без карты
+}
+
+// BindResult результат привязки устройства к медицинской карте
+type BindResult struct {
+	Session         *models.CTGSession
+	KeptPoints      int
+	DiscardedPoints int
+}
+
+// RouteDataPoint направляет точку в буфер активной сессии устройства.
+// Если сессии нет, точка накапливается до привязки устройства к карте.
+// Возвращает false, если устройство не привязано.
+func (sm *SessionManager) RouteDataPoint(deviceID, dataType string, point models.CTGPoint, seq uint64) bool {
+	sm.sessionsLock.Lock()
+	defer sm.sessionsLock.Unlock()
+
+	if session := sm.activeSessions[deviceID]; session != nil {
+		sm.dataBuffer.AddDataPoint(session.ID, dataType, point, seq)
+		sm.countQualityLocked(session.ID, dataType, point)
+		return true
+	}
+
+	sm.appendUnassignedLocked(deviceID, pendingPoint{dataType: dataType, point: point, seq: seq})
+	return false
+}
+
+// RouteEvent сохраняет событие анализа сигнала в активной сессии устройства или
+// до привязки устройства к карте. Возвращает сессию (uuid.Nil, если устройство не привязано).
+func (sm *SessionManager) RouteEvent(deviceID string, event models.CTGEvent) uuid.UUID {
+	sm.sessionsLock.Lock()
+	defer sm.sessionsLock.Unlock()
+
+	if session := sm.activeSessions[deviceID]; session != nil {
+		sm.dataBuffer.AddEvent(session.ID, event)
+		sm.tallyLocked(session.ID).Observe(event)
+		return session.ID
+	}
+
+	device := sm.getUnassignedLocked(deviceID)
+	device.events = append(device.events, event)
+	return uuid.Nil
+}
+
+// appendUnassignedLocked накапливает точку непривязанного устройства, вызывается под sessionsLock
+func (sm *SessionManager) appendUnassignedLocked(deviceID string, pending pendingPoint) {
+	device := sm.getUnassignedLocked(deviceID)
+	device.lastSeen = time.Now().UTC()
+	device.points = append(device.points, pending)
+
+	if overflow := len(device.points) - maxUnassignedPoints; overflow > 0 {
+		sm.dataBuffer.ack(pendingSeqs(device.points[:overflow])...)
+		device.points = device.points[overflow:]
+		if device.dropped == 0 {
+			log.Printf("Буфер непривязанного устройства %s переполнен, старые точки отбрасываются", deviceID)
+		}
+		device.dropped += overflow
+	}
+}
+
+// getUnassignedLocked возвращает состояние непривязанного устройства, вызывается под sessionsLock
+func (sm *SessionManager) getUnassignedLocked(deviceID string) *unassignedDevice {
+	device := sm.unassigned[deviceID]
+	if device == nil {
+		device = &unassignedDevice{firstSeen: time.Now().UTC()}
+		sm.unassigned[deviceID] = device
+		log.Printf("Устройство %s передает данные без привязки к медкарте", deviceID)
+	}
+	return device
+}
+
+// isDeviceIdle сообщает, что у устройства нет ни активной сессии, ни накопленных данных
+func (sm *SessionManager) isDeviceIdle(deviceID string) bool {
+	sm.sessionsLock.RLock()
+	defer sm.sessionsLock.RUnlock()
+	return sm.activeSessions[deviceID] == nil && sm.unassigned[deviceID] == nil
+}
+
+// BindDevice привязывает устройство к медицинской карте и начинает сессию.
+// keepData=true переносит накопленные до привязки точки в сессию, иначе они отбрасываются.
+func (sm *SessionManager) BindDevice(deviceID string, cardID uuid.UUID, keepData bool) (*BindResult, error) {
+	sm.sessionsLock.Lock()
+	defer sm.sessionsLock.Unlock()
+
+	if existing := sm.activeSessions[deviceID]; existing != nil {
+		return nil, fmt.Errorf("%w: устройство %s, сессия %s", ErrDeviceHasActiveSession, deviceID, existing.ID)
+	}
+
+	session := newSession(cardID, deviceID)
+	device := sm.unassigned[deviceID]
+	if device != nil && keepData {
+		// Сохраненные точки уже размещены на шкале сегментов непривязанного устройства
+		session.Segments = device.segments
+	}
+
+	session, err := sm.startSessionLocked(session)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BindResult{Session: session}
+	if device != nil {
+		delete(sm.unassigned, deviceID)
+
+		if keepData {
+			for _, pending := range device.points {
+				if pending.backfill {
+					sm.dataBuffer.AddBackfillPoint(session.ID, pending.dataType, pending.point, pending.seq)
+					continue
+				}
+				sm.dataBuffer.AddDataPoint(session.ID, pending.dataType, pending.point, pending.seq)
+				sm.countQualityLocked(session.ID, pending.dataType, pending.point)
+			}
+			for _, event := range device.events {
+				sm.dataBuffer.AddEvent(session.ID, event)
+				sm.tallyLocked(session.ID).Observe(event)
+			}
+			result.KeptPoints = len(device.points)
+		} else {
+			sm.dataBuffer.ack(pendingSeqs(device.points)...)
+			result.DiscardedPoints = len(device.points)
+		}
+	}
+
+	log.Printf("Устройство %s привязано к карте %s, сессия %s: сохранено %d, отброшено %d точек",
+		deviceID, cardID, session.ID, result.KeptPoints, result.DiscardedPoints)
+	return result, nil
+}
+
+// GetUnassignedDevices возвращает устройства, передающие данные без привязки к карте
+func (sm *SessionManager) GetUnassignedDevices() []UnassignedDeviceInfo {
+	sm.sessionsLock.RLock()
+	defer sm.sessionsLock.RUnlock()
+
+	devices := make([]UnassignedDeviceInfo, 0, len(sm.unassigned))
+	for deviceID, device := range sm.unassigned {
+		devices = append(devices, UnassignedDeviceInfo{
+			DeviceID:      deviceID,
+			PendingPoints: len(device.points),
+			DroppedPoints: device.dropped,
+			FirstSeen:     device.firstSeen,
+			LastSeen:      device.lastSeen,
+		})
+	}
+	return devices
+}
+
+// pendingSeqs номера записей спула для накопленных точек
+func pendingSeqs(points []pendingPoint) []uint64 {
+	seqs := make([]uint64, 0, len(points))
+	for _, pending := range points {
+		if pending.seq != 0 {
+			seqs = append(seqs, pending.seq)
+		}
+	}
+	return seqs
+}
@@ -0,0 +1,226 @@
+// internal/handlers/wallclock.go
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"CTG_monitor/internal/chunks"
+	"CTG_monitor/internal/models"
+	"github.com/google/uuid"
+)
+
+// ErrInvalidTimeRange неверно задан интервал запроса данных сессии
+var ErrInvalidTimeRange = errors.New("неверный интервал времени")
+
+// wallTime абсолютное время точки: по часам устройства с поправкой на их смещение
+// и дрейф, а без метки времени устройства - по началу сегмента сессии
+func (p *MQTTStreamProcessor) wallTime(stream *deviceStream, data *models.MedicalData, sessionTime float64) time.Time {
+	if data.Timestamp > 0 {
+		deviceTime := time.Unix(0, data.Timestamp)
+		if !data.Received.IsZero() {
+			p.clockBank.Observe(data.DeviceID, deviceTime, data.Received)
+		}
+		wall, _ := p.clockBank.ToServer(data.DeviceID, deviceTime)
+		return wall.UTC()
+	}
+
+	if anchor := stream.timeBase.anchor; !anchor.IsZero() {
+		return anchor.Add(secondsDuration(sessionTime)).UTC()
+	}
+	if !data.Received.IsZero() {
+		return data.Received.UTC()
+	}
+	return time.Now().UTC()
+}
+
+// secondsDuration переводит секунды шкалы сессии в time.Duration
+func secondsDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// pointWallTime абсолютное время записанной точки. Для точек, записанных
+// без метки времени, оно выводится из сегмента сессии или ее начала.
+func pointWallTime(point models.CTGPoint, segments []models.CTGSegment, startTime time.Time) time.Time {
+	if point.W != 0 {
+		return point.WallTime()
+	}
+
+	anchor := startTime
+	for _, segment := range segments {
+		if segment.DeviceTimeSec+segment.Offset > point.T {
+			break
+		}
+		anchor = segment.Anchor()
+	}
+	return anchor.Add(secondsDuration(point.T)).UTC()
+}
+
+// wallClockMargin запас при переводе абсолютного времени в шкалу сессии, с: время
+// точек по часам устройства расходится с оценкой по сегменту на смещение и дрейф часов
+const wallClockMargin = 5 * 60.0
+
+// sessionRange переводит интервал абсолютного времени в интервал шкалы сессии по
+// сегментам (как pointWallTime) с запасом wallClockMargin. Пустые границы остаются
+// пустыми; ok=false, если ни один сегмент не попадает в интервал.
+func sessionRange(session *models.CTGSession, timeRange TimeRange) (from, to *float64, ok bool) {
+	// part участок шкалы сессии [start, end) с общей точкой отсчета
+	type part struct {
+		start, end float64
+		anchor     time.Time
+	}
+	parts := []part{{start: math.Inf(-1), end: math.Inf(1), anchor: session.StartTime}}
+	for _, segment := range session.Segments {
+		start := segment.DeviceTimeSec + segment.Offset
+		parts[len(parts)-1].end = start
+		parts = append(parts, part{start: start, end: math.Inf(1), anchor: segment.Anchor()})
+	}
+
+	margin := secondsDuration(wallClockMargin)
+	lower, upper := math.Inf(1), math.Inf(-1)
+	for _, p := range parts {
+		low, high := p.start, p.end
+		if timeRange.FromTime != nil {
+			low = math.Max(low, timeRange.FromTime.Add(-margin).Sub(p.anchor).Seconds())
+		}
+		if timeRange.ToTime != nil {
+			high = math.Min(high, timeRange.ToTime.Add(margin).Sub(p.anchor).Seconds())
+		}
+		if low <= high {
+			lower, upper = math.Min(lower, low), math.Max(upper, high)
+		}
+	}
+	if lower > upper {
+		return nil, nil, false
+	}
+	if !math.IsInf(lower, -1) {
+		from = &lower
+	}
+	if !math.IsInf(upper, 1) {
+		to = &upper
+	}
+	return from, to, true
+}
+
+// TimeRange интервал запроса данных сессии: по времени сессии (секунды)
+// или по абсолютному времени UTC. Пустые границы не ограничивают выборку.
+type TimeRange struct {
+	From     *float64
+	To       *float64
+	FromTime *time.Time
+	ToTime   *time.Time
+}
+
+// IsWallClock сообщает, что интервал задан абсолютным временем
+func (r TimeRange) IsWallClock() bool {
+	return r.FromTime != nil || r.ToTime != nil
+}
+
+// Validate проверяет, что интервал задан одним способом и не перевернут
+func (r TimeRange) Validate() error {
+	if r.IsWallClock() && (r.From != nil || r.To != nil) {
+		return fmt.Errorf("%w: нельзя одновременно задавать from/to и from_time/to_time", ErrInvalidTimeRange)
+	}
+	if r.From != nil && r.To != nil && *r.From > *r.To {
+		return fmt.Errorf("%w: from больше to", ErrInvalidTimeRange)
+	}
+	if r.FromTime != nil && r.ToTime != nil && r.FromTime.After(*r.ToTime) {
+		return fmt.Errorf("%w: from_time позже to_time", ErrInvalidTimeRange)
+	}
+	return nil
+}
+
+// SessionData точки сессии в запрошенном интервале
+type SessionData struct {
+	Session *models.CTGSession
+	Series  map[string][]models.CTGPoint // точки по каналам
+	Events  []models.CTGEvent            // события анализа, начавшиеся в интервале
+}
+
+// GetSessionData возвращает точки сессии в интервале времени сессии или абсолютного времени.
+// У точек, записанных без абсолютного времени, оно заполняется.
+func (sm *SessionManager) GetSessionData(sessionID uuid.UUID, timeRange TimeRange) (*SessionData, error) {
+	return sm.GetSessionSeries(sessionID, nil, timeRange)
+}
+
+// GetSessionSeries возвращает точки каналов names (пусто - всех каналов) сессии в интервале
+func (sm *SessionManager) GetSessionSeries(sessionID uuid.UUID, names []string, timeRange TimeRange) (*SessionData, error) {
+	if err := sm.checkChannels(names); err != nil {
+		return nil, err
+	}
+	if err := timeRange.Validate(); err != nil {
+		return nil, err
+	}
+
+	session, err := sm.GetSession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Интервал по абсолютному времени переводится в шкалу сессии по сегментам,
+	// чтобы читать только нужные блоки; точки затем отбираются точно
+	var series map[string][]models.CTGPoint
+	from, to, overlaps := timeRange.From, timeRange.To, true
+	if timeRange.IsWallClock() {
+		from, to, overlaps = sessionRange(session, timeRange)
+	}
+	if overlaps {
+		if series, err = chunks.Load(sm.db, sessionID, names, from, to); err != nil {
+			return nil, err
+		}
+	}
+
+	data := &SessionData{
+		Session: session,
+		Series:  make(map[string][]models.CTGPoint),
+	}
+	for _, channel := range sm.dataBuffer.channels.All() {
+		if points := series[channel.Name]; len(points) > 0 {
+			data.Series[channel.Name] = selectPoints(points, session, timeRange)
+		}
+	}
+	data.Events = selectEvents(session, timeRange)
+	return data, nil
+}
+
+// selectEvents отбирает события анализа, начавшиеся в интервале
+func selectEvents(session *models.CTGSession, timeRange TimeRange) []models.CTGEvent {
+	selected := make([]models.CTGEvent, 0, len(session.Events))
+	for _, event := range session.Events {
+		if timeRange.IsWallClock() {
+			wall := pointWallTime(models.CTGPoint{T: event.Start}, session.Segments, session.StartTime)
+			if timeRange.FromTime != nil && wall.Before(*timeRange.FromTime) ||
+				timeRange.ToTime != nil && wall.After(*timeRange.ToTime) {
+				continue
+			}
+		} else if timeRange.From != nil && event.Start < *timeRange.From ||
+			timeRange.To != nil && event.Start > *timeRange.To {
+			continue
+		}
+		selected = append(selected, event)
+	}
+	return selected
+}
+
+// selectPoints отбирает точки ряда, попадающие в интервал
+func selectPoints(points []models.CTGPoint, session *models.CTGSession, timeRange TimeRange) []models.CTGPoint {
+	selected := make([]models.CTGPoint, 0, len(points))
+	for _, point := range points {
+		wall := pointWallTime(point, session.Segments, session.StartTime)
+		if timeRange.IsWallClock() {
+			if timeRange.FromTime != nil && wall.Before(*timeRange.FromTime) ||
+				timeRange.ToTime != nil && wall.After(*timeRange.ToTime) {
+				continue
+			}
+		} else if timeRange.From != nil && point.T < *timeRange.From ||
+			timeRange.To != nil && point.T > *timeRange.To {
+			continue
+		}
+
+		point.W = wall.UnixMilli()
+		selected = append(selected, point)
+	}
+	return selected
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"CTG_monitor/internal/models"
+)
+
+func TestSessionRange(t *testing.T) {
+	// Сессия с 12:00; после перерыва запись продолжилась в 13:30 с T = 3600
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := func(hour, minute int) *time.Time {
+		wall := time.Date(2026, 1, 1, hour, minute, 0, 0, time.UTC)
+		return &wall
+	}
+	segmented := &models.CTGSession{StartTime: start, Segments: []models.CTGSegment{
+		{Index: 0, StartTime: start, Reason: models.SegmentStart},
+		{Index: 1, StartTime: *clock(13, 30), DeviceTimeSec: 3600, Reason: models.SegmentGap},
+	}}
+	unsegmented := &models.CTGSession{StartTime: start}
+	seconds := func(v float64) *float64 { return &v }
+
+	cases := []struct {
+		name      string
+		session   *models.CTGSession
+		timeRange TimeRange
+		from, to  *float64
+		ok        bool
+	}{
+		{"внутри первого сегмента", segmented, TimeRange{FromTime: clock(12, 20), ToTime: clock(12, 30)}, seconds(900), seconds(2100), true},
+		{"внутри второго сегмента", segmented, TimeRange{FromTime: clock(13, 40), ToTime: clock(13, 50)}, seconds(3900), seconds(5100), true},
+		{"через перерыв", segmented, TimeRange{FromTime: clock(12, 50), ToTime: clock(13, 40)}, seconds(2700), seconds(4500), true},
+		{"в перерыве записи", segmented, TimeRange{FromTime: clock(13, 10), ToTime: clock(13, 20)}, nil, nil, false},
+		{"только начало", segmented, TimeRange{FromTime: clock(13, 40)}, seconds(3900), nil, true},
+		{"только конец", segmented, TimeRange{ToTime: clock(12, 20)}, nil, seconds(1500), true},
+		{"сессия без сегментов", unsegmented, TimeRange{FromTime: clock(12, 20), ToTime: clock(12, 30)}, seconds(900), seconds(2100), true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			from, to, ok := sessionRange(c.session, c.timeRange)
+			if ok != c.ok || !sameBound(from, c.from) || !sameBound(to, c.to) {
+				t.Errorf("интервал %s..%s (%v), ожидался %s..%s (%v)",
+					formatBound(from), formatBound(to), ok, formatBound(c.from), formatBound(c.to), c.ok)
+			}
+		})
+	}
+}
+
+// sameBound сравнивает границы интервала; nil - граница не задана
+func sameBound(a, b *float64) bool {
+	return a == nil && b == nil || a != nil && b != nil && *a == *b
+}
+
+// formatBound граница интервала для сообщения теста
+func formatBound(bound *float64) string {
+	if bound == nil {
+		return "-"
+	}
+	return time.Duration(*bound * float64(time.Second)).String()
+}
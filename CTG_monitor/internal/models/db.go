@@ -1,6 +1,7 @@
 package models
 
 import (
+	"ctg_common/decel"
 	"github.com/google/uuid"
 	"time"
 )
@@ -16,9 +17,27 @@ type CTGSession struct {
 	StartTime time.Time  `json:"start_time" gorm:"not null;index"`
 	EndTime   *time.Time `json:"end_time" gorm:"index"` // null пока сессия активна
 
-	// 🔥 КТГ данные как аппендабельные JSONB массивы
-	FHRData CTGTimeSeries `json:"fhr_data" gorm:"serializer:json;type:jsonb"` // fetal heart rate
-	UCData  CTGTimeSeries `json:"uc_data" gorm:"serializer:json;type:jsonb"`  // uterine contractions
+	// Время последней записи данных в БД (для восстановления сессий после перезапуска)
+	LastDataAt *time.Time `json:"last_data_at,omitempty"`
+
+	// Сегменты шкалы времени: новый сегмент начинается при сбросе time_sec устройства или долгом перерыве
+	Segments []CTGSegment `json:"segments,omitempty" gorm:"serializer:json;type:jsonb"`
+	// Предыдущая сессия, если эта сессия открыта как продолжение после сброса шкалы времени
+	ContinuesFrom *uuid.UUID `json:"continues_from,omitempty" gorm:"type:uuid;index"`
+
+	// Точки каналов хранятся блоками в ctg_chunks (см. CTGChunk)
+
+	// Результаты анализа сигнала в порядке обнаружения
+	Events []CTGEvent `json:"events,omitempty" gorm:"serializer:json;type:jsonb"`
+
+	// Протокол теста (nst, cst); пусто - обычный мониторинг без ограничения по времени
+	Protocol string `json:"protocol,omitempty" gorm:"type:varchar(16)"`
+	// Заключение по протоколу, заполняется при завершении сессии
+	ProtocolResult *ProtocolResult `json:"protocol_result,omitempty" gorm:"serializer:json;type:jsonb"`
+	// Качество сигнала по каналам, заполняется при завершении сессии
+	SignalQuality map[string]ChannelQuality `json:"signal_quality,omitempty" gorm:"serializer:json;type:jsonb"`
+	// Итоги сессии, заполняются при завершении
+	Summary *SessionSummary `json:"summary,omitempty" gorm:"serializer:json;type:jsonb"`
 
 	// Модели прогнозирования
 	Model15 string `json:"model_15" gorm:"type:varchar(255)"`
@@ -27,19 +46,250 @@ type CTGSession struct {
 	Model60 string `json:"model_60" gorm:"type:varchar(255)"`
 }
 
-// CTGTimeSeries оптимизированная структура для аппенда
-type CTGTimeSeries struct {
-	Points   []CTGPoint `json:"points"`    // Массив точек данных
-	LastTime float64    `json:"last_time"` // Последняя временная отметка
-	Count    int        `json:"count"`     // Количество точек
+// ProtocolResult заключение теста по протоколу
+type ProtocolResult struct {
+	Protocol          string    `json:"protocol"`           // nst, cst
+	Outcome           string    `json:"outcome"`            // reactive, non_reactive, negative, positive, equivocal, unsatisfactory, incomplete
+	Reason            string    `json:"reason"`             // criteria_met, time_limit, manual, interrupted
+	Duration          float64   `json:"duration"`           // Длительность записи по протоколу, с
+	Accelerations     int       `json:"accelerations"`      // Акцелерации за время теста
+	Contractions      int       `json:"contractions"`       // Схватки, по которым оценивался тест
+	LateDecelerations int       `json:"late_decelerations"` // Схватки, за которыми последовала поздняя децелерация
+	Summary           string    `json:"summary"`            // Текст заключения
+	CompletedAt       time.Time `json:"completed_at"`       // Время сервера на момент заключения
 }
 
+// ChannelQuality качество сигнала канала: доля точек с сигналом и эпизоды потери
+type ChannelQuality struct {
+	Points       int           `json:"points"`        // Всего точек
+	Valid        int           `json:"valid"`         // Точек с сигналом (не -1)
+	Corrected    int           `json:"corrected"`     // Точек, исправленных фильтрами артефактов
+	Spikes       int           `json:"spikes"`        // Из них выбросов, замененных фильтрами spike и Хампеля
+	ValidPercent float64       `json:"valid_percent"` // Доля точек с сигналом, %
+	LossSeconds  float64       `json:"loss_seconds"`  // Суммарная длительность эпизодов потери, с
+	LossEpisodes []LossEpisode `json:"loss_episodes"` // Эпизоды потери сигнала
+}
+
+// SessionSummary итоги сессии. Оценки ЧСС - по первому плоду на конец сессии.
+type SessionSummary struct {
+	Duration             float64      `json:"duration"`                   // Длительность сессии, с
+	Baseline             float64      `json:"baseline"`                   // Базальный ритм, уд/мин (0 - не определен)
+	Variability          float64      `json:"variability"`                // Амплитуда вариабельности, уд/мин
+	VariabilityBand      string       `json:"variability_band,omitempty"` // reduced, normal, increased
+	Accelerations        int          `json:"accelerations"`              // Акцелерации
+	Decelerations        decel.Counts `json:"decelerations"`              // Децелерации по типам
+	Contractions         int          `json:"contractions"`               // Схватки
+	ContractionFrequency float64      `json:"contraction_frequency"`      // Схваток за 10 минут в среднем за сессию
+	SignalQuality        float64      `json:"signal_quality"`             // Доля точек ЧСС с сигналом, %
+	SpikesCorrected      int          `json:"spikes_corrected"`           // Выбросов, замененных фильтрами, по всем каналам
+	Alarms               int          `json:"alarms"`                     // Поднятых тревог
+}
+
+// LossEpisode эпизод потери сигнала на шкале сессии, с
+type LossEpisode struct {
+	Start float64 `json:"start"` // Первая точка без сигнала
+	End   float64 `json:"end"`   // Первая точка с сигналом после потери (или последняя точка без сигнала)
+}
+
+// CTGSegment участок сессии с непрерывной шкалой времени устройства.
+// Время точки в сессии T = time_sec устройства + Offset.
+type CTGSegment struct {
+	Index         int       `json:"index"`           // Номер сегмента в сессии
+	StartTime     time.Time `json:"start_time"`      // Время сервера в начале сегмента
+	DeviceTimeSec float64   `json:"device_time_sec"` // Первое time_sec устройства в сегменте
+	Offset        float64   `json:"offset"`          // Смещение time_sec на шкалу сессии
+	Reason        string    `json:"reason"`          // start, time_reset или gap
+}
+
+// Anchor время сервера, соответствующее T = 0 на шкале сегмента
+func (s CTGSegment) Anchor() time.Time {
+	return s.StartTime.Add(-time.Duration((s.DeviceTimeSec + s.Offset) * float64(time.Second)))
+}
+
+// Причины начала сегмента
+const (
+	SegmentStart     = "start"
+	SegmentTimeReset = "time_reset"
+	SegmentGap       = "gap"
+)
+
+// CTGEvent результат анализа сигнала: эпизод (акцелерация, децелерация, схватка,
+// тахисистолия) или смена оценки (базальный ритм, вариабельность). Время - шкала сессии, с.
+type CTGEvent struct {
+	Type      string  `json:"type"`                // baseline, variability, acceleration, deceleration, contraction, tachysystole, signal_quality, signal_loss
+	Channel   string  `json:"channel"`             // Канал, по которому найдено событие
+	Start     float64 `json:"start"`               // Начало эпизода, окна оценки или момент смены оценки
+	End       float64 `json:"end,omitempty"`       // Окончание эпизода
+	Peak      float64 `json:"peak,omitempty"`      // Время пика акцелерации или схватки, надира децелерации
+	Value     float64 `json:"value"`               // ЧСС в пике/надире, базальный ритм, амплитуда вариабельности, давление в пике схватки, частота схваток за 30 минут, индекс качества сигнала или длительность потери
+	Amplitude float64 `json:"amplitude,omitempty"` // Отклонение от базального ритма (тонуса) в пике
+	Baseline  float64 `json:"baseline,omitempty"`  // Базальный ритм или тонус матки на момент события
+	Band      string  `json:"band,omitempty"`      // Класс вариабельности (reduced, normal, increased) или качества сигнала (good, fair, poor)
+
+	Kind            string  `json:"kind,omitempty"`             // Тип децелерации: early, late, variable, prolonged
+	ContractionPeak float64 `json:"contraction_peak,omitempty"` // Пик схватки, с которой связана децелерация
+	Lag             float64 `json:"lag,omitempty"`              // Надир минус пик схватки, с
+	Frequency       float64 `json:"frequency,omitempty"`        // Схваток за последние 10 минут на момент события
+}
+
+// Типы событий анализа
+const (
+	EventBaseline     = "baseline"
+	EventVariability  = "variability"
+	EventAcceleration = "acceleration"
+	EventDeceleration = "deceleration"
+	EventContraction  = "contraction"
+	EventTachysystole = "tachysystole"
+	EventQuality      = "signal_quality"
+	EventSignalLoss   = "signal_loss"
+)
+
 // CTGPoint одна точка данных
 type CTGPoint struct {
-	T float64 `json:"t"` // Время в секундах (компактно)
-	V float64 `json:"v"` // Значение
+	T float64     `json:"t"`           // Время в секундах (компактно)
+	V float64     `json:"v"`           // Очищенное значение
+	R *float64    `json:"r,omitempty"` // Исходное значение устройства (только если отличается от V)
+	F SampleFlags `json:"f,omitempty"` // Причины изменения или пометки точки
+	W int64       `json:"w,omitempty"` // Абсолютное время измерения UTC (мс Unix)
+}
+
+// WallTime возвращает абсолютное время точки (нулевое, если оно не записано)
+func (p CTGPoint) WallTime() time.Time {
+	if p.W == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(p.W).UTC()
+}
+
+// Raw возвращает значение, которое прислало устройство
+func (p CTGPoint) Raw() float64 {
+	if p.R != nil {
+		return *p.R
+	}
+	return p.V
+}
+
+// SampleFlags битовая маска причин, по которым точка была изменена или помечена
+type SampleFlags uint16
+
+const (
+	FlagSpike      SampleFlags = 1 << iota // единичный выброс заменен продолжением сигнала
+	FlagOutlier                            // выброс по фильтру Хампеля заменен медианой
+	FlagDoppler                            // исправлено удвоение/деление пополам ЧСС
+	FlagOutOfRange                         // значение вне допустимого диапазона заменено на -1
+	FlagSignalLoss                         // устройство сообщило о потере сигнала (-1)
+	FlagLate                               // точка пришла после закрытия окна упорядочивания
+	FlagBackfill                           // точка выгружена устройством после восстановления связи
+	FlagSameHeart                          // оба датчика ЧСС плода записывают одно сердце
+	FlagMaternalHR                         // датчик ЧСС плода записывает ЧСС матери
+)
+
+// sampleFlagNames названия флагов для API и журналов
+var sampleFlagNames = []struct {
+	flag SampleFlags
+	name string
+}{
+	{FlagSpike, "spike"},
+	{FlagOutlier, "outlier"},
+	{FlagDoppler, "doppler"},
+	{FlagOutOfRange, "out_of_range"},
+	{FlagSignalLoss, "signal_loss"},
+	{FlagLate, "late"},
+	{FlagBackfill, "backfill"},
+	{FlagSameHeart, "same_heart"},
+	{FlagMaternalHR, "maternal_hr"},
+}
+
+// Names возвращает названия установленных флагов
+func (f SampleFlags) Names() []string {
+	var names []string
+	for _, item := range sampleFlagNames {
+		if f&item.flag != 0 {
+			names = append(names, item.name)
+		}
+	}
+	return names
 }
 
 func (CTGSession) TableName() string {
 	return "ctg_sessions"
 }
+
+// CTGAlarm клиническая тревога по сигналу устройства.
+// Жизненный цикл: raised -> acknowledged -> cleared (снятие возможно и без подтверждения).
+type CTGAlarm struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	DeviceID  string     `json:"device_id" gorm:"type:varchar(100);not null;index"`
+	SessionID *uuid.UUID `json:"session_id,omitempty" gorm:"type:uuid;index"` // null, если устройство не привязано к карте
+	Channel   string     `json:"channel" gorm:"type:varchar(64)"`
+	Rule      string     `json:"rule" gorm:"type:varchar(64);not null;index"`
+	Priority  string     `json:"priority" gorm:"type:varchar(16);not null"` // low, medium, high
+	State     string     `json:"state" gorm:"type:varchar(16);not null;index"`
+	Message   string     `json:"message" gorm:"type:text"`
+
+	Value       float64 `json:"value"`        // Значение, вызвавшее тревогу
+	SessionTime float64 `json:"session_time"` // Время сигнала на шкале сессии, с
+
+	RaisedAt       time.Time  `json:"raised_at" gorm:"not null;index"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+	AcknowledgedBy string     `json:"acknowledged_by,omitempty" gorm:"type:varchar(100)"`
+	ClearedAt      *time.Time `json:"cleared_at,omitempty"`
+	EscalatedAt    *time.Time `json:"escalated_at,omitempty"`
+	Escalations    int        `json:"escalations"` // Сколько раз тревога эскалировалась без подтверждения
+}
+
+func (CTGAlarm) TableName() string {
+	return "ctg_alarms"
+}
+
+// Состояния тревоги
+const (
+	AlarmRaised       = "raised"
+	AlarmAcknowledged = "acknowledged"
+	AlarmCleared      = "cleared"
+)
+
+// CTGChunk блок точек канала сессии за интервал chunk.Span секунд шкалы сессии.
+// Каждая запись дописывает в блок кадр со своими точками (формат pkg/chunk),
+// поэтому запись не зависит от длины сессии. При чтении блоки собираются в ряд по времени.
+type CTGChunk struct {
+	ID        uint64    `json:"id" gorm:"primaryKey"`
+	SessionID uuid.UUID `json:"session_id" gorm:"type:uuid;not null;uniqueIndex:idx_ctg_chunks_key,priority:1"`
+	Channel   string    `json:"channel" gorm:"type:varchar(64);not null;uniqueIndex:idx_ctg_chunks_key,priority:2"`
+	Bucket    int64     `json:"bucket" gorm:"not null;uniqueIndex:idx_ctg_chunks_key,priority:3"` // Номер интервала: floor(T / chunk.Span)
+	FromTime  float64   `json:"from_time" gorm:"not null"`                                        // Время самой ранней точки блока, с
+	ToTime    float64   `json:"to_time" gorm:"not null"`                                          // Время самой поздней точки блока, с
+	Count     int       `json:"count" gorm:"not null"`                                            // Точек во всех кадрах, включая повторы
+	Data      []byte    `json:"-" gorm:"type:bytea;not null"`                                     // Кадры точек
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (CTGChunk) TableName() string {
+	return "ctg_chunks"
+}
+
+// CTGRollup сводка точек канала сессии за интервал Resolution секунд шкалы сессии:
+// [Bucket*Resolution, (Bucket+1)*Resolution). Минимум, максимум и среднее - по точкам
+// с сигналом; если их нет, -1.
+type CTGRollup struct {
+	ID         uint64    `json:"-" gorm:"primaryKey"`
+	SessionID  uuid.UUID `json:"-" gorm:"type:uuid;not null;uniqueIndex:idx_ctg_rollups_key,priority:1"`
+	Channel    string    `json:"-" gorm:"type:varchar(64);not null;uniqueIndex:idx_ctg_rollups_key,priority:2"`
+	Resolution int       `json:"-" gorm:"not null;uniqueIndex:idx_ctg_rollups_key,priority:3"` // Длительность интервала, с
+	Bucket     int64     `json:"-" gorm:"not null;uniqueIndex:idx_ctg_rollups_key,priority:4"` // Номер интервала
+	Min        float64   `json:"min"`
+	Max        float64   `json:"max"`
+	Mean       float64   `json:"mean"`
+	Valid      int       `json:"valid"` // Точек с сигналом
+	Count      int       `json:"count"` // Всего точек
+	UpdatedAt  time.Time `json:"-"`
+}
+
+func (CTGRollup) TableName() string {
+	return "ctg_rollups"
+}
+
+// Start начало интервала сводки на шкале сессии, с
+func (r CTGRollup) Start() float64 {
+	return float64(r.Bucket) * float64(r.Resolution)
+}
@@ -1,9 +1,17 @@
 package models
 
+import "time"
+
 type MedicalData struct {
 	DeviceID string  `json:"device_id"`
 	DataType string  `json:"data_type"`
 	Value    float64 `json:"value"`
 	Units    string  `json:"units"`
 	TimeSec  float64 `json:"time_sec"`
+
+	Timestamp int64 `json:"timestamp,omitempty"` // Время измерения на устройстве (Unix, нс)
+	Backfill  bool  `json:"backfill,omitempty"`  // Исторические данные, выгруженные после восстановления связи
+
+	Seq      uint64    `json:"-"` // Номер записи в спуле приема
+	Received time.Time `json:"-"` // Время приема сервером (пусто при восстановлении из спула)
 }
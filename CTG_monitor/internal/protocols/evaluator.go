@@ -0,0 +1,233 @@
+// internal/protocols/evaluator.go
+package protocols
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"CTG_monitor/internal/channels"
+	"CTG_monitor/internal/models"
+	"ctg_common/decel"
+)
+
+// Критерии НСТ: две акцелерации за любые 20 минут записи
+const (
+	reactiveAccelerations = 2
+	reactiveWindow        = 20 * 60.0
+)
+
+// Критерии СТ: три схватки не короче 40 с за 10 минут, поздние децелерации после
+// половины схваток и более - положительный тест
+const (
+	adequateContractions   = 3
+	contractionWindow      = 10 * 60.0
+	minContractionDuration = 40.0
+	positiveShare          = 0.5
+	peakTolerance          = 1.0 // совпадение пика схватки в децелерации и в схватке, с
+)
+
+// settleDelay сколько ждать после последней схватки окна поздней децелерации на нее, с
+const settleDelay = 2 * decel.MaxPairLag
+
+// timelineJump откат времени точек, после которого считается, что началась новая
+// шкала времени (сессия-продолжение), с. Меньшие откаты - перемешанные каналы.
+const timelineJump = 60.0
+
+// contraction схватка, учитываемая тестом
+type contraction struct {
+	at   float64 // длительность теста на момент обнаружения
+	peak float64 // пик на шкале сессии
+	late bool    // за схваткой последовала поздняя децелерация
+}
+
+// Evaluator ведет один тест: считает длительность записи по времени точек и
+// проверяет критерии по событиям анализа. Не потокобезопасен.
+type Evaluator struct {
+	protocol Protocol
+
+	started bool
+	last    float64 // последнее время точки на шкале сессии
+	elapsed float64 // длительность записи по протоколу, с
+
+	accelerations int
+	recent        []float64 // длительность теста на момент акцелераций в окне реактивности
+	reactive      bool
+
+	contractions []*contraction
+	latePeaks    []float64      // пики схваток поздних децелераций, пришедших раньше схватки
+	window       []*contraction // первые 10 минут с достаточным числом схваток
+	windowEnd    float64
+}
+
+// NewEvaluator создает оценку теста по протоколу
+func NewEvaluator(protocol Protocol) *Evaluator {
+	return &Evaluator{protocol: protocol}
+}
+
+// Protocol возвращает протокол теста
+func (e *Evaluator) Protocol() Protocol {
+	return e.protocol
+}
+
+// Resume продолжает тест после перезапуска сервиса с уже прошедшей длительностью.
+// События до перезапуска учитываются заново по времени их окончания на шкале сессии.
+func (e *Evaluator) Resume(elapsed float64, events []models.CTGEvent) {
+	e.started, e.last = true, 0
+	for _, event := range events {
+		e.Advance(math.Max(event.Start, event.End))
+		e.Observe(event)
+	}
+	e.elapsed = math.Max(e.elapsed, elapsed)
+	e.started = false
+}
+
+// Observe учитывает событие анализа
+func (e *Evaluator) Observe(event models.CTGEvent) {
+	switch event.Type {
+	case models.EventAcceleration:
+		if event.Channel != channels.FetalHeartRate {
+			return
+		}
+		e.accelerations++
+		e.recent = append(e.recent, e.elapsed)
+		drop := 0
+		for drop < len(e.recent) && e.recent[drop] < e.elapsed-reactiveWindow {
+			drop++
+		}
+		e.recent = e.recent[drop:]
+		if len(e.recent) >= reactiveAccelerations {
+			e.reactive = true
+		}
+	case models.EventContraction:
+		if event.End-event.Start < minContractionDuration {
+			return
+		}
+		c := &contraction{at: e.elapsed, peak: event.Peak}
+		for i, peak := range e.latePeaks {
+			if math.Abs(peak-c.peak) <= peakTolerance {
+				c.late = true
+				e.latePeaks = append(e.latePeaks[:i], e.latePeaks[i+1:]...)
+				break
+			}
+		}
+		e.contractions = append(e.contractions, c)
+		e.findWindow()
+	case models.EventDeceleration:
+		if event.Channel != channels.FetalHeartRate || event.Kind != decel.Late {
+			return
+		}
+		for _, c := range e.contractions {
+			if math.Abs(c.peak-event.ContractionPeak) <= peakTolerance {
+				c.late = true
+				return
+			}
+		}
+		e.latePeaks = append(e.latePeaks, event.ContractionPeak)
+	}
+}
+
+// findWindow запоминает первые 10 минут, за которые было достаточно схваток
+func (e *Evaluator) findWindow() {
+	if e.window != nil {
+		return
+	}
+	var inWindow []*contraction
+	for _, c := range e.contractions {
+		if c.at >= e.elapsed-contractionWindow {
+			inWindow = append(inWindow, c)
+		}
+	}
+	if len(inWindow) >= adequateContractions {
+		e.window = inWindow
+		e.windowEnd = e.elapsed
+	}
+}
+
+// Advance продвигает длительность теста по времени точки сессии. Возвращает
+// причину завершения, когда критерии выполнены или время вышло.
+func (e *Evaluator) Advance(t float64) (string, bool) {
+	switch {
+	case !e.started:
+		e.started = true
+		e.last = t
+	case t > e.last:
+		e.elapsed += t - e.last
+		e.last = t
+	case e.last-t > timelineJump:
+		e.last = t
+	}
+
+	if e.elapsed >= e.protocol.MaxDuration.Seconds() {
+		return ReasonTimeLimit, true
+	}
+	if e.elapsed >= e.protocol.MinDuration.Seconds() && e.criteriaMet() {
+		return ReasonCriteriaMet, true
+	}
+	return "", false
+}
+
+// criteriaMet критерии протокола выполнены и заключение не изменится
+func (e *Evaluator) criteriaMet() bool {
+	switch e.protocol.Name {
+	case NST:
+		return e.reactive
+	case CST:
+		return e.window != nil && e.elapsed >= e.windowEnd+settleDelay
+	}
+	return false
+}
+
+// Result формирует заключение теста
+func (e *Evaluator) Result(reason string, now time.Time) models.ProtocolResult {
+	evaluated := e.window
+	if evaluated == nil {
+		evaluated = e.contractions
+	}
+	late := 0
+	for _, c := range evaluated {
+		if c.late {
+			late++
+		}
+	}
+
+	result := models.ProtocolResult{
+		Protocol:          e.protocol.Name,
+		Reason:            reason,
+		Duration:          math.Round(e.elapsed),
+		Accelerations:     e.accelerations,
+		Contractions:      len(evaluated),
+		LateDecelerations: late,
+		CompletedAt:       now,
+	}
+	minutes := e.elapsed / 60
+
+	switch {
+	case e.elapsed < e.protocol.MinDuration.Seconds():
+		result.Outcome = OutcomeIncomplete
+		result.Summary = fmt.Sprintf("%s остановлен через %.0f мин, минимальная длительность %.0f мин",
+			e.protocol.Title, minutes, e.protocol.MinDuration.Minutes())
+	case e.protocol.Name == NST && e.reactive:
+		result.Outcome = OutcomeReactive
+		result.Summary = fmt.Sprintf("НСТ реактивный: не менее %d акцелераций за %.0f мин, всего %d за %.0f мин",
+			reactiveAccelerations, reactiveWindow/60, e.accelerations, minutes)
+	case e.protocol.Name == NST:
+		result.Outcome = OutcomeNonReactive
+		result.Summary = fmt.Sprintf("НСТ нереактивный: %d акцелераций за %.0f мин", e.accelerations, minutes)
+	case e.window == nil:
+		result.Outcome = OutcomeUnsatisfactory
+		result.Summary = fmt.Sprintf("СТ неинформативен: меньше %d схваток за %.0f мин, всего %d за %.0f мин",
+			adequateContractions, contractionWindow/60, len(e.contractions), minutes)
+	case late == 0:
+		result.Outcome = OutcomeNegative
+		result.Summary = fmt.Sprintf("СТ отрицательный: %d схваток за %.0f мин без поздних децелераций",
+			len(evaluated), contractionWindow/60)
+	case float64(late) >= positiveShare*float64(len(evaluated)):
+		result.Outcome = OutcomePositive
+		result.Summary = fmt.Sprintf("СТ положительный: поздние децелерации после %d из %d схваток", late, len(evaluated))
+	default:
+		result.Outcome = OutcomeEquivocal
+		result.Summary = fmt.Sprintf("СТ сомнительный: поздние децелерации после %d из %d схваток", late, len(evaluated))
+	}
+	return result
+}
@@ -0,0 +1,285 @@
+package protocols
+
+import (
+	"testing"
+	"time"
+
+	"CTG_monitor/internal/channels"
+	"CTG_monitor/internal/models"
+	"ctg_common/decel"
+)
+
+// step событие анализа, пришедшее в момент at шкалы сессии
+type step struct {
+	at    float64
+	event models.CTGEvent
+}
+
+// acceleration акцелерация ЧСС плода
+func acceleration(at float64) step {
+	return step{at, models.CTGEvent{Type: models.EventAcceleration, Channel: channels.FetalHeartRate, Start: at - 20, End: at}}
+}
+
+// contractionAt схватка длительностью duration с пиком за 10 с до окончания в at
+func contractionAt(at, duration float64) step {
+	return step{at, models.CTGEvent{Type: models.EventContraction, Channel: channels.UterineContractions,
+		Start: at - duration, Peak: at - 10, End: at}}
+}
+
+// lateAt поздняя децелерация на схватку с пиком peak
+func lateAt(at, peak float64) step {
+	return step{at, models.CTGEvent{Type: models.EventDeceleration, Channel: channels.FetalHeartRate,
+		Kind: decel.Late, Start: peak, End: peak + 60, ContractionPeak: peak}}
+}
+
+// run продвигает тест по точкам раз в секунду до until и передает события.
+// Возвращает причину и момент завершения; без завершения - ReasonManual и until.
+func run(evaluator *Evaluator, steps []step, until float64) (string, float64) {
+	next := 0
+	for t := 0.0; t <= until; t++ {
+		reason, done := evaluator.Advance(t)
+		if done {
+			return reason, t
+		}
+		for next < len(steps) && steps[next].at <= t {
+			evaluator.Observe(steps[next].event)
+			next++
+		}
+	}
+	return ReasonManual, until
+}
+
+func TestEvaluator(t *testing.T) {
+	cases := []struct {
+		name     string
+		protocol string
+		steps    []step
+		until    float64 // ручная остановка, если тест не завершился раньше
+		reason   string
+		at       float64
+		result   models.ProtocolResult
+	}{
+		{
+			name:     "НСТ реактивный после минимальной длительности",
+			protocol: NST,
+			steps:    []step{acceleration(60), acceleration(300)},
+			until:    3000,
+			reason:   ReasonCriteriaMet, at: 1200,
+			result: models.ProtocolResult{Outcome: OutcomeReactive, Accelerations: 2, Duration: 1200},
+		},
+		{
+			name:     "НСТ: акцелерации ровно через 20 минут",
+			protocol: NST,
+			steps:    []step{acceleration(60), acceleration(1260)},
+			until:    3000,
+			reason:   ReasonCriteriaMet, at: 1261,
+			result: models.ProtocolResult{Outcome: OutcomeReactive, Accelerations: 2, Duration: 1261},
+		},
+		{
+			name:     "НСТ: акцелерации дальше 20 минут друг от друга",
+			protocol: NST,
+			steps:    []step{acceleration(60), acceleration(1261)},
+			until:    3000,
+			reason:   ReasonTimeLimit, at: 2400,
+			result: models.ProtocolResult{Outcome: OutcomeNonReactive, Accelerations: 2, Duration: 2400},
+		},
+		{
+			name:     "НСТ: акцелерации второго плода не учитываются",
+			protocol: NST,
+			steps: []step{acceleration(60), {300, models.CTGEvent{Type: models.EventAcceleration,
+				Channel: channels.FetalHeartRate2, Start: 280, End: 300}}},
+			until:  3000,
+			reason: ReasonTimeLimit, at: 2400,
+			result: models.ProtocolResult{Outcome: OutcomeNonReactive, Accelerations: 1, Duration: 2400},
+		},
+		{
+			name:     "НСТ остановлен раньше минимальной длительности",
+			protocol: NST,
+			steps:    []step{acceleration(60), acceleration(300)},
+			until:    600,
+			reason:   ReasonManual, at: 600,
+			result: models.ProtocolResult{Outcome: OutcomeIncomplete, Accelerations: 2, Duration: 600},
+		},
+		{
+			name:     "СТ отрицательный: ожидание поздних децелераций после окна",
+			protocol: CST,
+			steps:    []step{contractionAt(100, 60), contractionAt(280, 60), contractionAt(460, 60)},
+			until:    3600,
+			reason:   ReasonCriteriaMet, at: 460 + settleDelay,
+			result: models.ProtocolResult{Outcome: OutcomeNegative, Contractions: 3, Duration: 640},
+		},
+		{
+			name:     "СТ положительный: поздние децелерации после половины схваток",
+			protocol: CST,
+			steps: []step{
+				contractionAt(100, 60), lateAt(150, 90),
+				contractionAt(280, 60),
+				contractionAt(460, 60), lateAt(600, 450),
+			},
+			until:  3600,
+			reason: ReasonCriteriaMet, at: 640,
+			result: models.ProtocolResult{Outcome: OutcomePositive, Contractions: 3, LateDecelerations: 2, Duration: 640},
+		},
+		{
+			name:     "СТ сомнительный: поздняя децелерация после одной схватки из трех",
+			protocol: CST,
+			steps: []step{
+				contractionAt(100, 60), contractionAt(280, 60), lateAt(330, 270),
+				contractionAt(460, 60),
+			},
+			until:  3600,
+			reason: ReasonCriteriaMet, at: 640,
+			result: models.ProtocolResult{Outcome: OutcomeEquivocal, Contractions: 3, LateDecelerations: 1, Duration: 640},
+		},
+		{
+			name:     "СТ: поздняя децелерация раньше своей схватки",
+			protocol: CST,
+			steps: []step{
+				lateAt(95, 90), contractionAt(100, 60),
+				lateAt(275, 270), contractionAt(280, 60),
+				contractionAt(460, 60),
+			},
+			until:  3600,
+			reason: ReasonCriteriaMet, at: 640,
+			result: models.ProtocolResult{Outcome: OutcomePositive, Contractions: 3, LateDecelerations: 2, Duration: 640},
+		},
+		{
+			name:     "СТ: окно - первые 10 минут с тремя схватками",
+			protocol: CST,
+			steps: []step{
+				contractionAt(100, 60), contractionAt(500, 60), contractionAt(800, 60),
+				contractionAt(1000, 60),
+			},
+			until:  3600,
+			reason: ReasonCriteriaMet, at: 1000 + settleDelay,
+			result: models.ProtocolResult{Outcome: OutcomeNegative, Contractions: 3, Duration: 1180},
+		},
+		{
+			name:     "СТ неинформативен: схватки короче 40 с не учитываются",
+			protocol: CST,
+			steps:    []step{contractionAt(100, 60), contractionAt(280, 39), contractionAt(460, 60)},
+			until:    4000,
+			reason:   ReasonTimeLimit, at: 3600,
+			result: models.ProtocolResult{Outcome: OutcomeUnsatisfactory, Contractions: 2, Duration: 3600},
+		},
+		{
+			name:     "СТ остановлен раньше минимальной длительности",
+			protocol: CST,
+			steps:    []step{contractionAt(100, 60)},
+			until:    300,
+			reason:   ReasonManual, at: 300,
+			result: models.ProtocolResult{Outcome: OutcomeIncomplete, Contractions: 1, Duration: 300},
+		},
+	}
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			protocol, _ := Lookup(c.protocol)
+			evaluator := NewEvaluator(protocol)
+
+			reason, at := run(evaluator, c.steps, c.until)
+			if reason != c.reason || at != c.at {
+				t.Errorf("завершен по %s в %.0f с, ожидалось %s в %.0f с", reason, at, c.reason, c.at)
+			}
+
+			result := evaluator.Result(reason, now)
+			want := c.result
+			want.Protocol, want.Reason, want.CompletedAt = c.protocol, reason, now
+			result.Summary = ""
+			if result != want {
+				t.Errorf("заключение %+v, ожидалось %+v", result, want)
+			}
+		})
+	}
+}
+
+func TestAdvanceTimeline(t *testing.T) {
+	protocol, _ := Lookup(NST)
+	evaluator := NewEvaluator(protocol)
+
+	// Небольшой откат - перемешанные каналы, большой - новая шкала времени
+	for _, at := range []float64{100, 110, 105, 110, 120, 10, 30} {
+		evaluator.Advance(at)
+	}
+	if duration := evaluator.Result(ReasonManual, time.Now()).Duration; duration != 40 {
+		t.Errorf("длительность %.0f с, ожидалось 40", duration)
+	}
+}
+
+func TestResume(t *testing.T) {
+	cases := []struct {
+		name     string
+		protocol string
+		events   []models.CTGEvent
+		elapsed  float64 // длительность до перезапуска
+		steps    []step
+		reason   string
+		at       float64
+		result   models.ProtocolResult
+	}{
+		{
+			name:     "НСТ: акцелерации до перезапуска",
+			protocol: NST,
+			events:   []models.CTGEvent{acceleration(100).event, acceleration(400).event},
+			elapsed:  900,
+			reason:   ReasonCriteriaMet, at: 5300,
+			result: models.ProtocolResult{Outcome: OutcomeReactive, Accelerations: 2, Duration: 1200},
+		},
+		{
+			name:     "НСТ: вторая акцелерация после перезапуска",
+			protocol: NST,
+			events:   []models.CTGEvent{acceleration(100).event},
+			elapsed:  900,
+			steps:    []step{acceleration(5100)},
+			reason:   ReasonCriteriaMet, at: 5300,
+			result: models.ProtocolResult{Outcome: OutcomeReactive, Accelerations: 2, Duration: 1200},
+		},
+		{
+			name:     "СТ: схватки и поздняя децелерация до перезапуска",
+			protocol: CST,
+			events: []models.CTGEvent{
+				lateAt(95, 90).event, contractionAt(100, 60).event,
+				contractionAt(280, 60).event, contractionAt(460, 60).event,
+			},
+			elapsed: 500,
+			reason:  ReasonCriteriaMet, at: 5140,
+			result: models.ProtocolResult{Outcome: OutcomeEquivocal, Contractions: 3, LateDecelerations: 1, Duration: 640},
+		},
+	}
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			protocol, _ := Lookup(c.protocol)
+			evaluator := NewEvaluator(protocol)
+			evaluator.Resume(c.elapsed, c.events)
+
+			// После перезапуска точки идут на шкале сессии с 5000 с: длительность
+			// продолжается от восстановленной без скачка
+			next := 0
+			reason, at := ReasonManual, 0.0
+			for tm := 5000.0; tm <= 9000; tm++ {
+				if r, done := evaluator.Advance(tm); done {
+					reason, at = r, tm
+					break
+				}
+				for next < len(c.steps) && c.steps[next].at <= tm {
+					evaluator.Observe(c.steps[next].event)
+					next++
+				}
+			}
+			if reason != c.reason || at != c.at {
+				t.Errorf("завершен по %s в %.0f с, ожидалось %s в %.0f с", reason, at, c.reason, c.at)
+			}
+
+			result := evaluator.Result(reason, now)
+			want := c.result
+			want.Protocol, want.Reason, want.CompletedAt = c.protocol, reason, now
+			result.Summary = ""
+			if result != want {
+				t.Errorf("заключение %+v, ожидалось %+v", result, want)
+			}
+		})
+	}
+}
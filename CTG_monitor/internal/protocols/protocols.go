@@ -0,0 +1,61 @@
+// internal/protocols/protocols.go
+package protocols
+
+import (
+	"sort"
+	"time"
+)
+
+// Протоколы тестов
+const (
+	NST = "nst" // нестрессовый тест: реактивность по акцелерациям
+	CST = "cst" // стрессовый (окситоциновый) тест: поздние децелерации на схватки
+)
+
+// Заключения
+const (
+	OutcomeReactive       = "reactive"       // НСТ: достаточно акцелераций
+	OutcomeNonReactive    = "non_reactive"   // НСТ: акцелераций недостаточно за отведенное время
+	OutcomeNegative       = "negative"       // СТ: поздних децелераций нет
+	OutcomePositive       = "positive"       // СТ: поздние децелерации после половины схваток и более
+	OutcomeEquivocal      = "equivocal"      // СТ: поздние децелерации после части схваток
+	OutcomeUnsatisfactory = "unsatisfactory" // СТ: схваток недостаточно для оценки
+	OutcomeIncomplete     = "incomplete"     // остановлен вручную раньше минимальной длительности
+)
+
+// Причины завершения теста
+const (
+	ReasonCriteriaMet = "criteria_met" // критерии выполнены после минимальной длительности
+	ReasonTimeLimit   = "time_limit"   // истекла максимальная длительность
+	ReasonManual      = "manual"       // сессия остановлена вручную
+	ReasonInterrupted = "interrupted"  // сессия закрыта после перезапуска сервиса
+)
+
+// Protocol описание теста
+type Protocol struct {
+	Name        string
+	Title       string
+	MinDuration time.Duration // запись короче не дает заключения
+	MaxDuration time.Duration // по истечении сессия завершается с тем, что есть
+}
+
+var definitions = map[string]Protocol{
+	NST: {Name: NST, Title: "Нестрессовый тест", MinDuration: 20 * time.Minute, MaxDuration: 40 * time.Minute},
+	CST: {Name: CST, Title: "Стрессовый тест", MinDuration: 10 * time.Minute, MaxDuration: 60 * time.Minute},
+}
+
+// Lookup возвращает протокол по имени
+func Lookup(name string) (Protocol, bool) {
+	protocol, ok := definitions[name]
+	return protocol, ok
+}
+
+// Names возвращает имена известных протоколов
+func Names() []string {
+	names := make([]string, 0, len(definitions))
+	for name := range definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
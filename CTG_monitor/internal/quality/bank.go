@@ -0,0 +1,85 @@
+// internal/quality/bank.go
+package quality
+
+import (
+	"sort"
+	"sync"
+
+	"CTG_monitor/internal/models"
+)
+
+// Bank хранит оценки качества сигнала для каждого устройства и канала
+type Bank struct {
+	cfg      Config
+	trackers map[channelKey]*Tracker
+	mu       sync.Mutex
+}
+
+// channelKey ключ канала конкретного устройства
+type channelKey struct {
+	deviceID string
+	dataType string
+}
+
+// ChannelStats оценка качества одного канала устройства
+type ChannelStats struct {
+	DeviceID string `json:"device_id"`
+	DataType string `json:"data_type"`
+	State
+}
+
+// NewBank создает банк оценок качества сигнала
+func NewBank(cfg Config) *Bank {
+	return &Bank{
+		cfg:      cfg,
+		trackers: make(map[channelKey]*Tracker),
+	}
+}
+
+// Observe учитывает точку канала устройства (см. Tracker.Observe)
+func (b *Bank) Observe(deviceID, dataType string, t, v float64, corrected bool) []models.CTGEvent {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	key := channelKey{deviceID: deviceID, dataType: dataType}
+	tracker, exists := b.trackers[key]
+	if !exists {
+		tracker = NewTracker(b.cfg, dataType)
+		b.trackers[key] = tracker
+	}
+	return tracker.Observe(t, v, corrected)
+}
+
+// ResetDevice забывает историю устройства (новый пациент)
+func (b *Bank) ResetDevice(deviceID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for key := range b.trackers {
+		if key.deviceID == deviceID {
+			delete(b.trackers, key)
+		}
+	}
+}
+
+// Stats возвращает оценки по всем каналам
+func (b *Bank) Stats() []ChannelStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stats := make([]ChannelStats, 0, len(b.trackers))
+	for key, tracker := range b.trackers {
+		stats = append(stats, ChannelStats{
+			DeviceID: key.deviceID,
+			DataType: key.dataType,
+			State:    tracker.State(),
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].DeviceID != stats[j].DeviceID {
+			return stats[i].DeviceID < stats[j].DeviceID
+		}
+		return stats[i].DataType < stats[j].DataType
+	})
+	return stats
+}
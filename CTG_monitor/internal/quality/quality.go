@@ -0,0 +1,154 @@
+// internal/quality/quality.go
+package quality
+
+import (
+	"math"
+	"time"
+
+	"CTG_monitor/internal/channels"
+	"CTG_monitor/internal/models"
+)
+
+// Классы качества сигнала по индексу
+const (
+	BandGood = "good" // запись можно интерпретировать
+	BandFair = "fair" // интерпретировать с осторожностью
+	BandPoor = "poor" // запись интерпретировать нельзя
+)
+
+// Границы классов: индекс - доля точек окна с сигналом без исправлений фильтрами, %
+const (
+	goodIndex = 80.0
+	fairIndex = 50.0
+)
+
+// MinLoss потеря сигнала короче не считается эпизодом
+const MinLoss = 5 * time.Second
+
+// Значения по умолчанию
+const (
+	defaultWindow   = 2 * time.Minute
+	defaultInterval = 30 * time.Second
+)
+
+// Config параметры оценки качества сигнала
+type Config struct {
+	Window   time.Duration // скользящее окно индекса качества
+	Interval time.Duration // период рассылки индекса
+}
+
+// withDefaults заполняет незаданные параметры
+func (c Config) withDefaults() Config {
+	if c.Window <= 0 {
+		c.Window = defaultWindow
+	}
+	if c.Interval <= 0 {
+		c.Interval = defaultInterval
+	}
+	return c
+}
+
+// Tracked оценивается ли качество канала: только непрерывные сигналы с постоянной частотой
+func Tracked(channel channels.Channel) bool {
+	return channel.Kind == channels.KindSignal && channel.SampleRate > 0
+}
+
+// Corrected исправлена ли точка фильтрами артефактов
+func Corrected(flags models.SampleFlags) bool {
+	return flags&(models.FlagSpike|models.FlagOutlier|models.FlagDoppler) != 0
+}
+
+// Band класс качества по индексу
+func Band(index float64) string {
+	switch {
+	case index >= goodIndex:
+		return BandGood
+	case index >= fairIndex:
+		return BandFair
+	default:
+		return BandPoor
+	}
+}
+
+// percent доля в процентах с точностью 0.1
+func percent(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(part)/float64(total)*1000) / 10
+}
+
+// Accumulator сводка качества сигнала канала за сессию. Точки, пришедшие не по
+// порядку (выгрузка, перемешанные каналы), учитываются только в счетчиках.
+type Accumulator struct {
+	quality models.ChannelQuality
+
+	started   bool
+	last      float64
+	inLoss    bool
+	lossStart float64
+	lossLast  float64
+}
+
+// Add учитывает точку канала
+func (a *Accumulator) Add(point models.CTGPoint) {
+	a.quality.Points++
+	valid := point.V != -1
+	if valid {
+		a.quality.Valid++
+	}
+	if Corrected(point.F) {
+		a.quality.Corrected++
+	}
+	if point.F&(models.FlagSpike|models.FlagOutlier) != 0 {
+		a.quality.Spikes++
+	}
+
+	if a.started && point.T < a.last {
+		return
+	}
+	a.started = true
+	a.last = point.T
+
+	switch {
+	case !valid:
+		if !a.inLoss {
+			a.inLoss = true
+			a.lossStart = point.T
+		}
+		a.lossLast = point.T
+	case a.inLoss:
+		a.inLoss = false
+		a.addEpisode(&a.quality, point.T)
+	}
+}
+
+// addEpisode записывает эпизод потери, если он не короче MinLoss
+func (a *Accumulator) addEpisode(quality *models.ChannelQuality, end float64) {
+	if end-a.lossStart < MinLoss.Seconds() {
+		return
+	}
+	quality.LossEpisodes = append(quality.LossEpisodes, models.LossEpisode{Start: a.lossStart, End: end})
+	quality.LossSeconds += end - a.lossStart
+}
+
+// Result возвращает сводку; незакончившаяся потеря считается до последней точки без сигнала
+func (a *Accumulator) Result() models.ChannelQuality {
+	result := a.quality
+	result.LossEpisodes = append([]models.LossEpisode{}, a.quality.LossEpisodes...)
+	if a.inLoss {
+		a.addEpisode(&result, a.lossLast)
+	}
+	result.ValidPercent = percent(result.Valid, result.Points)
+	result.LossSeconds = math.Round(result.LossSeconds*10) / 10
+	return result
+}
+
+// Summarize сводка качества по точкам ряда
+func Summarize(points []models.CTGPoint) models.ChannelQuality {
+	var accumulator Accumulator
+	for _, point := range points {
+		accumulator.Add(point)
+	}
+	return accumulator.Result()
+}
@@ -0,0 +1,137 @@
+// internal/quality/tracker.go
+package quality
+
+import (
+	"math"
+
+	"CTG_monitor/internal/models"
+)
+
+// sample точка окна индекса качества
+type sample struct {
+	t         float64
+	valid     bool
+	corrected bool
+}
+
+// State текущая оценка качества сигнала канала
+type State struct {
+	Index        float64 `json:"index"`         // Доля точек окна с сигналом без исправлений, %
+	ValidPercent float64 `json:"valid_percent"` // Доля точек окна с сигналом, %
+	Band         string  `json:"band"`          // good, fair, poor
+	Points       int     `json:"points"`        // Точек в окне
+	InLoss       bool    `json:"in_loss"`       // Сигнал сейчас потерян дольше MinLoss
+	LossEpisodes int     `json:"loss_episodes"` // Эпизодов потери с начала наблюдения
+}
+
+// Tracker оценивает качество сигнала одного канала по скользящему окну и
+// отслеживает эпизоды потери сигнала (серии -1). Не потокобезопасен.
+type Tracker struct {
+	cfg     Config
+	channel string
+
+	samples    []sample
+	reported   bool
+	lastReport float64
+
+	inLoss       bool
+	lossStart    float64
+	lossReported bool
+
+	state State
+}
+
+// NewTracker создает оценку качества канала
+func NewTracker(cfg Config, channel string) *Tracker {
+	return &Tracker{cfg: cfg.withDefaults(), channel: channel}
+}
+
+// Observe учитывает точку канала (v = -1 - нет сигнала, corrected - точка исправлена
+// фильтрами). Возвращает индекс качества раз в Interval и начало/окончание эпизода
+// потери сигнала: у начала End = 0.
+func (tr *Tracker) Observe(t, v float64, corrected bool) []models.CTGEvent {
+	// Новая шкала времени (сессия-продолжение): окно начинается заново
+	if n := len(tr.samples); n > 0 && t < tr.samples[n-1].t {
+		tr.samples = tr.samples[:0]
+		tr.reported = false
+	}
+
+	valid := v != -1
+	tr.samples = append(tr.samples, sample{t: t, valid: valid, corrected: corrected})
+	drop := 0
+	for drop < len(tr.samples) && tr.samples[drop].t < t-tr.cfg.Window.Seconds() {
+		drop++
+	}
+	tr.samples = tr.samples[drop:]
+	tr.updateState()
+
+	var events []models.CTGEvent
+	switch {
+	case !valid:
+		if !tr.inLoss {
+			tr.inLoss = true
+			tr.lossStart = t
+		}
+		if !tr.lossReported && t-tr.lossStart >= MinLoss.Seconds() {
+			tr.lossReported = true
+			tr.state.LossEpisodes++
+			events = append(events, tr.lossEvent(t, 0))
+		}
+	case tr.inLoss:
+		if tr.lossReported {
+			events = append(events, tr.lossEvent(t, t))
+		}
+		tr.inLoss = false
+		tr.lossReported = false
+	}
+	tr.state.InLoss = tr.lossReported
+
+	if !tr.reported {
+		tr.reported = true
+		tr.lastReport = t
+	} else if t-tr.lastReport >= tr.cfg.Interval.Seconds() {
+		tr.lastReport = t
+		events = append(events, models.CTGEvent{
+			Type:    models.EventQuality,
+			Channel: tr.channel,
+			Start:   tr.samples[0].t,
+			End:     t,
+			Value:   tr.state.Index,
+			Band:    tr.state.Band,
+		})
+	}
+	return events
+}
+
+// lossEvent событие эпизода потери сигнала; Value - длительность на момент события
+func (tr *Tracker) lossEvent(t, end float64) models.CTGEvent {
+	return models.CTGEvent{
+		Type:    models.EventSignalLoss,
+		Channel: tr.channel,
+		Start:   tr.lossStart,
+		End:     end,
+		Value:   math.Round((t-tr.lossStart)*10) / 10,
+	}
+}
+
+// updateState пересчитывает индекс по окну
+func (tr *Tracker) updateState() {
+	valid, clean := 0, 0
+	for _, s := range tr.samples {
+		if s.valid {
+			valid++
+			if !s.corrected {
+				clean++
+			}
+		}
+	}
+	tr.state.Points = len(tr.samples)
+	tr.state.ValidPercent = percent(valid, len(tr.samples))
+	tr.state.Index = percent(clean, len(tr.samples))
+	tr.state.Band = Band(tr.state.Index)
+}
+
+// State возвращает текущую оценку
+func (tr *Tracker) State() State {
+	return tr.state
+}
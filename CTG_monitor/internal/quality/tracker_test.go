@@ -0,0 +1,140 @@
+package quality
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"CTG_monitor/internal/models"
+)
+
+const testChannel = "fetal_heart_rate"
+
+// span точки раз в секунду с from по to включительно
+type span struct {
+	from, to  int
+	v         float64
+	corrected bool
+}
+
+// qualityEvent индекс качества окна start-end
+func qualityEvent(start, end, index float64) models.CTGEvent {
+	return models.CTGEvent{
+		Type:    models.EventQuality,
+		Channel: testChannel,
+		Start:   start,
+		End:     end,
+		Value:   index,
+		Band:    Band(index),
+	}
+}
+
+// lossEvent эпизод потери сигнала; у начала эпизода end = 0
+func lossEvent(start, end, duration float64) models.CTGEvent {
+	return models.CTGEvent{
+		Type:    models.EventSignalLoss,
+		Channel: testChannel,
+		Start:   start,
+		End:     end,
+		Value:   duration,
+	}
+}
+
+func TestTracker(t *testing.T) {
+	cfg := Config{Window: time.Minute, Interval: 30 * time.Second}
+
+	cases := []struct {
+		name   string
+		spans  []span
+		events []models.CTGEvent
+		state  State
+	}{
+		{
+			name:   "чистый сигнал",
+			spans:  []span{{from: 0, to: 60, v: 140}},
+			events: []models.CTGEvent{qualityEvent(0, 30, 100), qualityEvent(0, 60, 100)},
+			state:  State{Index: 100, ValidPercent: 100, Band: BandGood, Points: 61},
+		},
+		{
+			name: "исправленные фильтрами точки снижают индекс",
+			spans: []span{
+				{from: 0, to: 0, v: 140},
+				{from: 1, to: 60, v: 140, corrected: true},
+			},
+			events: []models.CTGEvent{qualityEvent(0, 30, 3.2), qualityEvent(0, 60, 1.6)},
+			state:  State{Index: 1.6, ValidPercent: 100, Band: BandPoor, Points: 61},
+		},
+		{
+			name: "скользящее окно",
+			spans: []span{
+				{from: 0, to: 59, v: 140, corrected: true},
+				{from: 60, to: 150, v: 140},
+			},
+			events: []models.CTGEvent{
+				qualityEvent(0, 30, 0),
+				qualityEvent(0, 60, 1.6),
+				qualityEvent(30, 90, 50.8),
+				qualityEvent(60, 120, 100),
+				qualityEvent(90, 150, 100),
+			},
+			state: State{Index: 100, ValidPercent: 100, Band: BandGood, Points: 61},
+		},
+		{
+			name: "эпизод потери сигнала",
+			spans: []span{
+				{from: 0, to: 9, v: 140},
+				{from: 10, to: 19, v: -1},
+				{from: 20, to: 25, v: 140},
+			},
+			events: []models.CTGEvent{lossEvent(10, 0, 5), lossEvent(10, 20, 10)},
+			state:  State{Index: 61.5, ValidPercent: 61.5, Band: BandFair, Points: 26, LossEpisodes: 1},
+		},
+		{
+			name: "короткая потеря не считается эпизодом",
+			spans: []span{
+				{from: 0, to: 9, v: 140},
+				{from: 10, to: 13, v: -1},
+				{from: 14, to: 19, v: 140},
+			},
+			state: State{Index: 80, ValidPercent: 80, Band: BandGood, Points: 20},
+		},
+		{
+			name: "потеря продолжается",
+			spans: []span{
+				{from: 0, to: 4, v: 140},
+				{from: 5, to: 20, v: -1},
+			},
+			events: []models.CTGEvent{lossEvent(5, 0, 5)},
+			state:  State{Index: 23.8, ValidPercent: 23.8, Band: BandPoor, Points: 21, InLoss: true, LossEpisodes: 1},
+		},
+		{
+			name: "новая шкала времени начинает окно заново",
+			spans: []span{
+				{from: 0, to: 40, v: 140},
+				{from: 0, to: 20, v: 140, corrected: true},
+			},
+			events: []models.CTGEvent{qualityEvent(0, 30, 100)},
+			state:  State{Index: 0, ValidPercent: 100, Band: BandPoor, Points: 21},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			tracker := NewTracker(cfg, testChannel)
+
+			var events []models.CTGEvent
+			for _, s := range c.spans {
+				for i := s.from; i <= s.to; i++ {
+					events = append(events, tracker.Observe(float64(i), s.v, s.corrected)...)
+				}
+			}
+
+			if !reflect.DeepEqual(events, c.events) {
+				t.Errorf("события %+v, ожидались %+v", events, c.events)
+			}
+			if state := tracker.State(); state != c.state {
+				t.Errorf("состояние %+v, ожидалось %+v", state, c.state)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+// internal/reorder/bank.go
+package reorder
+
+import (
+	"sync"
+	"time"
+
+	"CTG_monitor/internal/models"
+)
+
+// Bank хранит буферы упорядочивания для каждого устройства и канала
+type Bank struct {
+	cfg     Config
+	buffers map[channelKey]*Buffer
+	mu      sync.Mutex
+}
+
+// channelKey ключ канала конкретного устройства
+type channelKey struct {
+	deviceID string
+	dataType string
+}
+
+// ChannelStats счетчики буфера одного канала устройства
+type ChannelStats struct {
+	DeviceID string `json:"device_id"`
+	DataType string `json:"data_type"`
+	Stats
+}
+
+// NewBank создает банк буферов упорядочивания
+func NewBank(cfg Config) *Bank {
+	return &Bank{
+		cfg:     cfg,
+		buffers: make(map[channelKey]*Buffer),
+	}
+}
+
+// Push добавляет точку в буфер канала устройства (см. Buffer.Push)
+func (b *Bank) Push(data *models.MedicalData, now time.Time) ([]Sample, bool) {
+	return b.getBuffer(data.DeviceID, data.DataType).Push(data, now)
+}
+
+// FlushDevice выпускает точки устройства, ожидающие дольше MaxHold
+func (b *Bank) FlushDevice(deviceID string, now time.Time) []Sample {
+	var ready []Sample
+	for _, buffer := range b.deviceBuffers(deviceID) {
+		ready = append(ready, buffer.Flush(now)...)
+	}
+	return ready
+}
+
+// DrainDevice выпускает все ожидающие точки устройства
+func (b *Bank) DrainDevice(deviceID string) []Sample {
+	var ready []Sample
+	for _, buffer := range b.deviceBuffers(deviceID) {
+		ready = append(ready, buffer.Drain()...)
+	}
+	return ready
+}
+
+// Stats возвращает счетчики по всем каналам
+func (b *Bank) Stats() []ChannelStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stats := make([]ChannelStats, 0, len(b.buffers))
+	for key, buffer := range b.buffers {
+		stats = append(stats, ChannelStats{
+			DeviceID: key.deviceID,
+			DataType: key.dataType,
+			Stats:    buffer.Stats(),
+		})
+	}
+	return stats
+}
+
+// getBuffer возвращает буфер канала, создавая его при первом обращении
+func (b *Bank) getBuffer(deviceID, dataType string) *Buffer {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	key := channelKey{deviceID: deviceID, dataType: dataType}
+	buffer, exists := b.buffers[key]
+	if !exists {
+		buffer = NewBuffer(b.cfg)
+		b.buffers[key] = buffer
+	}
+	return buffer
+}
+
+// deviceBuffers возвращает буферы всех каналов устройства
+func (b *Bank) deviceBuffers(deviceID string) []*Buffer {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var buffers []*Buffer
+	for key, buffer := range b.buffers {
+		if key.deviceID == deviceID {
+			buffers = append(buffers, buffer)
+		}
+	}
+	return buffers
+}
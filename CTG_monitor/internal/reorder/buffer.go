@@ -0,0 +1,205 @@
+// internal/reorder/buffer.go
+package reorder
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"CTG_monitor/internal/models"
+)
+
+// Config параметры буфера упорядочивания
+type Config struct {
+	Window  time.Duration // сколько времени сигнала точка ждет более ранних точек
+	MaxHold time.Duration // максимальное ожидание по часам сервера, если поток прервался
+	History time.Duration // сколько времени сигнала помнить выпущенные точки для поиска дублей
+	// Насколько время должно уйти назад, чтобы считаться сбросом шкалы устройства,
+	// а не опоздавшей точкой
+	ResetThreshold time.Duration
+}
+
+// Stats счетчики буфера упорядочивания
+type Stats struct {
+	Received   int `json:"received"`   // всего поступило точек
+	Reordered  int `json:"reordered"`  // пришли не по порядку и были переставлены
+	Duplicates int `json:"duplicates"` // точные дубли (то же время и значение), отброшены
+	Conflicts  int `json:"conflicts"`  // то же время с другим значением, отброшены
+	Late       int `json:"late"`       // пришли после закрытия окна, выпущены вне порядка
+	Resets     int `json:"resets"`     // сбросы шкалы времени устройства
+	Held       int `json:"held"`       // сейчас ожидают в буфере
+}
+
+// Sample точка, выпущенная из буфера
+type Sample struct {
+	Data  *models.MedicalData
+	Late  bool // окно уже закрыто, точка выпущена вне порядка
+	Reset bool // первая точка после сброса шкалы времени устройства
+}
+
+// heldSample точка в ожидании
+type heldSample struct {
+	data    *models.MedicalData
+	arrived time.Time
+}
+
+// Buffer упорядочивает точки одного канала одного устройства по TimeSec
+type Buffer struct {
+	cfg Config
+
+	held []heldSample // по возрастанию TimeSec
+
+	released      map[float64]float64 // TimeSec -> значение недавно выпущенных точек
+	releasedOrder []float64           // порядок выпуска для очистки истории
+	lastReleased  float64
+	hasReleased   bool
+	maxSeen       float64 // самое позднее полученное время
+	seen          bool
+	resetPending  bool // следующая выпущенная точка помечается как Reset
+
+	stats Stats
+	mu    sync.Mutex
+}
+
+// NewBuffer создает буфер упорядочивания
+func NewBuffer(cfg Config) *Buffer {
+	return &Buffer{
+		cfg:      cfg,
+		released: make(map[float64]float64),
+	}
+}
+
+// Push добавляет точку и возвращает точки, готовые к обработке, по порядку.
+// duplicate=true означает, что точка уже была получена и отброшена.
+func (b *Buffer) Push(data *models.MedicalData, now time.Time) (ready []Sample, duplicate bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.stats.Received++
+	t := data.TimeSec
+
+	// Время ушло далеко назад: устройство начало новую шкалу времени,
+	// прежние точки больше не считаются дублями
+	if b.hasReleased && b.lastReleased-t > b.cfg.ResetThreshold.Seconds() {
+		b.stats.Resets++
+		ready = b.release(len(b.held))
+		b.released = make(map[float64]float64)
+		b.releasedOrder = nil
+		b.hasReleased = false
+		b.seen = false
+		b.resetPending = true
+	}
+
+	if value, found := b.lookup(t); found {
+		if value == data.Value {
+			b.stats.Duplicates++
+		} else {
+			b.stats.Conflicts++
+		}
+		return ready, true
+	}
+
+	if b.hasReleased && t <= b.lastReleased {
+		// Окно закрыто: выпускаем сразу, без переупорядочивания
+		b.stats.Late++
+		b.remember(t, data.Value)
+		return []Sample{{Data: data, Late: true}}, false
+	}
+
+	if b.seen && t < b.maxSeen {
+		b.stats.Reordered++
+	}
+	if !b.seen || t > b.maxSeen {
+		b.maxSeen = t
+	}
+	b.seen = true
+
+	i := sort.Search(len(b.held), func(i int) bool { return b.held[i].data.TimeSec > t })
+	b.held = append(b.held, heldSample{})
+	copy(b.held[i+1:], b.held[i:])
+	b.held[i] = heldSample{data: data, arrived: now}
+
+	// Выпускаем точки, для которых более ранние уже не ожидаются
+	cutoff := b.maxSeen - b.cfg.Window.Seconds()
+	n := sort.Search(len(b.held), func(i int) bool { return b.held[i].data.TimeSec > cutoff })
+	return append(ready, b.release(n)...), false
+}
+
+// Flush выпускает точки, которые ждут дольше MaxHold (и все более ранние)
+func (b *Buffer) Flush(now time.Time) []Sample {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	n := 0
+	for i, held := range b.held {
+		if now.Sub(held.arrived) >= b.cfg.MaxHold {
+			n = i + 1
+		}
+	}
+	return b.release(n)
+}
+
+// Drain выпускает все ожидающие точки
+func (b *Buffer) Drain() []Sample {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.release(len(b.held))
+}
+
+// Stats возвращает счетчики буфера
+func (b *Buffer) Stats() Stats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stats := b.stats
+	stats.Held = len(b.held)
+	return stats
+}
+
+// release выпускает первые n ожидающих точек
+func (b *Buffer) release(n int) []Sample {
+	if n == 0 {
+		return nil
+	}
+
+	ready := make([]Sample, 0, n)
+	for _, held := range b.held[:n] {
+		ready = append(ready, Sample{Data: held.data, Reset: b.resetPending && len(ready) == 0})
+		b.remember(held.data.TimeSec, held.data.Value)
+		if !b.hasReleased || held.data.TimeSec > b.lastReleased {
+			b.lastReleased = held.data.TimeSec
+		}
+		b.hasReleased = true
+	}
+	b.held = append(b.held[:0], b.held[n:]...)
+	b.resetPending = false
+	return ready
+}
+
+// lookup ищет точку с тем же временем среди ожидающих и недавно выпущенных
+func (b *Buffer) lookup(t float64) (float64, bool) {
+	if value, ok := b.released[t]; ok {
+		return value, true
+	}
+	i := sort.Search(len(b.held), func(i int) bool { return b.held[i].data.TimeSec >= t })
+	if i < len(b.held) && b.held[i].data.TimeSec == t {
+		return b.held[i].data.Value, true
+	}
+	return 0, false
+}
+
+// remember запоминает выпущенную точку и забывает слишком старые
+func (b *Buffer) remember(t, value float64) {
+	b.released[t] = value
+	b.releasedOrder = append(b.releasedOrder, t)
+
+	cutoff := b.lastReleased - b.cfg.History.Seconds()
+	drop := 0
+	for drop < len(b.releasedOrder) && b.releasedOrder[drop] < cutoff {
+		delete(b.released, b.releasedOrder[drop])
+		drop++
+	}
+	if drop > 0 {
+		b.releasedOrder = append(b.releasedOrder[:0], b.releasedOrder[drop:]...)
+	}
+}
@@ -0,0 +1,133 @@
+package reorder
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"CTG_monitor/internal/models"
+)
+
+// step точка, пришедшая через at после начала, или Flush в момент at
+type step struct {
+	t, v  float64
+	at    time.Duration
+	flush bool
+}
+
+// out выпущенная точка
+type out struct {
+	t     float64
+	late  bool
+	reset bool
+}
+
+func TestBuffer(t *testing.T) {
+	cfg := Config{
+		Window:         time.Second,
+		MaxHold:        200 * time.Millisecond,
+		History:        30 * time.Second,
+		ResetThreshold: 5 * time.Second,
+	}
+
+	cases := []struct {
+		name  string
+		steps []step
+		drain bool // в конце выпустить все ожидающие точки
+		want  []out
+		stats Stats
+	}{
+		{
+			name:  "перестановка в окне",
+			steps: []step{{t: 0}, {t: 0.5}, {t: 0.25}, {t: 0.75}, {t: 2}},
+			want:  []out{{t: 0}, {t: 0.25}, {t: 0.5}, {t: 0.75}},
+			stats: Stats{Received: 5, Reordered: 1, Held: 1},
+		},
+		{
+			name: "точный дубль и конфликт",
+			steps: []step{
+				{t: 0, v: 140}, {t: 0, v: 140}, {t: 0, v: 150},
+				// Дубль уже выпущенной точки
+				{t: 2, v: 140}, {t: 0, v: 140},
+			},
+			want:  []out{{t: 0}},
+			stats: Stats{Received: 5, Duplicates: 2, Conflicts: 1, Held: 1},
+		},
+		{
+			name:  "опоздавшая точка после закрытия окна",
+			steps: []step{{t: 0}, {t: 2}, {t: 3.5}, {t: 1}},
+			want:  []out{{t: 0}, {t: 2}, {t: 1, late: true}},
+			stats: Stats{Received: 4, Late: 1, Held: 1},
+		},
+		{
+			name: "выпуск по MaxHold",
+			steps: []step{
+				{t: 0}, {t: 0.5, at: 100 * time.Millisecond},
+				{at: 150 * time.Millisecond, flush: true},
+				// Ждет дольше MaxHold только первая точка
+				{at: 250 * time.Millisecond, flush: true},
+				{at: 350 * time.Millisecond, flush: true},
+			},
+			want:  []out{{t: 0}, {t: 0.5}},
+			stats: Stats{Received: 2},
+		},
+		{
+			name: "сброс шкалы времени",
+			steps: []step{
+				{t: 10}, {t: 12}, {t: 13.5},
+				// Назад меньше порога - опоздавшая точка, больше - новая шкала
+				{t: 9},
+				{t: 0}, {t: 0.5},
+			},
+			drain: true,
+			want: []out{
+				{t: 10}, {t: 12}, {t: 9, late: true},
+				{t: 13.5}, {t: 0, reset: true}, {t: 0.5},
+			},
+			stats: Stats{Received: 6, Late: 1, Resets: 1},
+		},
+		{
+			name: "после сброса прежние точки не считаются дублями",
+			steps: []step{
+				{t: 10, v: 140}, {t: 11.5, v: 140},
+				{t: 1, v: 140}, {t: 10, v: 140},
+			},
+			drain: true,
+			want:  []out{{t: 10}, {t: 11.5}, {t: 1, reset: true}, {t: 10}},
+			stats: Stats{Received: 4, Resets: 1},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			buffer := NewBuffer(cfg)
+			start := time.Now()
+
+			var got []out
+			collect := func(samples []Sample) {
+				for _, sample := range samples {
+					got = append(got, out{t: sample.Data.TimeSec, late: sample.Late, reset: sample.Reset})
+				}
+			}
+			for _, s := range c.steps {
+				now := start.Add(s.at)
+				if s.flush {
+					collect(buffer.Flush(now))
+					continue
+				}
+				ready, _ := buffer.Push(&models.MedicalData{TimeSec: s.t, Value: s.v}, now)
+				collect(ready)
+			}
+			if c.drain {
+				collect(buffer.Drain())
+			}
+
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("выпущены %v, ожидались %v", got, c.want)
+			}
+			if stats := buffer.Stats(); stats != c.stats {
+				t.Errorf("счетчики %+v, ожидались %+v", stats, c.stats)
+			}
+		})
+	}
+}
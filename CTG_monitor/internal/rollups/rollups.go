@@ -0,0 +1,149 @@
+// internal/rollups/rollups.go
+package rollups
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"CTG_monitor/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Resolutions длительности интервалов сводок, с, по возрастанию
+var Resolutions = []int{1, 10, 60}
+
+// Raw разрешение выборки исходных точек без сводок
+const Raw = 0
+
+// writeBatch строк сводок в одном запросе (ограничение числа параметров Postgres)
+const writeBatch = 1000
+
+// Span интервал на шкале сессии, на котором пересчитываются сводки после записи
+// точек: от начала до конца самых крупных интервалов, в которые попали точки
+func Span(series map[string][]models.CTGPoint) (float64, float64) {
+	from, to := math.Inf(1), math.Inf(-1)
+	for _, points := range series {
+		for _, point := range points {
+			from = math.Min(from, point.T)
+			to = math.Max(to, point.T)
+		}
+	}
+	if from > to {
+		return 0, 0
+	}
+	largest := float64(Resolutions[len(Resolutions)-1])
+	return math.Floor(from/largest) * largest, (math.Floor(to/largest) + 1) * largest
+}
+
+// Build сводки канала по всем разрешениям для интервалов внутри [from, to).
+// points - все точки канала на этом интервале, упорядоченные по времени и без повторов.
+func Build(sessionID uuid.UUID, channel string, points []models.CTGPoint, from, to float64) []models.CTGRollup {
+	now := time.Now().UTC()
+	var rows []models.CTGRollup
+	for _, resolution := range Resolutions {
+		var current *models.CTGRollup
+		var sum float64
+		for _, point := range points {
+			if point.T < from || point.T >= to {
+				continue
+			}
+			bucket := int64(math.Floor(point.T / float64(resolution)))
+			if current == nil || current.Bucket != bucket {
+				if current != nil {
+					rows = append(rows, finish(*current, sum))
+				}
+				current = &models.CTGRollup{
+					SessionID:  sessionID,
+					Channel:    channel,
+					Resolution: resolution,
+					Bucket:     bucket,
+					Min:        math.Inf(1),
+					Max:        math.Inf(-1),
+					UpdatedAt:  now,
+				}
+				sum = 0
+			}
+
+			current.Count++
+			if point.V < 0 || math.IsNaN(point.V) {
+				continue
+			}
+			current.Valid++
+			current.Min = math.Min(current.Min, point.V)
+			current.Max = math.Max(current.Max, point.V)
+			sum += point.V
+		}
+		if current != nil {
+			rows = append(rows, finish(*current, sum))
+		}
+	}
+	return rows
+}
+
+// finish вычисляет среднее; интервал без сигнала получает -1
+func finish(rollup models.CTGRollup, sum float64) models.CTGRollup {
+	if rollup.Valid == 0 {
+		rollup.Min, rollup.Max, rollup.Mean = -1, -1, -1
+		return rollup
+	}
+	rollup.Mean = math.Round(sum/float64(rollup.Valid)*100) / 100
+	return rollup
+}
+
+// Write записывает сводки, заменяя уже записанные для тех же интервалов
+func Write(db *gorm.DB, rows []models.CTGRollup) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "session_id"}, {Name: "channel"}, {Name: "resolution"}, {Name: "bucket"}},
+		DoUpdates: clause.AssignmentColumns([]string{"min", "max", "mean", "valid", "count", "updated_at"}),
+	}).CreateInBatches(&rows, writeBatch).Error
+}
+
+// Pick разрешение для интервала длительностью span секунд на width пикселей:
+// самые крупные интервалы сводок, которых на пиксель приходится не меньше одного.
+// Если пиксель короче самого мелкого интервала, нужны исходные точки (Raw).
+func Pick(span float64, width int) int {
+	if width <= 0 {
+		return Raw
+	}
+	perPixel := span / float64(width)
+	resolution := Raw
+	for _, candidate := range Resolutions {
+		if float64(candidate) <= perPixel {
+			resolution = candidate
+		}
+	}
+	return resolution
+}
+
+// Load читает сводки каналов names сессии (пусто - всех каналов) разрешения
+// resolution, пересекающие интервал [from, to] времени сессии
+func Load(db *gorm.DB, sessionID uuid.UUID, names []string, resolution int, from, to float64) (map[string][]models.CTGRollup, error) {
+	first, last := bucketRange(resolution, from, to)
+	query := db.Where("session_id = ? AND resolution = ?", sessionID, resolution).
+		Where("bucket >= ? AND bucket <= ?", first, last)
+	if len(names) > 0 {
+		query = query.Where("channel IN ?", names)
+	}
+
+	var rows []models.CTGRollup
+	if err := query.Order("channel, bucket").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("не удалось прочитать сводки сессии %s: %w", sessionID, err)
+	}
+	series := make(map[string][]models.CTGRollup)
+	for _, row := range rows {
+		series[row.Channel] = append(series[row.Channel], row)
+	}
+	return series, nil
+}
+
+// bucketRange номера первого и последнего интервалов разрешения resolution,
+// пересекающих [from, to]
+func bucketRange(resolution int, from, to float64) (int64, int64) {
+	return int64(math.Floor(from / float64(resolution))), int64(math.Floor(to / float64(resolution)))
+}
@@ -0,0 +1,163 @@
+package rollups
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"CTG_monitor/internal/channels"
+	"CTG_monitor/internal/models"
+	"github.com/google/uuid"
+)
+
+func TestSpan(t *testing.T) {
+	cases := []struct {
+		name     string
+		series   map[string][]models.CTGPoint
+		from, to float64
+	}{
+		{"нет точек", nil, 0, 0},
+		{"пустые каналы", map[string][]models.CTGPoint{channels.FetalHeartRate: {}}, 0, 0},
+		{"точки внутри минуты", map[string][]models.CTGPoint{channels.FetalHeartRate: {{T: 5}, {T: 50}}}, 0, 60},
+		{"точка на границе минуты", map[string][]models.CTGPoint{channels.FetalHeartRate: {{T: 60}}}, 60, 120},
+		{"по всем каналам", map[string][]models.CTGPoint{
+			channels.FetalHeartRate:      {{T: 130}},
+			channels.UterineContractions: {{T: 59.9}, {T: 61}},
+		}, 0, 180},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if from, to := Span(c.series); from != c.from || to != c.to {
+				t.Errorf("интервал [%.1f, %.1f), ожидался [%.1f, %.1f)", from, to, c.from, c.to)
+			}
+		})
+	}
+}
+
+func TestBuild(t *testing.T) {
+	sessionID := uuid.New()
+	// row сводка интервала; Min, Max и Mean -1 - интервал без сигнала
+	row := func(resolution int, bucket int64, min, max, mean float64, valid, count int) models.CTGRollup {
+		return models.CTGRollup{SessionID: sessionID, Channel: channels.FetalHeartRate, Resolution: resolution,
+			Bucket: bucket, Min: min, Max: max, Mean: mean, Valid: valid, Count: count}
+	}
+
+	cases := []struct {
+		name     string
+		points   []models.CTGPoint
+		from, to float64
+		want     []models.CTGRollup
+	}{
+		{
+			name:   "среднее с округлением до сотых",
+			points: []models.CTGPoint{{T: 0, V: 140}, {T: 0.25, V: 141}, {T: 0.5, V: 141}},
+			to:     60,
+			want: []models.CTGRollup{
+				row(1, 0, 140, 141, 140.67, 3, 3),
+				row(10, 0, 140, 141, 140.67, 3, 3),
+				row(60, 0, 140, 141, 140.67, 3, 3),
+			},
+		},
+		{
+			name:   "интервал без сигнала",
+			points: []models.CTGPoint{{T: 0, V: 140}, {T: 1, V: -1}, {T: 1.5, V: math.NaN()}},
+			to:     60,
+			want: []models.CTGRollup{
+				row(1, 0, 140, 140, 140, 1, 1),
+				row(1, 1, -1, -1, -1, 0, 2),
+				row(10, 0, 140, 140, 140, 1, 3),
+				row(60, 0, 140, 140, 140, 1, 3),
+			},
+		},
+		{
+			name:   "точки вне [from, to) не учитываются",
+			points: []models.CTGPoint{{T: 9.75, V: 100}, {T: 10, V: 140}, {T: 19.75, V: 150}, {T: 20, V: 200}},
+			from:   10,
+			to:     20,
+			want: []models.CTGRollup{
+				row(1, 10, 140, 140, 140, 1, 1),
+				row(1, 19, 150, 150, 150, 1, 1),
+				row(10, 1, 140, 150, 145, 2, 2),
+				row(60, 0, 140, 150, 145, 2, 2),
+			},
+		},
+		{
+			name:   "интервалы по разрешениям",
+			points: []models.CTGPoint{{T: 0, V: 100}, {T: 59, V: 120}, {T: 60, V: 140}},
+			to:     120,
+			want: []models.CTGRollup{
+				row(1, 0, 100, 100, 100, 1, 1),
+				row(1, 59, 120, 120, 120, 1, 1),
+				row(1, 60, 140, 140, 140, 1, 1),
+				row(10, 0, 100, 100, 100, 1, 1),
+				row(10, 5, 120, 120, 120, 1, 1),
+				row(10, 6, 140, 140, 140, 1, 1),
+				row(60, 0, 100, 120, 110, 2, 2),
+				row(60, 1, 140, 140, 140, 1, 1),
+			},
+		},
+		{name: "нет точек", to: 60},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rows := Build(sessionID, channels.FetalHeartRate, c.points, c.from, c.to)
+			for i := range rows {
+				if time.Since(rows[i].UpdatedAt) > time.Minute {
+					t.Errorf("время обновления %v", rows[i].UpdatedAt)
+				}
+				rows[i].UpdatedAt = time.Time{}
+			}
+			if !reflect.DeepEqual(rows, c.want) {
+				t.Errorf("сводки %+v, ожидались %+v", rows, c.want)
+			}
+		})
+	}
+}
+
+func TestPick(t *testing.T) {
+	cases := []struct {
+		name  string
+		span  float64
+		width int
+		want  int
+	}{
+		{"пиксель короче секунды", 100, 200, Raw},
+		{"секунда на пиксель", 100, 100, 1},
+		{"меньше 10 с на пиксель", 999, 100, 1},
+		{"10 с на пиксель", 1000, 100, 10},
+		{"минута на пиксель", 6000, 100, 60},
+		{"больше минуты на пиксель", 1e6, 100, 60},
+		{"нулевая ширина", 1000, 0, Raw},
+		{"отрицательная ширина", 1000, -1, Raw},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := Pick(c.span, c.width); got != c.want {
+				t.Errorf("разрешение %d, ожидалось %d", got, c.want)
+			}
+		})
+	}
+}
+
+func TestBucketRange(t *testing.T) {
+	cases := []struct {
+		name        string
+		resolution  int
+		from, to    float64
+		first, last int64
+	}{
+		{"внутри интервалов", 10, 15, 35, 1, 3},
+		{"на границах интервалов", 10, 20, 40, 2, 4},
+		{"один интервал", 60, 0, 59.9, 0, 0},
+		{"секундные интервалы", 1, 0.5, 2.5, 0, 2},
+		{"отрицательное время", 10, -5, 5, -1, 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if first, last := bucketRange(c.resolution, c.from, c.to); first != c.first || last != c.last {
+				t.Errorf("интервалы %d..%d, ожидались %d..%d", first, last, c.first, c.last)
+			}
+		})
+	}
+}
@@ -0,0 +1,479 @@
+// internal/spool/spool.go
+package spool
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Spool журнал предзаписи входящих MQTT сообщений.
+// Каждое сообщение сначала записывается на диск и получает порядковый номер,
+// а удаляется из журнала только после подтверждения записи в БД (Ack).
+// После перезапуска неподтвержденные сообщения возвращаются через Pending.
+type Spool struct {
+	dir          string
+	segmentLimit int64
+	syncWrites   bool
+
+	active     *os.File
+	activeBase uint64
+	activeSize int64
+	acksFile   *os.File
+
+	nextSeq   uint64
+	segments  []uint64          // первые номера сегментов по возрастанию
+	pending   map[uint64]uint64 // неподтвержденный seq -> сегмент
+	perSeg    map[uint64]int    // сегмент -> количество неподтвержденных записей
+	recovered []Record
+
+	mu sync.Mutex
+}
+
+// Record одно сообщение журнала
+type Record struct {
+	Seq     uint64
+	Topic   string
+	Payload []byte
+}
+
+// Config параметры журнала
+type Config struct {
+	Dir          string
+	SegmentBytes int64 // размер сегмента, после которого начинается новый файл
+	SyncWrites   bool  // fsync после каждой записи
+}
+
+const (
+	segmentPrefix = "segment-"
+	segmentSuffix = ".log"
+	acksFileName  = "acks.log"
+
+	// Заголовок записи: длина тела, CRC32 тела
+	recordHeaderSize = 8
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// Open открывает журнал и восстанавливает неподтвержденные записи
+func Open(cfg Config) (*Spool, error) {
+	if cfg.SegmentBytes <= 0 {
+		cfg.SegmentBytes = 16 << 20
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o750); err != nil {
+		return nil, fmt.Errorf("не удалось создать каталог спула: %w", err)
+	}
+
+	s := &Spool{
+		dir:          cfg.Dir,
+		segmentLimit: cfg.SegmentBytes,
+		syncWrites:   cfg.SyncWrites,
+		nextSeq:      1,
+		pending:      make(map[uint64]uint64),
+		perSeg:       make(map[uint64]int),
+	}
+
+	if err := s.recover(); err != nil {
+		return nil, err
+	}
+
+	acksFile, err := os.OpenFile(filepath.Join(s.dir, acksFileName), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть журнал подтверждений: %w", err)
+	}
+	s.acksFile = acksFile
+
+	if err := s.rotate(); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Спул открыт: %s, неподтвержденных записей: %d", s.dir, len(s.recovered))
+	return s, nil
+}
+
+// recover читает сегменты и подтверждения, оставшиеся с прошлого запуска
+func (s *Spool) recover() error {
+	acked, err := s.readAcks()
+	if err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return fmt.Errorf("не удалось прочитать каталог спула: %w", err)
+	}
+
+	var bases []uint64
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, segmentPrefix) || !strings.HasSuffix(name, segmentSuffix) {
+			continue
+		}
+		var base uint64
+		if _, err := fmt.Sscanf(strings.TrimSuffix(strings.TrimPrefix(name, segmentPrefix), segmentSuffix), "%d", &base); err != nil {
+			continue
+		}
+		bases = append(bases, base)
+	}
+	sort.Slice(bases, func(i, j int) bool { return bases[i] < bases[j] })
+
+	for _, base := range bases {
+		records, err := readSegment(s.segmentPath(base))
+		if err != nil {
+			return err
+		}
+
+		unacked := 0
+		for _, record := range records {
+			if record.Seq >= s.nextSeq {
+				s.nextSeq = record.Seq + 1
+			}
+			if acked[record.Seq] {
+				continue
+			}
+			s.pending[record.Seq] = base
+			s.recovered = append(s.recovered, record)
+			unacked++
+		}
+
+		if unacked == 0 {
+			if err := os.Remove(s.segmentPath(base)); err != nil {
+				log.Printf("Не удалось удалить подтвержденный сегмент %d: %v", base, err)
+			}
+			continue
+		}
+
+		s.segments = append(s.segments, base)
+		s.perSeg[base] = unacked
+	}
+
+	// Подтверждения удаленных сегментов больше не нужны
+	return s.compactAcks()
+}
+
+// readAcks читает номера подтвержденных записей
+func (s *Spool) readAcks() (map[uint64]bool, error) {
+	acked := make(map[uint64]bool)
+
+	data, err := os.ReadFile(filepath.Join(s.dir, acksFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return acked, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать журнал подтверждений: %w", err)
+	}
+
+	// Незавершенная последняя запись (обрыв при записи) отбрасывается
+	for i := 0; i+8 <= len(data); i += 8 {
+		acked[binary.LittleEndian.Uint64(data[i:i+8])] = true
+	}
+	return acked, nil
+}
+
+// readSegment читает записи сегмента до первой поврежденной
+func readSegment(path string) ([]Record, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть сегмент спула: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	var records []Record
+	header := make([]byte, recordHeaderSize)
+
+	for {
+		if _, err := io.ReadFull(reader, header); err != nil {
+			break
+		}
+		length := binary.LittleEndian.Uint32(header[0:4])
+		checksum := binary.LittleEndian.Uint32(header[4:8])
+
+		body := make([]byte, length)
+		if _, err := io.ReadFull(reader, body); err != nil {
+			log.Printf("Сегмент %s обрезан, последняя запись пропущена", filepath.Base(path))
+			break
+		}
+		if crc32.Checksum(body, crcTable) != checksum {
+			log.Printf("Поврежденная запись в сегменте %s, чтение остановлено", filepath.Base(path))
+			break
+		}
+
+		record, err := decodeRecord(body)
+		if err != nil {
+			log.Printf("Не удалось разобрать запись сегмента %s: %v", filepath.Base(path), err)
+			break
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// Append записывает сообщение в журнал и возвращает его номер
+func (s *Spool) Append(topic string, payload []byte) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.active == nil {
+		return 0, errors.New("спул закрыт")
+	}
+
+	if s.activeSize >= s.segmentLimit {
+		if err := s.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	seq := s.nextSeq
+	body := encodeRecord(Record{Seq: seq, Topic: topic, Payload: payload})
+
+	buf := make([]byte, recordHeaderSize+len(body))
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(len(body)))
+	binary.LittleEndian.PutUint32(buf[4:8], crc32.Checksum(body, crcTable))
+	copy(buf[recordHeaderSize:], body)
+
+	if _, err := s.active.Write(buf); err != nil {
+		return 0, fmt.Errorf("ошибка записи в спул: %w", err)
+	}
+	if s.syncWrites {
+		if err := s.active.Sync(); err != nil {
+			return 0, fmt.Errorf("ошибка fsync спула: %w", err)
+		}
+	}
+
+	s.nextSeq++
+	s.activeSize += int64(len(buf))
+	s.pending[seq] = s.activeBase
+	s.perSeg[s.activeBase]++
+
+	return seq, nil
+}
+
+// Ack подтверждает, что записи сохранены в БД и больше не нужны
+func (s *Spool) Ack(seqs ...uint64) error {
+	if len(seqs) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.acksFile == nil {
+		return errors.New("спул закрыт")
+	}
+
+	buf := make([]byte, 0, len(seqs)*8)
+	var released []uint64
+	for _, seq := range seqs {
+		base, ok := s.pending[seq]
+		if !ok {
+			continue
+		}
+		delete(s.pending, seq)
+		buf = binary.LittleEndian.AppendUint64(buf, seq)
+
+		s.perSeg[base]--
+		if s.perSeg[base] == 0 && base != s.activeBase {
+			released = append(released, base)
+		}
+	}
+
+	if len(buf) > 0 {
+		if _, err := s.acksFile.Write(buf); err != nil {
+			return fmt.Errorf("ошибка записи подтверждений: %w", err)
+		}
+		if s.syncWrites {
+			if err := s.acksFile.Sync(); err != nil {
+				return fmt.Errorf("ошибка fsync подтверждений: %w", err)
+			}
+		}
+	}
+
+	for _, base := range released {
+		s.removeSegment(base)
+	}
+	return nil
+}
+
+// Pending возвращает записи, не подтвержденные до перезапуска.
+// Повторный вызов возвращает пустой список.
+func (s *Spool) Pending() []Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records := s.recovered
+	s.recovered = nil
+	return records
+}
+
+// PendingCount возвращает количество неподтвержденных записей
+func (s *Spool) PendingCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.pending)
+}
+
+// Close закрывает файлы журнала; неподтвержденные записи остаются на диске
+func (s *Spool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var firstErr error
+	if s.active != nil {
+		if err := s.active.Close(); err != nil {
+			firstErr = err
+		}
+		s.active = nil
+	}
+	if s.acksFile != nil {
+		if err := s.acksFile.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		s.acksFile = nil
+	}
+
+	log.Printf("Спул закрыт, неподтвержденных записей: %d", len(s.pending))
+	return firstErr
+}
+
+// rotate начинает новый сегмент
+func (s *Spool) rotate() error {
+	if s.active != nil {
+		if err := s.active.Close(); err != nil {
+			log.Printf("Ошибка закрытия сегмента %d: %v", s.activeBase, err)
+		}
+		// Полностью подтвержденный сегмент можно удалить сразу
+		if s.perSeg[s.activeBase] == 0 {
+			s.removeSegment(s.activeBase)
+		}
+	}
+
+	base := s.nextSeq
+	file, err := os.OpenFile(s.segmentPath(base), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return fmt.Errorf("не удалось создать сегмент спула: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("не удалось получить размер сегмента: %w", err)
+	}
+
+	s.active = file
+	s.activeBase = base
+	s.activeSize = info.Size()
+	if _, exists := s.perSeg[base]; !exists {
+		s.segments = append(s.segments, base)
+		s.perSeg[base] = 0
+	}
+	return nil
+}
+
+// removeSegment удаляет полностью подтвержденный сегмент
+func (s *Spool) removeSegment(base uint64) {
+	if err := os.Remove(s.segmentPath(base)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("Не удалось удалить сегмент спула %d: %v", base, err)
+		return
+	}
+	delete(s.perSeg, base)
+	for i, b := range s.segments {
+		if b == base {
+			s.segments = append(s.segments[:i], s.segments[i+1:]...)
+			break
+		}
+	}
+
+	// Подтверждения старше самого раннего сегмента больше не нужны
+	if len(s.segments) > 0 && s.segments[0] > base {
+		if err := s.compactAcks(); err != nil {
+			log.Printf("Не удалось сжать журнал подтверждений: %v", err)
+		}
+	}
+}
+
+// compactAcks переписывает журнал подтверждений, оставляя только записи живых сегментов
+func (s *Spool) compactAcks() error {
+	path := filepath.Join(s.dir, acksFileName)
+
+	acked, err := s.readAcks()
+	if err != nil {
+		return err
+	}
+
+	var oldest uint64
+	if len(s.segments) > 0 {
+		oldest = s.segments[0]
+	} else {
+		oldest = s.nextSeq
+	}
+
+	buf := make([]byte, 0)
+	for seq := range acked {
+		if seq >= oldest {
+			buf = binary.LittleEndian.AppendUint64(buf, seq)
+		}
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, buf, 0o640); err != nil {
+		return fmt.Errorf("не удалось записать журнал подтверждений: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("не удалось заменить журнал подтверждений: %w", err)
+	}
+
+	// Файл подтверждений заменен, открываем его заново
+	if s.acksFile != nil {
+		s.acksFile.Close()
+		acksFile, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+		if err != nil {
+			s.acksFile = nil
+			return fmt.Errorf("не удалось открыть журнал подтверждений: %w", err)
+		}
+		s.acksFile = acksFile
+	}
+	return nil
+}
+
+// segmentPath путь к файлу сегмента
+func (s *Spool) segmentPath(base uint64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%s%020d%s", segmentPrefix, base, segmentSuffix))
+}
+
+// encodeRecord тело записи: seq, длина топика, топик, payload
+func encodeRecord(record Record) []byte {
+	body := make([]byte, 0, 10+len(record.Topic)+len(record.Payload))
+	body = binary.LittleEndian.AppendUint64(body, record.Seq)
+	body = binary.LittleEndian.AppendUint16(body, uint16(len(record.Topic)))
+	body = append(body, record.Topic...)
+	body = append(body, record.Payload...)
+	return body
+}
+
+// decodeRecord разбирает тело записи
+func decodeRecord(body []byte) (Record, error) {
+	if len(body) < 10 {
+		return Record{}, errors.New("запись слишком короткая")
+	}
+	seq := binary.LittleEndian.Uint64(body[0:8])
+	topicLen := int(binary.LittleEndian.Uint16(body[8:10]))
+	if len(body) < 10+topicLen {
+		return Record{}, errors.New("длина топика превышает размер записи")
+	}
+	return Record{
+		Seq:     seq,
+		Topic:   string(body[10 : 10+topicLen]),
+		Payload: append([]byte(nil), body[10+topicLen:]...),
+	}, nil
+}
@@ -0,0 +1,222 @@
+package spool
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testTopic = "medical/ctg/fetal_heart_rate/CTG-001"
+
+// openSpool открывает спул в каталоге dir
+func openSpool(t *testing.T, dir string, segmentBytes int64) *Spool {
+	t.Helper()
+	s, err := Open(Config{Dir: dir, SegmentBytes: segmentBytes})
+	if err != nil {
+		t.Fatalf("не удалось открыть спул: %v", err)
+	}
+	return s
+}
+
+// appendRecords дописывает count записей с payload {"value":i}
+func appendRecords(t *testing.T, s *Spool, count int) []uint64 {
+	t.Helper()
+	var seqs []uint64
+	for i := 0; i < count; i++ {
+		seq, err := s.Append(testTopic, []byte(fmt.Sprintf(`{"value":%d}`, i)))
+		if err != nil {
+			t.Fatalf("ошибка записи в спул: %v", err)
+		}
+		seqs = append(seqs, seq)
+	}
+	return seqs
+}
+
+// recordSeqs номера записей
+func recordSeqs(records []Record) []uint64 {
+	var seqs []uint64
+	for _, record := range records {
+		seqs = append(seqs, record.Seq)
+	}
+	return seqs
+}
+
+// segmentFiles файлы сегментов в каталоге спула
+func segmentFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, segmentPrefix+"*"+segmentSuffix))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestReplayAfterReopen(t *testing.T) {
+	dir := t.TempDir()
+	s := openSpool(t, dir, 0)
+	seqs := appendRecords(t, s, 5)
+	if err := s.Ack(seqs[1], seqs[3]); err != nil {
+		t.Fatalf("ошибка подтверждения: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s = openSpool(t, dir, 0)
+	defer s.Close()
+
+	pending := s.Pending()
+	if want := []uint64{seqs[0], seqs[2], seqs[4]}; !reflect.DeepEqual(recordSeqs(pending), want) {
+		t.Fatalf("после перезапуска возвращены записи %v, ожидались %v", recordSeqs(pending), want)
+	}
+	for _, record := range pending {
+		want := fmt.Sprintf(`{"value":%d}`, record.Seq-1)
+		if record.Topic != testTopic || string(record.Payload) != want {
+			t.Errorf("запись %d: %s %s", record.Seq, record.Topic, record.Payload)
+		}
+	}
+	if again := s.Pending(); len(again) != 0 {
+		t.Errorf("повторный Pending вернул %d записей", len(again))
+	}
+	if count := s.PendingCount(); count != 3 {
+		t.Errorf("неподтвержденных записей %d, ожидалось 3", count)
+	}
+
+	// Нумерация продолжается с последней записи
+	if next := appendRecords(t, s, 1); next[0] != seqs[4]+1 {
+		t.Errorf("новая запись получила номер %d, ожидался %d", next[0], seqs[4]+1)
+	}
+}
+
+func TestAckPersistence(t *testing.T) {
+	dir := t.TempDir()
+	s := openSpool(t, dir, 0)
+	seqs := appendRecords(t, s, 3)
+	s.Close()
+
+	// Подтверждение после перезапуска сохраняется до следующего
+	s = openSpool(t, dir, 0)
+	if err := s.Ack(seqs[0], seqs[2]); err != nil {
+		t.Fatalf("ошибка подтверждения: %v", err)
+	}
+	// Повторное и неизвестное подтверждения игнорируются
+	if err := s.Ack(seqs[0], 100); err != nil {
+		t.Fatalf("ошибка повторного подтверждения: %v", err)
+	}
+	s.Close()
+
+	s = openSpool(t, dir, 0)
+	defer s.Close()
+	if got := recordSeqs(s.Pending()); !reflect.DeepEqual(got, []uint64{seqs[1]}) {
+		t.Fatalf("после перезапуска возвращены записи %v, ожидалась %d", got, seqs[1])
+	}
+}
+
+func TestDamagedTail(t *testing.T) {
+	cases := []struct {
+		name   string
+		damage func(t *testing.T, dir string)
+	}{
+		{"оборванная последняя запись", func(t *testing.T, dir string) {
+			path := segmentFiles(t, dir)[0]
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Truncate(path, info.Size()-3); err != nil {
+				t.Fatal(err)
+			}
+		}},
+		{"несовпадение CRC", func(t *testing.T, dir string) {
+			path := segmentFiles(t, dir)[0]
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data[len(data)-1] ^= 0xff
+			if err := os.WriteFile(path, data, 0o640); err != nil {
+				t.Fatal(err)
+			}
+		}},
+		{"оборванное подтверждение", func(t *testing.T, dir string) {
+			// Последняя запись повреждена, а от ее подтверждения записана половина
+			path := segmentFiles(t, dir)[0]
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Truncate(path, info.Size()-1); err != nil {
+				t.Fatal(err)
+			}
+			acks, err := os.OpenFile(filepath.Join(dir, acksFileName), os.O_WRONLY|os.O_APPEND, 0o640)
+			if err != nil {
+				t.Fatal(err)
+			}
+			acks.Write([]byte{4, 0, 0, 0})
+			acks.Close()
+		}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dir := t.TempDir()
+			s := openSpool(t, dir, 0)
+			seqs := appendRecords(t, s, 4)
+			s.Close()
+
+			c.damage(t, dir)
+
+			s = openSpool(t, dir, 0)
+			defer s.Close()
+			if got := recordSeqs(s.Pending()); !reflect.DeepEqual(got, seqs[:3]) {
+				t.Fatalf("возвращены записи %v, ожидались целые %v", got, seqs[:3])
+			}
+		})
+	}
+}
+
+func TestSegmentRemoval(t *testing.T) {
+	dir := t.TempDir()
+
+	// Сегмент меньше записи: каждая запись начинает новый сегмент
+	s := openSpool(t, dir, 32)
+	seqs := appendRecords(t, s, 4)
+	if files := segmentFiles(t, dir); len(files) != 4 {
+		t.Fatalf("сегментов %d, ожидалось 4", len(files))
+	}
+
+	// Сегмент удаляется, как только подтверждены все его записи; текущий остается
+	if err := s.Ack(seqs[0], seqs[1]); err != nil {
+		t.Fatal(err)
+	}
+	if files := segmentFiles(t, dir); len(files) != 2 {
+		t.Fatalf("после подтверждения двух записей осталось сегментов %d, ожидалось 2", len(files))
+	}
+	if err := s.Ack(seqs[2], seqs[3]); err != nil {
+		t.Fatal(err)
+	}
+	if files := segmentFiles(t, dir); len(files) != 1 || filepath.Base(files[0]) != filepath.Base(s.segmentPath(seqs[3])) {
+		t.Fatalf("после подтверждения всех записей остались сегменты %v", files)
+	}
+	s.Close()
+
+	// После перезапуска подтвержденный сегмент удаляется, журнал подтверждений сжимается
+	s = openSpool(t, dir, 32)
+	defer s.Close()
+	if pending := s.Pending(); len(pending) != 0 {
+		t.Fatalf("возвращены подтвержденные записи %v", recordSeqs(pending))
+	}
+	files := segmentFiles(t, dir)
+	if len(files) != 1 || filepath.Base(files[0]) != filepath.Base(s.segmentPath(seqs[3]+1)) {
+		t.Fatalf("после перезапуска остались сегменты %v", files)
+	}
+	info, err := os.Stat(filepath.Join(dir, acksFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != 0 {
+		t.Errorf("журнал подтверждений не сжат: %d байт", info.Size())
+	}
+}
@@ -0,0 +1,74 @@
+// internal/summary/summary.go
+package summary
+
+import (
+	"math"
+
+	"CTG_monitor/internal/channels"
+	"CTG_monitor/internal/models"
+	"ctg_common/decel"
+)
+
+// Tally накапливает итоги сессии по событиям анализа и тревогам. Не потокобезопасен.
+type Tally struct {
+	baseline        float64
+	variability     float64
+	variabilityBand string
+	accelerations   int
+	decelerations   decel.Counts
+	contractions    int
+	alarms          int
+}
+
+// Observe учитывает событие анализа. Базальный ритм, вариабельность, акцелерации
+// и децелерации берутся по ЧСС первого плода.
+func (t *Tally) Observe(event models.CTGEvent) {
+	if event.Type == models.EventContraction {
+		t.contractions++
+		return
+	}
+	if event.Channel != channels.FetalHeartRate {
+		return
+	}
+
+	switch event.Type {
+	case models.EventBaseline:
+		t.baseline = event.Value
+	case models.EventVariability:
+		t.variability = event.Value
+		t.variabilityBand = event.Band
+	case models.EventAcceleration:
+		t.accelerations++
+	case models.EventDeceleration:
+		t.decelerations.Add(event.Kind)
+	}
+}
+
+// CountAlarm учитывает поднятую тревогу
+func (t *Tally) CountAlarm() {
+	t.alarms++
+}
+
+// Result формирует итоги сессии длительностью duration (с) со сводкой качества сигнала
+func (t *Tally) Result(duration float64, signalQuality map[string]models.ChannelQuality) models.SessionSummary {
+	result := models.SessionSummary{
+		Duration:        math.Round(duration),
+		Baseline:        t.baseline,
+		Variability:     t.variability,
+		VariabilityBand: t.variabilityBand,
+		Accelerations:   t.accelerations,
+		Decelerations:   t.decelerations,
+		Contractions:    t.contractions,
+		Alarms:          t.alarms,
+	}
+	if duration > 0 {
+		result.ContractionFrequency = math.Round(float64(t.contractions)*decel.FrequencyWindow/duration*10) / 10
+	}
+	if fhr, ok := signalQuality[channels.FetalHeartRate]; ok {
+		result.SignalQuality = fhr.ValidPercent
+	}
+	for _, channel := range signalQuality {
+		result.SpikesCorrected += channel.Spikes
+	}
+	return result
+}
@@ -0,0 +1,116 @@
+package summary
+
+import (
+	"reflect"
+	"testing"
+
+	"CTG_monitor/internal/channels"
+	"CTG_monitor/internal/models"
+	"ctg_common/decel"
+)
+
+func TestTally(t *testing.T) {
+	fhr := func(event models.CTGEvent) models.CTGEvent {
+		event.Channel = channels.FetalHeartRate
+		return event
+	}
+
+	cases := []struct {
+		name     string
+		events   []models.CTGEvent
+		alarms   int
+		duration float64
+		quality  map[string]models.ChannelQuality
+		want     models.SessionSummary
+	}{
+		{
+			name:     "пустая сессия",
+			duration: 0,
+			want:     models.SessionSummary{},
+		},
+		{
+			name: "последние оценки ЧСС",
+			events: []models.CTGEvent{
+				fhr(models.CTGEvent{Type: models.EventBaseline, Value: 140}),
+				fhr(models.CTGEvent{Type: models.EventVariability, Value: 3.5, Band: "reduced"}),
+				fhr(models.CTGEvent{Type: models.EventBaseline, Value: 145}),
+				fhr(models.CTGEvent{Type: models.EventVariability, Value: 8.2, Band: "normal"}),
+			},
+			duration: 1200.4,
+			want:     models.SessionSummary{Duration: 1200, Baseline: 145, Variability: 8.2, VariabilityBand: "normal"},
+		},
+		{
+			name: "эпизоды по ЧСС первого плода",
+			events: []models.CTGEvent{
+				fhr(models.CTGEvent{Type: models.EventAcceleration}),
+				fhr(models.CTGEvent{Type: models.EventAcceleration}),
+				fhr(models.CTGEvent{Type: models.EventDeceleration, Kind: decel.Late}),
+				fhr(models.CTGEvent{Type: models.EventDeceleration, Kind: decel.Variable}),
+				fhr(models.CTGEvent{Type: models.EventDeceleration, Kind: decel.Late}),
+				{Type: models.EventAcceleration, Channel: channels.FetalHeartRate2},
+				{Type: models.EventDeceleration, Channel: channels.FetalHeartRate2, Kind: decel.Early},
+				{Type: models.EventBaseline, Channel: channels.FetalHeartRate2, Value: 150},
+			},
+			duration: 600,
+			want: models.SessionSummary{Duration: 600, Accelerations: 2,
+				Decelerations: decel.Counts{Late: 2, Variable: 1}},
+		},
+		{
+			name: "частота схваток за 10 минут",
+			events: []models.CTGEvent{
+				{Type: models.EventContraction, Channel: channels.UterineContractions},
+				{Type: models.EventContraction, Channel: channels.UterineContractions},
+				{Type: models.EventContraction, Channel: channels.UterineContractions},
+				{Type: models.EventTachysystole, Channel: channels.UterineContractions, Value: 6},
+			},
+			duration: 1800,
+			want:     models.SessionSummary{Duration: 1800, Contractions: 3, ContractionFrequency: 1},
+		},
+		{
+			name: "частота схваток округляется до десятых",
+			events: []models.CTGEvent{
+				{Type: models.EventContraction, Channel: channels.UterineContractions},
+				{Type: models.EventContraction, Channel: channels.UterineContractions},
+			},
+			duration: 1400,
+			want:     models.SessionSummary{Duration: 1400, Contractions: 2, ContractionFrequency: 0.9},
+		},
+		{
+			name:     "тревоги",
+			alarms:   3,
+			duration: 60,
+			want:     models.SessionSummary{Duration: 60, Alarms: 3},
+		},
+		{
+			name:     "качество сигнала ЧСС и выбросы по всем каналам",
+			duration: 60,
+			quality: map[string]models.ChannelQuality{
+				channels.FetalHeartRate:      {ValidPercent: 92.5, Spikes: 4},
+				channels.UterineContractions: {ValidPercent: 50, Spikes: 2},
+			},
+			want: models.SessionSummary{Duration: 60, SignalQuality: 92.5, SpikesCorrected: 6},
+		},
+		{
+			name:     "без канала ЧСС качество не определено",
+			duration: 60,
+			quality: map[string]models.ChannelQuality{
+				channels.UterineContractions: {ValidPercent: 50, Spikes: 1},
+			},
+			want: models.SessionSummary{Duration: 60, SpikesCorrected: 1},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var tally Tally
+			for _, event := range c.events {
+				tally.Observe(event)
+			}
+			for i := 0; i < c.alarms; i++ {
+				tally.CountAlarm()
+			}
+			if got := tally.Result(c.duration, c.quality); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("итоги %+v, ожидались %+v", got, c.want)
+			}
+		})
+	}
+}
@@ -27,6 +27,7 @@ type StreamRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	DeviceIds     []string               `protobuf:"bytes,1,rep,name=device_ids,json=deviceIds,proto3" json:"device_ids,omitempty"` // Фильтр по устройствам (пустой = все)
 	DataTypes     []string               `protobuf:"bytes,2,rep,name=data_types,json=dataTypes,proto3" json:"data_types,omitempty"` // Фильтр по типам данных (пустой = все)
+	Packed        bool                   `protobuf:"varint,3,opt,name=packed,proto3" json:"packed,omitempty"`                       // StreamBatchCTGData: точки батча в packed вместо data
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -75,12 +76,24 @@ func (x *StreamRequest) GetDataTypes() []string {
 	return nil
 }
 
+func (x *StreamRequest) GetPacked() bool {
+	if x != nil {
+		return x.Packed
+	}
+	return false
+}
+
 type CTGDataResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	DeviceId      string                 `protobuf:"bytes,1,opt,name=device_id,json=deviceId,proto3" json:"device_id,omitempty"`
 	DataType      string                 `protobuf:"bytes,2,opt,name=data_type,json=dataType,proto3" json:"data_type,omitempty"`
-	Value         float64                `protobuf:"fixed64,3,opt,name=value,proto3" json:"value,omitempty"`
+	Value         float64                `protobuf:"fixed64,3,opt,name=value,proto3" json:"value,omitempty"` // Очищенное значение
 	TimeSec       float64                `protobuf:"fixed64,4,opt,name=time_sec,json=timeSec,proto3" json:"time_sec,omitempty"`
+	RawValue      float64                `protobuf:"fixed64,5,opt,name=raw_value,json=rawValue,proto3" json:"raw_value,omitempty"` // Значение, которое прислало устройство
+	Flags         []string               `protobuf:"bytes,6,rep,name=flags,proto3" json:"flags,omitempty"`                         // Причины изменения и пометки: spike, outlier, doppler, out_of_range, signal_loss, late, backfill, same_heart, maternal_hr
+	Unassigned    bool                   `protobuf:"varint,7,opt,name=unassigned,proto3" json:"unassigned,omitempty"`              // Устройство еще не привязано к медкарте
+	Timestamp     int64                  `protobuf:"varint,8,opt,name=timestamp,proto3" json:"timestamp,omitempty"`                // Абсолютное время измерения UTC, мс Unix
+	Fetus         int32                  `protobuf:"varint,9,opt,name=fetus,proto3" json:"fetus,omitempty"`                        // Номер плода для ЧСС плода (1, 2 при двойне), 0 для остальных каналов
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -143,11 +156,48 @@ func (x *CTGDataResponse) GetTimeSec() float64 {
 	return 0
 }
 
+func (x *CTGDataResponse) GetRawValue() float64 {
+	if x != nil {
+		return x.RawValue
+	}
+	return 0
+}
+
+func (x *CTGDataResponse) GetFlags() []string {
+	if x != nil {
+		return x.Flags
+	}
+	return nil
+}
+
+func (x *CTGDataResponse) GetUnassigned() bool {
+	if x != nil {
+		return x.Unassigned
+	}
+	return false
+}
+
+func (x *CTGDataResponse) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *CTGDataResponse) GetFetus() int32 {
+	if x != nil {
+		return x.Fetus
+	}
+	return 0
+}
+
 type CTGBatchResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Data          []*CTGDataResponse     `protobuf:"bytes,1,rep,name=data,proto3" json:"data,omitempty"`
 	Timestamp     int64                  `protobuf:"varint,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
 	Count         int32                  `protobuf:"varint,3,opt,name=count,proto3" json:"count,omitempty"`
+	DeviceId      string                 `protobuf:"bytes,4,opt,name=device_id,json=deviceId,proto3" json:"device_id,omitempty"`
+	Packed        []*PackedSeries        `protobuf:"bytes,5,rep,name=packed,proto3" json:"packed,omitempty"` // Точки по каналам в компактной кодировке, если запрошено packed
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -203,28 +253,1004 @@ func (x *CTGBatchResponse) GetCount() int32 {
 	return 0
 }
 
-var File_ctg_simple_proto protoreflect.FileDescriptor
+func (x *CTGBatchResponse) GetDeviceId() string {
+	if x != nil {
+		return x.DeviceId
+	}
+	return ""
+}
 
-const file_ctg_simple_proto_rawDesc = "" +
-	"\n" +
-	"\x10ctg_simple.proto\x12\x03ctg\"M\n" +
-	"\rStreamRequest\x12\x1d\n" +
-	"\n" +
-	"device_ids\x18\x01 \x03(\tR\tdeviceIds\x12\x1d\n" +
-	"\n" +
-	"data_types\x18\x02 \x03(\tR\tdataTypes\"|\n" +
-	"\x0fCTGDataResponse\x12\x1b\n" +
-	"\tdevice_id\x18\x01 \x01(\tR\bdeviceId\x12\x1b\n" +
-	"\tdata_type\x18\x02 \x01(\tR\bdataType\x12\x14\n" +
-	"\x05value\x18\x03 \x01(\x01R\x05value\x12\x19\n" +
-	"\btime_sec\x18\x04 \x01(\x01R\atimeSec\"p\n" +
-	"\x10CTGBatchResponse\x12(\n" +
-	"\x04data\x18\x01 \x03(\v2\x14.ctg.CTGDataResponseR\x04data\x12\x1c\n" +
-	"\ttimestamp\x18\x02 \x01(\x03R\ttimestamp\x12\x14\n" +
-	"\x05count\x18\x03 \x01(\x05R\x05count2\x92\x01\n" +
-	"\x10CTGStreamService\x12;\n" +
-	"\rStreamCTGData\x12\x12.ctg.StreamRequest\x1a\x14.ctg.CTGDataResponse0\x01\x12A\n" +
-	"\x12StreamBatchCTGData\x12\x12.ctg.StreamRequest\x1a\x15.ctg.CTGBatchResponse0\x01B\x13Z\x11CTG_monitor/protob\x06proto3"
+func (x *CTGBatchResponse) GetPacked() []*PackedSeries {
+	if x != nil {
+		return x.Packed
+	}
+	return nil
+}
+
+// Точки канала в кодировке pkg/codec (delta-of-delta время, XOR значения без потерь).
+// Исходные значения и флаги в компактной кодировке не передаются.
+type PackedSeries struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DataType      string                 `protobuf:"bytes,1,opt,name=data_type,json=dataType,proto3" json:"data_type,omitempty"`
+	Count         int32                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	Samples       []byte                 `protobuf:"bytes,3,opt,name=samples,proto3" json:"samples,omitempty"`       // Блок codec: time_sec (с точностью 1 мс) и value
+	Timestamps    []byte                 `protobuf:"bytes,4,opt,name=timestamps,proto3" json:"timestamps,omitempty"` // Блок codec целых: абсолютное время измерения, мс Unix (0 - нет)
+	Fetus         int32                  `protobuf:"varint,5,opt,name=fetus,proto3" json:"fetus,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PackedSeries) Reset() {
+	*x = PackedSeries{}
+	mi := &file_ctg_simple_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PackedSeries) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PackedSeries) ProtoMessage() {}
+
+func (x *PackedSeries) ProtoReflect() protoreflect.Message {
+	mi := &file_ctg_simple_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PackedSeries.ProtoReflect.Descriptor instead.
+func (*PackedSeries) Descriptor() ([]byte, []int) {
+	return file_ctg_simple_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *PackedSeries) GetDataType() string {
+	if x != nil {
+		return x.DataType
+	}
+	return ""
+}
+
+func (x *PackedSeries) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *PackedSeries) GetSamples() []byte {
+	if x != nil {
+		return x.Samples
+	}
+	return nil
+}
+
+func (x *PackedSeries) GetTimestamps() []byte {
+	if x != nil {
+		return x.Timestamps
+	}
+	return nil
+}
+
+func (x *PackedSeries) GetFetus() int32 {
+	if x != nil {
+		return x.Fetus
+	}
+	return 0
+}
+
+type BindDeviceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DeviceId      string                 `protobuf:"bytes,1,opt,name=device_id,json=deviceId,proto3" json:"device_id,omitempty"`
+	CardId        string                 `protobuf:"bytes,2,opt,name=card_id,json=cardId,proto3" json:"card_id,omitempty"`        // UUID медицинской карты
+	KeepData      bool                   `protobuf:"varint,3,opt,name=keep_data,json=keepData,proto3" json:"keep_data,omitempty"` // Сохранить данные, полученные до привязки
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BindDeviceRequest) Reset() {
+	*x = BindDeviceRequest{}
+	mi := &file_ctg_simple_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BindDeviceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BindDeviceRequest) ProtoMessage() {}
+
+func (x *BindDeviceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ctg_simple_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BindDeviceRequest.ProtoReflect.Descriptor instead.
+func (*BindDeviceRequest) Descriptor() ([]byte, []int) {
+	return file_ctg_simple_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *BindDeviceRequest) GetDeviceId() string {
+	if x != nil {
+		return x.DeviceId
+	}
+	return ""
+}
+
+func (x *BindDeviceRequest) GetCardId() string {
+	if x != nil {
+		return x.CardId
+	}
+	return ""
+}
+
+func (x *BindDeviceRequest) GetKeepData() bool {
+	if x != nil {
+		return x.KeepData
+	}
+	return false
+}
+
+type BindDeviceResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	SessionId       string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	KeptPoints      int32                  `protobuf:"varint,2,opt,name=kept_points,json=keptPoints,proto3" json:"kept_points,omitempty"`
+	DiscardedPoints int32                  `protobuf:"varint,3,opt,name=discarded_points,json=discardedPoints,proto3" json:"discarded_points,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *BindDeviceResponse) Reset() {
+	*x = BindDeviceResponse{}
+	mi := &file_ctg_simple_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BindDeviceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BindDeviceResponse) ProtoMessage() {}
+
+func (x *BindDeviceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ctg_simple_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BindDeviceResponse.ProtoReflect.Descriptor instead.
+func (*BindDeviceResponse) Descriptor() ([]byte, []int) {
+	return file_ctg_simple_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *BindDeviceResponse) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *BindDeviceResponse) GetKeptPoints() int32 {
+	if x != nil {
+		return x.KeptPoints
+	}
+	return 0
+}
+
+func (x *BindDeviceResponse) GetDiscardedPoints() int32 {
+	if x != nil {
+		return x.DiscardedPoints
+	}
+	return 0
+}
+
+type SessionEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	DeviceId      string                 `protobuf:"bytes,2,opt,name=device_id,json=deviceId,proto3" json:"device_id,omitempty"`
+	Type          string                 `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`                           // updated, warning, warning_cleared
+	Reason        string                 `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`                       // backfill; для предупреждений: same_heart, maternal_hr
+	Points        int32                  `protobuf:"varint,5,opt,name=points,proto3" json:"points,omitempty"`                      // Сколько точек добавлено
+	FromTime      float64                `protobuf:"fixed64,6,opt,name=from_time,json=fromTime,proto3" json:"from_time,omitempty"` // Затронутый интервал сессии, с
+	ToTime        float64                `protobuf:"fixed64,7,opt,name=to_time,json=toTime,proto3" json:"to_time,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,8,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Channels      []string               `protobuf:"bytes,9,rep,name=channels,proto3" json:"channels,omitempty"` // Каналы, к которым относится предупреждение
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SessionEvent) Reset() {
+	*x = SessionEvent{}
+	mi := &file_ctg_simple_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionEvent) ProtoMessage() {}
+
+func (x *SessionEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_ctg_simple_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionEvent.ProtoReflect.Descriptor instead.
+func (*SessionEvent) Descriptor() ([]byte, []int) {
+	return file_ctg_simple_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SessionEvent) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *SessionEvent) GetDeviceId() string {
+	if x != nil {
+		return x.DeviceId
+	}
+	return ""
+}
+
+func (x *SessionEvent) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *SessionEvent) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *SessionEvent) GetPoints() int32 {
+	if x != nil {
+		return x.Points
+	}
+	return 0
+}
+
+func (x *SessionEvent) GetFromTime() float64 {
+	if x != nil {
+		return x.FromTime
+	}
+	return 0
+}
+
+func (x *SessionEvent) GetToTime() float64 {
+	if x != nil {
+		return x.ToTime
+	}
+	return 0
+}
+
+func (x *SessionEvent) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *SessionEvent) GetChannels() []string {
+	if x != nil {
+		return x.Channels
+	}
+	return nil
+}
+
+type AnalysisEvent struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	DeviceId        string                 `protobuf:"bytes,1,opt,name=device_id,json=deviceId,proto3" json:"device_id,omitempty"`
+	SessionId       string                 `protobuf:"bytes,2,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"` // Пусто, если устройство не привязано к медкарте
+	DataType        string                 `protobuf:"bytes,3,opt,name=data_type,json=dataType,proto3" json:"data_type,omitempty"`    // Канал ЧСС плода или сокращений матки (signal_quality, signal_loss - любой канал сигнала)
+	Type            string                 `protobuf:"bytes,4,opt,name=type,proto3" json:"type,omitempty"`                            // baseline, variability, acceleration, deceleration, contraction, tachysystole, signal_quality, signal_loss
+	Start           float64                `protobuf:"fixed64,5,opt,name=start,proto3" json:"start,omitempty"`                        // Начало эпизода, окна индекса качества или момент смены оценки, с от начала сессии
+	End             float64                `protobuf:"fixed64,6,opt,name=end,proto3" json:"end,omitempty"`                            // Окончание эпизода (0 - потеря сигнала продолжается)
+	Peak            float64                `protobuf:"fixed64,7,opt,name=peak,proto3" json:"peak,omitempty"`                          // Время пика акцелерации или схватки, надира децелерации
+	Value           float64                `protobuf:"fixed64,8,opt,name=value,proto3" json:"value,omitempty"`                        // ЧСС в пике/надире, базальный ритм, амплитуда вариабельности, давление в пике схватки, частота схваток за 30 минут, индекс качества (%) или длительность потери (с)
+	Amplitude       float64                `protobuf:"fixed64,9,opt,name=amplitude,proto3" json:"amplitude,omitempty"`                // Отклонение от базального ритма (тонуса) в пике
+	Baseline        float64                `protobuf:"fixed64,10,opt,name=baseline,proto3" json:"baseline,omitempty"`                 // Базальный ритм или тонус матки на момент события
+	Band            string                 `protobuf:"bytes,11,opt,name=band,proto3" json:"band,omitempty"`                           // Класс вариабельности (reduced, normal, increased) или качества сигнала (good, fair, poor)
+	Timestamp       int64                  `protobuf:"varint,12,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Kind            string                 `protobuf:"bytes,13,opt,name=kind,proto3" json:"kind,omitempty"`                                                // Тип децелерации: early, late, variable, prolonged
+	ContractionPeak float64                `protobuf:"fixed64,14,opt,name=contraction_peak,json=contractionPeak,proto3" json:"contraction_peak,omitempty"` // Пик схватки, с которой связана децелерация (0 - нет)
+	Lag             float64                `protobuf:"fixed64,15,opt,name=lag,proto3" json:"lag,omitempty"`                                                // Надир минус пик схватки, с
+	Frequency       float64                `protobuf:"fixed64,16,opt,name=frequency,proto3" json:"frequency,omitempty"`                                    // Схваток за последние 10 минут (contraction, tachysystole)
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *AnalysisEvent) Reset() {
+	*x = AnalysisEvent{}
+	mi := &file_ctg_simple_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AnalysisEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AnalysisEvent) ProtoMessage() {}
+
+func (x *AnalysisEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_ctg_simple_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AnalysisEvent.ProtoReflect.Descriptor instead.
+func (*AnalysisEvent) Descriptor() ([]byte, []int) {
+	return file_ctg_simple_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *AnalysisEvent) GetDeviceId() string {
+	if x != nil {
+		return x.DeviceId
+	}
+	return ""
+}
+
+func (x *AnalysisEvent) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *AnalysisEvent) GetDataType() string {
+	if x != nil {
+		return x.DataType
+	}
+	return ""
+}
+
+func (x *AnalysisEvent) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *AnalysisEvent) GetStart() float64 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *AnalysisEvent) GetEnd() float64 {
+	if x != nil {
+		return x.End
+	}
+	return 0
+}
+
+func (x *AnalysisEvent) GetPeak() float64 {
+	if x != nil {
+		return x.Peak
+	}
+	return 0
+}
+
+func (x *AnalysisEvent) GetValue() float64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *AnalysisEvent) GetAmplitude() float64 {
+	if x != nil {
+		return x.Amplitude
+	}
+	return 0
+}
+
+func (x *AnalysisEvent) GetBaseline() float64 {
+	if x != nil {
+		return x.Baseline
+	}
+	return 0
+}
+
+func (x *AnalysisEvent) GetBand() string {
+	if x != nil {
+		return x.Band
+	}
+	return ""
+}
+
+func (x *AnalysisEvent) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *AnalysisEvent) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *AnalysisEvent) GetContractionPeak() float64 {
+	if x != nil {
+		return x.ContractionPeak
+	}
+	return 0
+}
+
+func (x *AnalysisEvent) GetLag() float64 {
+	if x != nil {
+		return x.Lag
+	}
+	return 0
+}
+
+func (x *AnalysisEvent) GetFrequency() float64 {
+	if x != nil {
+		return x.Frequency
+	}
+	return 0
+}
+
+type AlarmEvent struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	AlarmId        string                 `protobuf:"bytes,1,opt,name=alarm_id,json=alarmId,proto3" json:"alarm_id,omitempty"`
+	DeviceId       string                 `protobuf:"bytes,2,opt,name=device_id,json=deviceId,proto3" json:"device_id,omitempty"`
+	SessionId      string                 `protobuf:"bytes,3,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"` // Пусто, если устройство не привязано к медкарте
+	DataType       string                 `protobuf:"bytes,4,opt,name=data_type,json=dataType,proto3" json:"data_type,omitempty"`    // Канал, по которому поднята тревога
+	Rule           string                 `protobuf:"bytes,5,opt,name=rule,proto3" json:"rule,omitempty"`                            // bradycardia, tachycardia, reduced_variability, late_decelerations, signal_loss, tachysystole
+	Priority       string                 `protobuf:"bytes,6,opt,name=priority,proto3" json:"priority,omitempty"`                    // low, medium, high
+	State          string                 `protobuf:"bytes,7,opt,name=state,proto3" json:"state,omitempty"`                          // raised, acknowledged, cleared
+	Message        string                 `protobuf:"bytes,8,opt,name=message,proto3" json:"message,omitempty"`
+	Value          float64                `protobuf:"fixed64,9,opt,name=value,proto3" json:"value,omitempty"`                                         // Значение, вызвавшее тревогу
+	SessionTime    float64                `protobuf:"fixed64,10,opt,name=session_time,json=sessionTime,proto3" json:"session_time,omitempty"`         // Время сигнала, с от начала сессии
+	RaisedAt       int64                  `protobuf:"varint,11,opt,name=raised_at,json=raisedAt,proto3" json:"raised_at,omitempty"`                   // Время поднятия, мс Unix
+	AcknowledgedAt int64                  `protobuf:"varint,12,opt,name=acknowledged_at,json=acknowledgedAt,proto3" json:"acknowledged_at,omitempty"` // 0 - не подтверждена
+	AcknowledgedBy string                 `protobuf:"bytes,13,opt,name=acknowledged_by,json=acknowledgedBy,proto3" json:"acknowledged_by,omitempty"`
+	ClearedAt      int64                  `protobuf:"varint,14,opt,name=cleared_at,json=clearedAt,proto3" json:"cleared_at,omitempty"` // 0 - не снята
+	Escalations    int32                  `protobuf:"varint,15,opt,name=escalations,proto3" json:"escalations,omitempty"`              // Сколько раз тревога эскалировалась без подтверждения
+	Snapshot       bool                   `protobuf:"varint,16,opt,name=snapshot,proto3" json:"snapshot,omitempty"`                    // Неснятая тревога на момент подключения
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *AlarmEvent) Reset() {
+	*x = AlarmEvent{}
+	mi := &file_ctg_simple_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AlarmEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AlarmEvent) ProtoMessage() {}
+
+func (x *AlarmEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_ctg_simple_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AlarmEvent.ProtoReflect.Descriptor instead.
+func (*AlarmEvent) Descriptor() ([]byte, []int) {
+	return file_ctg_simple_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *AlarmEvent) GetAlarmId() string {
+	if x != nil {
+		return x.AlarmId
+	}
+	return ""
+}
+
+func (x *AlarmEvent) GetDeviceId() string {
+	if x != nil {
+		return x.DeviceId
+	}
+	return ""
+}
+
+func (x *AlarmEvent) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *AlarmEvent) GetDataType() string {
+	if x != nil {
+		return x.DataType
+	}
+	return ""
+}
+
+func (x *AlarmEvent) GetRule() string {
+	if x != nil {
+		return x.Rule
+	}
+	return ""
+}
+
+func (x *AlarmEvent) GetPriority() string {
+	if x != nil {
+		return x.Priority
+	}
+	return ""
+}
+
+func (x *AlarmEvent) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *AlarmEvent) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *AlarmEvent) GetValue() float64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *AlarmEvent) GetSessionTime() float64 {
+	if x != nil {
+		return x.SessionTime
+	}
+	return 0
+}
+
+func (x *AlarmEvent) GetRaisedAt() int64 {
+	if x != nil {
+		return x.RaisedAt
+	}
+	return 0
+}
+
+func (x *AlarmEvent) GetAcknowledgedAt() int64 {
+	if x != nil {
+		return x.AcknowledgedAt
+	}
+	return 0
+}
+
+func (x *AlarmEvent) GetAcknowledgedBy() string {
+	if x != nil {
+		return x.AcknowledgedBy
+	}
+	return ""
+}
+
+func (x *AlarmEvent) GetClearedAt() int64 {
+	if x != nil {
+		return x.ClearedAt
+	}
+	return 0
+}
+
+func (x *AlarmEvent) GetEscalations() int32 {
+	if x != nil {
+		return x.Escalations
+	}
+	return 0
+}
+
+func (x *AlarmEvent) GetSnapshot() bool {
+	if x != nil {
+		return x.Snapshot
+	}
+	return false
+}
+
+type SessionDataRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	DataTypes     []string               `protobuf:"bytes,2,rep,name=data_types,json=dataTypes,proto3" json:"data_types,omitempty"`  // Каналы (пустой = все)
+	From          *float64               `protobuf:"fixed64,3,opt,name=from,proto3,oneof" json:"from,omitempty"`                     // Начало интервала, с от начала сессии (не задано - с начала)
+	To            *float64               `protobuf:"fixed64,4,opt,name=to,proto3,oneof" json:"to,omitempty"`                         // Конец интервала (не задано - до конца)
+	MaxPoints     int32                  `protobuf:"varint,5,opt,name=max_points,json=maxPoints,proto3" json:"max_points,omitempty"` // Максимум точек на канал (0 - без прореживания, иначе не меньше 3)
+	Packed        bool                   `protobuf:"varint,6,opt,name=packed,proto3" json:"packed,omitempty"`                        // Точки каналов в packed вместо data
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SessionDataRequest) Reset() {
+	*x = SessionDataRequest{}
+	mi := &file_ctg_simple_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionDataRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionDataRequest) ProtoMessage() {}
+
+func (x *SessionDataRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ctg_simple_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionDataRequest.ProtoReflect.Descriptor instead.
+func (*SessionDataRequest) Descriptor() ([]byte, []int) {
+	return file_ctg_simple_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *SessionDataRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *SessionDataRequest) GetDataTypes() []string {
+	if x != nil {
+		return x.DataTypes
+	}
+	return nil
+}
+
+func (x *SessionDataRequest) GetFrom() float64 {
+	if x != nil && x.From != nil {
+		return *x.From
+	}
+	return 0
+}
+
+func (x *SessionDataRequest) GetTo() float64 {
+	if x != nil && x.To != nil {
+		return *x.To
+	}
+	return 0
+}
+
+func (x *SessionDataRequest) GetMaxPoints() int32 {
+	if x != nil {
+		return x.MaxPoints
+	}
+	return 0
+}
+
+func (x *SessionDataRequest) GetPacked() bool {
+	if x != nil {
+		return x.Packed
+	}
+	return false
+}
+
+type SessionDataResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	DeviceId      string                 `protobuf:"bytes,2,opt,name=device_id,json=deviceId,proto3" json:"device_id,omitempty"`
+	Series        []*SessionSeries       `protobuf:"bytes,3,rep,name=series,proto3" json:"series,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SessionDataResponse) Reset() {
+	*x = SessionDataResponse{}
+	mi := &file_ctg_simple_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionDataResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionDataResponse) ProtoMessage() {}
+
+func (x *SessionDataResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ctg_simple_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionDataResponse.ProtoReflect.Descriptor instead.
+func (*SessionDataResponse) Descriptor() ([]byte, []int) {
+	return file_ctg_simple_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *SessionDataResponse) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *SessionDataResponse) GetDeviceId() string {
+	if x != nil {
+		return x.DeviceId
+	}
+	return ""
+}
+
+func (x *SessionDataResponse) GetSeries() []*SessionSeries {
+	if x != nil {
+		return x.Series
+	}
+	return nil
+}
+
+type SessionSeries struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DataType      string                 `protobuf:"bytes,1,opt,name=data_type,json=dataType,proto3" json:"data_type,omitempty"`
+	Fetus         int32                  `protobuf:"varint,2,opt,name=fetus,proto3" json:"fetus,omitempty"`
+	SourcePoints  int32                  `protobuf:"varint,3,opt,name=source_points,json=sourcePoints,proto3" json:"source_points,omitempty"` // Точек в интервале до прореживания
+	Data          []*CTGDataResponse     `protobuf:"bytes,4,rep,name=data,proto3" json:"data,omitempty"`                                      // Точки (device_id и unassigned не заполняются)
+	Packed        *PackedSeries          `protobuf:"bytes,5,opt,name=packed,proto3" json:"packed,omitempty"`                                  // Точки в компактной кодировке, если запрошено packed
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SessionSeries) Reset() {
+	*x = SessionSeries{}
+	mi := &file_ctg_simple_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionSeries) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionSeries) ProtoMessage() {}
+
+func (x *SessionSeries) ProtoReflect() protoreflect.Message {
+	mi := &file_ctg_simple_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionSeries.ProtoReflect.Descriptor instead.
+func (*SessionSeries) Descriptor() ([]byte, []int) {
+	return file_ctg_simple_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *SessionSeries) GetDataType() string {
+	if x != nil {
+		return x.DataType
+	}
+	return ""
+}
+
+func (x *SessionSeries) GetFetus() int32 {
+	if x != nil {
+		return x.Fetus
+	}
+	return 0
+}
+
+func (x *SessionSeries) GetSourcePoints() int32 {
+	if x != nil {
+		return x.SourcePoints
+	}
+	return 0
+}
+
+func (x *SessionSeries) GetData() []*CTGDataResponse {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *SessionSeries) GetPacked() *PackedSeries {
+	if x != nil {
+		return x.Packed
+	}
+	return nil
+}
+
+var File_ctg_simple_proto protoreflect.FileDescriptor
+
+const file_ctg_simple_proto_rawDesc = "" +
+	"\n" +
+	"\x10ctg_simple.proto\x12\x03ctg\"e\n" +
+	"\rStreamRequest\x12\x1d\n" +
+	"\n" +
+	"device_ids\x18\x01 \x03(\tR\tdeviceIds\x12\x1d\n" +
+	"\n" +
+	"data_types\x18\x02 \x03(\tR\tdataTypes\x12\x16\n" +
+	"\x06packed\x18\x03 \x01(\bR\x06packed\"\x83\x02\n" +
+	"\x0fCTGDataResponse\x12\x1b\n" +
+	"\tdevice_id\x18\x01 \x01(\tR\bdeviceId\x12\x1b\n" +
+	"\tdata_type\x18\x02 \x01(\tR\bdataType\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\x01R\x05value\x12\x19\n" +
+	"\btime_sec\x18\x04 \x01(\x01R\atimeSec\x12\x1b\n" +
+	"\traw_value\x18\x05 \x01(\x01R\brawValue\x12\x14\n" +
+	"\x05flags\x18\x06 \x03(\tR\x05flags\x12\x1e\n" +
+	"\n" +
+	"unassigned\x18\a \x01(\bR\n" +
+	"unassigned\x12\x1c\n" +
+	"\ttimestamp\x18\b \x01(\x03R\ttimestamp\x12\x14\n" +
+	"\x05fetus\x18\t \x01(\x05R\x05fetus\"\xb8\x01\n" +
+	"\x10CTGBatchResponse\x12(\n" +
+	"\x04data\x18\x01 \x03(\v2\x14.ctg.CTGDataResponseR\x04data\x12\x1c\n" +
+	"\ttimestamp\x18\x02 \x01(\x03R\ttimestamp\x12\x14\n" +
+	"\x05count\x18\x03 \x01(\x05R\x05count\x12\x1b\n" +
+	"\tdevice_id\x18\x04 \x01(\tR\bdeviceId\x12)\n" +
+	"\x06packed\x18\x05 \x03(\v2\x11.ctg.PackedSeriesR\x06packed\"\x91\x01\n" +
+	"\fPackedSeries\x12\x1b\n" +
+	"\tdata_type\x18\x01 \x01(\tR\bdataType\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x05R\x05count\x12\x18\n" +
+	"\asamples\x18\x03 \x01(\fR\asamples\x12\x1e\n" +
+	"\n" +
+	"timestamps\x18\x04 \x01(\fR\n" +
+	"timestamps\x12\x14\n" +
+	"\x05fetus\x18\x05 \x01(\x05R\x05fetus\"f\n" +
+	"\x11BindDeviceRequest\x12\x1b\n" +
+	"\tdevice_id\x18\x01 \x01(\tR\bdeviceId\x12\x17\n" +
+	"\acard_id\x18\x02 \x01(\tR\x06cardId\x12\x1b\n" +
+	"\tkeep_data\x18\x03 \x01(\bR\bkeepData\"\x7f\n" +
+	"\x12BindDeviceResponse\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x1f\n" +
+	"\vkept_points\x18\x02 \x01(\x05R\n" +
+	"keptPoints\x12)\n" +
+	"\x10discarded_points\x18\x03 \x01(\x05R\x0fdiscardedPoints\"\xfe\x01\n" +
+	"\fSessionEvent\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x1b\n" +
+	"\tdevice_id\x18\x02 \x01(\tR\bdeviceId\x12\x12\n" +
+	"\x04type\x18\x03 \x01(\tR\x04type\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\x12\x16\n" +
+	"\x06points\x18\x05 \x01(\x05R\x06points\x12\x1b\n" +
+	"\tfrom_time\x18\x06 \x01(\x01R\bfromTime\x12\x17\n" +
+	"\ato_time\x18\a \x01(\x01R\x06toTime\x12\x1c\n" +
+	"\ttimestamp\x18\b \x01(\x03R\ttimestamp\x12\x1a\n" +
+	"\bchannels\x18\t \x03(\tR\bchannels\"\xa9\x03\n" +
+	"\rAnalysisEvent\x12\x1b\n" +
+	"\tdevice_id\x18\x01 \x01(\tR\bdeviceId\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x02 \x01(\tR\tsessionId\x12\x1b\n" +
+	"\tdata_type\x18\x03 \x01(\tR\bdataType\x12\x12\n" +
+	"\x04type\x18\x04 \x01(\tR\x04type\x12\x14\n" +
+	"\x05start\x18\x05 \x01(\x01R\x05start\x12\x10\n" +
+	"\x03end\x18\x06 \x01(\x01R\x03end\x12\x12\n" +
+	"\x04peak\x18\a \x01(\x01R\x04peak\x12\x14\n" +
+	"\x05value\x18\b \x01(\x01R\x05value\x12\x1c\n" +
+	"\tamplitude\x18\t \x01(\x01R\tamplitude\x12\x1a\n" +
+	"\bbaseline\x18\n" +
+	" \x01(\x01R\bbaseline\x12\x12\n" +
+	"\x04band\x18\v \x01(\tR\x04band\x12\x1c\n" +
+	"\ttimestamp\x18\f \x01(\x03R\ttimestamp\x12\x12\n" +
+	"\x04kind\x18\r \x01(\tR\x04kind\x12)\n" +
+	"\x10contraction_peak\x18\x0e \x01(\x01R\x0fcontractionPeak\x12\x10\n" +
+	"\x03lag\x18\x0f \x01(\x01R\x03lag\x12\x1c\n" +
+	"\tfrequency\x18\x10 \x01(\x01R\tfrequency\"\xe5\x03\n" +
+	"\n" +
+	"AlarmEvent\x12\x19\n" +
+	"\balarm_id\x18\x01 \x01(\tR\aalarmId\x12\x1b\n" +
+	"\tdevice_id\x18\x02 \x01(\tR\bdeviceId\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x03 \x01(\tR\tsessionId\x12\x1b\n" +
+	"\tdata_type\x18\x04 \x01(\tR\bdataType\x12\x12\n" +
+	"\x04rule\x18\x05 \x01(\tR\x04rule\x12\x1a\n" +
+	"\bpriority\x18\x06 \x01(\tR\bpriority\x12\x14\n" +
+	"\x05state\x18\a \x01(\tR\x05state\x12\x18\n" +
+	"\amessage\x18\b \x01(\tR\amessage\x12\x14\n" +
+	"\x05value\x18\t \x01(\x01R\x05value\x12!\n" +
+	"\fsession_time\x18\n" +
+	" \x01(\x01R\vsessionTime\x12\x1b\n" +
+	"\traised_at\x18\v \x01(\x03R\braisedAt\x12'\n" +
+	"\x0facknowledged_at\x18\f \x01(\x03R\x0eacknowledgedAt\x12'\n" +
+	"\x0facknowledged_by\x18\r \x01(\tR\x0eacknowledgedBy\x12\x1d\n" +
+	"\n" +
+	"cleared_at\x18\x0e \x01(\x03R\tclearedAt\x12 \n" +
+	"\vescalations\x18\x0f \x01(\x05R\vescalations\x12\x1a\n" +
+	"\bsnapshot\x18\x10 \x01(\bR\bsnapshot\"\xc7\x01\n" +
+	"\x12SessionDataRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x1d\n" +
+	"\n" +
+	"data_types\x18\x02 \x03(\tR\tdataTypes\x12\x17\n" +
+	"\x04from\x18\x03 \x01(\x01H\x00R\x04from\x88\x01\x01\x12\x13\n" +
+	"\x02to\x18\x04 \x01(\x01H\x01R\x02to\x88\x01\x01\x12\x1d\n" +
+	"\n" +
+	"max_points\x18\x05 \x01(\x05R\tmaxPoints\x12\x16\n" +
+	"\x06packed\x18\x06 \x01(\bR\x06packedB\a\n" +
+	"\x05_fromB\x05\n" +
+	"\x03_to\"}\n" +
+	"\x13SessionDataResponse\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x1b\n" +
+	"\tdevice_id\x18\x02 \x01(\tR\bdeviceId\x12*\n" +
+	"\x06series\x18\x03 \x03(\v2\x12.ctg.SessionSeriesR\x06series\"\xbc\x01\n" +
+	"\rSessionSeries\x12\x1b\n" +
+	"\tdata_type\x18\x01 \x01(\tR\bdataType\x12\x14\n" +
+	"\x05fetus\x18\x02 \x01(\x05R\x05fetus\x12#\n" +
+	"\rsource_points\x18\x03 \x01(\x05R\fsourcePoints\x12(\n" +
+	"\x04data\x18\x04 \x03(\v2\x14.ctg.CTGDataResponseR\x04data\x12)\n" +
+	"\x06packed\x18\x05 \x01(\v2\x11.ctg.PackedSeriesR\x06packed2\xcf\x03\n" +
+	"\x10CTGStreamService\x12;\n" +
+	"\rStreamCTGData\x12\x12.ctg.StreamRequest\x1a\x14.ctg.CTGDataResponse0\x01\x12A\n" +
+	"\x12StreamBatchCTGData\x12\x12.ctg.StreamRequest\x1a\x15.ctg.CTGBatchResponse0\x01\x12=\n" +
+	"\n" +
+	"BindDevice\x12\x16.ctg.BindDeviceRequest\x1a\x17.ctg.BindDeviceResponse\x12>\n" +
+	"\x13StreamSessionEvents\x12\x12.ctg.StreamRequest\x1a\x11.ctg.SessionEvent0\x01\x12@\n" +
+	"\x14StreamAnalysisEvents\x12\x12.ctg.StreamRequest\x1a\x12.ctg.AnalysisEvent0\x01\x125\n" +
+	"\fStreamAlarms\x12\x12.ctg.StreamRequest\x1a\x0f.ctg.AlarmEvent0\x01\x12C\n" +
+	"\x0eGetSessionData\x12\x17.ctg.SessionDataRequest\x1a\x18.ctg.SessionDataResponseB\x13Z\x11CTG_monitor/protob\x06proto3"
 
 var (
 	file_ctg_simple_proto_rawDescOnce sync.Once
@@ -238,23 +1264,46 @@ func file_ctg_simple_proto_rawDescGZIP() []byte {
 	return file_ctg_simple_proto_rawDescData
 }
 
-var file_ctg_simple_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_ctg_simple_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
 var file_ctg_simple_proto_goTypes = []any{
-	(*StreamRequest)(nil),    // 0: ctg.StreamRequest
-	(*CTGDataResponse)(nil),  // 1: ctg.CTGDataResponse
-	(*CTGBatchResponse)(nil), // 2: ctg.CTGBatchResponse
+	(*StreamRequest)(nil),       // 0: ctg.StreamRequest
+	(*CTGDataResponse)(nil),     // 1: ctg.CTGDataResponse
+	(*CTGBatchResponse)(nil),    // 2: ctg.CTGBatchResponse
+	(*PackedSeries)(nil),        // 3: ctg.PackedSeries
+	(*BindDeviceRequest)(nil),   // 4: ctg.BindDeviceRequest
+	(*BindDeviceResponse)(nil),  // 5: ctg.BindDeviceResponse
+	(*SessionEvent)(nil),        // 6: ctg.SessionEvent
+	(*AnalysisEvent)(nil),       // 7: ctg.AnalysisEvent
+	(*AlarmEvent)(nil),          // 8: ctg.AlarmEvent
+	(*SessionDataRequest)(nil),  // 9: ctg.SessionDataRequest
+	(*SessionDataResponse)(nil), // 10: ctg.SessionDataResponse
+	(*SessionSeries)(nil),       // 11: ctg.SessionSeries
 }
 var file_ctg_simple_proto_depIdxs = []int32{
-	1, // 0: ctg.CTGBatchResponse.data:type_name -> ctg.CTGDataResponse
-	0, // 1: ctg.CTGStreamService.StreamCTGData:input_type -> ctg.StreamRequest
-	0, // 2: ctg.CTGStreamService.StreamBatchCTGData:input_type -> ctg.StreamRequest
-	1, // 3: ctg.CTGStreamService.StreamCTGData:output_type -> ctg.CTGDataResponse
-	2, // 4: ctg.CTGStreamService.StreamBatchCTGData:output_type -> ctg.CTGBatchResponse
-	3, // [3:5] is the sub-list for method output_type
-	1, // [1:3] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	1,  // 0: ctg.CTGBatchResponse.data:type_name -> ctg.CTGDataResponse
+	3,  // 1: ctg.CTGBatchResponse.packed:type_name -> ctg.PackedSeries
+	11, // 2: ctg.SessionDataResponse.series:type_name -> ctg.SessionSeries
+	1,  // 3: ctg.SessionSeries.data:type_name -> ctg.CTGDataResponse
+	3,  // 4: ctg.SessionSeries.packed:type_name -> ctg.PackedSeries
+	0,  // 5: ctg.CTGStreamService.StreamCTGData:input_type -> ctg.StreamRequest
+	0,  // 6: ctg.CTGStreamService.StreamBatchCTGData:input_type -> ctg.StreamRequest
+	4,  // 7: ctg.CTGStreamService.BindDevice:input_type -> ctg.BindDeviceRequest
+	0,  // 8: ctg.CTGStreamService.StreamSessionEvents:input_type -> ctg.StreamRequest
+	0,  // 9: ctg.CTGStreamService.StreamAnalysisEvents:input_type -> ctg.StreamRequest
+	0,  // 10: ctg.CTGStreamService.StreamAlarms:input_type -> ctg.StreamRequest
+	9,  // 11: ctg.CTGStreamService.GetSessionData:input_type -> ctg.SessionDataRequest
+	1,  // 12: ctg.CTGStreamService.StreamCTGData:output_type -> ctg.CTGDataResponse
+	2,  // 13: ctg.CTGStreamService.StreamBatchCTGData:output_type -> ctg.CTGBatchResponse
+	5,  // 14: ctg.CTGStreamService.BindDevice:output_type -> ctg.BindDeviceResponse
+	6,  // 15: ctg.CTGStreamService.StreamSessionEvents:output_type -> ctg.SessionEvent
+	7,  // 16: ctg.CTGStreamService.StreamAnalysisEvents:output_type -> ctg.AnalysisEvent
+	8,  // 17: ctg.CTGStreamService.StreamAlarms:output_type -> ctg.AlarmEvent
+	10, // 18: ctg.CTGStreamService.GetSessionData:output_type -> ctg.SessionDataResponse
+	12, // [12:19] is the sub-list for method output_type
+	5,  // [5:12] is the sub-list for method input_type
+	5,  // [5:5] is the sub-list for extension type_name
+	5,  // [5:5] is the sub-list for extension extendee
+	0,  // [0:5] is the sub-list for field type_name
 }
 
 func init() { file_ctg_simple_proto_init() }
@@ -262,13 +1311,14 @@ func file_ctg_simple_proto_init() {
 	if File_ctg_simple_proto != nil {
 		return
 	}
+	file_ctg_simple_proto_msgTypes[9].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_ctg_simple_proto_rawDesc), len(file_ctg_simple_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   3,
+			NumMessages:   12,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -21,8 +21,13 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	CTGStreamService_StreamCTGData_FullMethodName      = "/ctg.CTGStreamService/StreamCTGData"
-	CTGStreamService_StreamBatchCTGData_FullMethodName = "/ctg.CTGStreamService/StreamBatchCTGData"
+	CTGStreamService_StreamCTGData_FullMethodName        = "/ctg.CTGStreamService/StreamCTGData"
+	CTGStreamService_StreamBatchCTGData_FullMethodName   = "/ctg.CTGStreamService/StreamBatchCTGData"
+	CTGStreamService_BindDevice_FullMethodName           = "/ctg.CTGStreamService/BindDevice"
+	CTGStreamService_StreamSessionEvents_FullMethodName  = "/ctg.CTGStreamService/StreamSessionEvents"
+	CTGStreamService_StreamAnalysisEvents_FullMethodName = "/ctg.CTGStreamService/StreamAnalysisEvents"
+	CTGStreamService_StreamAlarms_FullMethodName         = "/ctg.CTGStreamService/StreamAlarms"
+	CTGStreamService_GetSessionData_FullMethodName       = "/ctg.CTGStreamService/GetSessionData"
 )
 
 // CTGStreamServiceClient is the client API for CTGStreamService service.
@@ -33,6 +38,17 @@ type CTGStreamServiceClient interface {
 	StreamCTGData(ctx context.Context, in *StreamRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CTGDataResponse], error)
 	// Батчевая передача данных КТГ (каждые 4 минуты)
 	StreamBatchCTGData(ctx context.Context, in *StreamRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CTGBatchResponse], error)
+	// Привязка устройства, передающего данные без медкарты, к карте пациента
+	BindDevice(ctx context.Context, in *BindDeviceRequest, opts ...grpc.CallOption) (*BindDeviceResponse, error)
+	// События сессий: изменение уже переданных данных (например, выгрузка задним числом)
+	StreamSessionEvents(ctx context.Context, in *StreamRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SessionEvent], error)
+	// Результаты анализа по FIGO: базальный ритм, вариабельность, акцелерации, децелерации, схватки, тахисистолия;
+	// индекс качества сигнала и эпизоды потери сигнала по каналам
+	StreamAnalysisEvents(ctx context.Context, in *StreamRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AnalysisEvent], error)
+	// Клинические тревоги: сначала неснятые тревоги, затем изменения (поднята, эскалирована, подтверждена, снята)
+	StreamAlarms(ctx context.Context, in *StreamRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AlarmEvent], error)
+	// Точки каналов сессии за интервал; длинные ряды прореживаются методом LTTB до max_points
+	GetSessionData(ctx context.Context, in *SessionDataRequest, opts ...grpc.CallOption) (*SessionDataResponse, error)
 }
 
 type cTGStreamServiceClient struct {
@@ -81,6 +97,83 @@ func (c *cTGStreamServiceClient) StreamBatchCTGData(ctx context.Context, in *Str
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type CTGStreamService_StreamBatchCTGDataClient = grpc.ServerStreamingClient[CTGBatchResponse]
 
+func (c *cTGStreamServiceClient) BindDevice(ctx context.Context, in *BindDeviceRequest, opts ...grpc.CallOption) (*BindDeviceResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BindDeviceResponse)
+	err := c.cc.Invoke(ctx, CTGStreamService_BindDevice_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cTGStreamServiceClient) StreamSessionEvents(ctx context.Context, in *StreamRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SessionEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CTGStreamService_ServiceDesc.Streams[2], CTGStreamService_StreamSessionEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamRequest, SessionEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CTGStreamService_StreamSessionEventsClient = grpc.ServerStreamingClient[SessionEvent]
+
+func (c *cTGStreamServiceClient) StreamAnalysisEvents(ctx context.Context, in *StreamRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AnalysisEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CTGStreamService_ServiceDesc.Streams[3], CTGStreamService_StreamAnalysisEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamRequest, AnalysisEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CTGStreamService_StreamAnalysisEventsClient = grpc.ServerStreamingClient[AnalysisEvent]
+
+func (c *cTGStreamServiceClient) StreamAlarms(ctx context.Context, in *StreamRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AlarmEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CTGStreamService_ServiceDesc.Streams[4], CTGStreamService_StreamAlarms_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamRequest, AlarmEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CTGStreamService_StreamAlarmsClient = grpc.ServerStreamingClient[AlarmEvent]
+
+func (c *cTGStreamServiceClient) GetSessionData(ctx context.Context, in *SessionDataRequest, opts ...grpc.CallOption) (*SessionDataResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SessionDataResponse)
+	err := c.cc.Invoke(ctx, CTGStreamService_GetSessionData_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // CTGStreamServiceServer is the server API for CTGStreamService service.
 // All implementations must embed UnimplementedCTGStreamServiceServer
 // for forward compatibility.
@@ -89,6 +182,17 @@ type CTGStreamServiceServer interface {
 	StreamCTGData(*StreamRequest, grpc.ServerStreamingServer[CTGDataResponse]) error
 	// Батчевая передача данных КТГ (каждые 4 минуты)
 	StreamBatchCTGData(*StreamRequest, grpc.ServerStreamingServer[CTGBatchResponse]) error
+	// Привязка устройства, передающего данные без медкарты, к карте пациента
+	BindDevice(context.Context, *BindDeviceRequest) (*BindDeviceResponse, error)
+	// События сессий: изменение уже переданных данных (например, выгрузка задним числом)
+	StreamSessionEvents(*StreamRequest, grpc.ServerStreamingServer[SessionEvent]) error
+	// Результаты анализа по FIGO: базальный ритм, вариабельность, акцелерации, децелерации, схватки, тахисистолия;
+	// индекс качества сигнала и эпизоды потери сигнала по каналам
+	StreamAnalysisEvents(*StreamRequest, grpc.ServerStreamingServer[AnalysisEvent]) error
+	// Клинические тревоги: сначала неснятые тревоги, затем изменения (поднята, эскалирована, подтверждена, снята)
+	StreamAlarms(*StreamRequest, grpc.ServerStreamingServer[AlarmEvent]) error
+	// Точки каналов сессии за интервал; длинные ряды прореживаются методом LTTB до max_points
+	GetSessionData(context.Context, *SessionDataRequest) (*SessionDataResponse, error)
 	mustEmbedUnimplementedCTGStreamServiceServer()
 }
 
@@ -105,6 +209,21 @@ func (UnimplementedCTGStreamServiceServer) StreamCTGData(*StreamRequest, grpc.Se
 func (UnimplementedCTGStreamServiceServer) StreamBatchCTGData(*StreamRequest, grpc.ServerStreamingServer[CTGBatchResponse]) error {
 	return status.Errorf(codes.Unimplemented, "method StreamBatchCTGData not implemented")
 }
+func (UnimplementedCTGStreamServiceServer) BindDevice(context.Context, *BindDeviceRequest) (*BindDeviceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BindDevice not implemented")
+}
+func (UnimplementedCTGStreamServiceServer) StreamSessionEvents(*StreamRequest, grpc.ServerStreamingServer[SessionEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamSessionEvents not implemented")
+}
+func (UnimplementedCTGStreamServiceServer) StreamAnalysisEvents(*StreamRequest, grpc.ServerStreamingServer[AnalysisEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamAnalysisEvents not implemented")
+}
+func (UnimplementedCTGStreamServiceServer) StreamAlarms(*StreamRequest, grpc.ServerStreamingServer[AlarmEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamAlarms not implemented")
+}
+func (UnimplementedCTGStreamServiceServer) GetSessionData(context.Context, *SessionDataRequest) (*SessionDataResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSessionData not implemented")
+}
 func (UnimplementedCTGStreamServiceServer) mustEmbedUnimplementedCTGStreamServiceServer() {}
 func (UnimplementedCTGStreamServiceServer) testEmbeddedByValue()                          {}
 
@@ -148,13 +267,91 @@ func _CTGStreamService_StreamBatchCTGData_Handler(srv interface{}, stream grpc.S
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type CTGStreamService_StreamBatchCTGDataServer = grpc.ServerStreamingServer[CTGBatchResponse]
 
+func _CTGStreamService_BindDevice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BindDeviceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CTGStreamServiceServer).BindDevice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CTGStreamService_BindDevice_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CTGStreamServiceServer).BindDevice(ctx, req.(*BindDeviceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CTGStreamService_StreamSessionEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CTGStreamServiceServer).StreamSessionEvents(m, &grpc.GenericServerStream[StreamRequest, SessionEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CTGStreamService_StreamSessionEventsServer = grpc.ServerStreamingServer[SessionEvent]
+
+func _CTGStreamService_StreamAnalysisEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CTGStreamServiceServer).StreamAnalysisEvents(m, &grpc.GenericServerStream[StreamRequest, AnalysisEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CTGStreamService_StreamAnalysisEventsServer = grpc.ServerStreamingServer[AnalysisEvent]
+
+func _CTGStreamService_StreamAlarms_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CTGStreamServiceServer).StreamAlarms(m, &grpc.GenericServerStream[StreamRequest, AlarmEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CTGStreamService_StreamAlarmsServer = grpc.ServerStreamingServer[AlarmEvent]
+
+func _CTGStreamService_GetSessionData_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SessionDataRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CTGStreamServiceServer).GetSessionData(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CTGStreamService_GetSessionData_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CTGStreamServiceServer).GetSessionData(ctx, req.(*SessionDataRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // CTGStreamService_ServiceDesc is the grpc.ServiceDesc for CTGStreamService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
 var CTGStreamService_ServiceDesc = grpc.ServiceDesc{
 	ServiceName: "ctg.CTGStreamService",
 	HandlerType: (*CTGStreamServiceServer)(nil),
-	Methods:     []grpc.MethodDesc{},
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "BindDevice",
+			Handler:    _CTGStreamService_BindDevice_Handler,
+		},
+		{
+			MethodName: "GetSessionData",
+			Handler:    _CTGStreamService_GetSessionData_Handler,
+		},
+	},
 	Streams: []grpc.StreamDesc{
 		{
 			StreamName:    "StreamCTGData",
@@ -166,6 +363,21 @@ var CTGStreamService_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _CTGStreamService_StreamBatchCTGData_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "StreamSessionEvents",
+			Handler:       _CTGStreamService_StreamSessionEvents_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamAnalysisEvents",
+			Handler:       _CTGStreamService_StreamAnalysisEvents_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamAlarms",
+			Handler:       _CTGStreamService_StreamAlarms_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "ctg_simple.proto",
 }
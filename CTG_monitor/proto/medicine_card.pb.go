@@ -24,16 +24,21 @@ const (
 // Запрос для сохранения сессии КТГ
 type CTGSessionRequest struct {
 	state           protoimpl.MessageState `protogen:"open.v1"`
-	SessionId       string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`                    // UUID сессии
-	CardId          string                 `protobuf:"bytes,2,opt,name=card_id,json=cardId,proto3" json:"card_id,omitempty"`                             // UUID медицинской карты
-	DeviceId        string                 `protobuf:"bytes,3,opt,name=device_id,json=deviceId,proto3" json:"device_id,omitempty"`                       // ID устройства
-	StartTime       int64                  `protobuf:"varint,4,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`                   // Unix timestamp начала
-	EndTime         int64                  `protobuf:"varint,5,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`                         // Unix timestamp окончания
-	DurationSeconds int32                  `protobuf:"varint,6,opt,name=duration_seconds,json=durationSeconds,proto3" json:"duration_seconds,omitempty"` // Продолжительность в секундах
-	FhrData         []*CTGDataPoint        `protobuf:"bytes,7,rep,name=fhr_data,json=fhrData,proto3" json:"fhr_data,omitempty"`                          // Данные FHR
-	UcData          []*CTGDataPoint        `protobuf:"bytes,8,rep,name=uc_data,json=ucData,proto3" json:"uc_data,omitempty"`                             // Данные UC
-	TotalFhrPoints  int32                  `protobuf:"varint,9,opt,name=total_fhr_points,json=totalFhrPoints,proto3" json:"total_fhr_points,omitempty"`  // Общее количество FHR точек
-	TotalUcPoints   int32                  `protobuf:"varint,10,opt,name=total_uc_points,json=totalUcPoints,proto3" json:"total_uc_points,omitempty"`    // Общее количество UC точек
+	SessionId       string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`                       // UUID сессии
+	CardId          string                 `protobuf:"bytes,2,opt,name=card_id,json=cardId,proto3" json:"card_id,omitempty"`                                // UUID медицинской карты
+	DeviceId        string                 `protobuf:"bytes,3,opt,name=device_id,json=deviceId,proto3" json:"device_id,omitempty"`                          // ID устройства
+	StartTime       int64                  `protobuf:"varint,4,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`                      // Unix timestamp начала
+	EndTime         int64                  `protobuf:"varint,5,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`                            // Unix timestamp окончания
+	DurationSeconds int32                  `protobuf:"varint,6,opt,name=duration_seconds,json=durationSeconds,proto3" json:"duration_seconds,omitempty"`    // Продолжительность в секундах
+	FhrData         []*CTGDataPoint        `protobuf:"bytes,7,rep,name=fhr_data,json=fhrData,proto3" json:"fhr_data,omitempty"`                             // Данные FHR
+	UcData          []*CTGDataPoint        `protobuf:"bytes,8,rep,name=uc_data,json=ucData,proto3" json:"uc_data,omitempty"`                                // Данные UC
+	TotalFhrPoints  int32                  `protobuf:"varint,9,opt,name=total_fhr_points,json=totalFhrPoints,proto3" json:"total_fhr_points,omitempty"`     // Общее количество FHR точек
+	TotalUcPoints   int32                  `protobuf:"varint,10,opt,name=total_uc_points,json=totalUcPoints,proto3" json:"total_uc_points,omitempty"`       // Общее количество UC точек
+	Fhr2Data        []*CTGDataPoint        `protobuf:"bytes,11,rep,name=fhr2_data,json=fhr2Data,proto3" json:"fhr2_data,omitempty"`                         // Данные FHR второго плода (двойня)
+	TotalFhr2Points int32                  `protobuf:"varint,12,opt,name=total_fhr2_points,json=totalFhr2Points,proto3" json:"total_fhr2_points,omitempty"` // Общее количество точек FHR второго плода
+	Coincidences    []*CoincidenceInterval `protobuf:"bytes,13,rep,name=coincidences,proto3" json:"coincidences,omitempty"`                                 // Интервалы совпадения каналов ЧСС
+	ProtocolResult  *ProtocolResult        `protobuf:"bytes,14,opt,name=protocol_result,json=protocolResult,proto3" json:"protocol_result,omitempty"`       // Заключение теста, если сессия шла по протоколу
+	Summary         *SessionSummary        `protobuf:"bytes,15,opt,name=summary,proto3" json:"summary,omitempty"`                                           // Итоги сессии
 	unknownFields   protoimpl.UnknownFields
 	sizeCache       protoimpl.SizeCache
 }
@@ -138,18 +143,374 @@ func (x *CTGSessionRequest) GetTotalUcPoints() int32 {
 	return 0
 }
 
+func (x *CTGSessionRequest) GetFhr2Data() []*CTGDataPoint {
+	if x != nil {
+		return x.Fhr2Data
+	}
+	return nil
+}
+
+func (x *CTGSessionRequest) GetTotalFhr2Points() int32 {
+	if x != nil {
+		return x.TotalFhr2Points
+	}
+	return 0
+}
+
+func (x *CTGSessionRequest) GetCoincidences() []*CoincidenceInterval {
+	if x != nil {
+		return x.Coincidences
+	}
+	return nil
+}
+
+func (x *CTGSessionRequest) GetProtocolResult() *ProtocolResult {
+	if x != nil {
+		return x.ProtocolResult
+	}
+	return nil
+}
+
+func (x *CTGSessionRequest) GetSummary() *SessionSummary {
+	if x != nil {
+		return x.Summary
+	}
+	return nil
+}
+
+// Итоги сессии, вычисляются при завершении. Оценки ЧСС - по первому плоду на конец сессии
+type SessionSummary struct {
+	state                  protoimpl.MessageState `protogen:"open.v1"`
+	DurationSeconds        float64                `protobuf:"fixed64,1,opt,name=duration_seconds,json=durationSeconds,proto3" json:"duration_seconds,omitempty"`                     // Длительность сессии
+	Baseline               float64                `protobuf:"fixed64,2,opt,name=baseline,proto3" json:"baseline,omitempty"`                                                          // Базальный ритм, уд/мин (0 - не определен)
+	Variability            float64                `protobuf:"fixed64,3,opt,name=variability,proto3" json:"variability,omitempty"`                                                    // Амплитуда вариабельности, уд/мин
+	VariabilityBand        string                 `protobuf:"bytes,4,opt,name=variability_band,json=variabilityBand,proto3" json:"variability_band,omitempty"`                       // reduced, normal, increased
+	Accelerations          int32                  `protobuf:"varint,5,opt,name=accelerations,proto3" json:"accelerations,omitempty"`                                                 // Акцелерации
+	EarlyDecelerations     int32                  `protobuf:"varint,6,opt,name=early_decelerations,json=earlyDecelerations,proto3" json:"early_decelerations,omitempty"`             // Ранние децелерации
+	LateDecelerations      int32                  `protobuf:"varint,7,opt,name=late_decelerations,json=lateDecelerations,proto3" json:"late_decelerations,omitempty"`                // Поздние децелерации
+	VariableDecelerations  int32                  `protobuf:"varint,8,opt,name=variable_decelerations,json=variableDecelerations,proto3" json:"variable_decelerations,omitempty"`    // Вариабельные децелерации
+	ProlongedDecelerations int32                  `protobuf:"varint,9,opt,name=prolonged_decelerations,json=prolongedDecelerations,proto3" json:"prolonged_decelerations,omitempty"` // Пролонгированные децелерации
+	Contractions           int32                  `protobuf:"varint,10,opt,name=contractions,proto3" json:"contractions,omitempty"`                                                  // Схватки
+	ContractionFrequency   float64                `protobuf:"fixed64,11,opt,name=contraction_frequency,json=contractionFrequency,proto3" json:"contraction_frequency,omitempty"`     // Схваток за 10 минут в среднем за сессию
+	SignalQuality          float64                `protobuf:"fixed64,12,opt,name=signal_quality,json=signalQuality,proto3" json:"signal_quality,omitempty"`                          // Доля точек ЧСС с сигналом, %
+	SpikesCorrected        int32                  `protobuf:"varint,13,opt,name=spikes_corrected,json=spikesCorrected,proto3" json:"spikes_corrected,omitempty"`                     // Выбросов, замененных фильтрами, по всем каналам
+	Alarms                 int32                  `protobuf:"varint,14,opt,name=alarms,proto3" json:"alarms,omitempty"`                                                              // Поднятых тревог
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *SessionSummary) Reset() {
+	*x = SessionSummary{}
+	mi := &file_medicine_card_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionSummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionSummary) ProtoMessage() {}
+
+func (x *SessionSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_medicine_card_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionSummary.ProtoReflect.Descriptor instead.
+func (*SessionSummary) Descriptor() ([]byte, []int) {
+	return file_medicine_card_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SessionSummary) GetDurationSeconds() float64 {
+	if x != nil {
+		return x.DurationSeconds
+	}
+	return 0
+}
+
+func (x *SessionSummary) GetBaseline() float64 {
+	if x != nil {
+		return x.Baseline
+	}
+	return 0
+}
+
+func (x *SessionSummary) GetVariability() float64 {
+	if x != nil {
+		return x.Variability
+	}
+	return 0
+}
+
+func (x *SessionSummary) GetVariabilityBand() string {
+	if x != nil {
+		return x.VariabilityBand
+	}
+	return ""
+}
+
+func (x *SessionSummary) GetAccelerations() int32 {
+	if x != nil {
+		return x.Accelerations
+	}
+	return 0
+}
+
+func (x *SessionSummary) GetEarlyDecelerations() int32 {
+	if x != nil {
+		return x.EarlyDecelerations
+	}
+	return 0
+}
+
+func (x *SessionSummary) GetLateDecelerations() int32 {
+	if x != nil {
+		return x.LateDecelerations
+	}
+	return 0
+}
+
+func (x *SessionSummary) GetVariableDecelerations() int32 {
+	if x != nil {
+		return x.VariableDecelerations
+	}
+	return 0
+}
+
+func (x *SessionSummary) GetProlongedDecelerations() int32 {
+	if x != nil {
+		return x.ProlongedDecelerations
+	}
+	return 0
+}
+
+func (x *SessionSummary) GetContractions() int32 {
+	if x != nil {
+		return x.Contractions
+	}
+	return 0
+}
+
+func (x *SessionSummary) GetContractionFrequency() float64 {
+	if x != nil {
+		return x.ContractionFrequency
+	}
+	return 0
+}
+
+func (x *SessionSummary) GetSignalQuality() float64 {
+	if x != nil {
+		return x.SignalQuality
+	}
+	return 0
+}
+
+func (x *SessionSummary) GetSpikesCorrected() int32 {
+	if x != nil {
+		return x.SpikesCorrected
+	}
+	return 0
+}
+
+func (x *SessionSummary) GetAlarms() int32 {
+	if x != nil {
+		return x.Alarms
+	}
+	return 0
+}
+
+// Заключение теста по протоколу (НСТ, СТ)
+type ProtocolResult struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Protocol          string                 `protobuf:"bytes,1,opt,name=protocol,proto3" json:"protocol,omitempty"`                                             // nst, cst
+	Outcome           string                 `protobuf:"bytes,2,opt,name=outcome,proto3" json:"outcome,omitempty"`                                               // reactive, non_reactive, negative, positive, equivocal, unsatisfactory, incomplete
+	Reason            string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`                                                 // criteria_met - критерии выполнены, time_limit - истекло время, manual - остановлен вручную, interrupted - закрыт после перезапуска сервиса
+	DurationSeconds   float64                `protobuf:"fixed64,4,opt,name=duration_seconds,json=durationSeconds,proto3" json:"duration_seconds,omitempty"`      // Длительность записи по протоколу
+	Accelerations     int32                  `protobuf:"varint,5,opt,name=accelerations,proto3" json:"accelerations,omitempty"`                                  // Акцелерации за время теста
+	Contractions      int32                  `protobuf:"varint,6,opt,name=contractions,proto3" json:"contractions,omitempty"`                                    // Схватки, по которым оценивался тест
+	LateDecelerations int32                  `protobuf:"varint,7,opt,name=late_decelerations,json=lateDecelerations,proto3" json:"late_decelerations,omitempty"` // Схватки с поздней децелерацией
+	Summary           string                 `protobuf:"bytes,8,opt,name=summary,proto3" json:"summary,omitempty"`                                               // Текст заключения
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *ProtocolResult) Reset() {
+	*x = ProtocolResult{}
+	mi := &file_medicine_card_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProtocolResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProtocolResult) ProtoMessage() {}
+
+func (x *ProtocolResult) ProtoReflect() protoreflect.Message {
+	mi := &file_medicine_card_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProtocolResult.ProtoReflect.Descriptor instead.
+func (*ProtocolResult) Descriptor() ([]byte, []int) {
+	return file_medicine_card_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ProtocolResult) GetProtocol() string {
+	if x != nil {
+		return x.Protocol
+	}
+	return ""
+}
+
+func (x *ProtocolResult) GetOutcome() string {
+	if x != nil {
+		return x.Outcome
+	}
+	return ""
+}
+
+func (x *ProtocolResult) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *ProtocolResult) GetDurationSeconds() float64 {
+	if x != nil {
+		return x.DurationSeconds
+	}
+	return 0
+}
+
+func (x *ProtocolResult) GetAccelerations() int32 {
+	if x != nil {
+		return x.Accelerations
+	}
+	return 0
+}
+
+func (x *ProtocolResult) GetContractions() int32 {
+	if x != nil {
+		return x.Contractions
+	}
+	return 0
+}
+
+func (x *ProtocolResult) GetLateDecelerations() int32 {
+	if x != nil {
+		return x.LateDecelerations
+	}
+	return 0
+}
+
+func (x *ProtocolResult) GetSummary() string {
+	if x != nil {
+		return x.Summary
+	}
+	return ""
+}
+
+// Интервал, когда канал ЧСС плода совпадал с другим каналом
+type CoincidenceInterval struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Kind          string                 `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"`                           // same_heart - оба датчика на одном сердце, maternal_hr - записывается ЧСС матери
+	DataType      string                 `protobuf:"bytes,2,opt,name=data_type,json=dataType,proto3" json:"data_type,omitempty"`   // Канал, точки которого помечены
+	FromTime      float64                `protobuf:"fixed64,3,opt,name=from_time,json=fromTime,proto3" json:"from_time,omitempty"` // Начало интервала, с от начала сессии
+	ToTime        float64                `protobuf:"fixed64,4,opt,name=to_time,json=toTime,proto3" json:"to_time,omitempty"`       // Конец интервала, с от начала сессии
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CoincidenceInterval) Reset() {
+	*x = CoincidenceInterval{}
+	mi := &file_medicine_card_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CoincidenceInterval) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CoincidenceInterval) ProtoMessage() {}
+
+func (x *CoincidenceInterval) ProtoReflect() protoreflect.Message {
+	mi := &file_medicine_card_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CoincidenceInterval.ProtoReflect.Descriptor instead.
+func (*CoincidenceInterval) Descriptor() ([]byte, []int) {
+	return file_medicine_card_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CoincidenceInterval) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *CoincidenceInterval) GetDataType() string {
+	if x != nil {
+		return x.DataType
+	}
+	return ""
+}
+
+func (x *CoincidenceInterval) GetFromTime() float64 {
+	if x != nil {
+		return x.FromTime
+	}
+	return 0
+}
+
+func (x *CoincidenceInterval) GetToTime() float64 {
+	if x != nil {
+		return x.ToTime
+	}
+	return 0
+}
+
 // Точка данных КТГ
 type CTGDataPoint struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	TimeSec       float64                `protobuf:"fixed64,1,opt,name=time_sec,json=timeSec,proto3" json:"time_sec,omitempty"` // Время в секундах от начала сессии
-	Value         float64                `protobuf:"fixed64,2,opt,name=value,proto3" json:"value,omitempty"`                    // Значение (FHR или UC)
+	TimeSec       float64                `protobuf:"fixed64,1,opt,name=time_sec,json=timeSec,proto3" json:"time_sec,omitempty"`    // Время в секундах от начала сессии
+	Value         float64                `protobuf:"fixed64,2,opt,name=value,proto3" json:"value,omitempty"`                       // Очищенное значение (FHR или UC)
+	RawValue      float64                `protobuf:"fixed64,3,opt,name=raw_value,json=rawValue,proto3" json:"raw_value,omitempty"` // Значение, которое прислало устройство
+	Flags         []string               `protobuf:"bytes,4,rep,name=flags,proto3" json:"flags,omitempty"`                         // Причины изменения значения
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *CTGDataPoint) Reset() {
 	*x = CTGDataPoint{}
-	mi := &file_medicine_card_proto_msgTypes[1]
+	mi := &file_medicine_card_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -161,7 +522,7 @@ func (x *CTGDataPoint) String() string {
 func (*CTGDataPoint) ProtoMessage() {}
 
 func (x *CTGDataPoint) ProtoReflect() protoreflect.Message {
-	mi := &file_medicine_card_proto_msgTypes[1]
+	mi := &file_medicine_card_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -174,7 +535,7 @@ func (x *CTGDataPoint) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CTGDataPoint.ProtoReflect.Descriptor instead.
 func (*CTGDataPoint) Descriptor() ([]byte, []int) {
-	return file_medicine_card_proto_rawDescGZIP(), []int{1}
+	return file_medicine_card_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *CTGDataPoint) GetTimeSec() float64 {
@@ -191,6 +552,20 @@ func (x *CTGDataPoint) GetValue() float64 {
 	return 0
 }
 
+func (x *CTGDataPoint) GetRawValue() float64 {
+	if x != nil {
+		return x.RawValue
+	}
+	return 0
+}
+
+func (x *CTGDataPoint) GetFlags() []string {
+	if x != nil {
+		return x.Flags
+	}
+	return nil
+}
+
 // Ответ на сохранение сессии
 type SaveSessionResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -203,7 +578,7 @@ type SaveSessionResponse struct {
 
 func (x *SaveSessionResponse) Reset() {
 	*x = SaveSessionResponse{}
-	mi := &file_medicine_card_proto_msgTypes[2]
+	mi := &file_medicine_card_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -215,7 +590,7 @@ func (x *SaveSessionResponse) String() string {
 func (*SaveSessionResponse) ProtoMessage() {}
 
 func (x *SaveSessionResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_medicine_card_proto_msgTypes[2]
+	mi := &file_medicine_card_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -228,7 +603,7 @@ func (x *SaveSessionResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SaveSessionResponse.ProtoReflect.Descriptor instead.
 func (*SaveSessionResponse) Descriptor() ([]byte, []int) {
-	return file_medicine_card_proto_rawDescGZIP(), []int{2}
+	return file_medicine_card_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *SaveSessionResponse) GetSuccess() bool {
@@ -256,7 +631,7 @@ var File_medicine_card_proto protoreflect.FileDescriptor
 
 const file_medicine_card_proto_rawDesc = "" +
 	"\n" +
-	"\x13medicine_card.proto\x12\x0fmedical_records\"\x91\x03\n" +
+	"\x13medicine_card.proto\x12\x0fmedical_records\"\xc8\x05\n" +
 	"\x11CTGSessionRequest\x12\x1d\n" +
 	"\n" +
 	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x17\n" +
@@ -270,10 +645,47 @@ const file_medicine_card_proto_rawDesc = "" +
 	"\auc_data\x18\b \x03(\v2\x1d.medical_records.CTGDataPointR\x06ucData\x12(\n" +
 	"\x10total_fhr_points\x18\t \x01(\x05R\x0etotalFhrPoints\x12&\n" +
 	"\x0ftotal_uc_points\x18\n" +
-	" \x01(\x05R\rtotalUcPoints\"?\n" +
+	" \x01(\x05R\rtotalUcPoints\x12:\n" +
+	"\tfhr2_data\x18\v \x03(\v2\x1d.medical_records.CTGDataPointR\bfhr2Data\x12*\n" +
+	"\x11total_fhr2_points\x18\f \x01(\x05R\x0ftotalFhr2Points\x12H\n" +
+	"\fcoincidences\x18\r \x03(\v2$.medical_records.CoincidenceIntervalR\fcoincidences\x12H\n" +
+	"\x0fprotocol_result\x18\x0e \x01(\v2\x1f.medical_records.ProtocolResultR\x0eprotocolResult\x129\n" +
+	"\asummary\x18\x0f \x01(\v2\x1f.medical_records.SessionSummaryR\asummary\"\xdd\x04\n" +
+	"\x0eSessionSummary\x12)\n" +
+	"\x10duration_seconds\x18\x01 \x01(\x01R\x0fdurationSeconds\x12\x1a\n" +
+	"\bbaseline\x18\x02 \x01(\x01R\bbaseline\x12 \n" +
+	"\vvariability\x18\x03 \x01(\x01R\vvariability\x12)\n" +
+	"\x10variability_band\x18\x04 \x01(\tR\x0fvariabilityBand\x12$\n" +
+	"\raccelerations\x18\x05 \x01(\x05R\raccelerations\x12/\n" +
+	"\x13early_decelerations\x18\x06 \x01(\x05R\x12earlyDecelerations\x12-\n" +
+	"\x12late_decelerations\x18\a \x01(\x05R\x11lateDecelerations\x125\n" +
+	"\x16variable_decelerations\x18\b \x01(\x05R\x15variableDecelerations\x127\n" +
+	"\x17prolonged_decelerations\x18\t \x01(\x05R\x16prolongedDecelerations\x12\"\n" +
+	"\fcontractions\x18\n" +
+	" \x01(\x05R\fcontractions\x123\n" +
+	"\x15contraction_frequency\x18\v \x01(\x01R\x14contractionFrequency\x12%\n" +
+	"\x0esignal_quality\x18\f \x01(\x01R\rsignalQuality\x12)\n" +
+	"\x10spikes_corrected\x18\r \x01(\x05R\x0fspikesCorrected\x12\x16\n" +
+	"\x06alarms\x18\x0e \x01(\x05R\x06alarms\"\x9c\x02\n" +
+	"\x0eProtocolResult\x12\x1a\n" +
+	"\bprotocol\x18\x01 \x01(\tR\bprotocol\x12\x18\n" +
+	"\aoutcome\x18\x02 \x01(\tR\aoutcome\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\x12)\n" +
+	"\x10duration_seconds\x18\x04 \x01(\x01R\x0fdurationSeconds\x12$\n" +
+	"\raccelerations\x18\x05 \x01(\x05R\raccelerations\x12\"\n" +
+	"\fcontractions\x18\x06 \x01(\x05R\fcontractions\x12-\n" +
+	"\x12late_decelerations\x18\a \x01(\x05R\x11lateDecelerations\x12\x18\n" +
+	"\asummary\x18\b \x01(\tR\asummary\"|\n" +
+	"\x13CoincidenceInterval\x12\x12\n" +
+	"\x04kind\x18\x01 \x01(\tR\x04kind\x12\x1b\n" +
+	"\tdata_type\x18\x02 \x01(\tR\bdataType\x12\x1b\n" +
+	"\tfrom_time\x18\x03 \x01(\x01R\bfromTime\x12\x17\n" +
+	"\ato_time\x18\x04 \x01(\x01R\x06toTime\"r\n" +
 	"\fCTGDataPoint\x12\x19\n" +
 	"\btime_sec\x18\x01 \x01(\x01R\atimeSec\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\x01R\x05value\"f\n" +
+	"\x05value\x18\x02 \x01(\x01R\x05value\x12\x1b\n" +
+	"\traw_value\x18\x03 \x01(\x01R\brawValue\x12\x14\n" +
+	"\x05flags\x18\x04 \x03(\tR\x05flags\"f\n" +
 	"\x13SaveSessionResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
 	"\amessage\x18\x02 \x01(\tR\amessage\x12\x1b\n" +
@@ -293,22 +705,29 @@ func file_medicine_card_proto_rawDescGZIP() []byte {
 	return file_medicine_card_proto_rawDescData
 }
 
-var file_medicine_card_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_medicine_card_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
 var file_medicine_card_proto_goTypes = []any{
 	(*CTGSessionRequest)(nil),   // 0: medical_records.CTGSessionRequest
-	(*CTGDataPoint)(nil),        // 1: medical_records.CTGDataPoint
-	(*SaveSessionResponse)(nil), // 2: medical_records.SaveSessionResponse
+	(*SessionSummary)(nil),      // 1: medical_records.SessionSummary
+	(*ProtocolResult)(nil),      // 2: medical_records.ProtocolResult
+	(*CoincidenceInterval)(nil), // 3: medical_records.CoincidenceInterval
+	(*CTGDataPoint)(nil),        // 4: medical_records.CTGDataPoint
+	(*SaveSessionResponse)(nil), // 5: medical_records.SaveSessionResponse
 }
 var file_medicine_card_proto_depIdxs = []int32{
-	1, // 0: medical_records.CTGSessionRequest.fhr_data:type_name -> medical_records.CTGDataPoint
-	1, // 1: medical_records.CTGSessionRequest.uc_data:type_name -> medical_records.CTGDataPoint
-	0, // 2: medical_records.MedicalRecordsService.SaveCTGSession:input_type -> medical_records.CTGSessionRequest
-	2, // 3: medical_records.MedicalRecordsService.SaveCTGSession:output_type -> medical_records.SaveSessionResponse
-	3, // [3:4] is the sub-list for method output_type
-	2, // [2:3] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	4, // 0: medical_records.CTGSessionRequest.fhr_data:type_name -> medical_records.CTGDataPoint
+	4, // 1: medical_records.CTGSessionRequest.uc_data:type_name -> medical_records.CTGDataPoint
+	4, // 2: medical_records.CTGSessionRequest.fhr2_data:type_name -> medical_records.CTGDataPoint
+	3, // 3: medical_records.CTGSessionRequest.coincidences:type_name -> medical_records.CoincidenceInterval
+	2, // 4: medical_records.CTGSessionRequest.protocol_result:type_name -> medical_records.ProtocolResult
+	1, // 5: medical_records.CTGSessionRequest.summary:type_name -> medical_records.SessionSummary
+	0, // 6: medical_records.MedicalRecordsService.SaveCTGSession:input_type -> medical_records.CTGSessionRequest
+	5, // 7: medical_records.MedicalRecordsService.SaveCTGSession:output_type -> medical_records.SaveSessionResponse
+	7, // [7:8] is the sub-list for method output_type
+	6, // [6:7] is the sub-list for method input_type
+	6, // [6:6] is the sub-list for extension type_name
+	6, // [6:6] is the sub-list for extension extendee
+	0, // [0:6] is the sub-list for field type_name
 }
 
 func init() { file_medicine_card_proto_init() }
@@ -322,7 +741,7 @@ func file_medicine_card_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_medicine_card_proto_rawDesc), len(file_medicine_card_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   3,
+			NumMessages:   6,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
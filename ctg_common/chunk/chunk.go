@@ -0,0 +1,62 @@
+// Package chunk формат блоков точек в таблице ctg_chunks. Блок - последовательность
+// кадров; каждая запись дописывает в блок кадр со своими точками, не переписывая
+// предыдущие. Кадр: байт кодировки, длина содержимого (uvarint), содержимое.
+//
+// Пакет общий для CTG_monitor и ml-service (модуль ctg_common).
+package chunk
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Кодировки содержимого кадра
+const (
+	EncodingJSON  byte = 1 // JSON массив точек {"t", "v", ...}
+	EncodingCodec byte = 2 // блок pkg/codec с временем и значениями, за ним дополнительные поля точек
+)
+
+// Span длительность блока на шкале сессии, с. Точки блока лежат в
+// [Bucket*Span, (Bucket+1)*Span).
+const Span = 600.0
+
+// ErrCorrupt блок не разбирается на кадры
+var ErrCorrupt = errors.New("поврежденный блок точек")
+
+// Frame кадр блока
+type Frame struct {
+	Encoding byte
+	Payload  []byte
+}
+
+// Bucket номер блока, в который попадает точка со временем t
+func Bucket(t float64) int64 {
+	return int64(math.Floor(t / Span))
+}
+
+// AppendFrame дописывает кадр в конец блока
+func AppendFrame(dst []byte, encoding byte, payload []byte) []byte {
+	dst = append(dst, encoding)
+	dst = binary.AppendUvarint(dst, uint64(len(payload)))
+	return append(dst, payload...)
+}
+
+// Frames разбирает блок на кадры в порядке записи. Содержимое кадров
+// ссылается на data.
+func Frames(data []byte) ([]Frame, error) {
+	var frames []Frame
+	for offset := 0; offset < len(data); {
+		encoding := data[offset]
+		offset++
+		size, n := binary.Uvarint(data[offset:])
+		if n <= 0 || size > uint64(len(data)-offset-n) {
+			return nil, fmt.Errorf("%w: кадр %d", ErrCorrupt, len(frames))
+		}
+		offset += n
+		frames = append(frames, Frame{Encoding: encoding, Payload: data[offset : offset+int(size)]})
+		offset += int(size)
+	}
+	return frames, nil
+}
@@ -0,0 +1,151 @@
+package chunk
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"ctg_common/codec"
+)
+
+// Point точка кадра. Поля и JSON совпадают с точкой CTG_monitor (models.CTGPoint).
+type Point struct {
+	T float64  `json:"t"`           // время на шкале сессии, с
+	V float64  `json:"v"`           // очищенное значение
+	R *float64 `json:"r,omitempty"` // исходное значение устройства, если отличается от V
+	F uint16   `json:"f,omitempty"` // флаги изменения или пометки точки
+	W int64    `json:"w,omitempty"` // абсолютное время измерения UTC, мс Unix (0 - нет)
+}
+
+// AppendPoints кодирует точки и дописывает кадр в конец блока. opts используются
+// только кодировкой EncodingCodec.
+//
+// Кадр codec: блок codec с временем и значениями, блок codec с абсолютным временем
+// (мс, 0 - нет), затем точки с флагами или исходным значением: число таких точек,
+// для каждой - разность номера с предыдущей, флаги и, если есть, исходное значение.
+func AppendPoints(dst []byte, points []Point, encoding byte, opts codec.Options) ([]byte, error) {
+	switch encoding {
+	case EncodingJSON:
+		payload, err := json.Marshal(points)
+		if err != nil {
+			return nil, err
+		}
+		return AppendFrame(dst, EncodingJSON, payload), nil
+	case EncodingCodec:
+	default:
+		return nil, fmt.Errorf("неизвестная кодировка %d", encoding)
+	}
+
+	times := make([]float64, len(points))
+	values := make([]float64, len(points))
+	walls := make([]int64, len(points))
+	var marked []int
+	for i, point := range points {
+		times[i], values[i], walls[i] = point.T, point.V, point.W
+		if point.F != 0 || point.R != nil {
+			marked = append(marked, i)
+		}
+	}
+
+	payload, err := codec.Append(nil, times, values, opts)
+	if err != nil {
+		return nil, err
+	}
+	payload = codec.AppendInts(payload, walls)
+	payload = binary.AppendUvarint(payload, uint64(len(marked)))
+	prev := 0
+	for _, i := range marked {
+		payload = binary.AppendUvarint(payload, uint64(i-prev))
+		payload = binary.AppendUvarint(payload, uint64(points[i].F))
+		if raw := points[i].R; raw != nil {
+			payload = append(payload, 1)
+			payload = binary.LittleEndian.AppendUint64(payload, math.Float64bits(*raw))
+		} else {
+			payload = append(payload, 0)
+		}
+		prev = i
+	}
+	return AppendFrame(dst, EncodingCodec, payload), nil
+}
+
+// Decode разбирает точки всех кадров блока в порядке записи
+func Decode(data []byte) ([]Point, error) {
+	frames, err := Frames(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var points []Point
+	for _, frame := range frames {
+		var framePoints []Point
+		switch frame.Encoding {
+		case EncodingJSON:
+			err = json.Unmarshal(frame.Payload, &framePoints)
+		case EncodingCodec:
+			framePoints, err = decodeCodec(frame.Payload)
+		default:
+			err = fmt.Errorf("неизвестная кодировка %d", frame.Encoding)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+		}
+		points = append(points, framePoints...)
+	}
+	return points, nil
+}
+
+// decodeCodec разбирает содержимое кадра codec
+func decodeCodec(payload []byte) ([]Point, error) {
+	times, values, rest, err := codec.Decode(payload)
+	if err != nil {
+		return nil, err
+	}
+	walls, rest, err := codec.DecodeInts(rest)
+	if err != nil {
+		return nil, err
+	}
+	if len(walls) != len(times) {
+		return nil, codec.ErrCorrupt
+	}
+
+	points := make([]Point, len(times))
+	for i := range points {
+		points[i] = Point{T: times[i], V: values[i], W: walls[i]}
+	}
+
+	marked, n := binary.Uvarint(rest)
+	if n <= 0 {
+		return nil, codec.ErrCorrupt
+	}
+	rest = rest[n:]
+	index := 0
+	for ; marked > 0; marked-- {
+		delta, n := binary.Uvarint(rest)
+		if n <= 0 {
+			return nil, codec.ErrCorrupt
+		}
+		rest = rest[n:]
+		flags, n := binary.Uvarint(rest)
+		if n <= 0 || len(rest) <= n || flags > math.MaxUint16 {
+			return nil, codec.ErrCorrupt
+		}
+		hasRaw := rest[n] == 1
+		rest = rest[n+1:]
+
+		index += int(delta)
+		if index >= len(points) {
+			return nil, codec.ErrCorrupt
+		}
+		points[index].F = uint16(flags)
+		if hasRaw {
+			if len(rest) < 8 {
+				return nil, codec.ErrCorrupt
+			}
+			raw := math.Float64frombits(binary.LittleEndian.Uint64(rest))
+			points[index].R = &raw
+			rest = rest[8:]
+		}
+	}
+	return points, nil
+}
@@ -0,0 +1,60 @@
+package chunk
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"ctg_common/codec"
+)
+
+func TestPointsRoundTrip(t *testing.T) {
+	raw := 280.0
+	points := []Point{
+		{T: 0, V: 140, W: 1760000000000},
+		{T: 0.25, V: 140.5, W: 1760000000250},
+		{T: 0.5, V: -1, F: 1 << 4, W: 1760000000500},
+		{T: 0.75, V: 140, R: &raw, F: 1 << 2},
+		{T: 1, V: 141},
+	}
+	for _, encoding := range []byte{EncodingJSON, EncodingCodec} {
+		// Два кадра в одном блоке, как после двух записей
+		var data []byte
+		var err error
+		for _, part := range [][]Point{points[:2], points[2:]} {
+			if data, err = AppendPoints(data, part, encoding, codec.Options{}); err != nil {
+				t.Fatalf("кодировка %d: не удалось закодировать: %v", encoding, err)
+			}
+		}
+		decoded, err := Decode(data)
+		if err != nil {
+			t.Fatalf("кодировка %d: не удалось разобрать: %v", encoding, err)
+		}
+		if !reflect.DeepEqual(decoded, points) {
+			t.Errorf("кодировка %d: точки %+v, ожидались %+v", encoding, decoded, points)
+		}
+	}
+}
+
+func TestDecodeCorrupt(t *testing.T) {
+	valid, err := AppendPoints(nil, []Point{{T: 0, V: 140}}, EncodingCodec, codec.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name string
+		data []byte
+	}{
+		{"обрезанный кадр", valid[:len(valid)-1]},
+		{"неизвестная кодировка", AppendFrame(nil, 9, []byte{1})},
+		{"поврежденный JSON", AppendFrame(nil, EncodingJSON, []byte("[{"))},
+		{"поврежденный codec", AppendFrame(nil, EncodingCodec, []byte{0xff})},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if _, err := Decode(c.data); !errors.Is(err, ErrCorrupt) {
+				t.Errorf("ошибка %v, ожидалась ErrCorrupt", err)
+			}
+		})
+	}
+}
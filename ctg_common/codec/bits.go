@@ -0,0 +1,64 @@
+package codec
+
+// bitWriter пишет биты старшими вперед
+type bitWriter struct {
+	buf  []byte
+	free uint // свободных бит в последнем байте
+}
+
+// writeBit пишет один бит
+func (w *bitWriter) writeBit(bit bool) {
+	if bit {
+		w.writeBits(1, 1)
+	} else {
+		w.writeBits(0, 1)
+	}
+}
+
+// writeBits пишет n младших бит v
+func (w *bitWriter) writeBits(v uint64, n uint) {
+	for n > 0 {
+		if w.free == 0 {
+			w.buf = append(w.buf, 0)
+			w.free = 8
+		}
+		k := min(n, w.free)
+		bits := byte(v>>(n-k)) & byte(1<<k-1)
+		w.buf[len(w.buf)-1] |= bits << (w.free - k)
+		w.free -= k
+		n -= k
+	}
+}
+
+// bitReader читает биты, записанные bitWriter
+type bitReader struct {
+	buf  []byte
+	pos  int  // текущий байт
+	used uint // прочитано бит текущего байта
+}
+
+// readBit читает один бит
+func (r *bitReader) readBit() (bool, error) {
+	v, err := r.readBits(1)
+	return v == 1, err
+}
+
+// readBits читает n бит
+func (r *bitReader) readBits(n uint) (uint64, error) {
+	var v uint64
+	for n > 0 {
+		if r.pos >= len(r.buf) {
+			return 0, ErrCorrupt
+		}
+		k := min(n, 8-r.used)
+		bits := r.buf[r.pos] >> (8 - r.used - k) & byte(1<<k-1)
+		v = v<<k | uint64(bits)
+		r.used += k
+		n -= k
+		if r.used == 8 {
+			r.pos++
+			r.used = 0
+		}
+	}
+	return v, nil
+}
@@ -0,0 +1,383 @@
+// Package codec компактная двоичная кодировка рядов (время, значение) в духе
+// Gorilla: время хранится разностями второго порядка (delta-of-delta), значения -
+// XOR с предыдущим значением или, при квантовании, разностями целых.
+// Равномерный ряд с повторяющимися значениями занимает около 2 бит на точку.
+//
+// Пакет общий для CTG_monitor и ml-service (модуль ctg_common).
+package codec
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/bits"
+)
+
+// DefaultTimeResolution шаг времени по умолчанию: 1 мс
+const DefaultTimeResolution = 1000
+
+// version версия формата блока
+const version = 1
+
+// Кодировка значений
+const (
+	modeXOR   = 0 // float64 без потерь, XOR с предыдущим значением
+	modeFixed = 1 // целые число шагов 1/ValueScale, разности
+)
+
+var (
+	// ErrCorrupt блок не разбирается
+	ErrCorrupt = errors.New("поврежденный блок codec")
+	// ErrNotFinite значение нельзя квантовать
+	ErrNotFinite = errors.New("квантуемое значение не конечно")
+)
+
+// Options параметры кодирования
+type Options struct {
+	// Шагов времени в секунде; время округляется до шага. 0 - DefaultTimeResolution
+	TimeResolution int64
+	// Шагов значения в единице (4 - шаг 0,25; 100 - шаг 0,01); значения
+	// округляются до шага. 0 - значения без потерь
+	ValueScale float64
+}
+
+// withDefaults заполняет незаданные параметры
+func (o Options) withDefaults() Options {
+	if o.TimeResolution <= 0 {
+		o.TimeResolution = DefaultTimeResolution
+	}
+	if o.ValueScale < 0 {
+		o.ValueScale = 0
+	}
+	return o
+}
+
+// Append кодирует ряд и дописывает блок в dst. Длины times и values должны совпадать.
+//
+// Блок: версия, режим значений, число точек, шагов времени в секунде,
+// шагов значения в единице (только при квантовании), длина и биты ряда.
+func Append(dst []byte, times, values []float64, opts Options) ([]byte, error) {
+	if len(times) != len(values) {
+		return nil, errors.New("длины рядов времени и значений различаются")
+	}
+	opts = opts.withDefaults()
+
+	mode := byte(modeXOR)
+	if opts.ValueScale > 0 {
+		mode = modeFixed
+	}
+	dst = append(dst, version, mode)
+	dst = binary.AppendUvarint(dst, uint64(len(times)))
+	dst = binary.AppendUvarint(dst, uint64(opts.TimeResolution))
+	if mode == modeFixed {
+		dst = binary.LittleEndian.AppendUint64(dst, math.Float64bits(opts.ValueScale))
+	}
+
+	var w bitWriter
+	ticks := make([]int64, len(times))
+	for i, t := range times {
+		ticks[i] = int64(math.Round(t * float64(opts.TimeResolution)))
+	}
+	writeDeltas(&w, ticks, 2)
+
+	if mode == modeFixed {
+		steps := make([]int64, len(values))
+		for i, v := range values {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				return nil, ErrNotFinite
+			}
+			steps[i] = int64(math.Round(v * opts.ValueScale))
+		}
+		writeDeltas(&w, steps, 1)
+	} else {
+		writeXOR(&w, values)
+	}
+
+	dst = binary.AppendUvarint(dst, uint64(len(w.buf)))
+	return append(dst, w.buf...), nil
+}
+
+// Decode разбирает блок в начале data и возвращает ряд и остаток data после блока
+func Decode(data []byte) (times, values []float64, rest []byte, err error) {
+	if len(data) < 2 || data[0] != version || data[1] > modeFixed {
+		return nil, nil, nil, ErrCorrupt
+	}
+	mode := data[1]
+	data = data[2:]
+
+	count, data, err := readUvarint(data)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	resolution, data, err := readUvarint(data)
+	if err != nil || resolution == 0 {
+		return nil, nil, nil, ErrCorrupt
+	}
+	var scale float64
+	if mode == modeFixed {
+		if len(data) < 8 {
+			return nil, nil, nil, ErrCorrupt
+		}
+		scale = math.Float64frombits(binary.LittleEndian.Uint64(data))
+		data = data[8:]
+	}
+	size, data, err := readUvarint(data)
+	if err != nil || size > uint64(len(data)) || count > size*8 {
+		return nil, nil, nil, ErrCorrupt
+	}
+	r := &bitReader{buf: data[:size]}
+	rest = data[size:]
+
+	ticks, err := readDeltas(r, int(count), 2)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	times = make([]float64, count)
+	for i, tick := range ticks {
+		times[i] = float64(tick) / float64(resolution)
+	}
+
+	if mode == modeFixed {
+		steps, err := readDeltas(r, int(count), 1)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		values = make([]float64, count)
+		for i, step := range steps {
+			values[i] = float64(step) / scale
+		}
+	} else if values, err = readXOR(r, int(count)); err != nil {
+		return nil, nil, nil, err
+	}
+	return times, values, rest, nil
+}
+
+// AppendInts кодирует ряд целых, меняющихся почти равномерно (например, абсолютное
+// время в мс), разностями второго порядка и дописывает блок в dst
+func AppendInts(dst []byte, values []int64) []byte {
+	var w bitWriter
+	writeDeltas(&w, values, 2)
+	dst = binary.AppendUvarint(dst, uint64(len(values)))
+	dst = binary.AppendUvarint(dst, uint64(len(w.buf)))
+	return append(dst, w.buf...)
+}
+
+// DecodeInts разбирает блок AppendInts в начале data и возвращает остаток data
+func DecodeInts(data []byte) ([]int64, []byte, error) {
+	count, data, err := readUvarint(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	size, data, err := readUvarint(data)
+	if err != nil || size > uint64(len(data)) || count > size*8 {
+		return nil, nil, ErrCorrupt
+	}
+	values, err := readDeltas(&bitReader{buf: data[:size]}, int(count), 2)
+	if err != nil {
+		return nil, nil, err
+	}
+	return values, data[size:], nil
+}
+
+// readUvarint читает uvarint в начале data
+func readUvarint(data []byte) (uint64, []byte, error) {
+	v, n := binary.Uvarint(data)
+	if n <= 0 {
+		return 0, nil, ErrCorrupt
+	}
+	return v, data[n:], nil
+}
+
+// Разрядности кодов разностей: '0' - ноль, '10' - 7 бит, '110' - 9 бит,
+// '1110' - 12 бит, '1111' - 64 бита
+var deltaWidths = []uint{7, 9, 12}
+
+// writeDeltas пишет первое значение целиком, а дальше разности порядка order
+// (1 - разности, 2 - разности разностей)
+func writeDeltas(w *bitWriter, values []int64, order int) {
+	var prev, prevDelta int64
+	for i, v := range values {
+		if i == 0 {
+			w.writeBits(uint64(v), 64)
+			prev = v
+			continue
+		}
+		delta := v - prev
+		d := delta
+		if order == 2 {
+			d = delta - prevDelta
+		}
+		writeDelta(w, d)
+		prev, prevDelta = v, delta
+	}
+}
+
+// writeDelta пишет разность кодом переменной длины
+func writeDelta(w *bitWriter, d int64) {
+	if d == 0 {
+		w.writeBit(false)
+		return
+	}
+	for i, width := range deltaWidths {
+		if d >= -(1<<(width-1)) && d < 1<<(width-1) {
+			w.writeBits(1<<(i+2)-2, uint(i+2)) // i+1 единиц и ноль
+			w.writeBits(uint64(d), width)
+			return
+		}
+	}
+	w.writeBits(0xF, 4)
+	w.writeBits(uint64(d), 64)
+}
+
+// readDeltas читает count значений, записанных writeDeltas
+func readDeltas(r *bitReader, count, order int) ([]int64, error) {
+	values := make([]int64, count)
+	var prev, prevDelta int64
+	for i := range values {
+		if i == 0 {
+			v, err := r.readBits(64)
+			if err != nil {
+				return nil, err
+			}
+			values[0], prev = int64(v), int64(v)
+			continue
+		}
+		d, err := readDelta(r)
+		if err != nil {
+			return nil, err
+		}
+		delta := d
+		if order == 2 {
+			delta = prevDelta + d
+		}
+		prev += delta
+		values[i], prevDelta = prev, delta
+	}
+	return values, nil
+}
+
+// readDelta читает разность, записанную writeDelta
+func readDelta(r *bitReader) (int64, error) {
+	var ones int
+	for ones < len(deltaWidths)+1 {
+		bit, err := r.readBit()
+		if err != nil {
+			return 0, err
+		}
+		if !bit {
+			break
+		}
+		ones++
+	}
+	if ones == 0 {
+		return 0, nil
+	}
+
+	width := uint(64)
+	if ones <= len(deltaWidths) {
+		width = deltaWidths[ones-1]
+	}
+	v, err := r.readBits(width)
+	if err != nil {
+		return 0, err
+	}
+	// Знаковое расширение числа из width бит
+	shift := 64 - width
+	return int64(v<<shift) >> shift, nil
+}
+
+// writeXOR пишет значения XOR с предыдущим: '0' - значение повторилось,
+// '10' - значащие биты помещаются в окно предыдущего значения,
+// '11' - 5 бит ведущих нулей, 6 бит длины и значащие биты
+func writeXOR(w *bitWriter, values []float64) {
+	var prev uint64
+	leading, trailing := uint(math.MaxUint8), uint(0)
+	for i, value := range values {
+		v := math.Float64bits(value)
+		if i == 0 {
+			w.writeBits(v, 64)
+			prev = v
+			continue
+		}
+
+		xor := v ^ prev
+		prev = v
+		if xor == 0 {
+			w.writeBit(false)
+			continue
+		}
+		w.writeBit(true)
+
+		lead := min(uint(bits.LeadingZeros64(xor)), 31)
+		trail := uint(bits.TrailingZeros64(xor))
+		if leading != math.MaxUint8 && lead >= leading && trail >= trailing {
+			w.writeBit(false)
+			w.writeBits(xor>>trailing, 64-leading-trailing)
+			continue
+		}
+
+		leading, trailing = lead, trail
+		significant := 64 - lead - trail
+		w.writeBit(true)
+		w.writeBits(uint64(lead), 5)
+		w.writeBits(uint64(significant&63), 6) // 64 значащих бита записываются как 0
+		w.writeBits(xor>>trail, significant)
+	}
+}
+
+// readXOR читает count значений, записанных writeXOR
+func readXOR(r *bitReader, count int) ([]float64, error) {
+	values := make([]float64, count)
+	var prev uint64
+	var leading, trailing uint
+	window := false // окно значащих бит уже задано
+	for i := range values {
+		if i == 0 {
+			v, err := r.readBits(64)
+			if err != nil {
+				return nil, err
+			}
+			prev = v
+			values[0] = math.Float64frombits(v)
+			continue
+		}
+
+		changed, err := r.readBit()
+		if err != nil {
+			return nil, err
+		}
+		if changed {
+			newWindow, err := r.readBit()
+			if err != nil {
+				return nil, err
+			}
+			if newWindow {
+				lead, err := r.readBits(5)
+				if err != nil {
+					return nil, err
+				}
+				significant, err := r.readBits(6)
+				if err != nil {
+					return nil, err
+				}
+				if significant == 0 {
+					significant = 64
+				}
+				if lead+significant > 64 {
+					return nil, ErrCorrupt
+				}
+				leading, trailing = uint(lead), uint(64-lead-significant)
+				window = true
+			} else if !window {
+				return nil, ErrCorrupt
+			}
+			xor, err := r.readBits(64 - leading - trailing)
+			if err != nil {
+				return nil, err
+			}
+			prev ^= xor << trailing
+		}
+		values[i] = math.Float64frombits(prev)
+	}
+	return values, nil
+}
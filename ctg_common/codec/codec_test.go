@@ -0,0 +1,276 @@
+package codec
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// regular равномерный ряд 4 Гц с ЧСС около 140
+func regular(n int) ([]float64, []float64) {
+	times := make([]float64, n)
+	values := make([]float64, n)
+	for i := range times {
+		times[i] = float64(i) * 0.25
+		values[i] = 140 + float64(i%7) - 3
+	}
+	return times, values
+}
+
+func TestRoundTrip(t *testing.T) {
+	regularTimes, regularValues := regular(200)
+	one := 1.0
+
+	cases := []struct {
+		name   string
+		times  []float64
+		values []float64
+		opts   Options
+		want   []float64 // ожидаемые значения, если отличаются от исходных
+	}{
+		{name: "пустой ряд"},
+		{name: "одна точка", times: []float64{12.5}, values: []float64{140}},
+		{name: "равномерный ряд", times: regularTimes, values: regularValues},
+		{
+			name:   "неравномерное время",
+			times:  []float64{0, 0.25, 0.26, 0.9, 0.9, 5, 3600, 3600.001, 1e6, -10},
+			values: []float64{140, 141, 141, 139, 139, 150, 120, 120, 100, 90},
+		},
+		{
+			name:   "потеря сигнала",
+			times:  []float64{0, 0.25, 0.5, 0.75, 1, 1.25},
+			values: []float64{140, -1, -1, -1, 141.5, -1},
+		},
+		{
+			name:   "отрицательный ноль",
+			times:  []float64{0, 1, 2, 3},
+			values: []float64{0, math.Copysign(0, -1), 0, math.Copysign(0, -1)},
+		},
+		{
+			// XOR со всеми 64 значащими битами записывается длиной 0
+			name:   "окно XOR во всю ширину",
+			times:  []float64{0, 1, 2, 3},
+			values: []float64{one, math.Float64frombits(0x8000000000000001 ^ math.Float64bits(one)), one, math.Float64frombits(1)},
+		},
+		{
+			name:   "особые значения без квантования",
+			times:  []float64{0, 1, 2, 3, 4, 5},
+			values: []float64{math.NaN(), math.Inf(1), math.Inf(-1), math.MaxFloat64, math.SmallestNonzeroFloat64, -math.MaxFloat64},
+		},
+		{
+			name:   "квантование значений",
+			times:  []float64{0, 0.25, 0.5, 0.75},
+			values: []float64{140.1, 140.13, -1, 12.86},
+			opts:   Options{ValueScale: 4},
+			want:   []float64{140, 140.25, -1, 12.75},
+		},
+		{
+			name:   "квантование: отрицательный ноль становится нулем",
+			times:  []float64{0, 1},
+			values: []float64{math.Copysign(0, -1), -0.1},
+			opts:   Options{ValueScale: 1},
+			want:   []float64{0, 0},
+		},
+		{
+			name:   "шаг времени",
+			times:  []float64{0, 0.24, 0.51},
+			values: []float64{1, 2, 3},
+			opts:   Options{TimeResolution: 4},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			block, err := Append(nil, c.times, c.values, c.opts)
+			if err != nil {
+				t.Fatalf("ошибка кодирования: %v", err)
+			}
+			times, values, rest, err := Decode(block)
+			if err != nil {
+				t.Fatalf("ошибка разбора: %v", err)
+			}
+			if len(rest) != 0 {
+				t.Errorf("после блока осталось %d байт", len(rest))
+			}
+			if len(times) != len(c.times) || len(values) != len(c.values) {
+				t.Fatalf("разобрано %d/%d точек из %d", len(times), len(values), len(c.times))
+			}
+
+			resolution := float64(c.opts.withDefaults().TimeResolution)
+			for i, tm := range c.times {
+				if want := math.Round(tm*resolution) / resolution; times[i] != want {
+					t.Errorf("время %d: %v, ожидалось %v", i, times[i], want)
+				}
+			}
+			want := c.values
+			if c.want != nil {
+				want = c.want
+			}
+			for i, v := range want {
+				if math.Float64bits(values[i]) != math.Float64bits(v) {
+					t.Errorf("значение %d: %v (%#x), ожидалось %v (%#x)", i, values[i], math.Float64bits(values[i]), v, math.Float64bits(v))
+				}
+			}
+		})
+	}
+}
+
+func TestFixedRejectsNonFinite(t *testing.T) {
+	for _, v := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		_, err := Append(nil, []float64{0, 1}, []float64{140, v}, Options{ValueScale: 4})
+		if !errors.Is(err, ErrNotFinite) {
+			t.Errorf("значение %v: ошибка %v, ожидалась ErrNotFinite", v, err)
+		}
+	}
+	if _, err := Append(nil, []float64{0, 1}, []float64{140}, Options{}); err == nil {
+		t.Error("ряды разной длины закодированы")
+	}
+}
+
+func TestConsecutiveBlocks(t *testing.T) {
+	times, values := regular(10)
+	data, err := Append(nil, times, values, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err = Append(data, times[:3], values[:3], Options{ValueScale: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = AppendInts(data, []int64{1700000000000, 1700000000250, 1700000000500, 1700000000751})
+
+	first, _, rest, err := Decode(data)
+	if err != nil || len(first) != 10 {
+		t.Fatalf("первый блок: %d точек, %v", len(first), err)
+	}
+	second, _, rest, err := Decode(rest)
+	if err != nil || len(second) != 3 {
+		t.Fatalf("второй блок: %d точек, %v", len(second), err)
+	}
+	ints, rest, err := DecodeInts(rest)
+	if err != nil || len(rest) != 0 {
+		t.Fatalf("блок целых: %v, остаток %d байт", err, len(rest))
+	}
+	if ints[3] != 1700000000751 {
+		t.Errorf("целые разобраны неверно: %v", ints)
+	}
+}
+
+// mustNotPanic вызывает decode и проверяет, что разбор не паникует
+func mustNotPanic(t *testing.T, name string, decode func() error) error {
+	t.Helper()
+	var err error
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				t.Fatalf("%s: паника при разборе: %v", name, r)
+			}
+		}()
+		err = decode()
+	}()
+	return err
+}
+
+func TestTruncatedInput(t *testing.T) {
+	times, values := regular(50)
+	values[10] = -1
+	for _, opts := range []Options{{}, {ValueScale: 4}} {
+		block, err := Append(nil, times, values, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for n := 0; n < len(block); n++ {
+			err := mustNotPanic(t, "Decode", func() error {
+				_, _, _, err := Decode(block[:n])
+				return err
+			})
+			if !errors.Is(err, ErrCorrupt) {
+				t.Errorf("блок %+v, обрезан до %d байт: ошибка %v", opts, n, err)
+			}
+		}
+	}
+
+	ints := AppendInts(nil, []int64{0, 250, 500, 1000, 1e12})
+	for n := 0; n < len(ints); n++ {
+		err := mustNotPanic(t, "DecodeInts", func() error {
+			_, _, err := DecodeInts(ints[:n])
+			return err
+		})
+		if !errors.Is(err, ErrCorrupt) {
+			t.Errorf("блок целых обрезан до %d байт: ошибка %v", n, err)
+		}
+	}
+}
+
+func TestCorruptInput(t *testing.T) {
+	times, values := regular(20)
+	block, err := Append(nil, times, values, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Повреждения заголовка, которые должны обнаруживаться
+	header := []struct {
+		name   string
+		damage func([]byte) []byte
+	}{
+		{"неизвестная версия", func(b []byte) []byte { b[0] = version + 1; return b }},
+		{"неизвестный режим", func(b []byte) []byte { b[1] = modeFixed + 1; return b }},
+		{"нулевой шаг времени", func(b []byte) []byte {
+			b = append(b[:0:0], b...)
+			return append(append(b[:3], 0), b[5:]...) // 1000 занимает 2 байта uvarint
+		}},
+		{"точек больше, чем бит", func(b []byte) []byte {
+			return append([]byte{version, modeXOR, 0xff, 0xff, 0x03}, b[3:]...)
+		}},
+		{"окно XOR без начального", func([]byte) []byte {
+			// Два значения: первое целиком, затем '10' - прежнее окно, которого нет
+			var w bitWriter
+			writeDeltas(&w, []int64{0, 1000}, 2)
+			w.writeBits(math.Float64bits(140), 64)
+			w.writeBits(0b10, 2)
+			w.writeBits(0, 16)
+			return append([]byte{version, modeXOR, 2, 0xe8, 0x07, byte(len(w.buf))}, w.buf...)
+		}},
+	}
+	for _, c := range header {
+		t.Run(c.name, func(t *testing.T) {
+			data := c.damage(append([]byte{}, block...))
+			err := mustNotPanic(t, c.name, func() error {
+				_, _, _, err := Decode(data)
+				return err
+			})
+			if !errors.Is(err, ErrCorrupt) {
+				t.Errorf("ошибка %v, ожидалась ErrCorrupt", err)
+			}
+		})
+	}
+
+	// Произвольные повреждения бит могут дать другой ряд, но не панику
+	for i := range block {
+		for bit := 0; bit < 8; bit++ {
+			data := append([]byte{}, block...)
+			data[i] ^= 1 << bit
+			mustNotPanic(t, "Decode", func() error {
+				_, _, _, err := Decode(data)
+				return err
+			})
+		}
+	}
+}
+
+func FuzzDecode(f *testing.F) {
+	times, values := regular(20)
+	for _, opts := range []Options{{}, {ValueScale: 4}} {
+		block, err := Append(nil, times, values, opts)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(block)
+	}
+	f.Add([]byte{version, modeXOR, 0xff, 0xff, 0xff, 0xff, 0x0f, 1, 0})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		Decode(data)
+		DecodeInts(data)
+	})
+}
@@ -0,0 +1,338 @@
+// Package decel классифицирует децелерации ЧСС плода по их связи со схватками:
+// ранние, поздние, вариабельные и пролонгированные (критерии FIGO 2015 / NICHD).
+// Здесь же критерии схваток, их частота и тахисистолия.
+//
+// Пакет общий для CTG_monitor и ml-service (модуль ctg_common).
+package decel
+
+import (
+	"math"
+	"sort"
+)
+
+// Типы децелераций
+const (
+	Early     = "early"     // надир совпадает с пиком схватки, плавное начало
+	Late      = "late"      // надир позже пика схватки, плавное начало
+	Variable  = "variable"  // резкое начало или нет связи со схваткой
+	Prolonged = "prolonged" // длительность от 3 минут
+)
+
+// Критерии классификации, с
+const (
+	ProlongedDuration = 180.0 // длительность пролонгированной децелерации
+	AbruptOnset       = 30.0  // падение до надира быстрее - резкое начало
+	EarlyLag          = 15.0  // допустимое расхождение надира и пика схватки для ранней
+	MaxPairLag        = 90.0  // дальше пик схватки не связывается с децелерацией
+)
+
+// Критерии схваток и тахисистолии
+const (
+	ContractionAmplitude = 10.0   // подъем над базальным тонусом, с которого считается схватка
+	ContractionEdge      = 5.0    // подъем над тонусом на границах схватки
+	ContractionMin       = 30.0   // минимальная длительность схватки, с
+	ToneQuantile         = 0.1    // базальный тонус - нижний дециль токограммы
+	FrequencyWindow      = 600.0  // частота схваток считается на 10 минут, с
+	TachysystoleWindow   = 1800.0 // частота для тахисистолии усредняется за 30 минут, с
+	TachysystoleLimit    = 5.0    // тахисистолия - более 5 схваток за 10 минут
+)
+
+// Критерии поиска эпизодов в записи
+const (
+	decelThreshold   = 15.0 // глубина децелерации, уд/мин
+	decelMinDuration = 15.0 // минимальная длительность децелерации, с
+	recoveryBand     = 5.0  // возврат к базальному ритму, уд/мин
+	maxGap           = 10.0 // потеря сигнала, прерывающая эпизод, с
+)
+
+// Sample точка записи (время, с; значение). Отрицательное значение или NaN - потеря сигнала.
+type Sample struct {
+	T float64 `json:"t"`
+	V float64 `json:"v"`
+}
+
+// Deceleration найденная децелерация
+type Deceleration struct {
+	Start float64 `json:"start"` // отклонение от базального ритма
+	Nadir float64 `json:"nadir"` // время минимума ЧСС
+	End   float64 `json:"end"`   // возврат к базальному ритму
+	Depth float64 `json:"depth"` // глубина в надире ниже базального ритма, уд/мин
+}
+
+// Contraction схватка по записи токограммы
+type Contraction struct {
+	Onset     float64 `json:"onset"`
+	Peak      float64 `json:"peak"`
+	Offset    float64 `json:"offset"`
+	Amplitude float64 `json:"amplitude"` // подъем в пике над базальным тонусом
+	Tone      float64 `json:"tone"`      // базальный тонус
+}
+
+// Duration длительность схватки, с
+func (c Contraction) Duration() float64 {
+	return c.Offset - c.Onset
+}
+
+// Event децелерация с типом и парной схваткой
+type Event struct {
+	Deceleration
+	Type            string  `json:"type"`
+	Paired          bool    `json:"paired"`                     // найдена схватка в пределах MaxPairLag
+	ContractionPeak float64 `json:"contraction_peak,omitempty"` // время пика парной схватки
+	Lag             float64 `json:"lag,omitempty"`              // надир минус пик схватки, с
+}
+
+// Counts количество децелераций по типам
+type Counts struct {
+	Early     int `json:"early"`
+	Late      int `json:"late"`
+	Variable  int `json:"variable"`
+	Prolonged int `json:"prolonged"`
+}
+
+// WindowCounts количество децелераций в окне [From, To)
+type WindowCounts struct {
+	From float64 `json:"from"`
+	To   float64 `json:"to"`
+	Counts
+}
+
+// Add учитывает децелерацию типа kind
+func (c *Counts) Add(kind string) {
+	switch kind {
+	case Early:
+		c.Early++
+	case Late:
+		c.Late++
+	case Variable:
+		c.Variable++
+	case Prolonged:
+		c.Prolonged++
+	}
+}
+
+// Total общее количество децелераций
+func (c Counts) Total() int {
+	return c.Early + c.Late + c.Variable + c.Prolonged
+}
+
+// Classify определяет тип децелерации по ближайшему к надиру пику схватки.
+// Пролонгированная - по длительности, вариабельная - по резкому началу,
+// плавная - по времени надира относительно пика схватки.
+func Classify(d Deceleration, contractions []Contraction) Event {
+	event := Event{Deceleration: d}
+	if nearest, ok := nearestPeak(d.Nadir, contractions); ok {
+		event.Paired = true
+		event.ContractionPeak = nearest.Peak
+		event.Lag = d.Nadir - nearest.Peak
+	}
+
+	switch {
+	case d.End-d.Start >= ProlongedDuration:
+		event.Type = Prolonged
+	case d.Nadir-d.Start < AbruptOnset:
+		event.Type = Variable
+	case !event.Paired || event.Lag < -EarlyLag:
+		// Плавная децелерация без схватки или раньше нее - не связана с сокращением
+		event.Type = Variable
+	case event.Lag <= EarlyLag:
+		event.Type = Early
+	default:
+		event.Type = Late
+	}
+	return event
+}
+
+// ClassifyAll классифицирует все децелерации записи
+func ClassifyAll(decelerations []Deceleration, contractions []Contraction) []Event {
+	events := make([]Event, 0, len(decelerations))
+	for _, d := range decelerations {
+		events = append(events, Classify(d, contractions))
+	}
+	return events
+}
+
+// Count считает децелерации с надиром в [from, to)
+func Count(events []Event, from, to float64) Counts {
+	var counts Counts
+	for _, event := range events {
+		if event.Nadir >= from && event.Nadir < to {
+			counts.Add(event.Type)
+		}
+	}
+	return counts
+}
+
+// CountWindows считает децелерации в последовательных окнах длиной window от from до to
+func CountWindows(events []Event, from, to, window float64) []WindowCounts {
+	if window <= 0 {
+		return nil
+	}
+	var windows []WindowCounts
+	for start := from; start < to; start += window {
+		end := math.Min(start+window, to)
+		windows = append(windows, WindowCounts{From: start, To: end, Counts: Count(events, start, end)})
+	}
+	return windows
+}
+
+// Baseline оценка базального ритма записи: медиана значений ЧСС
+func Baseline(samples []Sample) float64 {
+	values := validValues(samples)
+	if len(values) == 0 {
+		return 0
+	}
+	sort.Float64s(values)
+	return values[len(values)/2]
+}
+
+// FindDecelerations находит децелерации: падение ЧСС на 15 уд/мин и более ниже
+// baseline длительностью от 15 с, от последней точки у базального ритма до возврата к нему
+func FindDecelerations(samples []Sample, baseline float64) []Deceleration {
+	if baseline <= 0 {
+		return nil
+	}
+
+	var decelerations []Deceleration
+	var current *Deceleration
+	lastNear, lastT, started := 0.0, 0.0, false
+	for _, s := range samples {
+		if !valid(s.V) || s.V == 0 {
+			continue
+		}
+		if started && s.T-lastT > maxGap {
+			current = nil
+			lastNear = s.T
+		}
+		if !started {
+			lastNear, started = s.T, true
+		}
+		lastT = s.T
+
+		depth := baseline - s.V
+		switch {
+		case depth <= recoveryBand:
+			if current != nil {
+				current.End = s.T
+				if current.End-current.Start >= decelMinDuration {
+					decelerations = append(decelerations, *current)
+				}
+				current = nil
+			}
+			lastNear = s.T
+		case current == nil && depth >= decelThreshold:
+			current = &Deceleration{Start: lastNear, Nadir: s.T, Depth: depth}
+		case current != nil && depth > current.Depth:
+			current.Nadir, current.Depth = s.T, depth
+		}
+	}
+	return decelerations
+}
+
+// Tone базальный тонус токограммы: нижний дециль значений
+func Tone(samples []Sample) float64 {
+	values := validValues(samples)
+	if len(values) == 0 {
+		return 0
+	}
+	sort.Float64s(values)
+	return values[int(float64(len(values)-1)*ToneQuantile)]
+}
+
+// FindContractions находит схватки: подъем над базальным тонусом (нижний дециль
+// записи) от 10 единиц длительностью от 30 с. Границы схватки - подъем на 5 единиц.
+// Незавершенная схватка в конце записи возвращается с Offset последней точки.
+func FindContractions(samples []Sample) []Contraction {
+	if len(validValues(samples)) == 0 {
+		return nil
+	}
+	tone := Tone(samples)
+
+	var contractions []Contraction
+	var current *Contraction
+	edge, lastT, started := 0.0, 0.0, false
+	finish := func(offset float64) {
+		if current != nil && offset-current.Onset >= ContractionMin {
+			current.Offset = offset
+			contractions = append(contractions, *current)
+		}
+		current = nil
+	}
+	for _, s := range samples {
+		if !valid(s.V) {
+			continue
+		}
+		if started && s.T-lastT > maxGap {
+			current = nil
+			edge = s.T
+		}
+		if !started {
+			edge, started = s.T, true
+		}
+		lastT = s.T
+
+		rise := s.V - tone
+		switch {
+		case rise <= ContractionEdge:
+			finish(s.T)
+			edge = s.T
+		case current == nil && rise >= ContractionAmplitude:
+			current = &Contraction{Onset: edge, Peak: s.T, Amplitude: rise, Tone: tone}
+		case current != nil && rise > current.Amplitude:
+			current.Peak, current.Amplitude = s.T, rise
+		}
+	}
+	finish(lastT)
+	return contractions
+}
+
+// Frequency частота схваток в интервале [from, to), схваток за 10 минут (по пикам)
+func Frequency(contractions []Contraction, from, to float64) float64 {
+	if to <= from {
+		return 0
+	}
+	count := 0
+	for _, c := range contractions {
+		if c.Peak >= from && c.Peak < to {
+			count++
+		}
+	}
+	return float64(count) * FrequencyWindow / (to - from)
+}
+
+// Tachysystole сообщает о тахисистолии к моменту end: частота схваток, усредненная
+// за 30 минут, выше 5 за 10 минут. Запись должна покрывать все 30 минут.
+func Tachysystole(contractions []Contraction, end float64) (float64, bool) {
+	frequency := Frequency(contractions, end-TachysystoleWindow, end)
+	return frequency, frequency > TachysystoleLimit
+}
+
+// nearestPeak схватка с пиком, ближайшим к t, в пределах MaxPairLag
+func nearestPeak(t float64, contractions []Contraction) (Contraction, bool) {
+	var best Contraction
+	found := false
+	for _, c := range contractions {
+		lag := math.Abs(t - c.Peak)
+		if lag > MaxPairLag {
+			continue
+		}
+		if !found || lag < math.Abs(t-best.Peak) {
+			best, found = c, true
+		}
+	}
+	return best, found
+}
+
+func valid(v float64) bool {
+	return v >= 0 && !math.IsNaN(v)
+}
+
+func validValues(samples []Sample) []float64 {
+	values := make([]float64, 0, len(samples))
+	for _, s := range samples {
+		if valid(s.V) {
+			values = append(values, s.V)
+		}
+	}
+	return values
+}
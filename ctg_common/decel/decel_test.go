@@ -0,0 +1,188 @@
+package decel
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	// Схватка с пиком на 100 с
+	contractions := []Contraction{{Onset: 70, Peak: 100, Offset: 140, Amplitude: 30}}
+
+	// smooth плавная децелерация с надиром в nadir: падение 40 с, восстановление 40 с
+	smooth := func(nadir float64) Deceleration {
+		return Deceleration{Start: nadir - 40, Nadir: nadir, End: nadir + 40, Depth: 30}
+	}
+
+	cases := []struct {
+		name   string
+		d      Deceleration
+		want   string
+		paired bool
+	}{
+		{"длительность 3 минуты - пролонгированная", Deceleration{Start: 0, Nadir: 100, End: ProlongedDuration}, Prolonged, true},
+		{"длительность меньше 3 минут", Deceleration{Start: 0.1, Nadir: 100, End: ProlongedDuration}, Early, true},
+		{"падение быстрее 30 с - вариабельная", Deceleration{Start: 100 - AbruptOnset + 0.1, Nadir: 100, End: 130}, Variable, true},
+		{"падение за 30 с - плавная", Deceleration{Start: 100 - AbruptOnset, Nadir: 100, End: 130}, Early, true},
+		{"надир на пике схватки - ранняя", smooth(100), Early, true},
+		{"надир позже пика на 15 с - ранняя", smooth(100 + EarlyLag), Early, true},
+		{"надир позже пика больше чем на 15 с - поздняя", smooth(100 + EarlyLag + 0.1), Late, true},
+		{"надир раньше пика на 15 с - ранняя", smooth(100 - EarlyLag), Early, true},
+		{"надир раньше пика больше чем на 15 с - вариабельная", smooth(100 - EarlyLag - 0.1), Variable, true},
+		{"пик схватки в 90 с от надира - поздняя", smooth(100 + MaxPairLag), Late, true},
+		{"схватка дальше 90 с - не связана", smooth(100 + MaxPairLag + 0.1), Variable, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			event := Classify(c.d, contractions)
+			if event.Type != c.want || event.Paired != c.paired {
+				t.Errorf("тип %s, связь со схваткой %v; ожидались %s, %v", event.Type, event.Paired, c.want, c.paired)
+			}
+			if event.Paired && event.Lag != c.d.Nadir-100 {
+				t.Errorf("задержка %.1f, ожидалась %.1f", event.Lag, c.d.Nadir-100)
+			}
+		})
+	}
+
+	// Из нескольких схваток берется ближайшая к надиру
+	event := Classify(smooth(200), []Contraction{{Peak: 120}, {Peak: 190}, {Peak: 260}})
+	if event.ContractionPeak != 190 || event.Type != Early {
+		t.Errorf("выбрана схватка с пиком %.0f, тип %s", event.ContractionPeak, event.Type)
+	}
+}
+
+// plateau запись токограммы 1 Гц длиной n с тонусом 10 и подъемом rise в (from, to);
+// точки в missing пропущены
+func plateau(n int, from, to int, rise float64, missing func(int) bool) []Sample {
+	samples := make([]Sample, 0, n)
+	for i := 0; i < n; i++ {
+		if missing != nil && missing(i) {
+			continue
+		}
+		v := 10.0
+		if i > from && i < to {
+			v += rise
+		}
+		samples = append(samples, Sample{T: float64(i), V: v})
+	}
+	return samples
+}
+
+func TestFindContractions(t *testing.T) {
+	cases := []struct {
+		name    string
+		samples []Sample
+		want    []Contraction
+	}{
+		{
+			name:    "схватка 30 с",
+			samples: plateau(300, 100, 100+int(ContractionMin), 20, nil),
+			want:    []Contraction{{Onset: 100, Peak: 101, Offset: 130, Amplitude: 20, Tone: 10}},
+		},
+		{
+			name:    "короче 30 с - не схватка",
+			samples: plateau(300, 100, 100+int(ContractionMin)-1, 20, nil),
+		},
+		{
+			name:    "подъем на 10 - схватка",
+			samples: plateau(300, 100, 160, ContractionAmplitude, nil),
+			want:    []Contraction{{Onset: 100, Peak: 101, Offset: 160, Amplitude: ContractionAmplitude, Tone: 10}},
+		},
+		{
+			name:    "подъем меньше 10 - не схватка",
+			samples: plateau(300, 100, 160, ContractionAmplitude-0.1, nil),
+		},
+		{
+			name:    "потеря сигнала 10 с не прерывает схватку",
+			samples: plateau(300, 100, 160, 20, func(i int) bool { return i > 120 && i < 130 }),
+			want:    []Contraction{{Onset: 100, Peak: 101, Offset: 160, Amplitude: 20, Tone: 10}},
+		},
+		{
+			name:    "потеря сигнала дольше 10 с прерывает схватку",
+			samples: plateau(300, 100, 160, 20, func(i int) bool { return i > 120 && i < 132 }),
+		},
+		{
+			name:    "незавершенная схватка в конце записи",
+			samples: plateau(300, 250, 400, 20, nil),
+			want:    []Contraction{{Onset: 250, Peak: 251, Offset: 299, Amplitude: 20, Tone: 10}},
+		},
+		{
+			name: "точки без сигнала пропускаются",
+			samples: func() []Sample {
+				samples := plateau(300, 100, 160, 20, nil)
+				samples[140].V = -1
+				return samples
+			}(),
+			want: []Contraction{{Onset: 100, Peak: 101, Offset: 160, Amplitude: 20, Tone: 10}},
+		},
+		{name: "нет сигнала", samples: []Sample{{T: 0, V: -1}, {T: 1, V: -1}}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := FindContractions(c.samples); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("схватки %+v, ожидались %+v", got, c.want)
+			}
+		})
+	}
+}
+
+// peaks схватки с пиками в заданное время
+func peaks(times ...float64) []Contraction {
+	contractions := make([]Contraction, 0, len(times))
+	for _, t := range times {
+		contractions = append(contractions, Contraction{Peak: t})
+	}
+	return contractions
+}
+
+func TestFrequency(t *testing.T) {
+	contractions := peaks(0, 100, 599.9, 600)
+	cases := []struct {
+		name     string
+		from, to float64
+		want     float64
+	}{
+		{"10 минут: пик на правой границе не входит", 0, 600, 3},
+		{"20 минут", 0, 1200, 2},
+		{"пик на левой границе входит", 600, 1200, 1},
+		{"пустой интервал", 600, 600, 0},
+		{"обратный интервал", 600, 0, 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := Frequency(contractions, c.from, c.to); got != c.want {
+				t.Errorf("частота %.2f, ожидалась %.2f", got, c.want)
+			}
+		})
+	}
+}
+
+func TestTachysystole(t *testing.T) {
+	// count схваток с равными промежутками в последних 30 минутах до end и одна раньше
+	series := func(count int, end float64) []Contraction {
+		contractions := peaks(end - TachysystoleWindow - 1)
+		step := TachysystoleWindow / float64(count)
+		for i := 0; i < count; i++ {
+			contractions = append(contractions, Contraction{Peak: end - TachysystoleWindow + float64(i)*step})
+		}
+		return contractions
+	}
+
+	cases := []struct {
+		name  string
+		count int
+		want  bool
+	}{
+		{"16 схваток за 30 минут - тахисистолия", 16, true},
+		{"15 схваток за 30 минут - ровно 5 за 10 минут", 15, false},
+		{"нет схваток", 0, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			frequency, tachysystole := Tachysystole(series(c.count, 3600), 3600)
+			if want := float64(c.count) / 3; frequency != want || tachysystole != c.want {
+				t.Errorf("частота %.2f, тахисистолия %v; ожидались %.2f, %v", frequency, tachysystole, want, c.want)
+			}
+		})
+	}
+}
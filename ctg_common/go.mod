@@ -0,0 +1,3 @@
+module ctg_common
+
+go 1.24.2
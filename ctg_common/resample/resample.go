@@ -0,0 +1,205 @@
+// Package resample приводит точки записи с нерегулярным временем к равномерной
+// сетке заданной частоты. Точки сетки между соседними точками с сигналом
+// интерполируются линейно; где сигнала нет или точки слишком далеко друг от
+// друга, сетка содержит Gap, а маска - false.
+//
+// Пакет общий для CTG_monitor и ml-service (модуль ctg_common).
+package resample
+
+import (
+	"math"
+	"sort"
+)
+
+// Значения по умолчанию
+const (
+	DefaultRate   = 4.0 // частота сетки, Гц
+	DefaultMaxGap = 2.0 // между точками дальше интерполяция не проводится, с
+)
+
+// Gap значение точки сетки без сигнала
+const Gap = -1.0
+
+// Sample точка записи (время, с; значение). Отрицательное значение или NaN - потеря сигнала.
+type Sample struct {
+	T float64 `json:"t"`
+	V float64 `json:"v"`
+}
+
+// valid есть ли в точке сигнал
+func (s Sample) valid() bool {
+	return s.V >= 0 && !math.IsNaN(s.V)
+}
+
+// Config параметры сетки
+type Config struct {
+	Rate   float64 // частота, Гц
+	MaxGap float64 // наибольший интервал между точками для интерполяции, с
+}
+
+// withDefaults заполняет незаданные параметры
+func (c Config) withDefaults() Config {
+	if c.Rate <= 0 {
+		c.Rate = DefaultRate
+	}
+	if c.MaxGap <= 0 {
+		c.MaxGap = DefaultMaxGap
+	}
+	return c
+}
+
+// Grid равномерный ряд: точка i относится ко времени Start + i/Rate
+type Grid struct {
+	Start  float64   `json:"start"`  // время первой точки, с
+	Rate   float64   `json:"rate"`   // частота, Гц
+	Values []float64 `json:"values"` // значения; Gap там, где сигнала нет
+	Mask   []bool    `json:"mask"`   // true - в точке есть сигнал
+}
+
+// Len число точек сетки
+func (g Grid) Len() int {
+	return len(g.Values)
+}
+
+// Time время точки сетки
+func (g Grid) Time(i int) float64 {
+	return g.Start + float64(i)/g.Rate
+}
+
+// Valid число точек с сигналом
+func (g Grid) Valid() int {
+	count := 0
+	for _, ok := range g.Mask {
+		if ok {
+			count++
+		}
+	}
+	return count
+}
+
+// Head первые seconds секунд сетки
+func (g Grid) Head(seconds float64) Grid {
+	n := int(math.Round(seconds * g.Rate))
+	if n < 0 {
+		n = 0
+	}
+	if n >= len(g.Values) {
+		return g
+	}
+	return Grid{Start: g.Start, Rate: g.Rate, Values: g.Values[:n], Mask: g.Mask[:n]}
+}
+
+// Append дописывает в конец сетку той же частоты, например следующую сессию.
+// Время добавленных точек продолжает шкалу сетки.
+func (g Grid) Append(next Grid) Grid {
+	if len(g.Values) == 0 && g.Rate == 0 {
+		return next
+	}
+	return Grid{
+		Start:  g.Start,
+		Rate:   g.Rate,
+		Values: append(append([]float64{}, g.Values...), next.Values...),
+		Mask:   append(append([]bool{}, g.Mask...), next.Mask...),
+	}
+}
+
+// Uniform строит сетку на интервале [start, end) по точкам записи в любом порядке
+func Uniform(samples []Sample, start, end float64, cfg Config) Grid {
+	cfg = cfg.withDefaults()
+	n := int(math.Ceil((end - start) * cfg.Rate))
+	if n < 0 {
+		n = 0
+	}
+	grid := Grid{Start: start, Rate: cfg.Rate, Values: make([]float64, n), Mask: make([]bool, n)}
+
+	sorted := make([]Sample, len(samples))
+	copy(sorted, samples)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].T < sorted[j].T })
+
+	next := 0
+	for i := range grid.Values {
+		t := grid.Time(i)
+		for next < len(sorted) && sorted[next].T < t {
+			next++
+		}
+		var prev, after *Sample
+		if next > 0 {
+			prev = &sorted[next-1]
+		}
+		if next < len(sorted) {
+			after = &sorted[next]
+		}
+		grid.Values[i], grid.Mask[i] = interpolate(prev, after, t, cfg)
+	}
+	return grid
+}
+
+// interpolate значение сетки в момент t между соседними точками записи:
+// prev - последняя точка раньше t, after - первая не раньше t
+func interpolate(prev, after *Sample, t float64, cfg Config) (float64, bool) {
+	if after != nil && after.T == t {
+		if after.valid() {
+			return after.V, true
+		}
+		return Gap, false
+	}
+	if prev != nil && after != nil && prev.valid() && after.valid() && after.T-prev.T <= cfg.MaxGap {
+		return prev.V + (after.V-prev.V)*(t-prev.T)/(after.T-prev.T), true
+	}
+
+	// На краях записи и у границ потери берем точку с сигналом не дальше полушага сетки
+	half := 0.5 / cfg.Rate
+	switch {
+	case prev != nil && prev.valid() && t-prev.T <= half && (after == nil || !after.valid()):
+		return prev.V, true
+	case after != nil && after.valid() && after.T-t <= half && (prev == nil || !prev.valid()):
+		return after.V, true
+	}
+	return Gap, false
+}
+
+// Stream строит сетку по мере поступления точек: точка сетки выдается, как только
+// пришла первая точка записи не раньше нее. Откат времени начинает новую шкалу.
+// Не потокобезопасен.
+type Stream struct {
+	cfg     Config
+	started bool
+	prev    Sample
+	next    float64 // время следующей точки сетки
+}
+
+// NewStream создает построение сетки по потоку точек
+func NewStream(cfg Config) *Stream {
+	return &Stream{cfg: cfg.withDefaults()}
+}
+
+// Add учитывает точку записи и возвращает точки сетки до ее времени включительно
+func (s *Stream) Add(sample Sample) []Sample {
+	if !s.started || sample.T < s.prev.T {
+		s.started = true
+		s.prev = sample
+		s.next = math.Ceil(sample.T*s.cfg.Rate) / s.cfg.Rate
+		if s.next != sample.T {
+			return nil
+		}
+		s.next = s.following()
+		value, _ := interpolate(nil, &sample, sample.T, s.cfg)
+		return []Sample{{T: sample.T, V: value}}
+	}
+
+	var out []Sample
+	prev := s.prev
+	for s.next <= sample.T {
+		value, _ := interpolate(&prev, &sample, s.next, s.cfg)
+		out = append(out, Sample{T: s.next, V: value})
+		s.next = s.following()
+	}
+	s.prev = sample
+	return out
+}
+
+// following время точки сетки после следующей; считается от номера точки,
+// чтобы ошибка округления не накапливалась
+func (s *Stream) following() float64 {
+	return (math.Round(s.next*s.cfg.Rate) + 1) / s.cfg.Rate
+}
@@ -0,0 +1,262 @@
+package resample
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// testConfig сетка 4 Гц, интерполяция через разрывы до 2 с
+var testConfig = Config{Rate: 4, MaxGap: 2}
+
+// gaps n точек сетки без сигнала
+func gaps(n int) ([]float64, []bool) {
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = Gap
+	}
+	return values, make([]bool, n)
+}
+
+func TestUniform(t *testing.T) {
+	wideValues, wideMask := gaps(10)
+	wideValues[0], wideMask[0] = 100, true
+
+	cases := []struct {
+		name       string
+		samples    []Sample
+		start, end float64
+		values     []float64
+		mask       []bool
+	}{
+		{
+			name:    "точки на сетке",
+			samples: []Sample{{0, 1}, {0.25, 2}, {0.5, 3}},
+			end:     0.75,
+			values:  []float64{1, 2, 3},
+			mask:    []bool{true, true, true},
+		},
+		{
+			name:    "линейная интерполяция",
+			samples: []Sample{{0, 100}, {1, 104}},
+			end:     1,
+			values:  []float64{100, 101, 102, 103},
+			mask:    []bool{true, true, true, true},
+		},
+		{
+			name:    "точки в любом порядке",
+			samples: []Sample{{1, 104}, {0.5, 102}, {0, 100}},
+			end:     1,
+			values:  []float64{100, 101, 102, 103},
+			mask:    []bool{true, true, true, true},
+		},
+		{
+			name:    "разрыв в MaxGap интерполируется",
+			samples: []Sample{{0, 100}, {2, 108}},
+			end:     1,
+			values:  []float64{100, 101, 102, 103},
+			mask:    []bool{true, true, true, true},
+		},
+		{
+			name:    "разрыв больше MaxGap не интерполируется",
+			samples: []Sample{{0, 100}, {2.5, 110}},
+			end:     2.5,
+			values:  wideValues,
+			mask:    wideMask,
+		},
+		{
+			name:    "потеря сигнала",
+			samples: []Sample{{0, 100}, {0.25, -1}, {0.5, 100}, {0.75, math.NaN()}},
+			end:     1,
+			values:  []float64{100, Gap, 100, Gap},
+			mask:    []bool{true, false, true, false},
+		},
+		{
+			name:    "между сигналом и потерей не интерполируется",
+			samples: []Sample{{0, 100}, {1, -1}},
+			end:     1,
+			values:  []float64{100, Gap, Gap, Gap},
+			mask:    []bool{true, false, false, false},
+		},
+		{
+			name:    "точка в пределах полушага от края",
+			samples: []Sample{{0.125, 100}},
+			end:     0.25,
+			values:  []float64{100},
+			mask:    []bool{true},
+		},
+		{
+			name:    "точка дальше полушага от края",
+			samples: []Sample{{0.13, 100}},
+			end:     0.25,
+			values:  []float64{Gap},
+			mask:    []bool{false},
+		},
+		{
+			name:    "полушаг у начала потери",
+			samples: []Sample{{0, 100}, {0.375, 100}, {0.4, -1}},
+			end:     0.75,
+			values:  []float64{100, 100, Gap},
+			mask:    []bool{true, true, false},
+		},
+		{
+			name:    "сетка со смещенным началом",
+			samples: []Sample{{10, 100}, {11, 104}},
+			start:   10.5,
+			end:     11,
+			values:  []float64{102, 103},
+			mask:    []bool{true, true},
+		},
+		{
+			name:    "неполный шаг в конце",
+			samples: []Sample{{0, 100}, {1, 104}},
+			end:     0.3,
+			values:  []float64{100, 101},
+			mask:    []bool{true, true},
+		},
+		{
+			name:    "пустой интервал",
+			samples: []Sample{{0, 100}},
+			start:   1,
+			values:  []float64{},
+			mask:    []bool{},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			grid := Uniform(c.samples, c.start, c.end, testConfig)
+			if grid.Start != c.start || grid.Rate != testConfig.Rate {
+				t.Errorf("начало %.2f, частота %.0f", grid.Start, grid.Rate)
+			}
+			if !reflect.DeepEqual(grid.Values, c.values) || !reflect.DeepEqual(grid.Mask, c.mask) {
+				t.Errorf("сетка %v %v, ожидалась %v %v", grid.Values, grid.Mask, c.values, c.mask)
+			}
+		})
+	}
+
+	// Исходные точки не переупорядочиваются
+	samples := []Sample{{1, 104}, {0, 100}}
+	Uniform(samples, 0, 1, testConfig)
+	if samples[0].T != 1 {
+		t.Error("Uniform изменил порядок исходных точек")
+	}
+
+	// Незаданные параметры берутся по умолчанию
+	if grid := Uniform(nil, 0, 1, Config{}); grid.Rate != DefaultRate || grid.Len() != int(DefaultRate) {
+		t.Errorf("сетка по умолчанию: частота %.0f, %d точек", grid.Rate, grid.Len())
+	}
+}
+
+func TestStream(t *testing.T) {
+	cases := []struct {
+		name    string
+		samples []Sample
+		want    [][]Sample // точки сетки после каждой точки записи
+	}{
+		{
+			name:    "первая точка на сетке",
+			samples: []Sample{{0, 100}, {0.5, 102}},
+			want:    [][]Sample{{{0, 100}}, {{0.25, 101}, {0.5, 102}}},
+		},
+		{
+			name:    "первая точка между узлами сетки",
+			samples: []Sample{{0.1, 100}, {0.2, 101}, {0.6, 105}},
+			want:    [][]Sample{nil, nil, {{0.25, 101.5}, {0.5, 104}}},
+		},
+		{
+			name:    "разрыв больше MaxGap",
+			samples: []Sample{{0, 100}, {2.5, 110}},
+			want:    [][]Sample{{{0, 100}}, {{0.25, Gap}, {0.5, Gap}, {0.75, Gap}, {1, Gap}, {1.25, Gap}, {1.5, Gap}, {1.75, Gap}, {2, Gap}, {2.25, Gap}, {2.5, 110}}},
+		},
+		{
+			name:    "потеря сигнала",
+			samples: []Sample{{0, 100}, {0.25, -1}, {0.5, 100}},
+			want:    [][]Sample{{{0, 100}}, {{0.25, Gap}}, {{0.5, 100}}},
+		},
+		{
+			name:    "откат времени начинает новую шкалу",
+			samples: []Sample{{10, 100}, {10.5, 100}, {0.1, 90}, {0.25, 91}},
+			want:    [][]Sample{{{10, 100}}, {{10.25, 100}, {10.5, 100}}, nil, {{0.25, 91}}},
+		},
+		{
+			name:    "повтор времени",
+			samples: []Sample{{0, 100}, {0, 100}, {0.25, 101}},
+			want:    [][]Sample{{{0, 100}}, nil, {{0.25, 101}}},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			stream := NewStream(testConfig)
+			for i, sample := range c.samples {
+				if got := stream.Add(sample); !reflect.DeepEqual(got, c.want[i]) {
+					t.Errorf("точка %v: сетка %v, ожидалась %v", sample, got, c.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestStreamGridAlignment(t *testing.T) {
+	// Точки каждые 0,1 с: узлы сетки идут подряд без накопления ошибки округления
+	stream := NewStream(testConfig)
+	var grid []Sample
+	for i := 0; i <= 36000; i++ {
+		grid = append(grid, stream.Add(Sample{T: float64(i) / 10, V: 140})...)
+	}
+	if len(grid) != 3600*4+1 {
+		t.Fatalf("точек сетки %d, ожидалось %d", len(grid), 3600*4+1)
+	}
+	for i, s := range grid {
+		if s.T != float64(i)/4 || s.V != 140 {
+			t.Fatalf("точка сетки %d: %+v", i, s)
+		}
+	}
+}
+
+func TestHeadAndAppend(t *testing.T) {
+	// Две сессии приводятся к сетке и склеиваются, затем обрезаются до нужной длительности
+	first := Uniform([]Sample{{0, 100}, {1, 104}}, 0, 1, testConfig)
+	second := Uniform([]Sample{{0, -1}, {0.25, 120}, {0.5, 120}}, 0, 0.75, testConfig)
+
+	var all Grid
+	all = all.Append(first)
+	if !reflect.DeepEqual(all, first) {
+		t.Fatalf("добавление к пустой сетке: %+v", all)
+	}
+	all = all.Append(second)
+	wantValues := []float64{100, 101, 102, 103, Gap, 120, 120}
+	wantMask := []bool{true, true, true, true, false, true, true}
+	if !reflect.DeepEqual(all.Values, wantValues) || !reflect.DeepEqual(all.Mask, wantMask) {
+		t.Fatalf("склеенная сетка %v %v", all.Values, all.Mask)
+	}
+	if all.Start != 0 || all.Time(5) != 1.25 || all.Valid() != 6 {
+		t.Errorf("начало %.2f, время точки 5 %.2f, с сигналом %d", all.Start, all.Time(5), all.Valid())
+	}
+
+	// Склейка не меняет исходные сетки
+	all.Values[0] = 0
+	if first.Values[0] != 100 {
+		t.Error("склеенная сетка ссылается на исходную")
+	}
+
+	cases := []struct {
+		name    string
+		seconds float64
+		want    int
+	}{
+		{"обрезка по границе сессии", 1, 4},
+		{"обрезка с округлением до точки", 1.1, 4},
+		{"длиннее сетки", 10, 7},
+		{"ровно вся сетка", 1.75, 7},
+		{"ноль", 0, 0},
+		{"отрицательная длительность", -1, 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			head := all.Head(c.seconds)
+			if head.Len() != c.want || len(head.Mask) != c.want || head.Rate != all.Rate || head.Start != all.Start {
+				t.Errorf("первые %.2f с: %d точек (маска %d), ожидалось %d", c.seconds, head.Len(), len(head.Mask), c.want)
+			}
+		})
+	}
+}
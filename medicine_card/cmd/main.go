@@ -35,6 +35,33 @@ func (s *MedicalRecordsServer) SaveCTGSession(
 	log.Printf("📋 Duration: %d секунд", req.DurationSeconds)
 	log.Printf("📋 FHR точек: %d", req.TotalFhrPoints)
 	log.Printf("📋 UC точек: %d", req.TotalUcPoints)
+	if req.TotalFhr2Points > 0 {
+		log.Printf("📋 FHR второго плода точек: %d", req.TotalFhr2Points)
+	}
+
+	// Интервалы, когда датчики ЧСС записывали одно сердце или ЧСС матери
+	for _, interval := range req.Coincidences {
+		log.Printf("⚠️ Совпадение каналов %s (%s): %.1f-%.1fs",
+			interval.Kind, interval.DataType, interval.FromTime, interval.ToTime)
+	}
+
+	// Заключение теста, если сессия шла по протоколу
+	if result := req.ProtocolResult; result != nil {
+		log.Printf("🩺 Протокол %s: %s (%s), %.0fs, акцелераций %d, схваток %d, с поздними децелерациями %d",
+			result.Protocol, result.Outcome, result.Reason, result.DurationSeconds,
+			result.Accelerations, result.Contractions, result.LateDecelerations)
+		log.Printf("🩺 %s", result.Summary)
+	}
+
+	// Итоги сессии
+	if summary := req.Summary; summary != nil {
+		log.Printf("📈 Итоги: базальный ритм %.0f, вариабельность %.1f (%s), акцелераций %d",
+			summary.Baseline, summary.Variability, summary.VariabilityBand, summary.Accelerations)
+		log.Printf("📈 Децелерации: ранних %d, поздних %d, вариабельных %d, пролонгированных %d",
+			summary.EarlyDecelerations, summary.LateDecelerations, summary.VariableDecelerations, summary.ProlongedDecelerations)
+		log.Printf("📈 Схваток %d (%.1f за 10 мин), сигнал ЧСС %.1f%%, выбросов исправлено %d, тревог %d",
+			summary.Contractions, summary.ContractionFrequency, summary.SignalQuality, summary.SpikesCorrected, summary.Alarms)
+	}
 
 	// Выводим первые 5 точек FHR данных для примера
 	if len(req.FhrData) > 0 {
@@ -42,8 +69,8 @@ func (s *MedicalRecordsServer) SaveCTGSession(
 		count := min(5, len(req.FhrData))
 		for i := 0; i < count; i++ {
 			point := req.FhrData[i]
-			log.Printf("   FHR[%d]: время=%.2fs, значение=%.2f",
-				i, point.TimeSec, point.Value)
+			log.Printf("   FHR[%d]: время=%.2fs, значение=%.2f, исходное=%.2f %v",
+				i, point.TimeSec, point.Value, point.RawValue, point.Flags)
 		}
 		if len(req.FhrData) > 5 {
 			log.Printf("   ... и еще %d точек FHR", len(req.FhrData)-5)
@@ -56,8 +83,8 @@ func (s *MedicalRecordsServer) SaveCTGSession(
 		count := min(5, len(req.UcData))
 		for i := 0; i < count; i++ {
 			point := req.UcData[i]
-			log.Printf("   UC[%d]: время=%.2fs, значение=%.2f",
-				i, point.TimeSec, point.Value)
+			log.Printf("   UC[%d]: время=%.2fs, значение=%.2f, исходное=%.2f %v",
+				i, point.TimeSec, point.Value, point.RawValue, point.Flags)
 		}
 		if len(req.UcData) > 5 {
 			log.Printf("   ... и еще %d точек UC", len(req.UcData)-5)
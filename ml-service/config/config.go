@@ -1,7 +1,9 @@
 package config
 
 import (
+	"ml-service/internal/features"
 	"os"
+	"strconv"
 )
 
 type Config struct {
@@ -22,6 +24,8 @@ type DatabaseConfig struct {
 type MLConfig struct {
 	ServiceURL string
 	Timeout    int
+	SampleRate float64 // частота равномерной сетки записей для расчета фичей, Гц
+	MaxGap     float64 // между точками дальше интерполяция не проводится, с
 }
 
 func Load() *Config {
@@ -38,6 +42,8 @@ func Load() *Config {
 		ML: MLConfig{
 			ServiceURL: getEnv("ML_SERVICE_URL", "http://localhost:8000"),
 			Timeout:    30,
+			SampleRate: getEnvAsFloat("ML_SAMPLE_RATE", features.TrainedSampleRate),
+			MaxGap:     getEnvAsFloat("ML_MAX_GAP", 2),
 		},
 	}
 }
@@ -48,3 +54,12 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
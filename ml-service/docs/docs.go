@@ -142,6 +142,42 @@ const docTemplate = `{
         }
     },
     "definitions": {
+        "decel.Event": {
+            "type": "object",
+            "properties": {
+                "contraction_peak": {
+                    "description": "время пика парной схватки",
+                    "type": "number"
+                },
+                "depth": {
+                    "description": "глубина в надире ниже базального ритма, уд/мин",
+                    "type": "number"
+                },
+                "end": {
+                    "description": "возврат к базальному ритму",
+                    "type": "number"
+                },
+                "lag": {
+                    "description": "надир минус пик схватки, с",
+                    "type": "number"
+                },
+                "nadir": {
+                    "description": "время минимума ЧСС",
+                    "type": "number"
+                },
+                "paired": {
+                    "description": "найдена схватка в пределах MaxPairLag",
+                    "type": "boolean"
+                },
+                "start": {
+                    "description": "отклонение от базального ритма",
+                    "type": "number"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
         "models.ErrorResponse": {
             "type": "object",
             "properties": {
@@ -177,6 +213,13 @@ const docTemplate = `{
                     "type": "string",
                     "example": "550e8400-e29b-41d4-a716-446655440000"
                 },
+                "decelerations": {
+                    "description": "Децелерации записи: тип, время надира, пик парной схватки",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/decel.Event"
+                    }
+                },
                 "features": {
                     "description": "Словарь вычисленных фичей",
                     "type": "object",
@@ -186,9 +229,9 @@ const docTemplate = `{
                     }
                 },
                 "fs_hz": {
-                    "description": "Частота дискретизации",
+                    "description": "Частота равномерной сетки, Гц",
                     "type": "number",
-                    "example": 8
+                    "example": 4
                 },
                 "t_sec": {
                     "description": "Время анализа в секундах",
@@ -209,6 +252,13 @@ const docTemplate = `{
                 "card_id": {
                     "type": "string"
                 },
+                "decelerations": {
+                    "description": "Децелерации записи с типом (только /ml/features)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/decel.Event"
+                    }
+                },
                 "features": {
                     "type": "object",
                     "additionalProperties": {
@@ -217,7 +267,7 @@ const docTemplate = `{
                     }
                 },
                 "fs_hz": {
-                    "type": "integer"
+                    "type": "number"
                 },
                 "t_sec": {
                     "type": "integer"
@@ -13,14 +13,20 @@ type FeatureCalculator struct {
 }
 
 
+// TrainedSampleRate частота сетки, на которой рассчитаны фичи обучающей выборки модели, Гц.
+// От частоты зависят RMSSD и STV по соседним отсчетам и число отсчетов в окнах,
+// поэтому при другой частоте модель нужно переобучить.
+const TrainedSampleRate = 8.0
+
+
 // NewFeatureCalculator создает новый калькулятор фичей
 func NewFeatureCalculator(fs float64) *FeatureCalculator {
    return &FeatureCalculator{fs: fs}
 }
 
 
-// Calculate вычисляет все фичи для заданного окна
-func (fc *FeatureCalculator) Calculate(fhr, uc []float64, windowSec int) map[string]float64 {
+// Calculate вычисляет все фичи для заданного окна. fhr2 - ЧСС второго плода (пусто, если плод один)
+func (fc *FeatureCalculator) Calculate(fhr, fhr2, uc []float64, windowSec int) map[string]float64 {
    windowSize := int(float64(windowSec) * fc.fs)
   
    // Берем данные из конца массива (последние windowSize точек)
@@ -51,12 +57,43 @@ func (fc *FeatureCalculator) Calculate(fhr, uc []float64, windowSec int) map[str
    features[prefix+"uc_iqr"] = utils.SafeFloat(ucFeats.IQR)
    features[prefix+"uc_peak_cnt"] = utils.SafeFloat(float64(ucFeats.PeakCnt))
    features[prefix+"uc_area"] = utils.SafeFloat(ucFeats.Area)
+   features[prefix+"uc_contr_cnt"] = utils.SafeFloat(float64(ucFeats.ContrCnt))
+   features[prefix+"uc_contr_freq"] = utils.SafeFloat(ucFeats.ContrFreq)
+   features[prefix+"uc_contr_dur_mean"] = utils.SafeFloat(ucFeats.ContrDurMean)
+   features[prefix+"uc_contr_amp_mean"] = utils.SafeFloat(ucFeats.ContrAmpMean)
   
    // Кросс-корреляция с проверкой на NaN
    xcorrFeats := CalculateXCorrFeatures(fhrWindow, ucWindow, fc.fs, 60.0)
    features[prefix+"xcorr_maxabs"] = utils.SafeFloat(xcorrFeats.MaxAbs)
    features[prefix+"xcorr_lag"] = utils.SafeFloat(xcorrFeats.Lag)
   
+   // Типы децелераций относительно схваток
+   decelCounts := CalculateDecelTypeCounts(fhrWindow, ucWindow, fc.fs)
+   features[prefix+"decel_early_cnt"] = float64(decelCounts.Early)
+   features[prefix+"decel_late_cnt"] = float64(decelCounts.Late)
+   features[prefix+"decel_variable_cnt"] = float64(decelCounts.Variable)
+   features[prefix+"decel_prolonged_cnt"] = float64(decelCounts.Prolonged)
+  
+   // Двойня: фичи ЧСС второго плода и совпадение датчиков
+   if len(fhr2) > 0 {
+       fhr2Window := fc.getLastWindow(fhr2, windowSize)
+       fhr2Feats := CalculateFHRFeatures(fhr2Window, fc.fs)
+       features[prefix+"fhr2_mean"] = utils.SafeFloat(fhr2Feats.Mean)
+       features[prefix+"fhr2_std"] = utils.SafeFloat(fhr2Feats.Std)
+       features[prefix+"fhr2_min"] = utils.SafeFloat(fhr2Feats.Min)
+       features[prefix+"fhr2_max"] = utils.SafeFloat(fhr2Feats.Max)
+       features[prefix+"fhr2_iqr"] = utils.SafeFloat(fhr2Feats.IQR)
+       features[prefix+"fhr2_rmssd"] = utils.SafeFloat(fhr2Feats.RMSSD)
+       features[prefix+"fhr2_abs_dev"] = utils.SafeFloat(fhr2Feats.AbsDev)
+       features[prefix+"fhr2_brady_len"] = utils.SafeFloat(fhr2Feats.BradyLen)
+       features[prefix+"fhr2_tachy_len"] = utils.SafeFloat(fhr2Feats.TachyLen)
+       features[prefix+"fhr2_decel_cnt"] = utils.SafeFloat(float64(fhr2Feats.DecelCnt))
+
+       twinFeats := CalculateTwinFeatures(fhrWindow, fhr2Window)
+       features[prefix+"fhr_fhr2_coinc_ratio"] = utils.SafeFloat(twinFeats.CoincRatio)
+       features[prefix+"fhr_fhr2_abs_diff"] = utils.SafeFloat(twinFeats.AbsDiff)
+   }
+  
    return features
 }
 
@@ -71,19 +108,29 @@ func (fc *FeatureCalculator) getLastWindow(data []float64, windowSize int) []flo
 
 
 // CalculateAllFeatures вычисляет фичи для всех доступных окон
-func (fc *FeatureCalculator) CalculateAllFeatures(fhr, uc []float64, duration int) map[string]float64 {
+func (fc *FeatureCalculator) CalculateAllFeatures(fhr, fhr2, uc []float64, duration int) map[string]float64 {
    features := make(map[string]float64)
    windows := []int{240, 600, 900}
   
    for _, window := range windows {
        if duration >= window {
-           windowFeatures := fc.Calculate(fhr, uc, window)
+           windowFeatures := fc.Calculate(fhr, fhr2, uc, window)
            for k, v := range windowFeatures {
                features[k] = v
            }
        }
    }
   
+   // Тахисистолия: частота схваток за 10 минут в среднем за последние 30 минут
+   if duration >= 1800 {
+       frequency, tachysystole := CalculateTachysystole(fc.getLastWindow(uc, int(1800*fc.fs)), fc.fs)
+       features["uc_contr_freq_30min"] = utils.SafeFloat(frequency)
+       features["uc_tachysystole"] = 0
+       if tachysystole {
+           features["uc_tachysystole"] = 1
+       }
+   }
+  
    return features
 }
 
@@ -0,0 +1,33 @@
+package features
+
+import (
+    "ctg_common/decel"
+)
+
+// ClassifyDecelerations находит децелерации FHR и схватки UC в записи с частотой fs
+// и определяет тип каждой децелерации. Время событий - секунды от начала записи.
+func ClassifyDecelerations(fhr, uc []float64, fs float64) []decel.Event {
+    fhrSamples := toSamples(fhr, fs)
+    decelerations := decel.FindDecelerations(fhrSamples, decel.Baseline(fhrSamples))
+    return decel.ClassifyAll(decelerations, decel.FindContractions(toSamples(uc, fs)))
+}
+
+// CalculateDecelTypeCounts считает децелерации окна по типам
+func CalculateDecelTypeCounts(fhr, uc []float64, fs float64) decel.Counts {
+    events := ClassifyDecelerations(fhr, uc, fs)
+    return decel.Count(events, 0, float64(len(fhr))/fs)
+}
+
+// Decelerations децелерации всей записи с типом относительно схваток
+func (fc *FeatureCalculator) Decelerations(fhr, uc []float64) []decel.Event {
+    return ClassifyDecelerations(fhr, uc, fc.fs)
+}
+
+// toSamples переводит массив значений с частотой fs в точки со временем
+func toSamples(data []float64, fs float64) []decel.Sample {
+    samples := make([]decel.Sample, len(data))
+    for i, v := range data {
+        samples[i] = decel.Sample{T: float64(i) / fs, V: v}
+    }
+    return samples
+}
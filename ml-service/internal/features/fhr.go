@@ -19,16 +19,18 @@ type FHRFeatures struct {
     DecelCnt int     `json:"decel_cnt"`
 }
 
-// CalculateFHRFeatures вычисляет все признаки FHR
+// CalculateFHRFeatures вычисляет все признаки FHR по равномерной сетке с частотой fs.
+// Точки без сигнала (-1) в статистику не входят и прерывают серии
 func CalculateFHRFeatures(fhr []float64, fs float64) FHRFeatures {
+    valid := utils.Valid(fhr)
     return FHRFeatures{
-        Mean:     utils.Mean(fhr),
-        Std:      utils.Std(fhr),
-        Min:      utils.Min(fhr),
-        Max:      utils.Max(fhr),
-        IQR:      utils.IQR(fhr),
+        Mean:     utils.Mean(valid),
+        Std:      utils.Std(valid),
+        Min:      utils.Min(valid),
+        Max:      utils.Max(valid),
+        IQR:      utils.IQR(valid),
         RMSSD:    calculateRMSSD(fhr),
-        AbsDev:   calculateAbsDev(fhr),
+        AbsDev:   calculateAbsDev(valid),
         BradyLen: calculateBradyLen(fhr, fs),
         TachyLen: calculateTachyLen(fhr, fs),
         DecelCnt: calculateDecelCnt(fhr, fs),
@@ -36,23 +38,25 @@ func CalculateFHRFeatures(fhr []float64, fs float64) FHRFeatures {
 }
 
 // calculateRMSSD вычисляет RMSSD (Root Mean Square of Successive Differences)
+// по соседним точкам, в обеих из которых есть сигнал
 func calculateRMSSD(fhr []float64) float64 {
-    if len(fhr) <= 1 {
-        return math.NaN()
-    }
-
-    diff := utils.Diff(fhr)
     sumSquares := 0.0
+    count := 0
 
-    for _, d := range diff {
+    for i := 1; i < len(fhr); i++ {
+        if fhr[i] < 0 || fhr[i-1] < 0 {
+            continue
+        }
+        d := fhr[i] - fhr[i-1]
         sumSquares += d * d
+        count++
     }
 
-    if len(diff) == 0 {
+    if count == 0 {
         return math.NaN()
     }
 
-    return math.Sqrt(sumSquares / float64(len(diff)))
+    return math.Sqrt(sumSquares / float64(count))
 }
 
 // calculateAbsDev вычисляет среднее абсолютное отклонение от медианы
@@ -74,7 +78,7 @@ func calculateAbsDev(fhr []float64) float64 {
 // calculateBradyLen вычисляет суммарную длительность брадикардии (FHR < 110)
 func calculateBradyLen(fhr []float64, fs float64) float64 {
     return calculateRunLength(fhr, fs, func(v float64) bool {
-        return v < 110
+        return v >= 0 && v < 110
     })
 }
 
@@ -108,18 +112,19 @@ func calculateRunLength(data []float64, fs float64, condition func(float64) bool
 
 // calculateDecelCnt вычисляет количество децелераций
 func calculateDecelCnt(fhr []float64, fs float64) int {
-    if len(fhr) == 0 {
+    valid := utils.Valid(fhr)
+    if len(valid) == 0 {
         return 0
     }
 
-    threshold := utils.Percentile(fhr, 50) - 15
+    threshold := utils.Percentile(valid, 50) - 15
     minLen := int(7.5 * fs) // минимум 7.5 секунд
 
     count := 0
     run := 0
 
     for _, v := range fhr {
-        if v < threshold {
+        if v >= 0 && v < threshold {
             run++
         } else {
             if run >= minLen {
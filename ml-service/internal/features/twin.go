@@ -0,0 +1,50 @@
+package features
+
+import (
+    "math"
+)
+
+// TwinCoincidenceTolerance разница ЧСС, уд/мин, при которой точки двух датчиков считаются совпадающими
+const TwinCoincidenceTolerance = 5.0
+
+// TwinFeatures признаки согласованности ЧСС двух плодов
+type TwinFeatures struct {
+    CoincRatio float64 `json:"coinc_ratio"` // доля точек, где датчики показывают одну ЧСС
+    AbsDiff    float64 `json:"abs_diff"`    // средняя абсолютная разница ЧСС
+}
+
+// CalculateTwinFeatures сравнивает ряды ЧСС двух плодов. Ряды выровнены по индексу,
+// как FHR и UC; высокая доля совпадений означает, что оба датчика записывают одно сердце.
+// Сравниваются только точки, где сигнал есть у обоих датчиков
+func CalculateTwinFeatures(fhr, fhr2 []float64) TwinFeatures {
+    length := len(fhr)
+    if len(fhr2) < length {
+        length = len(fhr2)
+    }
+
+    n := 0
+    coinc := 0
+    sumDiff := 0.0
+    for i := 0; i < length; i++ {
+        if fhr[i] < 0 || fhr2[i] < 0 {
+            continue
+        }
+        diff := math.Abs(fhr[i] - fhr2[i])
+        if diff <= TwinCoincidenceTolerance {
+            coinc++
+        }
+        sumDiff += diff
+        n++
+    }
+    if n == 0 {
+        return TwinFeatures{
+            CoincRatio: math.NaN(),
+            AbsDiff:    math.NaN(),
+        }
+    }
+
+    return TwinFeatures{
+        CoincRatio: float64(coinc) / float64(n),
+        AbsDiff:    sumDiff / float64(n),
+    }
+}
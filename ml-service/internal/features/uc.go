@@ -1,41 +1,82 @@
 package features
 
 import (
+    "ctg_common/decel"
     "ml-service/pkg/utils"
 )
 
 // UCFeatures вычисляет признаки для UC данных
 type UCFeatures struct {
-    Mean     float64 `json:"mean"`
-    Std      float64 `json:"std"`
-    Max      float64 `json:"max"`
-    IQR      float64 `json:"iqr"`
-    PeakCnt  int     `json:"peak_cnt"`
-    Area     float64 `json:"area"`
+    Mean         float64 `json:"mean"`
+    Std          float64 `json:"std"`
+    Max          float64 `json:"max"`
+    IQR          float64 `json:"iqr"`
+    PeakCnt      int     `json:"peak_cnt"`       // участков выше порога площади дольше 5 с
+    Area         float64 `json:"area"`
+    ContrCnt     int     `json:"contr_cnt"`      // количество схваток
+    ContrFreq    float64 `json:"contr_freq"`     // схваток за 10 минут
+    ContrDurMean float64 `json:"contr_dur_mean"` // средняя длительность схватки, с
+    ContrAmpMean float64 `json:"contr_amp_mean"` // средний подъем над базальным тонусом
 }
 
-// CalculateUCFeatures вычисляет все признаки UC
+// CalculateUCFeatures вычисляет все признаки UC по равномерной сетке с частотой fs.
+// Точки без сигнала (-1) в статистику не входят
 func CalculateUCFeatures(uc []float64, fs float64) UCFeatures {
+    valid := utils.Valid(uc)
     peakCnt, area := calculateUCPeaksAndArea(uc, fs)
+    contractions := FindContractions(uc, fs)
+    durMean, ampMean := 0.0, 0.0
+    for _, c := range contractions {
+        durMean += c.Duration()
+        ampMean += c.Amplitude
+    }
+    if len(contractions) > 0 {
+        durMean /= float64(len(contractions))
+        ampMean /= float64(len(contractions))
+    }
     
     return UCFeatures{
-        Mean:    utils.Mean(uc),
-        Std:     utils.Std(uc),
-        Max:     utils.Max(uc),
-        IQR:     utils.IQR(uc),
-        PeakCnt: peakCnt,
-        Area:    area,
+        Mean:         utils.Mean(valid),
+        Std:          utils.Std(valid),
+        Max:          utils.Max(valid),
+        IQR:          utils.IQR(valid),
+        PeakCnt:      peakCnt,
+        Area:         area,
+        ContrCnt:     len(contractions),
+        ContrFreq:    decel.Frequency(contractions, 0, float64(len(uc))/fs),
+        ContrDurMean: durMean,
+        ContrAmpMean: ampMean,
+    }
+}
+
+// FindContractions находит схватки в записи UC с частотой fs: начало, пик, окончание
+// и подъем над базальным тонусом. Время - секунды от начала записи.
+func FindContractions(uc []float64, fs float64) []decel.Contraction {
+    return decel.FindContractions(toSamples(uc, fs))
+}
+
+// CalculateTachysystole частота схваток за 10 минут, усредненная за последние 30 минут
+// записи, и признак тахисистолии (более 5). Записи короче 30 минут не оцениваются.
+func CalculateTachysystole(uc []float64, fs float64) (float64, bool) {
+    end := float64(len(uc)) / fs
+    if end < decel.TachysystoleWindow {
+        return 0, false
     }
+    return decel.Tachysystole(FindContractions(uc, fs), end)
 }
 
-// calculateUCPeaksAndArea вычисляет количество пиков и площадь под кривой UC
+// calculateUCPeaksAndArea вычисляет количество участков выше середины между 10 и 90
+// процентилями длительностью не меньше 5 секунд и площадь под кривой UC выше этого порога.
+// Подсчет участков совпадает с тем, на котором обучена модель (uc_peak_cnt); схватки
+// считаются отдельно (uc_contr_cnt).
 func calculateUCPeaksAndArea(uc []float64, fs float64) (int, float64) {
-    if len(uc) == 0 {
+    valid := utils.Valid(uc)
+    if len(valid) == 0 {
         return 0, 0.0
     }
     
-    p10 := utils.Percentile(uc, 10)
-    p90 := utils.Percentile(uc, 90)
+    p10 := utils.Percentile(valid, 10)
+    p90 := utils.Percentile(valid, 90)
     threshold := p10 + 0.5*(p90-p10)
     minLen := int(5 * fs) // минимум 5 секунд
     
@@ -60,4 +101,4 @@ func calculateUCPeaksAndArea(uc []float64, fs float64) (int, float64) {
     }
     
     return count, area / fs // конвертируем в секунды
-}
\ No newline at end of file
+}
@@ -11,9 +11,12 @@ type XCorrFeatures struct {
     Lag    float64 `json:"lag"`
 }
 
-// CalculateXCorrFeatures вычисляет признаки кросс-корреляции
+// CalculateXCorrFeatures вычисляет признаки кросс-корреляции. Точки без сигнала (-1)
+// после нормализации равны нулю и в корреляцию не вносят вклада
 func CalculateXCorrFeatures(fhr, uc []float64, fs float64, maxLagS float64) XCorrFeatures {
-    if len(fhr) == 0 || len(uc) == 0 {
+    fhrValid := utils.Valid(fhr)
+    ucValid := utils.Valid(uc)
+    if len(fhrValid) == 0 || len(ucValid) == 0 {
         return XCorrFeatures{
             MaxAbs: math.NaN(),
             Lag:    math.NaN(),
@@ -21,10 +24,10 @@ func CalculateXCorrFeatures(fhr, uc []float64, fs float64, maxLagS float64) XCor
     }
     
     // Z-score нормализация
-    fhrMean := utils.Mean(fhr)
-    fhrStd := utils.Std(fhr)
-    ucMean := utils.Mean(uc)
-    ucStd := utils.Std(uc)
+    fhrMean := utils.Mean(fhrValid)
+    fhrStd := utils.Std(fhrValid)
+    ucMean := utils.Mean(ucValid)
+    ucStd := utils.Std(ucValid)
     
     if fhrStd < 1e-6 || ucStd < 1e-6 {
         return XCorrFeatures{
@@ -38,11 +41,15 @@ func CalculateXCorrFeatures(fhr, uc []float64, fs float64, maxLagS float64) XCor
     ucNorm := make([]float64, len(uc))
     
     for i, v := range fhr {
-        fhrNorm[i] = (v - fhrMean) / fhrStd
+        if v >= 0 {
+            fhrNorm[i] = (v - fhrMean) / fhrStd
+        }
     }
     
     for i, v := range uc {
-        ucNorm[i] = (v - ucMean) / ucStd
+        if v >= 0 {
+            ucNorm[i] = (v - ucMean) / ucStd
+        }
     }
     
     maxLag := int(maxLagS * fs)
@@ -0,0 +1,68 @@
+package models
+
+import (
+    "fmt"
+    "sort"
+
+    "ctg_common/chunk"
+)
+
+// Каналы сессии, которые использует сервис
+const (
+    ChannelFHR  = "fetal_heart_rate"
+    ChannelFHR2 = "fetal_heart_rate_2" // ЧСС второго плода (двойня)
+    ChannelUC   = "uterine_contractions"
+)
+
+// CTGChunk блок точек канала сессии в таблице ctg_chunks (пишет CTG_monitor)
+type CTGChunk struct {
+    ID        uint64 `gorm:"primaryKey"`
+    SessionID string `gorm:"type:uuid"`
+    Channel   string
+    Bucket    int64
+    Data      []byte `gorm:"type:bytea"`
+}
+
+// TableName устанавливает имя таблицы
+func (CTGChunk) TableName() string {
+    return "ctg_chunks"
+}
+
+// decodeChunk разбирает кадры блока в порядке записи; остальные поля точек не нужны
+func decodeChunk(row CTGChunk) ([]DataPoint, error) {
+    framePoints, err := chunk.Decode(row.Data)
+    if err != nil {
+        return nil, err
+    }
+    points := make([]DataPoint, len(framePoints))
+    for i, point := range framePoints {
+        points[i] = DataPoint{T: point.T, V: point.V}
+    }
+    return points, nil
+}
+
+// AssembleChunks собирает ряды каналов из блоков. Точки упорядочиваются по времени;
+// из точек с одинаковым временем остается записанная первой, как в CTG_monitor
+func AssembleChunks(rows []CTGChunk) (map[string][]DataPoint, error) {
+    series := make(map[string][]DataPoint)
+    for _, row := range rows {
+        points, err := decodeChunk(row)
+        if err != nil {
+            return nil, fmt.Errorf("ошибка разбора блока %s/%d: %w", row.Channel, row.Bucket, err)
+        }
+        series[row.Channel] = append(series[row.Channel], points...)
+    }
+
+    for name, points := range series {
+        sort.SliceStable(points, func(i, j int) bool { return points[i].T < points[j].T })
+        unique := points[:0]
+        for i, point := range points {
+            if i > 0 && point.T == unique[len(unique)-1].T {
+                continue
+            }
+            unique = append(unique, point)
+        }
+        series[name] = unique
+    }
+    return series, nil
+}
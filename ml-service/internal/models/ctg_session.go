@@ -80,8 +80,6 @@ type CTGSession struct {
     DeviceID  string      `gorm:"not null" json:"device_id"`
     StartTime time.Time   `gorm:"not null" json:"start_time"`
     EndTime   *time.Time  `json:"end_time"`
-    FHRData   string      `gorm:"type:text" json:"fhr_data"`
-    UCData    string      `gorm:"type:text" json:"uc_data"`
     Model15   NullFloat64 `json:"model15"`
     Model30   NullFloat64 `json:"model30"`
     Model45   NullFloat64 `json:"model45"`
@@ -107,40 +105,6 @@ type DataPoint struct {
     V float64 `json:"v"` // значение
 }
 
-// CTGData представляет структуру данных FHR или UC
-type CTGData struct {
-    Count  int         `json:"count"`
-    Points []DataPoint `json:"points"`
-}
-
-// GetFHRPoints парсит и возвращает точки FHR данных
-func (s *CTGSession) GetFHRPoints() ([]DataPoint, error) {
-    if s.FHRData == "" {
-        return []DataPoint{}, nil
-    }
-    
-    var ctgData CTGData
-    if err := json.Unmarshal([]byte(s.FHRData), &ctgData); err != nil {
-        return nil, fmt.Errorf("ошибка парсинга FHR данных: %w", err)
-    }
-    
-    return ctgData.Points, nil
-}
-
-// GetUCPoints парсит и возвращает точки UC данных  
-func (s *CTGSession) GetUCPoints() ([]DataPoint, error) {
-    if s.UCData == "" {
-        return []DataPoint{}, nil
-    }
-    
-    var ctgData CTGData
-    if err := json.Unmarshal([]byte(s.UCData), &ctgData); err != nil {
-        return nil, fmt.Errorf("ошибка парсинга UC данных: %w", err)
-    }
-    
-    return ctgData.Points, nil
-}
-
 // GetDurationSeconds возвращает длительность сессии в секундах
 func (s *CTGSession) GetDurationSeconds() int {
     if s.EndTime == nil {
@@ -1,10 +1,13 @@
 package models
 
+import "ctg_common/decel"
+
 // FeaturesResponse структура ответа с вычисленными фичами
 type FeaturesResponse struct {
 	CardID           string             `json:"card_id" example:"550e8400-e29b-41d4-a716-446655440000"` // ID карты пациента
 	TSec             int                `json:"t_sec" example:"960"`                                    // Время анализа в секундах
-	FsHz             float64            `json:"fs_hz" example:"8.0"`                                    // Частота дискретизации
+	FsHz             float64            `json:"fs_hz" example:"4.0"`                                    // Частота равномерной сетки, Гц
 	AvailableWindows []string           `json:"available_windows" example:"240s,600s,900s"`             // Доступные временные окна
 	Features         map[string]float64 `json:"features"`                                               // Словарь вычисленных фичей
+	Decelerations    []decel.Event      `json:"decelerations,omitempty"`                                // Децелерации записи: тип, время надира, пик парной схватки
 }
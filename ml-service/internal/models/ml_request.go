@@ -1,13 +1,14 @@
 package models
 
-// import "encoding/json"
+import "ctg_common/decel"
 
 type MLRequest struct {
     CardID           string                 `json:"card_id"`
     TSec             int                    `json:"t_sec"`
-    FsHz             int                    `json:"fs_hz"`
+    FsHz             float64                `json:"fs_hz"`
     AvailableWindows []string               `json:"available_windows"`
     Features         map[string]float64     `json:"features"`
+    Decelerations    []decel.Event          `json:"decelerations,omitempty"` // Децелерации записи с типом (только /ml/features)
 }
 
 type MLResponse struct {
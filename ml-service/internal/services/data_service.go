@@ -5,20 +5,25 @@ import (
     "log"
     "ml-service/internal/models"
 
+    "ctg_common/resample"
     "gorm.io/gorm"
 )
 
 // DataService отвечает за работу с данными
 type DataService struct {
-    db *gorm.DB
+    db   *gorm.DB
+    grid resample.Config
 }
 
-// NewDataService создает новый сервис для работы с данными
-func NewDataService(db *gorm.DB) *DataService {
-    return &DataService{db: db}
+// NewDataService создает новый сервис для работы с данными. Записи сессий
+// приводятся к равномерной сетке grid
+func NewDataService(db *gorm.DB, grid resample.Config) *DataService {
+    return &DataService{db: db, grid: grid}
 }
 
-// GetPatientDataForTime получает данные пациента для заданного времени
+// GetPatientDataForTime получает данные пациента для заданного времени. Сессии
+// склеиваются по порядку, каждая занимает на сетке свою длительность целиком,
+// так что окно в N секунд - это N секунд записи, включая потери сигнала
 func (ds *DataService) GetPatientDataForTime(cardID string, targetTime int) (*PatientData, error) {
     log.Printf("Поиск данных для пациента %s на время %d секунд", cardID, targetTime)
     
@@ -39,129 +44,103 @@ func (ds *DataService) GetPatientDataForTime(cardID string, targetTime int) (*Pa
     log.Printf("Найдено сессий: %d", len(sessions))
 
     // Объединить данные из всех сессий
-    allFHR := []float64{}
-    allUC := []float64{}
+    var allFHR, allFHR2, allUC resample.Grid
     totalTime := 0
 
     for sessionIdx, session := range sessions {
         log.Printf("Обрабатываем сессию %d (ID: %s)", sessionIdx+1, session.ID)
         
-        // Получить данные FHR
-        fhrPoints, err := session.GetFHRPoints()
+        // Собрать точки каналов из блоков ctg_chunks
+        series, err := ds.loadSeries(session.ID)
         if err != nil {
-            log.Printf("Ошибка парсинга FHR для сессии %s: %v", session.ID, err)
+            log.Printf("Ошибка чтения точек сессии %s: %v", session.ID, err)
             continue
         }
-        log.Printf("FHR точек получено: %d", len(fhrPoints))
+        fhrPoints := series[models.ChannelFHR]
+        ucPoints := series[models.ChannelUC]
+        fhr2Points := series[models.ChannelFHR2] // второй плод (двойня)
 
-        // Получить данные UC
-        ucPoints, err := session.GetUCPoints()
-        if err != nil {
-            log.Printf("Ошибка парсинга UC для сессии %s: %v", session.ID, err)
-            continue
-        }
-        log.Printf("UC точек получено: %d", len(ucPoints))
-
-        // Логируем первые несколько точек для диагностики
-        if len(fhrPoints) > 0 {
-            log.Printf("Первые 3 FHR точки:")
-            for i := 0; i < min(3, len(fhrPoints)); i++ {
-                log.Printf("  FHR[%d]: t=%.3f, v=%.3f", i, fhrPoints[i].T, fhrPoints[i].V)
-            }
-        }
-
-        if len(ucPoints) > 0 {
-            log.Printf("Первые 3 UC точки:")
-            for i := 0; i < min(3, len(ucPoints)); i++ {
-                log.Printf("  UC[%d]: t=%.3f, v=%.3f", i, ucPoints[i].T, ucPoints[i].V)
-            }
-        }
-
-        // Конвертировать в массивы значений
-        sessionFHR := make([]float64, 0)
-        sessionUC := make([]float64, 0)
-
-        // Фильтруем FHR данные (исключаем -1)
-        for _, point := range fhrPoints {
-            if point.V != -1.0 {
-                sessionFHR = append(sessionFHR, point.V)
-            }
-        }
+        // Привести к сетке на всю длительность сессии
+        sessionDuration := session.GetDurationSeconds()
+        end := float64(sessionDuration)
+        sessionFHR := resample.Uniform(toSamples(fhrPoints), 0, end, ds.grid)
+        sessionFHR2 := resample.Uniform(toSamples(fhr2Points), 0, end, ds.grid)
+        sessionUC := resample.Uniform(toSamples(ucPoints), 0, end, ds.grid)
 
-        // Фильтруем UC данные (исключаем -1)
-        for _, point := range ucPoints {
-            if point.V != -1.0 {
-                sessionUC = append(sessionUC, point.V)
-            }
-        }
+        log.Printf("Сессия %d: %d сек, FHR %d точек -> %d с сигналом из %d, FHR2 %d точек, UC %d точек -> %d с сигналом из %d",
+            sessionIdx+1, sessionDuration, len(fhrPoints), sessionFHR.Valid(), sessionFHR.Len(),
+            len(fhr2Points), len(ucPoints), sessionUC.Valid(), sessionUC.Len())
 
-        log.Printf("Сессия %d: FHR отфильтровано %d из %d, UC отфильтровано %d из %d", 
-            sessionIdx+1, len(sessionFHR), len(fhrPoints), len(sessionUC), len(ucPoints))
+        allFHR = allFHR.Append(sessionFHR)
+        allFHR2 = allFHR2.Append(sessionFHR2)
+        allUC = allUC.Append(sessionUC)
 
-        // Добавляем к общим массивам
-        allFHRBefore := len(allFHR)
-        allUCBefore := len(allUC)
-        
-        allFHR = append(allFHR, sessionFHR...)
-        allUC = append(allUC, sessionUC...)
-        
-        log.Printf("Общие массивы: FHR %d -> %d (+%d), UC %d -> %d (+%d)", 
-            allFHRBefore, len(allFHR), len(sessionFHR),
-            allUCBefore, len(allUC), len(sessionUC))
-
-        sessionDuration := session.GetDurationSeconds()
         totalTime += sessionDuration
         log.Printf("Длительность сессии: %d сек, общая длительность: %d сек", sessionDuration, totalTime)
 
-        // Проверить, достигли ли нужного времени
         // Проверить, достигли ли нужного времени
         if totalTime >= targetTime {
             log.Printf("Достигнуто целевое время %d сек, прерываем обработку", targetTime)
-            
-            // Фиксированная частота
-            const sampleRate = 8
-            
-            // Обрезка до нужного числа семплов
-            samplesNeeded := targetTime * sampleRate
-            log.Printf("Нужно семплов для %d сек при %d Гц: %d", targetTime, sampleRate, samplesNeeded)
-            
-            if len(allFHR) > samplesNeeded {
-                allFHR = allFHR[:samplesNeeded]
-                log.Printf("FHR обрезан до %d семплов", len(allFHR))
-            }
-            if len(allUC) > samplesNeeded {
-                allUC = allUC[:samplesNeeded]
-                log.Printf("UC обрезан до %d семплов", len(allUC))
-            }
+            allFHR = allFHR.Head(float64(targetTime))
+            allFHR2 = allFHR2.Head(float64(targetTime))
+            allUC = allUC.Head(float64(targetTime))
             break
         }
+    }
 
+    // Второй плод есть, только если хоть в одной сессии у него был сигнал
+    if allFHR2.Valid() == 0 {
+        allFHR2 = resample.Grid{}
     }
-    
-    log.Printf("Итого FHR: %d, UC: %d, Duration: %d", len(allFHR), len(allUC), totalTime)
+
+    log.Printf("Итого FHR: %d (с сигналом %d), FHR2: %d, UC: %d (с сигналом %d), Duration: %d",
+        allFHR.Len(), allFHR.Valid(), allFHR2.Len(), allUC.Len(), allUC.Valid(), totalTime)
 
     return &PatientData{
         CardID:     cardID,
-        FHR:        allFHR,
-        UC:         allUC,
+        FHR:        allFHR.Values,
+        FHR2:       allFHR2.Values,
+        UC:         allUC.Values,
+        FHRMask:    allFHR.Mask,
+        FHR2Mask:   allFHR2.Mask,
+        UCMask:     allUC.Mask,
         Duration:   totalTime,
-        SampleRate: 8, // По умолчанию 8 Гц
+        SampleRate: ds.grid.Rate,
     }, nil
 }
 
-// min возвращает минимальное из двух целых чисел
-func min(a, b int) int {
-    if a < b {
-        return a
+// loadSeries читает точки сессии по каналам
+func (ds *DataService) loadSeries(sessionID string) (map[string][]models.DataPoint, error) {
+    var rows []models.CTGChunk
+    err := ds.db.Where("session_id = ? AND channel IN ?", sessionID,
+        []string{models.ChannelFHR, models.ChannelFHR2, models.ChannelUC}).
+        Order("channel, bucket").
+        Find(&rows).Error
+    if err != nil {
+        return nil, err
+    }
+    return models.AssembleChunks(rows)
+}
+
+// toSamples переводит точки сессии в точки для построения сетки
+func toSamples(points []models.DataPoint) []resample.Sample {
+    samples := make([]resample.Sample, len(points))
+    for i, point := range points {
+        samples[i] = resample.Sample{T: point.T, V: point.V}
     }
-    return b
+    return samples
 }
 
-// PatientData содержит данные пациента
+// PatientData содержит данные пациента на равномерной сетке SampleRate.
+// Точки без сигнала имеют значение -1 и false в маске
 type PatientData struct {
     CardID     string    `json:"card_id"`
     FHR        []float64 `json:"fhr"`
+    FHR2       []float64 `json:"fhr2,omitempty"` // ЧСС второго плода (двойня)
     UC         []float64 `json:"uc"`
+    FHRMask    []bool    `json:"fhr_mask"`
+    FHR2Mask   []bool    `json:"fhr2_mask,omitempty"`
+    UCMask     []bool    `json:"uc_mask"`
     Duration   int       `json:"duration"`
-    SampleRate int       `json:"sample_rate"`
+    SampleRate float64   `json:"sample_rate"`
 }
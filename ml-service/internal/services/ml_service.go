@@ -19,11 +19,12 @@ type MLService struct {
 	httpClient  *http.Client
 }
 
-// NewMLService создает новый ML сервис
-func NewMLService(dataService *DataService, mlURL string) *MLService {
+// NewMLService создает новый ML сервис. sampleRate - частота сетки, на которую
+// DataService приводит записи
+func NewMLService(dataService *DataService, mlURL string, sampleRate float64) *MLService {
 	return &MLService{
 		dataService: dataService,
-		calculator:  features.NewFeatureCalculator(8.0), // 8 Гц
+		calculator:  features.NewFeatureCalculator(sampleRate),
 		mlURL:       mlURL,
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
@@ -42,6 +43,7 @@ func (ms *MLService) ProcessMLRequest(cardID string, targetTime int) (*models.ML
 	// Вычислить фичи
 	features := ms.calculator.CalculateAllFeatures(
 		patientData.FHR,
+		patientData.FHR2,
 		patientData.UC,
 		patientData.Duration,
 	)
@@ -118,6 +120,7 @@ func (ms *MLService) CalculateFeatures(cardID string, targetTime int) (*models.M
 	// Вычислить фичи
 	features := ms.calculator.CalculateAllFeatures(
 		patientData.FHR,
+		patientData.FHR2,
 		patientData.UC,
 		patientData.Duration,
 	)
@@ -125,11 +128,15 @@ func (ms *MLService) CalculateFeatures(cardID string, targetTime int) (*models.M
 	// Определить доступные окна
 	availableWindows := ms.calculator.GetAvailableWindows(patientData.Duration)
 
+	// Децелерации всей записи с типом относительно схваток
+	decelerations := ms.calculator.Decelerations(patientData.FHR, patientData.UC)
+
 	return &models.MLRequest{
 		CardID:           cardID,
 		TSec:             targetTime,
 		FsHz:             patientData.SampleRate,
 		AvailableWindows: availableWindows,
 		Features:         features,
+		Decelerations:    decelerations,
 	}, nil
 }
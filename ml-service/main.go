@@ -4,9 +4,11 @@ import (
 	"log"
 	"ml-service/config"
 	"ml-service/internal/database"
+	"ml-service/internal/features"
 	"ml-service/internal/handlers"
 	"ml-service/internal/services"
 
+	"ctg_common/resample"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
@@ -32,15 +34,22 @@ import (
 
 func main() {
 	cfg := config.Load()
+	if cfg.ML.SampleRate != features.TrainedSampleRate {
+		log.Printf("⚠️ ML_SAMPLE_RATE=%g Гц, модель обучена на фичах при %g Гц: нужна переобученная модель",
+			cfg.ML.SampleRate, features.TrainedSampleRate)
+	}
 
 	db, err := database.Connect(cfg)
 	if err != nil {
 		log.Fatalf("Ошибка подключения к базе данных: %v", err)
 	}
 
-	dataService := services.NewDataService(db)
-	// Передаём dataService и URL
-	mlService := services.NewMLService(dataService, cfg.ML.ServiceURL)
+	dataService := services.NewDataService(db, resample.Config{
+		Rate:   cfg.ML.SampleRate,
+		MaxGap: cfg.ML.MaxGap,
+	})
+	// Передаём dataService, URL и частоту сетки записей
+	mlService := services.NewMLService(dataService, cfg.ML.ServiceURL, cfg.ML.SampleRate)
 	// Передаём только mlService
 	handler := handlers.NewMLHandler(mlService)
 
@@ -12,6 +12,17 @@ func SafeFloat(v float64) float64 {
     return v
 }
 
+// Valid возвращает точки с сигналом: без потерь (-1) и NaN
+func Valid(data []float64) []float64 {
+    valid := make([]float64, 0, len(data))
+    for _, v := range data {
+        if v >= 0 {
+            valid = append(valid, v)
+        }
+    }
+    return valid
+}
+
 // Percentile вычисляет процентиль массива
 func Percentile(data []float64, p float64) float64 {
     if len(data) == 0 {